@@ -0,0 +1,48 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/hiroki/scrapbox_mcp/internal/tools"
+)
+
+// adminToolsRequest is the body of POST /admin/tools
+type adminToolsRequest struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+}
+
+// newAdminToolsHandler returns a handler to list (GET) and enable/disable (POST) tools at runtime.
+// Requests must carry "Authorization: Bearer <token>".
+func newAdminToolsHandler(registry *tools.Registry, token string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		switch r.Method {
+		case "GET":
+		case "POST":
+			var req adminToolsRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+			if err := registry.SetEnabled(req.Name, req.Enabled); err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(registry.Status())
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hiroki/scrapbox_mcp/internal/config"
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	"github.com/hiroki/scrapbox_mcp/internal/tools"
+	mcperrors "github.com/hiroki/scrapbox_mcp/pkg/errors"
+)
+
+const cliUsage = `Usage:
+  server                                 Run the MCP server
+  server list                            List available tools
+  server call <tool> [--args '{...}']    Run a single tool and print the result as JSON
+  server replay <capture.jsonl>          Replay a DEBUG_CAPTURE_PATH capture and report differences
+  server import-markdown <dir> [flags]   Import a directory of Markdown files as pages
+         [--if-exists skip|append|overwrite|rename] [--tag name] [--max-pages n] [--dry-run]
+  server -fake-upstream [command]        Run the server or a command against an in-memory Scrapbox
+`
+
+// cliResult is the JSON printed by the call subcommand.
+// It mirrors the MCP tools/call result.
+type cliResult struct {
+	Content []cliContent `json:"content"`
+	IsError bool         `json:"isError"`
+}
+
+type cliContent struct {
+	Type        string                  `json:"type"`
+	Text        string                  `json:"text,omitempty"`
+	Data        string                  `json:"data,omitempty"`
+	MimeType    string                  `json:"mimeType,omitempty"`
+	Resource    *tools.EmbeddedResource `json:"resource,omitempty"`
+	Annotations *tools.Annotations      `json:"annotations,omitempty"`
+}
+
+// runCLI runs a CLI subcommand and returns the process exit code
+func runCLI(args []string) int {
+	switch args[0] {
+	case "list":
+		return runList(os.Stdout)
+	case "call":
+		return runCall(args[1:], os.Stdout)
+	case "replay":
+		return runReplay(args[1:], os.Stdout)
+	case "import-markdown":
+		return runImportMarkdown(args[1:], os.Stdout)
+	case "help", "-h", "--help":
+		fmt.Fprint(os.Stdout, cliUsage)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n%s", args[0], cliUsage)
+		return 2
+	}
+}
+
+// newCLIRegistry loads configuration and builds a registry with the core tools.
+// The returned client is nil in offline mode.
+func newCLIRegistry() (*tools.Registry, *scrapbox.Client, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	for _, secret := range cfg.Secrets() {
+		mcperrors.RegisterSecret(secret)
+	}
+
+	reader, client, err := newBackend(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize Scrapbox backend: %w", err)
+	}
+
+	registry := tools.NewRegistry()
+	registry.SetMaxResponseBytes(cfg.MaxResponseBytes)
+	if err := registerCoreTools(registry, cfg, reader, client); err != nil {
+		return nil, nil, fmt.Errorf("failed to register tools: %w", err)
+	}
+	return registry, client, nil
+}
+
+func runList(out io.Writer) int {
+	registry, _, err := newCLIRegistry()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	list := registry.List()
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(list); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func runCall(args []string, out io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "Tool name required\n\n%s", cliUsage)
+		return 2
+	}
+	name := args[0]
+
+	fs := flag.NewFlagSet("call", flag.ContinueOnError)
+	argsJSON := fs.String("args", "{}", "Tool arguments as a JSON object")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	var arguments map[string]interface{}
+	if err := json.Unmarshal([]byte(*argsJSON), &arguments); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --args JSON: %v\n", err)
+		return 2
+	}
+
+	registry, client, err := newCLIRegistry()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if client != nil {
+		defer client.Close()
+	}
+
+	// Keep stdout clean for the JSON result
+	log.SetOutput(mcperrors.NewRedactingWriter(os.Stderr))
+
+	return printResult(context.Background(), registry, name, arguments, out)
+}
+
+// runImportMarkdown runs import_markdown on a local directory, reporting
+// progress on stderr
+func runImportMarkdown(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("import-markdown", flag.ContinueOnError)
+	ifExists := fs.String("if-exists", "skip", "What to do with existing pages: skip, append, overwrite or rename")
+	tag := fs.String("tag", "", "Tag added to every imported page")
+	maxPages := fs.Int("max-pages", 0, "Maximum number of files to import")
+	dryRun := fs.Bool("dry-run", false, "Only report what would be imported")
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		fmt.Fprintf(os.Stderr, "Directory required\n\n%s", cliUsage)
+		return 2
+	}
+	dir := args[0]
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	registry, client, err := newCLIRegistry()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if client == nil {
+		fmt.Fprintln(os.Stderr, "import-markdown requires COSENSE_SID (offline mode is read-only)")
+		return 1
+	}
+	defer client.Close()
+	// The directory on the command line replaces MARKDOWN_IMPORT_DIR
+	registry.Register(tools.NewImportMarkdownTool(client, dir))
+
+	log.SetOutput(mcperrors.NewRedactingWriter(os.Stderr))
+
+	arguments := map[string]interface{}{
+		"if_exists": *ifExists,
+		"tag":       *tag,
+		"dry_run":   *dryRun,
+	}
+	if *maxPages > 0 {
+		arguments["max_pages"] = float64(*maxPages)
+	}
+	ctx := tools.WithProgress(context.Background(), func(progress, total float64, message string) {
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s\n", int(progress), int(total), message)
+	})
+	return printResult(ctx, registry, "import_markdown", arguments, out)
+}
+
+// printResult executes a tool and prints its result as a cliResult
+func printResult(ctx context.Context, registry *tools.Registry, name string, arguments map[string]interface{}, out io.Writer) int {
+	result, execErr := registry.Execute(ctx, name, arguments)
+	if execErr != nil {
+		fmt.Fprintln(os.Stderr, execErr)
+		var mcpErr *mcperrors.MCPError
+		if errors.As(execErr, &mcpErr) {
+			if data, ok := mcpErr.Data.(*mcperrors.ErrorData); ok && data.Error != mcpErr.Message {
+				fmt.Fprintln(os.Stderr, data.Error)
+			}
+		}
+		return 1
+	}
+
+	output := cliResult{
+		Content: make([]cliContent, 0, len(result.Content)),
+		IsError: result.IsError,
+	}
+	for _, c := range result.Content {
+		output.Content = append(output.Content, cliContent{Type: c.Type, Text: c.Text, Data: c.Data, MimeType: c.MimeType, Resource: c.Resource, Annotations: c.Annotations})
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(output); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if result.IsError {
+		return 1
+	}
+	return 0
+}
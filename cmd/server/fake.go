@@ -0,0 +1,47 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapboxtest"
+)
+
+// fakeUpstreamFlag runs the server or a CLI command against an in-memory
+// Scrapbox instead of the real one
+const fakeUpstreamFlag = "-fake-upstream"
+
+// startFakeUpstream starts a fake Scrapbox with a few sample pages and points
+// the configuration at it, so the whole MCP flow works without credentials.
+// COSENSE_PROJECT_NAME is kept if set; real credentials are never used.
+func startFakeUpstream() *scrapboxtest.Server {
+	project := os.Getenv("COSENSE_PROJECT_NAME")
+	if project == "" {
+		project = "fake-project"
+	}
+	fake := scrapboxtest.New(project)
+	fake.AddPage("Welcome",
+		"This project is served by the fake upstream.",
+		"See [Getting Started] and [Notes].",
+	)
+	fake.AddPage("Getting Started",
+		"[* Steps]",
+		" Read [Welcome]",
+		" Try search_pages with \"fake\"",
+	)
+	fake.AddPage("Notes",
+		"Edits are kept in memory until the server exits.",
+		"#sample",
+	)
+
+	os.Setenv("SCRAPBOX_API_URL", fake.APIURL())
+	os.Setenv("SCRAPBOX_WS_URL", fake.WebSocketURL())
+	os.Setenv("COSENSE_PROJECT_NAME", project)
+	os.Setenv("COSENSE_SID", fake.SessionCookie)
+	for _, name := range []string{"COSENSE_SID_FILE", "COSENSE_SID_REFRESH_COMMAND", "OFFLINE_EXPORT_PATH", "PROJECT_API_URLS", "PROJECT_WS_URLS", "SCRAPBOX_PROXY_URL"} {
+		os.Unsetenv(name)
+	}
+
+	log.Printf("Fake upstream: serving project %s at %s", project, fake.APIURL())
+	return fake
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/config"
+	"github.com/hiroki/scrapbox_mcp/internal/health"
+	"github.com/hiroki/scrapbox_mcp/internal/mcp"
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+// healthCheckTimeout bounds each individual deep health check
+const healthCheckTimeout = 10 * time.Second
+
+// newHealthChecker registers the deep health checks for the configured backend
+func newHealthChecker(cfg *config.Config, reader scrapbox.Reader, client *scrapbox.Client, sessionMgr *mcp.SessionManager) *health.Checker {
+	checker := health.NewChecker(healthCheckTimeout)
+
+	checker.Register("sessions", false, func(ctx context.Context) (interface{}, error) {
+		return map[string]int{"active": sessionMgr.Count()}, nil
+	})
+	checker.Register("streams", false, func(ctx context.Context) (interface{}, error) {
+		return sessionMgr.StreamStats(), nil
+	})
+
+	if offline, ok := reader.(*scrapbox.OfflineClient); ok {
+		checker.Register("offline_export", true, func(ctx context.Context) (interface{}, error) {
+			return map[string]interface{}{
+				"path":  cfg.OfflineExportPath,
+				"pages": offline.PageCount(),
+			}, nil
+		})
+	}
+
+	if client != nil {
+		checker.Register("scrapbox_rest", true, func(ctx context.Context) (interface{}, error) {
+			project, err := client.RESTClient.GetProject(client.ProjectName)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]string{"project": project.Name}, nil
+		})
+		checker.Register("scrapbox_credentials", false, func(ctx context.Context) (interface{}, error) {
+			return client.ValidateCredentials()
+		})
+		checker.Register("scrapbox_websocket", false, func(ctx context.Context) (interface{}, error) {
+			transport, err := client.CheckWebSocket(cfg.WebSocketURL)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]scrapbox.Transport{"transport": transport}, nil
+		})
+		checker.Register("write_queue", false, func(ctx context.Context) (interface{}, error) {
+			return client.WriteQueueStats(), nil
+		})
+	}
+
+	return checker
+}
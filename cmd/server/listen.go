@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+)
+
+// listenFDsStart is the first file descriptor passed by systemd socket activation
+const listenFDsStart = 3
+
+// newListener returns the socket passed by systemd socket activation
+// (LISTEN_PID/LISTEN_FDS), or listens on addr. With an activated socket,
+// systemd keeps accepting connections while the process restarts, so
+// clients queue instead of being refused.
+func newListener(addr string) (net.Listener, error) {
+	pid, _ := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	fds, _ := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if pid != os.Getpid() || fds < 1 {
+		return net.Listen("tcp", addr)
+	}
+
+	// Do not pass the socket on to child processes (e.g. COSENSE_SID_REFRESH_COMMAND)
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+	if fds > 1 {
+		return nil, fmt.Errorf("expected one activated socket, got %d", fds)
+	}
+
+	file := os.NewFile(uintptr(listenFDsStart), "LISTEN_FD_3")
+	defer file.Close()
+	listener, err := net.FileListener(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to use activated socket: %v", err)
+	}
+	return listener, nil
+}
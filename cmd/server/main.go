@@ -2,18 +2,34 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/hiroki/scrapbox_mcp/internal/attribution"
+	"github.com/hiroki/scrapbox_mcp/internal/audit"
+	"github.com/hiroki/scrapbox_mcp/internal/backup"
+	"github.com/hiroki/scrapbox_mcp/internal/changes"
 	"github.com/hiroki/scrapbox_mcp/internal/config"
+	"github.com/hiroki/scrapbox_mcp/internal/gitmirror"
+	"github.com/hiroki/scrapbox_mcp/internal/gyazo"
+	"github.com/hiroki/scrapbox_mcp/internal/index"
+	"github.com/hiroki/scrapbox_mcp/internal/linkcheck"
 	"github.com/hiroki/scrapbox_mcp/internal/mcp"
+	"github.com/hiroki/scrapbox_mcp/internal/quota"
+	"github.com/hiroki/scrapbox_mcp/internal/rbac"
+	"github.com/hiroki/scrapbox_mcp/internal/recording"
 	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	"github.com/hiroki/scrapbox_mcp/internal/secrets"
+	"github.com/hiroki/scrapbox_mcp/internal/tasks"
+	"github.com/hiroki/scrapbox_mcp/internal/titles"
 	"github.com/hiroki/scrapbox_mcp/internal/tools"
+	"github.com/hiroki/scrapbox_mcp/internal/undo"
+	mcperrors "github.com/hiroki/scrapbox_mcp/pkg/errors"
 	"github.com/joho/godotenv"
 )
 
@@ -21,44 +37,285 @@ func main() {
 	// Load .env file (optional, won't error if file doesn't exist)
 	_ = godotenv.Load()
 
+	// Fake upstream mode: an in-memory Scrapbox replaces the real one
+	if len(os.Args) > 1 && (os.Args[1] == fakeUpstreamFlag || os.Args[1] == "-"+fakeUpstreamFlag) {
+		fake := startFakeUpstream()
+		defer fake.Close()
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+
+	// CLI mode: run a single tool and exit
+	if len(os.Args) > 1 {
+		os.Exit(runCLI(os.Args[1:]))
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// Keep secrets out of logs and error payloads
+	for _, secret := range cfg.Secrets() {
+		mcperrors.RegisterSecret(secret)
+	}
+	log.SetOutput(mcperrors.NewRedactingWriter(os.Stderr))
+
 	log.Printf("Starting Scrapbox MCP Server...")
 	log.Printf("Environment: %s", cfg.Environment)
 	log.Printf("Port: %s", cfg.Port)
 	log.Printf("Project: %s", cfg.ProjectName)
 
-	// Initialize Scrapbox client
-	scrapboxClient := scrapbox.NewClient(
-		cfg.ProjectName,
-		cfg.SessionCookie,
-		cfg.RestAPIBaseURL,
-		cfg.RequestTimeout,
-	)
+	// Initialize Scrapbox backend (scrapboxClient is nil in offline mode)
+	reader, scrapboxClient, err := newBackend(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize Scrapbox backend: %v", err)
+	}
+	if scrapboxClient == nil {
+		log.Printf("Offline mode: serving read-only tools from %s", cfg.OfflineExportPath)
+	}
+
+	// Debug capture of Scrapbox traffic and MCP messages (optional)
+	var recorder *recording.Recorder
+	if cfg.DebugCapturePath != "" {
+		recorder, err = recording.Open(cfg.DebugCapturePath, cfg.ProjectName, cfg.RestAPIBaseURL)
+		if err != nil {
+			log.Fatalf("Failed to open debug capture: %v", err)
+		}
+		defer recorder.Close()
+		if scrapboxClient != nil {
+			scrapboxClient.SetRecorder(recorder)
+		}
+		log.Printf("Debug capture enabled: recording Scrapbox traffic and MCP messages to %s", cfg.DebugCapturePath)
+	}
+
+	// serverUser is the account COSENSE_SID belongs to, when validated
+	var serverUser string
+	if cfg.ValidateCredentials && scrapboxClient != nil {
+		info, err := scrapboxClient.ValidateCredentials()
+		if err != nil {
+			log.Fatalf("Credential validation failed: %v", err)
+		}
+		log.Printf("Authenticated as %s on project %s", info.User, info.Project)
+		serverUser = info.User
+	}
 
 	// Initialize tool registry
 	registry := tools.NewRegistry()
-	registry.Register(tools.NewGetPageTool(scrapboxClient))
-	registry.Register(tools.NewListPagesTool(scrapboxClient))
-	registry.Register(tools.NewSearchPagesTool(scrapboxClient))
-	registry.Register(tools.NewInsertLinesTool(scrapboxClient, cfg.WebSocketURL))
-	registry.Register(tools.NewCreatePageTool(scrapboxClient, cfg.WebSocketURL))
-	registry.Register(tools.NewEditPageTool(scrapboxClient, cfg.WebSocketURL))
+	registry.SetTimeout(cfg.ToolTimeout)
+	registry.SetMaxResponseBytes(cfg.MaxResponseBytes)
+	if err := registerCoreTools(registry, cfg, reader, scrapboxClient); err != nil {
+		log.Fatalf("Failed to register tools: %v", err)
+	}
+
+	// Image uploads (live only)
+	if scrapboxClient != nil {
+		registry.Register(tools.NewUploadImageTool(scrapboxClient, newImageUploader(cfg, scrapboxClient)))
+	}
+
+	// Initialize audit log (optional)
+	var auditLogger *audit.Logger
+	if cfg.AuditLogPath != "" {
+		auditLogger, err = audit.NewLogger(cfg.AuditLogPath)
+		if err != nil {
+			log.Fatalf("Failed to initialize audit log: %v", err)
+		}
+		registry.SetAuditLogger(auditLogger)
+		registry.SetAuditUser(serverUser)
+		registry.Register(tools.NewGetAuditLogTool(auditLogger))
+		log.Printf("Audit log: %s", cfg.AuditLogPath)
+	}
+
+	// Restrict tools by the role of each session's bearer token (optional).
+	// Added first so refused calls get no confirmation token or quota.
+	var roles *rbac.Policy
+	if len(cfg.RoleTokens) > 0 || !strings.EqualFold(cfg.DefaultRole, rbac.Admin) {
+		roles, err = rbac.NewPolicy(cfg.RoleTokens, cfg.DefaultRole)
+		if err != nil {
+			log.Fatalf("Invalid ROLE_TOKENS or DEFAULT_ROLE: %v", err)
+		}
+		registry.Use(roles.Middleware)
+		log.Printf("Tool roles enabled: %d tokens, default role %s", len(cfg.RoleTokens), cfg.DefaultRole)
+	}
+
+	// Require confirmation of destructive calls (optional). Added before the
+	// quotas so unconfirmed previews do not use up quota.
+	if cfg.ConfirmDestructive {
+		registry.RequireConfirmation(cfg.ConfirmationTTL)
+		log.Printf("Destructive calls require confirmation within %s", cfg.ConfirmationTTL)
+	}
+
+	// Enforce per-session tool quotas (optional)
+	var limiter *quota.Limiter
+	if len(cfg.ToolQuotas) > 0 {
+		rules, err := quota.ParseRules(cfg.ToolQuotas)
+		if err != nil {
+			log.Fatalf("Invalid TOOL_QUOTAS: %v", err)
+		}
+		limiter = quota.NewLimiter(rules)
+		registry.Use(limiter.Middleware)
+		log.Printf("Tool quotas: %v", rules)
+	}
+
+	// Initialize undo store (optional)
+	var undoStore *undo.Store
+	if cfg.UndoStorePath != "" && scrapboxClient != nil {
+		undoStore, err = undo.NewStore(cfg.UndoStorePath)
+		if err != nil {
+			log.Fatalf("Failed to initialize undo store: %v", err)
+		}
+		registry.SetUndoStore(undoStore, scrapboxClient)
+		// Restored lines are not the server's own content, so they stay unmarked
+		registry.Register(tools.NewRevertLastEditTool(scrapboxClient.WithoutLineMarker(), undoStore))
+		// Replace get_recent_changes with a version that can diff against snapshots
+		registry.Register(tools.NewGetRecentChangesTool(reader, undoStore))
+		// and blame_page with one that marks lines written through this server
+		registry.Register(tools.NewBlamePageTool(scrapboxClient, scrapboxClient, undoStore))
+		log.Printf("Undo store: %s", cfg.UndoStorePath)
+	}
+
+	// Initialize backups (optional)
+	backupCtx, stopBackups := context.WithCancel(context.Background())
+	defer stopBackups()
+	if (cfg.BackupDir != "" || cfg.BackupS3Bucket != "") && scrapboxClient != nil {
+		var storage backup.Storage
+		if cfg.BackupS3Bucket != "" {
+			storage = backup.NewS3Storage(cfg.BackupS3Endpoint, cfg.BackupS3Bucket, cfg.BackupS3Prefix,
+				cfg.BackupS3Region, cfg.BackupS3AccessKey, cfg.BackupS3SecretKey, cfg.RequestTimeout)
+		} else {
+			storage, err = backup.NewLocalStorage(cfg.BackupDir)
+			if err != nil {
+				log.Fatalf("Failed to initialize backup storage: %v", err)
+			}
+		}
+		scheduler := backup.NewScheduler(scrapboxClient, storage, cfg.BackupInterval, cfg.BackupRetention)
+		registry.Register(tools.NewTriggerBackupTool(scheduler))
+		go scheduler.Run(backupCtx)
+		log.Printf("Backups: %s (interval: %s, retention: %d)", storage, cfg.BackupInterval, cfg.BackupRetention)
+	}
+
+	// Broken link checks (live only; scheduled when LINK_CHECK_INTERVAL is set)
+	linkCheckCtx, stopLinkChecks := context.WithCancel(context.Background())
+	defer stopLinkChecks()
+	if scrapboxClient != nil {
+		checker := linkcheck.NewChecker(cfg.LinkCheckConcurrency, cfg.LinkCheckTimeout)
+		linkScheduler := linkcheck.NewScheduler(scrapboxClient, index.New(scrapboxClient, cfg.PageIndexMaxAge),
+			checker, cfg.LinkCheckInterval, cfg.LinkCheckPage)
+		registry.Register(tools.NewRunLinkCheckTool(linkScheduler))
+		go linkScheduler.Run(linkCheckCtx)
+		if cfg.LinkCheckInterval > 0 {
+			log.Printf("Link checks: every %s to %s", cfg.LinkCheckInterval, linkScheduler.ReportPage())
+		}
+	}
+
+	// Git mirror (optional; scheduled with GIT_MIRROR_INTERVAL, or after commits with GIT_MIRROR_WATCH)
+	mirrorCtx, stopMirror := context.WithCancel(context.Background())
+	defer stopMirror()
+	if cfg.GitMirrorDir != "" && scrapboxClient != nil {
+		mirror := gitmirror.NewMirror(scrapboxClient, cfg.GitMirrorDir, cfg.GitMirrorInterval, cfg.GitMirrorRemote)
+		registry.Register(tools.NewSyncToGitTool(mirror))
+		watching := false
+		if cfg.GitMirrorWatch {
+			if err := mirror.Watch(cfg.WebSocketURL); err != nil {
+				log.Printf("Git mirror: failed to join the project updates stream: %v", err)
+			} else {
+				watching = true
+			}
+		}
+		go mirror.Run(mirrorCtx, watching)
+		log.Printf("Git mirror: %s (interval: %s, watch: %t)", mirror.Dir(), cfg.GitMirrorInterval, watching)
+	}
 
 	// Initialize MCP components
 	sessionMgr := mcp.NewSessionManager(cfg.SessionTTL)
+	if len(cfg.SessionEncryptionKeys) > 0 {
+		keyring, err := secrets.ParseKeys(cfg.SessionEncryptionKeys)
+		if err != nil {
+			log.Fatalf("Invalid SESSION_ENCRYPTION_KEYS: %v", err)
+		}
+		sessionMgr.SetKeyring(keyring)
+	}
+	if cfg.SessionStatePath != "" {
+		restored, err := sessionMgr.Load(cfg.SessionStatePath)
+		if err != nil {
+			log.Printf("Failed to restore sessions: %v", err)
+		} else {
+			log.Printf("Restored %d sessions from %s", restored, cfg.SessionStatePath)
+		}
+	}
 	handler := mcp.NewMessageHandler(registry, sessionMgr)
+
+	// Limit tool calls to the projects granted by the client's roots
+	registry.EnforceProjectScope(reader)
+	handler.SetRequireRoots(cfg.RequireRoots)
+
+	// Client-supplied credentials (optional; live only)
+	tenantCtx, stopTenants := context.WithCancel(context.Background())
+	defer stopTenants()
+	var tenants *mcp.Tenants
+	if cfg.AllowClientCredentials && scrapboxClient != nil {
+		setup := &tenantSetup{cfg: cfg, auditLogger: auditLogger, roles: roles, limiter: limiter}
+		tenants = mcp.NewTenants(setup.newRegistry, cfg.SessionTTL)
+		setup.tenants = tenants
+		handler.SetTenants(tenants)
+		registry.Register(tools.NewSetCredentialsTool(tenants))
+		go tenants.Run(tenantCtx)
+		log.Printf("Client-supplied credentials enabled")
+	}
+
+	// Realtime change subscriptions (optional)
+	watcherCtx, stopWatcher := context.WithCancel(context.Background())
+	defer stopWatcher()
+	if cfg.EnableSubscriptions && scrapboxClient != nil {
+		watcher := changes.NewWatcher(scrapboxClient, cfg.WebSocketURL)
+		handler.SetChangeWatcher(watcher)
+		registry.Register(tools.NewWatchPageTool(watcher, scrapboxClient.ProjectName))
+		go watcher.Run(watcherCtx)
+		log.Printf("Resource subscriptions enabled")
+	}
+
 	transport := mcp.NewTransport(handler, sessionMgr, cfg.AllowedOrigins, cfg.EnableCORS)
+	transport.SetMaxBodyBytes(cfg.MaxRequestBodyBytes)
+	transport.SetHeartbeat(cfg.StreamHeartbeatInterval)
+	if recorder != nil {
+		transport.SetRecorder(recorder)
+	}
+	if roles != nil {
+		transport.SetRoles(roles)
+	}
+
+	originPolicy, err := mcp.ParseOriginPolicy(cfg.OriginPolicy)
+	if err != nil {
+		log.Fatalf("Invalid ORIGIN_POLICY: %v", err)
+	}
+	trustedProxies, err := mcp.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+	transport.SetOriginPolicy(originPolicy, trustedProxies)
+
+	// Apply tool allowlist and reload configuration on SIGHUP
+	applyToolAllowlist(registry, cfg.ToolAllowlist)
+	watchReload(cfg, registry, transport, scrapboxClient)
 
 	// Setup HTTP server
 	mux := http.NewServeMux()
 
+	// Restrict the MCP endpoints by source address and client certificate (optional)
+	allowedIPs, err := mcp.ParseAllowedIPs(cfg.MCPAllowedIPs)
+	if err != nil {
+		log.Fatalf("Invalid MCP_ALLOWED_IPS: %v", err)
+	}
+	guard := func(handler http.HandlerFunc) http.HandlerFunc { return handler }
+	if len(allowedIPs) > 0 || cfg.TLSClientCA != "" {
+		guard = mcp.NewClientAccess(allowedIPs, trustedProxies, cfg.TLSClientCA != "").Wrap
+		if len(allowedIPs) > 0 {
+			log.Printf("MCP endpoints limited to %v", cfg.MCPAllowedIPs)
+		}
+	}
+
 	// MCP endpoint
-	mux.HandleFunc("/mcp", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/mcp", guard(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case "POST":
 			transport.HandlePOST(w, r)
@@ -71,14 +328,24 @@ func main() {
 		default:
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
-	})
+	}))
+
+	// MCP over WebSocket (optional)
+	if cfg.EnableWebSocketTransport {
+		mux.HandleFunc("/mcp/ws", guard(transport.HandleWebSocket))
+		log.Printf("MCP WebSocket endpoint enabled at /mcp/ws")
+	}
 
-	// Health check endpoint
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		fmt.Fprintf(w, `{"status":"healthy"}`)
-	})
+	// Admin endpoint for enabling/disabling tools at runtime
+	if cfg.AdminToken != "" {
+		mux.HandleFunc("/admin/tools", newAdminToolsHandler(registry, cfg.AdminToken))
+	}
+
+	// Health check endpoints
+	checker := newHealthChecker(cfg, reader, scrapboxClient, sessionMgr)
+	mux.HandleFunc("/health", checker.HealthHandler())
+	mux.HandleFunc("/live", checker.LiveHandler())
+	mux.HandleFunc("/ready", checker.ReadyHandler())
 
 	// Create HTTP server
 	server := &http.Server{
@@ -89,10 +356,23 @@ func main() {
 		IdleTimeout:  600 * time.Second,
 	}
 
+	// End SSE and WebSocket streams on shutdown so clients reconnect
+	server.RegisterOnShutdown(sessionMgr.CloseStreams)
+
+	listener, err := newListener(server.Addr)
+	if err != nil {
+		log.Fatalf("Failed to listen: %v", err)
+	}
+
+	serve, err := newServeFunc(cfg, server, listener)
+	if err != nil {
+		log.Fatalf("Failed to configure TLS: %v", err)
+	}
+
 	// Start server in a goroutine
 	go func() {
-		log.Printf("Server listening on port %s", cfg.Port)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Printf("Server listening on %s", listener.Addr())
+		if err := serve(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Server failed: %v", err)
 		}
 	}()
@@ -109,8 +389,198 @@ func main() {
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		log.Printf("Server forced to shutdown: %v", err)
+	}
+
+	// Wait for in-flight tool calls so page writes are not left half-applied
+	if err := registry.Wait(ctx); err != nil {
+		log.Printf("Timed out waiting for in-flight tool calls: %v", err)
+	}
+
+	// Close the connections opened with client-supplied credentials
+	if tenants != nil {
+		tenants.Close()
+	}
+
+	// Hand the sessions over to the next process
+	if cfg.SessionStatePath != "" {
+		if err := sessionMgr.Save(cfg.SessionStatePath); err != nil {
+			log.Printf("Failed to save sessions: %v", err)
+		}
+	}
+
+	// Close WebSocket connection to Scrapbox
+	if scrapboxClient != nil {
+		if err := scrapboxClient.Close(); err != nil {
+			log.Printf("Failed to close Scrapbox connection: %v", err)
+		}
+	}
+
+	if auditLogger != nil {
+		if err := auditLogger.Close(); err != nil {
+			log.Printf("Failed to close audit log: %v", err)
+		}
+	}
+
+	if undoStore != nil {
+		if err := undoStore.Close(); err != nil {
+			log.Printf("Failed to close undo store: %v", err)
+		}
 	}
 
 	log.Println("Server exited")
 }
+
+// newBackend returns the Scrapbox backend selected by configuration.
+// In offline mode the returned *scrapbox.Client is nil and only reads are possible.
+func newBackend(cfg *config.Config) (scrapbox.Reader, *scrapbox.Client, error) {
+	if cfg.OfflineExportPath != "" {
+		offline, err := scrapbox.NewOfflineClient(cfg.OfflineExportPath, cfg.ProjectName)
+		if err != nil {
+			return nil, nil, err
+		}
+		return offline, nil, nil
+	}
+
+	client := scrapbox.NewClient(
+		cfg.ProjectName,
+		cfg.SessionCookie,
+		cfg.RestAPIBaseURL,
+		cfg.RequestTimeout,
+	)
+	proxy, err := scrapbox.NewProxyFunc(cfg.UpstreamProxy)
+	if err != nil {
+		return nil, nil, err
+	}
+	client.SetProxy(proxy)
+	headers, err := scrapbox.ParseHeaders(cfg.UpstreamHeaders)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cfg.UpstreamUserAgent != "" {
+		headers.Set("User-Agent", cfg.UpstreamUserAgent)
+	}
+	client.SetHeaders(headers)
+	apiURLs, err := scrapbox.ParseProjectURLs(cfg.ProjectAPIURLs)
+	if err != nil {
+		return nil, nil, err
+	}
+	wsURLs, err := scrapbox.ParseProjectURLs(cfg.ProjectWSURLs)
+	if err != nil {
+		return nil, nil, err
+	}
+	client.SetProjectEndpoints(apiURLs, wsURLs)
+	namespace, err := scrapbox.ParseNamespace(cfg.WebSocketNamespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	socketAuth, err := scrapbox.ParseSocketAuth(cfg.WebSocketAuth)
+	if err != nil {
+		return nil, nil, err
+	}
+	client.SetNamespace(namespace, socketAuth)
+	transport, err := scrapbox.ParseTransport(cfg.WebSocketTransport)
+	if err != nil {
+		return nil, nil, err
+	}
+	client.SetTransport(transport)
+	attributionMode, err := attribution.ParseMode(cfg.AIAttribution)
+	if err != nil {
+		return nil, nil, err
+	}
+	if marker := attribution.NewMarker(attributionMode, cfg.AIAttributionText); marker != nil {
+		client.SetLineMarker(marker.MarkLines)
+	}
+	client.EnsureWebSocket(cfg.WebSocketURL)
+	client.WebSocketClient.SetIdleTimeout(cfg.WSIdleTimeout)
+
+	if cfg.SessionRefreshCommand != "" {
+		client.SetCredentialProvider(scrapbox.NewCommandCredentialProvider(cfg.SessionRefreshCommand))
+	} else if cfg.SessionCookieFile != "" {
+		client.SetCredentialProvider(scrapbox.NewFileCredentialProvider(cfg.SessionCookieFile))
+	}
+	return client, client, nil
+}
+
+// newImageUploader returns where upload_image stores images: Gyazo when
+// GYAZO_ACCESS_TOKEN is set, else the project's own file storage
+func newImageUploader(cfg *config.Config, client *scrapbox.Client) tools.ImageUploader {
+	if cfg.GyazoAccessToken != "" {
+		return gyazo.NewClient(cfg.GyazoAccessToken, cfg.RequestTimeout)
+	}
+	return scrapbox.NewFileUploader(client)
+}
+
+// registerCoreTools registers the tools that only depend on the Scrapbox backend.
+// They are available in both server and CLI mode. Write tools are skipped when
+// client is nil (offline mode).
+func registerCoreTools(registry *tools.Registry, cfg *config.Config, reader scrapbox.Reader, client *scrapbox.Client) error {
+	aliases, err := titles.ParseAliases(cfg.TitleAliases)
+	if err != nil {
+		return err
+	}
+	resolver := titles.NewResolver(reader, cfg.PageIndexMaxAge, aliases)
+	registry.SetTitleResolver(resolver, reader)
+	pageIndex := index.New(reader, cfg.PageIndexMaxAge)
+	taskConventions, err := tasks.ParseConventions(cfg.TaskMarkers)
+	if err != nil {
+		return err
+	}
+	taskMatcher := tasks.NewMatcher(taskConventions)
+
+	registry.Register(tools.NewGetPageTool(reader))
+	registry.Register(tools.NewListPagesTool(reader))
+	registry.Register(tools.NewSearchPagesTool(reader))
+	registry.Register(tools.NewSetDefaultProjectTool(reader))
+	registry.Register(tools.NewProjectStatsTool(reader))
+	registry.Register(tools.NewGetRecentChangesTool(reader, nil))
+	registry.Register(tools.NewGetPageLinksTool(reader))
+	registry.Register(tools.NewGetPageOutlineTool(reader))
+	registry.Register(tools.NewGetPageTextBetweenTool(reader))
+	registry.Register(tools.NewListTasksTool(reader, pageIndex, taskMatcher))
+	registry.Register(tools.NewGrepPagesTool(reader, pageIndex))
+	registry.Register(tools.NewListExternalLinksTool(reader, pageIndex))
+	registry.Register(tools.NewAnalyzeGraphTool(reader, pageIndex))
+	registry.Register(tools.NewFindStalePagesTool(reader, pageIndex))
+	registry.Register(tools.NewCheckPageExistsTool(reader))
+	registry.Register(tools.NewFindDuplicateTitlesTool(reader, resolver))
+	var linker tools.PageLinker
+	if client != nil {
+		linker = client
+	}
+	registry.Register(tools.NewExportMarkdownTool(reader, pageIndex, linker, cfg.MarkdownExportDir))
+	registry.Register(tools.NewExportPageListTool(reader, cfg.ExportDir))
+
+	if client == nil {
+		return nil
+	}
+	attributionMode, err := attribution.ParseMode(cfg.AIAttribution)
+	if err != nil {
+		return err
+	}
+	if attributionMode == attribution.ModeIndex {
+		registry.Use(attribution.NewIndex(client, cfg.AIAttributionPage).Middleware)
+	}
+	registry.Register(tools.NewDiagnoseTool(client, cfg.WebSocketURL))
+	registry.Register(tools.NewGetPageCommitsTool(client, client))
+	registry.Register(tools.NewBlamePageTool(client, client, nil))
+	registry.Register(tools.NewPageGrowthTool(client, client))
+	registry.Register(tools.NewGetActivityStreamTool(client, client))
+	registry.Register(tools.NewInsertLinesTool(client))
+	registry.Register(tools.NewUpdateLineTool(client))
+	registry.Register(tools.NewCreatePageTool(client))
+	registry.Register(tools.NewEditPageTool(client, cfg.EditTitleMismatch))
+	registry.Register(tools.NewEditSectionTool(client))
+	registry.Register(tools.NewToggleTaskTool(client, taskMatcher))
+	registry.Register(tools.NewInsertIconTool(client, client))
+	registry.Register(tools.NewBatchEditTool(client, cfg.EditTitleMismatch))
+	registry.Register(tools.NewReplaceAcrossProjectTool(client, pageIndex))
+	registry.Register(tools.NewRenamePageTool(client, pageIndex))
+	registry.Register(tools.NewMergePagesTool(client, pageIndex))
+	registry.Register(tools.NewArchivePageTool(client, client, pageIndex))
+	registry.Register(tools.NewGenerateIndexPageTool(client, pageIndex))
+	if cfg.MarkdownImportDir != "" {
+		registry.Register(tools.NewImportMarkdownTool(client, cfg.MarkdownImportDir))
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/hiroki/scrapbox_mcp/internal/config"
+	"github.com/hiroki/scrapbox_mcp/internal/mcp"
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	"github.com/hiroki/scrapbox_mcp/internal/tools"
+	mcperrors "github.com/hiroki/scrapbox_mcp/pkg/errors"
+	"github.com/joho/godotenv"
+)
+
+// applyToolAllowlist enables only the listed tools; an empty list enables all tools
+func applyToolAllowlist(registry *tools.Registry, allowlist []string) {
+	allowed := make(map[string]bool, len(allowlist))
+	for _, name := range allowlist {
+		allowed[name] = true
+	}
+
+	for name := range registry.Status() {
+		if err := registry.SetEnabled(name, len(allowed) == 0 || allowed[name]); err != nil {
+			log.Printf("Failed to update tool %s: %v", name, err)
+		}
+	}
+}
+
+// watchReload reloads configuration on SIGHUP without dropping sessions.
+// The .env file is re-read (overriding the environment) so values can be rotated
+// in place. Reloadable settings: session cookie, tool allowlist, allowed origins.
+func watchReload(current *config.Config, registry *tools.Registry, transport *mcp.Transport, client *scrapbox.Client) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		for range hup {
+			log.Println("Reloading configuration...")
+
+			_ = godotenv.Overload()
+			cfg, err := config.Load()
+			if err != nil {
+				log.Printf("Failed to reload configuration, keeping current settings: %v", err)
+				continue
+			}
+
+			for _, secret := range cfg.Secrets() {
+				mcperrors.RegisterSecret(secret)
+			}
+
+			if cfg.ProjectName != current.ProjectName {
+				log.Printf("COSENSE_PROJECT_NAME cannot be changed without a restart; ignoring")
+			}
+
+			if client != nil && cfg.SessionCookie != current.SessionCookie {
+				client.SetSessionCookie(cfg.SessionCookie)
+				log.Printf("Session cookie rotated")
+			}
+
+			applyToolAllowlist(registry, cfg.ToolAllowlist)
+			transport.SetAllowedOrigins(cfg.AllowedOrigins)
+
+			current = cfg
+			log.Println("Configuration reloaded")
+		}
+	}()
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/mcp"
+	"github.com/hiroki/scrapbox_mcp/internal/recording"
+	mcperrors "github.com/hiroki/scrapbox_mcp/pkg/errors"
+)
+
+// replayResult compares one recorded MCP request with its replay
+type replayResult struct {
+	Method   string          `json:"method"`
+	Tool     string          `json:"tool,omitempty"`
+	Match    bool            `json:"match"`
+	Recorded json.RawMessage `json:"recorded,omitempty"`
+	Replayed json.RawMessage `json:"replayed,omitempty"`
+}
+
+// replayReport is the JSON printed by the replay subcommand
+type replayReport struct {
+	Requests   int            `json:"requests"`
+	Matched    int            `json:"matched"`
+	Results    []replayResult `json:"results"`
+	Mismatches []string       `json:"upstreamMismatches,omitempty"`
+}
+
+// runReplay replays the tool calls of a capture against the core tools,
+// with Scrapbox played back from the same capture, and reports the requests
+// whose responses differ from the recorded ones
+func runReplay(args []string, out io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Capture file required\n\n%s", cliUsage)
+		return 2
+	}
+	entries, err := recording.Load(args[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	log.SetOutput(mcperrors.NewRedactingWriter(os.Stderr))
+
+	// Serve the recorded Scrapbox traffic locally
+	upstream := recording.NewServer(entries)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	go http.Serve(listener, upstream)
+	defer listener.Close()
+
+	// Point the backend at it, keeping the rest of the configuration
+	meta := recording.Meta(entries)
+	apiPath := "/api"
+	if u, err := url.Parse(meta.APIURL); err == nil && u.Path != "" {
+		apiPath = u.Path
+	}
+	os.Setenv("SCRAPBOX_API_URL", "http://"+listener.Addr().String()+apiPath)
+	os.Setenv("SCRAPBOX_WS_URL", "ws://"+listener.Addr().String()+"/socket.io/")
+	if meta.Project != "" {
+		os.Setenv("COSENSE_PROJECT_NAME", meta.Project)
+	}
+	// The capture holds no cookie, so a placeholder keeps real credentials out of the replay
+	os.Setenv("COSENSE_SID", "replay")
+	for _, name := range []string{"COSENSE_SID_FILE", "COSENSE_SID_REFRESH_COMMAND", "OFFLINE_EXPORT_PATH", "PROJECT_API_URLS", "PROJECT_WS_URLS", "SCRAPBOX_PROXY_URL", "DEBUG_CAPTURE_PATH"} {
+		os.Unsetenv(name)
+	}
+
+	registry, client, err := newCLIRegistry()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	handler := mcp.NewMessageHandler(registry, mcp.NewSessionManager(time.Hour))
+
+	report := replayReport{Results: []replayResult{}}
+	for _, entry := range entries {
+		if entry.Kind != recording.KindMCP {
+			continue
+		}
+		// Only tool calls are replayed; other methods depend on the session
+		// and on server-only configuration
+		var req mcp.JSONRPCRequest
+		if err := json.Unmarshal(entry.Request, &req); err != nil || req.ID == nil || req.Method != "tools/call" {
+			continue
+		}
+
+		result := replayResult{Method: req.Method}
+		var params mcp.ToolsCallRequest
+		if json.Unmarshal(req.Params, &params) == nil {
+			result.Tool = params.Name
+		}
+		replayed, _ := json.Marshal(handler.HandleRequest(context.Background(), &req, ""))
+		result.Match = sameJSON(entry.Response, replayed)
+		if !result.Match {
+			result.Recorded, result.Replayed = entry.Response, replayed
+		}
+
+		report.Requests++
+		if result.Match {
+			report.Matched++
+		}
+		report.Results = append(report.Results, result)
+	}
+	if client != nil {
+		client.Close()
+	}
+	report.Mismatches = upstream.Mismatches()
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if report.Matched != report.Requests || len(report.Mismatches) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// sameJSON reports whether two JSON documents are equal
+func sameJSON(a, b []byte) bool {
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
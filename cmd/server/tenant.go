@@ -0,0 +1,93 @@
+package main
+
+import (
+	"log"
+
+	"github.com/hiroki/scrapbox_mcp/internal/audit"
+	"github.com/hiroki/scrapbox_mcp/internal/config"
+	"github.com/hiroki/scrapbox_mcp/internal/mcp"
+	"github.com/hiroki/scrapbox_mcp/internal/quota"
+	"github.com/hiroki/scrapbox_mcp/internal/rbac"
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	"github.com/hiroki/scrapbox_mcp/internal/tools"
+	mcperrors "github.com/hiroki/scrapbox_mcp/pkg/errors"
+)
+
+// tenantSetup builds the tool registries of sessions that supplied their own
+// credentials. They get the core tools and upload_image with the same audit
+// log, roles, confirmation, quotas, project scope and allowlist as the server's
+// registry. Features bound to the server's project (undo, backups, link
+// checks, git mirror, subscriptions) are not registered for them, and the
+// server's own accounts and directories (Gyazo token, import and export
+// directories) are cleared from their config: upload_image stores files in
+// the tenant's project and exports come back inline.
+type tenantSetup struct {
+	cfg         *config.Config
+	auditLogger *audit.Logger
+	roles       *rbac.Policy
+	limiter     *quota.Limiter
+	tenants     *mcp.Tenants
+}
+
+// newRegistry is the mcp.TenantFactory. The tenant's cookie is redacted from
+// logs until the tenant closes.
+func (s *tenantSetup) newRegistry(credentials scrapbox.Credentials) (*tools.Registry, func(), error) {
+	releaseSecret := mcperrors.HoldSecret(credentials.SessionCookie)
+
+	// The tenant's cookie cannot be refreshed from the server's sources
+	cfg := *s.cfg
+	cfg.ProjectName = credentials.Project
+	cfg.SessionCookie = credentials.SessionCookie
+	cfg.SessionRefreshCommand = ""
+	cfg.SessionCookieFile = ""
+	// Files on the server are not the tenant's to import or overwrite
+	cfg.MarkdownImportDir = ""
+	cfg.MarkdownExportDir = ""
+	cfg.ExportDir = ""
+	// Images go to the tenant's project, not the operator's Gyazo account
+	cfg.GyazoAccessToken = ""
+
+	_, client, err := newBackend(&cfg)
+	if err != nil {
+		releaseSecret()
+		return nil, nil, err
+	}
+	closeClient := func() {
+		if err := client.Close(); err != nil {
+			log.Printf("[TENANT] Failed to close Scrapbox connection: %v", err)
+		}
+		releaseSecret()
+	}
+	info, err := client.ValidateCredentials()
+	if err != nil {
+		closeClient()
+		return nil, nil, err
+	}
+
+	registry := tools.NewRegistry()
+	registry.SetTimeout(cfg.ToolTimeout)
+	registry.SetMaxResponseBytes(cfg.MaxResponseBytes)
+	if err := registerCoreTools(registry, &cfg, client, client); err != nil {
+		closeClient()
+		return nil, nil, err
+	}
+	registry.Register(tools.NewUploadImageTool(client, newImageUploader(&cfg, client)))
+	registry.Register(tools.NewSetCredentialsTool(s.tenants))
+
+	if s.auditLogger != nil {
+		registry.SetAuditLogger(s.auditLogger)
+		registry.SetAuditUser(info.User)
+	}
+	if s.roles != nil {
+		registry.Use(s.roles.Middleware)
+	}
+	if cfg.ConfirmDestructive {
+		registry.RequireConfirmation(cfg.ConfirmationTTL)
+	}
+	if s.limiter != nil {
+		registry.Use(s.limiter.Middleware)
+	}
+	registry.EnforceProjectScope(client)
+	applyToolAllowlist(registry, cfg.ToolAllowlist)
+	return registry, closeClient, nil
+}
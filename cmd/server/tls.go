@@ -0,0 +1,99 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/config"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// newServeFunc returns the function that starts server on listener, serving HTTPS when
+// TLS is configured. Static certificates (TLS_CERT/TLS_KEY) take precedence
+// over automatic certificates (TLS_AUTOCERT_DOMAINS).
+func newServeFunc(cfg *config.Config, server *http.Server, listener net.Listener) (func() error, error) {
+	serve, err := newTLSServeFunc(cfg, server, listener)
+	if err != nil || cfg.TLSClientCA == "" {
+		return serve, err
+	}
+	if server.TLSConfig == nil && cfg.TLSCertFile == "" {
+		return nil, fmt.Errorf("TLS_CLIENT_CA requires TLS_CERT/TLS_KEY or TLS_AUTOCERT_DOMAINS")
+	}
+	if err := requestClientCerts(server, cfg.TLSClientCA); err != nil {
+		return nil, err
+	}
+	log.Printf("Mutual TLS enabled: /mcp requires client certificates signed by %s", cfg.TLSClientCA)
+	return serve, nil
+}
+
+// requestClientCerts verifies client certificates against the CA bundle at
+// caFile. Certificates are asked for but not required during the handshake,
+// so /health stays reachable for load balancers; /mcp refuses requests
+// without a verified one (mcp.ClientAccess).
+func requestClientCerts(server *http.Server, caFile string) error {
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return fmt.Errorf("failed to read TLS_CLIENT_CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("TLS_CLIENT_CA %s contains no PEM certificates", caFile)
+	}
+	if server.TLSConfig == nil {
+		server.TLSConfig = &tls.Config{}
+	}
+	server.TLSConfig.ClientCAs = pool
+	server.TLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	return nil
+}
+
+// newTLSServeFunc picks plain HTTP, static certificates or automatic certificates
+func newTLSServeFunc(cfg *config.Config, server *http.Server, listener net.Listener) (func() error, error) {
+	switch {
+	case cfg.TLSCertFile != "" || cfg.TLSKeyFile != "":
+		if cfg.TLSCertFile == "" || cfg.TLSKeyFile == "" {
+			return nil, fmt.Errorf("both TLS_CERT and TLS_KEY must be set")
+		}
+		log.Printf("TLS enabled with certificate %s", cfg.TLSCertFile)
+		return func() error {
+			return server.ServeTLS(listener, cfg.TLSCertFile, cfg.TLSKeyFile)
+		}, nil
+
+	case len(cfg.TLSAutocertDomains) > 0:
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.TLSAutocertDomains...),
+			Cache:      autocert.DirCache(cfg.TLSAutocertCacheDir),
+			Email:      cfg.TLSAutocertEmail,
+		}
+		server.TLSConfig = manager.TLSConfig()
+
+		// Serve ACME HTTP-01 challenges and redirect everything else to HTTPS
+		challengeServer := &http.Server{
+			Addr:              cfg.TLSAutocertHTTPAddr,
+			Handler:           manager.HTTPHandler(nil),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			if err := challengeServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Printf("ACME challenge server failed: %v", err)
+			}
+		}()
+
+		log.Printf("TLS enabled with automatic certificates for %v", cfg.TLSAutocertDomains)
+		return func() error {
+			return server.ServeTLS(listener, "", "")
+		}, nil
+
+	default:
+		return func() error {
+			return server.Serve(listener)
+		}, nil
+	}
+}
@@ -3,10 +3,11 @@ module github.com/hiroki/scrapbox_mcp
 go 1.23
 
 require (
-	github.com/caarlos0/env/v10 v10.0.0 // indirect
-	github.com/google/uuid v1.6.0 // indirect
-	github.com/gorilla/websocket v1.5.1 // indirect
-	github.com/joho/godotenv v1.5.1 // indirect
-	golang.org/x/net v0.17.0 // indirect
-	golang.org/x/time v0.5.0 // indirect
+	github.com/caarlos0/env/v10 v10.0.0
+	github.com/google/uuid v1.6.0
+	github.com/gorilla/websocket v1.5.1
+	github.com/joho/godotenv v1.5.1
+	golang.org/x/crypto v0.31.0
+	golang.org/x/net v0.21.0
+	golang.org/x/text v0.21.0
 )
@@ -0,0 +1,107 @@
+// Package attribution marks content written by the server so people browsing
+// Scrapbox can tell it was machine-generated: by appending a suffix or an
+// icon to the lines it writes, or by listing the pages it changed on an index page.
+package attribution
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hiroki/scrapbox_mcp/pkg/notation"
+)
+
+// Mode selects how writes are attributed
+type Mode string
+
+const (
+	// ModeOff leaves content unmarked
+	ModeOff Mode = "off"
+	// ModeSuffix appends a text suffix to written lines
+	ModeSuffix Mode = "suffix"
+	// ModeIcon appends an [name.icon] to written lines
+	ModeIcon Mode = "icon"
+	// ModeIndex lists changed pages on an index page
+	ModeIndex Mode = "index"
+)
+
+// Defaults for the attribution text of each mode
+const (
+	DefaultSuffix    = "(AI)"
+	DefaultIcon      = "bot"
+	DefaultIndexPage = "Edited by AI"
+)
+
+// ParseMode validates a mode name; empty means off
+func ParseMode(mode string) (Mode, error) {
+	switch m := Mode(strings.ToLower(strings.TrimSpace(mode))); m {
+	case "":
+		return ModeOff, nil
+	case ModeOff, ModeSuffix, ModeIcon, ModeIndex:
+		return m, nil
+	}
+	return "", fmt.Errorf("invalid attribution mode %q (expected off, suffix, icon or index)", mode)
+}
+
+// Marker appends an attribution to the lines the server writes
+type Marker struct {
+	// mark is appended to lines, including the separating space
+	mark string
+}
+
+// NewMarker returns the marker for the suffix and icon modes, or nil for the
+// other modes. text is the suffix or the icon name; empty uses the default.
+func NewMarker(mode Mode, text string) *Marker {
+	text = strings.TrimSpace(text)
+	switch mode {
+	case ModeSuffix:
+		if text == "" {
+			text = DefaultSuffix
+		}
+		return &Marker{mark: " " + text}
+	case ModeIcon:
+		if text == "" {
+			text = DefaultIcon
+		}
+		return &Marker{mark: " [" + strings.TrimSuffix(text, ".icon") + ".icon]"}
+	}
+	return nil
+}
+
+// MarkLines returns the page texts with the attribution appended to the lines
+// that are not in previous (the current page content, nil for a new page).
+// The title line, blank lines, lines already marked and the contents of code
+// and table blocks are left alone.
+func (m *Marker) MarkLines(previous, texts []string) []string {
+	// Lines that already exist keep their text, however often they occur
+	existing := make(map[string]int, len(previous))
+	for _, text := range previous {
+		existing[text]++
+	}
+
+	marked := make([]string, len(texts))
+	copy(marked, texts)
+	i := 0
+	for _, block := range notation.Parse(texts).Blocks {
+		if block.Type != notation.BlockLine {
+			// Code and table blocks would break if their lines changed
+			for j := i; j < i+block.Len(); j++ {
+				if existing[texts[j]] > 0 {
+					existing[texts[j]]--
+				}
+			}
+			i += block.Len()
+			continue
+		}
+
+		text := texts[i]
+		switch {
+		case existing[text] > 0:
+			existing[text]--
+		case i == 0, strings.TrimSpace(text) == "", strings.HasSuffix(text, m.mark):
+		default:
+			marked[i] = text + m.mark
+		}
+		i++
+	}
+	return marked
+}
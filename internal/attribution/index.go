@@ -0,0 +1,64 @@
+package attribution
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	"github.com/hiroki/scrapbox_mcp/internal/tools"
+)
+
+// Index lists the pages changed by write tools as [links] on an index page
+type Index struct {
+	client scrapbox.API
+	page   string
+	// mu serializes updates so concurrent writes do not add a page twice
+	mu sync.Mutex
+}
+
+// NewIndex creates an Index kept on page; empty uses DefaultIndexPage
+func NewIndex(client scrapbox.API, page string) *Index {
+	if page == "" {
+		page = DefaultIndexPage
+	}
+	return &Index{client: client, page: page}
+}
+
+// Middleware adds the pages targeted by successful write tool calls to the index
+func (x *Index) Middleware(next tools.ToolHandler) tools.ToolHandler {
+	return tools.WrapExecute(next, func(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+		result, err := next.Execute(ctx, arguments)
+		if err == nil && tools.IsWriteTool(next) {
+			for _, title := range tools.TargetPages(next, arguments) {
+				if err := x.Add(title); err != nil {
+					log.Printf("[ATTRIBUTION] Failed to add %s to %s: %v", title, x.page, err)
+				}
+			}
+		}
+		return result, err
+	})
+}
+
+// Add lists title on the index page unless it is already there
+func (x *Index) Add(title string) error {
+	if title == "" || title == x.page {
+		return nil
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	link := "[" + title + "]"
+	page, err := x.client.GetPage(x.client.DefaultProject(), x.page)
+	if err != nil {
+		return err
+	}
+	for _, line := range page.Lines {
+		if line.Text == link {
+			return nil
+		}
+	}
+	_, err = x.client.CreatePage(x.page, []string{link}, scrapbox.IfExistsAppend)
+	return err
+}
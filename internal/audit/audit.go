@@ -0,0 +1,137 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Result values recorded in audit entries
+const (
+	ResultSuccess = "success"
+	ResultError   = "error"
+)
+
+// Entry represents a single audited write operation
+type Entry struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	SessionID string    `json:"sessionId,omitempty"`
+	// User is the Scrapbox user the write was made as, when known
+	User     string `json:"user,omitempty"`
+	Tool     string `json:"tool"`
+	ArgsHash string `json:"argsHash"`
+	Page     string `json:"page,omitempty"`
+	Summary  string `json:"summary,omitempty"`
+	Result   string `json:"result"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Logger appends audit entries to a JSONL file
+type Logger struct {
+	path string
+	file *os.File
+	mu   sync.Mutex
+}
+
+// NewLogger opens (or creates) the audit log file at path in append mode
+func NewLogger(path string) (*Logger, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &Logger{
+		path: path,
+		file: file,
+	}, nil
+}
+
+// Record appends an entry to the log, filling in ID and Timestamp if unset
+func (l *Logger) Record(entry *Entry) error {
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now().UTC()
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return nil
+}
+
+// Recent returns up to limit entries, newest first.
+// If page is non-empty, only entries for that page are returned.
+func (l *Logger) Recent(limit int, page string) ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	file, err := os.Open(l.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// Skip partially written or corrupt lines
+			continue
+		}
+		if page != "" && entry.Page != page {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	// Reverse to newest first
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+// Close closes the underlying file
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// HashArguments returns a stable SHA-256 hash of tool arguments.
+// Arguments are hashed rather than stored so page content does not leak into the log.
+func HashArguments(arguments map[string]interface{}) string {
+	// encoding/json sorts map keys, so the output is deterministic
+	data, err := json.Marshal(arguments)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
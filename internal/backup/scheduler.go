@@ -0,0 +1,148 @@
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+// Scheduler periodically exports the project and writes timestamped archives to storage
+type Scheduler struct {
+	client    *scrapbox.Client
+	storage   Storage
+	interval  time.Duration
+	retention int
+	mu        sync.Mutex // serializes backup runs
+}
+
+// NewScheduler creates a new backup scheduler.
+// An interval of 0 disables scheduled runs (manual triggers still work).
+// A retention of 0 keeps every archive.
+func NewScheduler(client *scrapbox.Client, storage Storage, interval time.Duration, retention int) *Scheduler {
+	return &Scheduler{
+		client:    client,
+		storage:   storage,
+		interval:  interval,
+		retention: retention,
+	}
+}
+
+// Run performs a backup every interval until ctx is done
+func (s *Scheduler) Run(ctx context.Context) {
+	if s.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			name, err := s.Backup(ctx)
+			if err != nil {
+				log.Printf("[BACKUP] Scheduled backup failed: %v", err)
+				continue
+			}
+			log.Printf("[BACKUP] Wrote %s to %s", name, s.storage)
+		}
+	}
+}
+
+// Backup exports the project, stores a gzipped archive and prunes old archives.
+// It returns the archive name.
+func (s *Scheduler) Backup(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.client.RESTClient.ExportPages(s.client.ProjectName)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		return "", fmt.Errorf("failed to compress backup: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return "", fmt.Errorf("failed to compress backup: %w", err)
+	}
+
+	// Timestamps sort lexicographically, which retention relies on
+	name := archiveName(s.client.ProjectName, time.Now())
+	if err := s.storage.Put(ctx, name, buf.Bytes()); err != nil {
+		return "", err
+	}
+
+	if err := s.prune(ctx); err != nil {
+		log.Printf("[BACKUP] Failed to prune old backups: %v", err)
+	}
+
+	return name, nil
+}
+
+// Storage returns the configured storage destination
+func (s *Scheduler) Storage() Storage {
+	return s.storage
+}
+
+// prune deletes the oldest archives of this project beyond the retention limit
+func (s *Scheduler) prune(ctx context.Context) error {
+	if s.retention <= 0 {
+		return nil
+	}
+
+	names, err := s.storage.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	archives := make([]string, 0, len(names))
+	for _, name := range names {
+		if isArchive(s.client.ProjectName, name) {
+			archives = append(archives, name)
+		}
+	}
+
+	for len(archives) > s.retention {
+		if err := s.storage.Delete(ctx, archives[0]); err != nil {
+			return err
+		}
+		archives = archives[1:]
+	}
+	return nil
+}
+
+// archiveTimeFormat is the timestamp in archive names
+const archiveTimeFormat = "20060102T150405Z"
+
+// archiveName names the archive of project taken at t
+func archiveName(project string, t time.Time) string {
+	return fmt.Sprintf("%s-%s.json.gz", project, t.UTC().Format(archiveTimeFormat))
+}
+
+// isArchive reports whether name is an archive of project. The whole name is
+// matched, since storage may be shared with projects whose names start with
+// this one's (e.g. "foo" and "foo-bar").
+func isArchive(project, name string) bool {
+	stamp, ok := strings.CutPrefix(name, project+"-")
+	if !ok {
+		return false
+	}
+	stamp, ok = strings.CutSuffix(stamp, ".json.gz")
+	if !ok {
+		return false
+	}
+	_, err := time.Parse(archiveTimeFormat, stamp)
+	return err == nil
+}
@@ -0,0 +1,76 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Storage is a destination for backup archives
+type Storage interface {
+	// Put writes an archive with the given name
+	Put(ctx context.Context, name string, data []byte) error
+	// List returns the names of all stored archives
+	List(ctx context.Context) ([]string, error)
+	// Delete removes the archive with the given name
+	Delete(ctx context.Context, name string) error
+	// String describes the storage location for logs and tool output
+	String() string
+}
+
+// LocalStorage stores archives in a local directory
+type LocalStorage struct {
+	dir string
+}
+
+// NewLocalStorage creates the directory if needed and returns a LocalStorage for it
+func NewLocalStorage(dir string) (*LocalStorage, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	return &LocalStorage{dir: dir}, nil
+}
+
+func (s *LocalStorage) Put(ctx context.Context, name string, data []byte) error {
+	// Write to a temporary file first so a partial archive is never left behind
+	tmp := filepath.Join(s.dir, "."+name+".tmp")
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, name)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	return nil
+}
+
+func (s *LocalStorage) List(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, name string) error {
+	if err := os.Remove(filepath.Join(s.dir, name)); err != nil {
+		return fmt.Errorf("failed to delete backup: %w", err)
+	}
+	return nil
+}
+
+func (s *LocalStorage) String() string {
+	return s.dir
+}
@@ -0,0 +1,199 @@
+package changes
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+// URIScheme is the scheme used for Scrapbox page resource URIs
+const URIScheme = "scrapbox"
+
+// reconnectInterval is how often the watcher checks that the stream is still connected
+const reconnectInterval = 10 * time.Second
+
+// PageURI returns the resource URI for a page: scrapbox://<project>/<title>
+func PageURI(project, title string) string {
+	return fmt.Sprintf("%s://%s/%s", URIScheme, project, url.PathEscape(title))
+}
+
+// ParsePageURI splits a page resource URI into project and title
+func ParsePageURI(uri string) (project, title string, err error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid resource URI: %s", uri)
+	}
+	if u.Scheme != URIScheme || u.Host == "" {
+		return "", "", fmt.Errorf("unsupported resource URI: %s", uri)
+	}
+	title = strings.TrimPrefix(u.Path, "/")
+	if title == "" {
+		return "", "", fmt.Errorf("resource URI has no page title: %s", uri)
+	}
+	return u.Host, title, nil
+}
+
+// Notifier delivers an update for uri to a subscriber.
+// It returns false if the subscriber no longer exists, which removes its subscriptions.
+type Notifier func(subscriberID, uri string) bool
+
+// Watcher tracks page subscriptions and dispatches commit events from the
+// Scrapbox project updates stream to subscribers.
+type Watcher struct {
+	client *scrapbox.Client
+	wsURL  string
+
+	mu       sync.Mutex
+	subs     map[string]map[string]string // pageID -> subscriberID -> uri
+	notifier Notifier
+	started  bool
+}
+
+// NewWatcher creates a new change watcher for the client's default project
+func NewWatcher(client *scrapbox.Client, wsURL string) *Watcher {
+	return &Watcher{
+		client: client,
+		wsURL:  wsURL,
+		subs:   make(map[string]map[string]string),
+	}
+}
+
+// SetNotifier sets the function used to deliver updates
+func (w *Watcher) SetNotifier(notifier Notifier) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.notifier = notifier
+}
+
+// Subscribe registers interest in changes to the page identified by uri
+func (w *Watcher) Subscribe(subscriberID, uri string) error {
+	project, title, err := ParsePageURI(uri)
+	if err != nil {
+		return err
+	}
+	if project != w.client.ProjectName {
+		return fmt.Errorf("subscriptions are only supported for project: %s", w.client.ProjectName)
+	}
+
+	page, err := w.client.RESTClient.GetPage(project, title)
+	if err != nil {
+		return err
+	}
+
+	if err := w.ensureStream(); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.subs[page.ID] == nil {
+		w.subs[page.ID] = make(map[string]string)
+	}
+	w.subs[page.ID][subscriberID] = uri
+	return nil
+}
+
+// Unsubscribe removes a subscription previously made with Subscribe
+func (w *Watcher) Unsubscribe(subscriberID, uri string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for pageID, subscribers := range w.subs {
+		if subscribers[subscriberID] == uri {
+			delete(subscribers, subscriberID)
+			if len(subscribers) == 0 {
+				delete(w.subs, pageID)
+			}
+		}
+	}
+}
+
+// Subscriptions returns the URIs subscriberID is subscribed to, sorted
+func (w *Watcher) Subscriptions(subscriberID string) []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	uris := []string{}
+	for _, subscribers := range w.subs {
+		if uri, ok := subscribers[subscriberID]; ok {
+			uris = append(uris, uri)
+		}
+	}
+	sort.Strings(uris)
+	return uris
+}
+
+// Run keeps the project updates stream connected while there are subscriptions.
+// It returns when ctx is done.
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(reconnectInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.mu.Lock()
+			active := w.started && len(w.subs) > 0
+			w.mu.Unlock()
+
+			// Connect rejoins the updates room on reconnect
+			if active && !w.client.WebSocketClient.Connected() {
+				if err := w.client.WebSocketClient.Connect(); err != nil {
+					log.Printf("[CHANGES] Failed to reconnect project updates stream: %v", err)
+				}
+			}
+		}
+	}
+}
+
+// ensureStream joins the project updates stream on first use
+func (w *Watcher) ensureStream() error {
+	w.mu.Lock()
+	started := w.started
+	w.mu.Unlock()
+	if started {
+		return nil
+	}
+
+	projectInfo, err := w.client.RESTClient.GetProject(w.client.ProjectName)
+	if err != nil {
+		return err
+	}
+
+	w.client.EnsureWebSocket(w.wsURL)
+	w.client.WebSocketClient.AddCommitHandler(w.handleCommit)
+	if err := w.client.WebSocketClient.JoinProjectUpdates(projectInfo.ID); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	w.started = true
+	w.mu.Unlock()
+	return nil
+}
+
+// handleCommit notifies every subscriber of the committed page
+func (w *Watcher) handleCommit(event scrapbox.CommitEvent) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.notifier == nil {
+		return
+	}
+	for subscriberID, uri := range w.subs[event.PageID] {
+		if !w.notifier(subscriberID, uri) {
+			delete(w.subs[event.PageID], subscriberID)
+		}
+	}
+	if len(w.subs[event.PageID]) == 0 {
+		delete(w.subs, event.PageID)
+	}
+}
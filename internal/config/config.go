@@ -1,6 +1,12 @@
 package config
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/caarlos0/env/v10"
@@ -15,20 +21,162 @@ type Config struct {
 	// MCP configuration
 	SessionTTL time.Duration `env:"SESSION_TTL" envDefault:"1h"`
 	EnableSSE  bool          `env:"ENABLE_SSE" envDefault:"true"`
+	// Save sessions here on shutdown and restore them on startup
+	SessionStatePath string `env:"SESSION_STATE_PATH"`
+	// Base64 AES-256 keys encrypting saved session IDs and cookies, newest first
+	SessionEncryptionKeys []string `env:"SESSION_ENCRYPTION_KEYS" envSeparator:","`
+	// How often idle SSE and WebSocket streams send a heartbeat (0 disables)
+	StreamHeartbeatInterval time.Duration `env:"STREAM_HEARTBEAT_INTERVAL" envDefault:"30s"`
+
+	// Limits
+	MaxRequestBodyBytes int64         `env:"MAX_REQUEST_BODY_BYTES" envDefault:"4194304"`
+	ToolTimeout         time.Duration `env:"TOOL_TIMEOUT" envDefault:"60s"`
+	MaxResponseBytes    int           `env:"MAX_RESPONSE_BYTES" envDefault:"0"`
+
+	// Enable resources/subscribe via the Scrapbox project updates stream
+	EnableSubscriptions bool `env:"ENABLE_SUBSCRIPTIONS" envDefault:"false"`
+
+	// Serve MCP over WebSocket at /mcp/ws in addition to Streamable HTTP
+	EnableWebSocketTransport bool `env:"ENABLE_MCP_WEBSOCKET" envDefault:"false"`
+
+	// Record Scrapbox traffic and MCP messages here for bug reports (debug only)
+	DebugCapturePath string `env:"DEBUG_CAPTURE_PATH"`
 
 	// Scrapbox configuration
 	ProjectName   string `env:"COSENSE_PROJECT_NAME,required"`
-	SessionCookie string `env:"COSENSE_SID,required"`
+	SessionCookie string `env:"COSENSE_SID"`
+
+	// Where to get a new session cookie when the current one expires:
+	// a command printing the cookie, or else the COSENSE_SID_FILE secret (re-read)
+	SessionRefreshCommand string `env:"COSENSE_SID_REFRESH_COMMAND"`
+	SessionCookieFile     string `env:"COSENSE_SID_FILE"`
+
+	// Offline mode: serve read-only tools from a local project export
+	OfflineExportPath string `env:"OFFLINE_EXPORT_PATH"`
 
 	// API configuration
 	RestAPIBaseURL string        `env:"SCRAPBOX_API_URL" envDefault:"https://scrapbox.io/api"`
 	WebSocketURL   string        `env:"SCRAPBOX_WS_URL" envDefault:"wss://scrapbox.io/socket.io/"`
+	WSIdleTimeout  time.Duration `env:"WS_IDLE_TIMEOUT" envDefault:"5m"`
 	RequestTimeout time.Duration `env:"REQUEST_TIMEOUT" envDefault:"30s"`
 	MaxRetries     int           `env:"MAX_RETRIES" envDefault:"3"`
 
+	// Proxy for REST and WebSocket connections to Scrapbox; when unset,
+	// HTTPS_PROXY/HTTP_PROXY/NO_PROXY are used
+	UpstreamProxy string `env:"SCRAPBOX_PROXY_URL"`
+	// User-Agent and comma-separated Name=value headers for requests to Scrapbox
+	UpstreamUserAgent string   `env:"SCRAPBOX_USER_AGENT"`
+	UpstreamHeaders   []string `env:"SCRAPBOX_HEADERS" envSeparator:","`
+	// Comma-separated project=URL overrides of SCRAPBOX_API_URL/SCRAPBOX_WS_URL
+	ProjectAPIURLs []string `env:"PROJECT_API_URLS" envSeparator:","`
+	ProjectWSURLs  []string `env:"PROJECT_WS_URLS" envSeparator:","`
+	// Socket.IO namespace and CONNECT auth payload (a JSON object) for
+	// deployments that require them; scrapbox.io uses neither
+	WebSocketNamespace string `env:"SCRAPBOX_WS_NAMESPACE"`
+	WebSocketAuth      string `env:"SCRAPBOX_WS_AUTH"`
+	// Socket.IO transport: auto (WebSocket, falling back to HTTP long-polling
+	// when the upgrade is blocked), websocket or polling
+	WebSocketTransport string `env:"SCRAPBOX_WS_TRANSPORT" envDefault:"auto"`
+
+	// Check the session cookie and project at startup and exit if they are invalid
+	ValidateCredentials bool `env:"VALIDATE_CREDENTIALS" envDefault:"false"`
+
+	// Image uploads go to Gyazo when a token is set, otherwise to Scrapbox file storage
+	GyazoAccessToken string `env:"GYAZO_ACCESS_TOKEN"`
+
+	// How long grep_pages trusts its local page cache before re-checking the page list
+	PageIndexMaxAge time.Duration `env:"PAGE_INDEX_MAX_AGE" envDefault:"5m"`
+
+	// Title aliases as alias=Title pairs, resolved by every tool's title argument
+	TitleAliases []string `env:"TITLE_ALIASES" envSeparator:","`
+
+	// Task markers as open=done pairs for list_tasks and toggle_task; "#" markers are tags
+	TaskMarkers []string `env:"TASK_MARKERS" envSeparator:","`
+
+	// What edit_page does when content does not start with the title: prepend or error
+	EditTitleMismatch string `env:"EDIT_TITLE_MISMATCH" envDefault:"prepend"`
+
+	// Attribution of content written by the server: off, suffix, icon or index.
+	// The text is the suffix or icon name; the page is the index page.
+	AIAttribution     string `env:"AI_ATTRIBUTION" envDefault:"off"`
+	AIAttributionText string `env:"AI_ATTRIBUTION_TEXT"`
+	AIAttributionPage string `env:"AI_ATTRIBUTION_PAGE"`
+
+	// Destructive calls return a preview and a token and only run when repeated with the token
+	ConfirmDestructive bool          `env:"CONFIRM_DESTRUCTIVE" envDefault:"false"`
+	ConfirmationTTL    time.Duration `env:"CONFIRMATION_TTL" envDefault:"5m"`
+
+	// Reject tool calls unless the client's roots grant Scrapbox projects
+	RequireRoots bool `env:"REQUIRE_ROOTS" envDefault:"false"`
+
+	// Let clients supply their own project and session cookie per session
+	AllowClientCredentials bool `env:"ALLOW_CLIENT_CREDENTIALS" envDefault:"false"`
+
+	// Roles granted by bearer tokens as token=role (viewer, editor, admin), and
+	// the role of requests without a token ("none" requires a token)
+	RoleTokens  []string `env:"ROLE_TOKENS" envSeparator:","`
+	DefaultRole string   `env:"DEFAULT_ROLE" envDefault:"admin"`
+
+	// Per-session tool quotas as scope=limit/window (scope: tool name, writes or *)
+	ToolQuotas []string `env:"TOOL_QUOTAS" envSeparator:","`
+
+	// Audit configuration
+	AuditLogPath string `env:"AUDIT_LOG_PATH"`
+
+	// Undo configuration
+	UndoStorePath string `env:"UNDO_STORE_PATH"`
+
+	// Export and import configuration
+	MarkdownExportDir string `env:"MARKDOWN_EXPORT_DIR"`
+	MarkdownImportDir string `env:"MARKDOWN_IMPORT_DIR"`
+	ExportDir         string `env:"EXPORT_DIR"`
+
+	// Backup configuration
+	BackupDir         string        `env:"BACKUP_DIR"`
+	BackupInterval    time.Duration `env:"BACKUP_INTERVAL" envDefault:"0"`
+	BackupRetention   int           `env:"BACKUP_RETENTION" envDefault:"7"`
+	BackupS3Bucket    string        `env:"BACKUP_S3_BUCKET"`
+	BackupS3Prefix    string        `env:"BACKUP_S3_PREFIX"`
+	BackupS3Endpoint  string        `env:"BACKUP_S3_ENDPOINT" envDefault:"https://s3.amazonaws.com"`
+	BackupS3Region    string        `env:"BACKUP_S3_REGION" envDefault:"us-east-1"`
+	BackupS3AccessKey string        `env:"AWS_ACCESS_KEY_ID"`
+	BackupS3SecretKey string        `env:"AWS_SECRET_ACCESS_KEY"`
+
+	// External link check configuration
+	LinkCheckInterval    time.Duration `env:"LINK_CHECK_INTERVAL" envDefault:"0"`
+	LinkCheckPage        string        `env:"LINK_CHECK_PAGE"`
+	LinkCheckConcurrency int           `env:"LINK_CHECK_CONCURRENCY" envDefault:"8"`
+	LinkCheckTimeout     time.Duration `env:"LINK_CHECK_TIMEOUT" envDefault:"10s"`
+
+	// Git mirror configuration (one file and commit per page change)
+	GitMirrorDir      string        `env:"GIT_MIRROR_DIR"`
+	GitMirrorInterval time.Duration `env:"GIT_MIRROR_INTERVAL" envDefault:"0"`
+	GitMirrorWatch    bool          `env:"GIT_MIRROR_WATCH" envDefault:"false"`
+	GitMirrorRemote   string        `env:"GIT_MIRROR_REMOTE"`
+
 	// Security
 	AllowedOrigins []string `env:"ALLOWED_ORIGINS" envSeparator:","`
 	EnableCORS     bool     `env:"ENABLE_CORS" envDefault:"true"`
+	OriginPolicy   string   `env:"ORIGIN_POLICY" envDefault:"allowlist"`
+	TrustedProxies []string `env:"TRUSTED_PROXIES" envSeparator:","`
+	// IP addresses and CIDR ranges allowed to use /mcp (all if empty)
+	MCPAllowedIPs []string `env:"MCP_ALLOWED_IPS" envSeparator:","`
+
+	// TLS: static certificate, or automatic certificates via ACME (Let's Encrypt)
+	TLSCertFile         string   `env:"TLS_CERT"`
+	TLSKeyFile          string   `env:"TLS_KEY"`
+	TLSAutocertDomains  []string `env:"TLS_AUTOCERT_DOMAINS" envSeparator:","`
+	TLSAutocertEmail    string   `env:"TLS_AUTOCERT_EMAIL"`
+	TLSAutocertCacheDir string   `env:"TLS_AUTOCERT_CACHE_DIR" envDefault:"certs"`
+	TLSAutocertHTTPAddr string   `env:"TLS_AUTOCERT_HTTP_ADDR" envDefault:":80"`
+	// PEM CA bundle; /mcp then requires client certificates signed by it
+	TLSClientCA string `env:"TLS_CLIENT_CA"`
+
+	// Tools to expose (comma-separated); all tools are enabled if empty
+	ToolAllowlist []string `env:"TOOL_ALLOWLIST" envSeparator:","`
+
+	// Admin endpoints are disabled unless a token is set
+	AdminToken string `env:"ADMIN_TOKEN"`
 }
 
 func Load() (*Config, error) {
@@ -36,5 +184,64 @@ func Load() (*Config, error) {
 	if err := env.Parse(cfg); err != nil {
 		return nil, err
 	}
+	if err := cfg.loadSecretFiles(); err != nil {
+		return nil, err
+	}
+	if cfg.EditTitleMismatch != "prepend" && cfg.EditTitleMismatch != "error" {
+		return nil, fmt.Errorf("invalid EDIT_TITLE_MISMATCH %q (expected prepend or error)", cfg.EditTitleMismatch)
+	}
+	if cfg.SessionCookie == "" && cfg.OfflineExportPath == "" {
+		return nil, errors.New(`required environment variable "COSENSE_SID" (or "COSENSE_SID_FILE") is not set (or set OFFLINE_EXPORT_PATH for read-only offline mode)`)
+	}
 	return cfg, nil
 }
+
+// loadSecretFiles fills secrets from <NAME>_FILE variables (e.g. COSENSE_SID_FILE)
+// so they can be mounted as Docker/Kubernetes secrets. A directly set variable wins.
+func (cfg *Config) loadSecretFiles() error {
+	secrets := []struct {
+		name  string
+		value *string
+	}{
+		{"COSENSE_SID", &cfg.SessionCookie},
+		{"ADMIN_TOKEN", &cfg.AdminToken},
+		{"GYAZO_ACCESS_TOKEN", &cfg.GyazoAccessToken},
+		{"SCRAPBOX_WS_AUTH", &cfg.WebSocketAuth},
+		{"AWS_ACCESS_KEY_ID", &cfg.BackupS3AccessKey},
+		{"AWS_SECRET_ACCESS_KEY", &cfg.BackupS3SecretKey},
+	}
+
+	for _, secret := range secrets {
+		path := os.Getenv(secret.name + "_FILE")
+		if *secret.value != "" || path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s_FILE: %w", secret.name, err)
+		}
+		*secret.value = strings.TrimSpace(string(data))
+	}
+	return nil
+}
+
+// Secrets returns the sensitive values that must never be logged
+func (cfg *Config) Secrets() []string {
+	secrets := []string{cfg.SessionCookie, cfg.AdminToken, cfg.BackupS3SecretKey, cfg.GyazoAccessToken, cfg.WebSocketAuth}
+	// Values of the Socket.IO auth payload are usually tokens
+	var auth map[string]interface{}
+	if json.Unmarshal([]byte(cfg.WebSocketAuth), &auth) == nil {
+		for _, value := range auth {
+			if text, ok := value.(string); ok {
+				secrets = append(secrets, text)
+			}
+		}
+	}
+	// A remote URL may carry a token as its password
+	if u, err := url.Parse(cfg.GitMirrorRemote); err == nil && u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			secrets = append(secrets, password)
+		}
+	}
+	return secrets
+}
@@ -0,0 +1,406 @@
+// Package gitmirror mirrors a Scrapbox project into a local git repository,
+// one text file per page and one commit per page change, authored by the
+// page's last editor at the time of the edit.
+package gitmirror
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/markdown"
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+const (
+	// listPageSize is the page size used to walk the page list (the API maximum)
+	listPageSize = 1000
+	// streamDebounce is how long a sync triggered by the change stream waits
+	// for further commits, so a burst of edits becomes one sync
+	streamDebounce = 10 * time.Second
+	// reconnectInterval is how often the stream connection is checked
+	reconnectInterval = 10 * time.Second
+
+	// stateFile keeps the file of each page and the update it was mirrored
+	// at; it lives inside .git so it is never committed
+	stateFile = "scrapbox-mirror.json"
+
+	committerName  = "scrapbox-mcp"
+	committerEmail = "scrapbox-mcp@localhost"
+)
+
+// Mirror writes the pages of the client's project into a git repository
+type Mirror struct {
+	client   *scrapbox.Client
+	dir      string
+	interval time.Duration
+	remote   string
+	mu       sync.Mutex // serializes syncs
+	trigger  chan struct{}
+}
+
+// Result describes one sync
+type Result struct {
+	Project  string    `json:"project"`
+	Dir      string    `json:"dir"`
+	SyncedAt time.Time `json:"syncedAt"`
+	Pages    int       `json:"pages"`
+	Commits  int       `json:"commits"`
+	Added    []string  `json:"added,omitempty"`
+	Updated  []string  `json:"updated,omitempty"`
+	Deleted  []string  `json:"deleted,omitempty"`
+	Head     string    `json:"head,omitempty"`
+	Pushed   bool      `json:"pushed,omitempty"`
+}
+
+// pageState is the mirrored state of one page
+type pageState struct {
+	File    string `json:"file"`
+	Updated int64  `json:"updated"`
+}
+
+// NewMirror creates a mirror into dir. An interval of 0 disables scheduled
+// syncs (Watch and manual syncs still work); a non-empty remote is pushed
+// to after every sync that commits.
+func NewMirror(client *scrapbox.Client, dir string, interval time.Duration, remote string) *Mirror {
+	return &Mirror{
+		client:   client,
+		dir:      dir,
+		interval: interval,
+		remote:   remote,
+		trigger:  make(chan struct{}, 1),
+	}
+}
+
+// Dir returns the repository directory
+func (m *Mirror) Dir() string {
+	return m.dir
+}
+
+// Watch joins the project updates stream so that commits to the project
+// trigger a sync from Run
+func (m *Mirror) Watch(wsURL string) error {
+	projectInfo, err := m.client.RESTClient.GetProject(m.client.ProjectName)
+	if err != nil {
+		return err
+	}
+	m.client.EnsureWebSocket(wsURL)
+	m.client.WebSocketClient.AddCommitHandler(func(scrapbox.CommitEvent) {
+		select {
+		case m.trigger <- struct{}{}:
+		default:
+		}
+	})
+	return m.client.WebSocketClient.JoinProjectUpdates(projectInfo.ID)
+}
+
+// Run syncs every interval and after commits from the change stream until
+// ctx is done
+func (m *Mirror) Run(ctx context.Context, watching bool) {
+	var tick <-chan time.Time
+	if m.interval > 0 {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	var reconnect <-chan time.Time
+	if watching {
+		ticker := time.NewTicker(reconnectInterval)
+		defer ticker.Stop()
+		reconnect = ticker.C
+	}
+	if tick == nil && !watching {
+		return
+	}
+
+	debounce := time.NewTimer(0)
+	<-debounce.C
+	for {
+		select {
+		case <-ctx.Done():
+			debounce.Stop()
+			return
+		case <-reconnect:
+			// Connect rejoins the updates room on reconnect
+			if !m.client.WebSocketClient.Connected() {
+				if err := m.client.WebSocketClient.Connect(); err != nil {
+					log.Printf("[GITMIRROR] Failed to reconnect project updates stream: %v", err)
+				}
+			}
+			continue
+		case <-m.trigger:
+			debounce.Reset(streamDebounce)
+			continue
+		case <-tick:
+		case <-debounce.C:
+		}
+
+		result, err := m.Sync(ctx)
+		if err != nil {
+			log.Printf("[GITMIRROR] Sync failed: %v", err)
+			continue
+		}
+		if result.Commits > 0 {
+			log.Printf("[GITMIRROR] Committed %d changes to %s (%s)", result.Commits, m.dir, result.Head)
+		}
+	}
+}
+
+// Sync writes new and updated pages and removes deleted ones, committing
+// each page change separately in the order the pages were edited
+func (m *Mirror) Sync(ctx context.Context) (*Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.init(ctx); err != nil {
+		return nil, err
+	}
+	state, err := m.loadState()
+	if err != nil {
+		return nil, err
+	}
+
+	infos, err := m.listPages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Project: m.client.ProjectName, Dir: m.dir, SyncedAt: time.Now().UTC(), Pages: len(infos)}
+
+	// Existing pages keep their file; new ones get a name not yet taken
+	taken := make(map[string]bool, len(state))
+	for _, s := range state {
+		taken[strings.ToLower(s.File)] = true
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Updated < infos[j].Updated })
+
+	live := make(map[string]bool, len(infos))
+	for _, info := range infos {
+		live[info.Title] = true
+		s, known := state[info.Title]
+		if known && s.Updated == info.Updated {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !known {
+			s = pageState{File: fileName(info.Title, taken)}
+			taken[strings.ToLower(s.File)] = true
+		}
+
+		page, err := m.client.GetPage(m.client.ProjectName, info.Title)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %q: %w", info.Title, err)
+		}
+		committed, err := m.commitPage(ctx, page, info, s.File, known)
+		if err != nil {
+			return nil, err
+		}
+		s.Updated = info.Updated
+		state[info.Title] = s
+		if committed {
+			result.Commits++
+			if known {
+				result.Updated = append(result.Updated, info.Title)
+			} else {
+				result.Added = append(result.Added, info.Title)
+			}
+		}
+	}
+
+	var deleted []string
+	for title := range state {
+		if !live[title] {
+			deleted = append(deleted, title)
+		}
+	}
+	sort.Strings(deleted)
+	for _, title := range deleted {
+		if err := m.removePage(ctx, title, state[title].File); err != nil {
+			return nil, err
+		}
+		delete(state, title)
+		result.Commits++
+		result.Deleted = append(result.Deleted, title)
+	}
+
+	if err := m.saveState(state); err != nil {
+		return nil, err
+	}
+	if head, err := m.git(ctx, nil, "rev-parse", "--short", "HEAD"); err == nil {
+		result.Head = head
+	}
+	if result.Commits > 0 && m.remote != "" {
+		if _, err := m.git(ctx, nil, "push", "--quiet", m.remote, "HEAD"); err != nil {
+			return nil, err
+		}
+		result.Pushed = true
+	}
+	return result, nil
+}
+
+// commitPage writes a page's file and commits it as the page's last editor.
+// It reports false when the file did not change.
+func (m *Mirror) commitPage(ctx context.Context, page *scrapbox.Page, info scrapbox.PageInfo, file string, known bool) (bool, error) {
+	var content strings.Builder
+	for _, line := range page.Lines {
+		content.WriteString(line.Text + "\n")
+	}
+	if err := os.WriteFile(filepath.Join(m.dir, file), []byte(content.String()), 0o644); err != nil {
+		return false, fmt.Errorf("failed to write %s: %w", file, err)
+	}
+	if _, err := m.git(ctx, nil, "add", "--", file); err != nil {
+		return false, err
+	}
+	if _, err := m.git(ctx, nil, "diff", "--cached", "--quiet"); err == nil {
+		return false, nil
+	}
+
+	author := info.LastUpdateUser
+	if author == nil {
+		author = info.User
+	}
+	if author == nil {
+		author = &page.User
+	}
+	message := "Update " + info.Title
+	if !known {
+		message = "Add " + info.Title
+	}
+	_, err := m.git(ctx, authorEnv(author, time.Unix(info.Updated, 0)), "commit", "--quiet", "-m", message)
+	return err == nil, err
+}
+
+// removePage deletes the file of a page that no longer exists
+func (m *Mirror) removePage(ctx context.Context, title, file string) error {
+	if _, err := m.git(ctx, nil, "rm", "--quiet", "--ignore-unmatch", "--", file); err != nil {
+		return err
+	}
+	if _, err := m.git(ctx, nil, "diff", "--cached", "--quiet"); err == nil {
+		return nil
+	}
+	_, err := m.git(ctx, nil, "commit", "--quiet", "-m", "Delete "+title)
+	return err
+}
+
+// listPages walks the page list of the project
+func (m *Mirror) listPages(ctx context.Context) ([]scrapbox.PageInfo, error) {
+	var infos []scrapbox.PageInfo
+	for skip := 0; ; skip += listPageSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		resp, err := m.client.ListPages(m.client.ProjectName, listPageSize, skip)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, resp.Pages...)
+		if len(resp.Pages) < listPageSize || len(infos) >= resp.Count {
+			return infos, nil
+		}
+	}
+}
+
+// init creates the repository on first use
+func (m *Mirror) init(ctx context.Context) error {
+	if _, err := os.Stat(filepath.Join(m.dir, ".git")); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create mirror directory: %w", err)
+	}
+	_, err := m.git(ctx, nil, "init", "--quiet")
+	return err
+}
+
+func (m *Mirror) loadState() (map[string]pageState, error) {
+	state := make(map[string]pageState)
+	data, err := os.ReadFile(filepath.Join(m.dir, ".git", stateFile))
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mirror state: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse mirror state: %w", err)
+	}
+	return state, nil
+}
+
+func (m *Mirror) saveState(state map[string]pageState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to format mirror state: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(m.dir, ".git", stateFile), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write mirror state: %w", err)
+	}
+	return nil
+}
+
+// git runs a git command in the repository and returns its trimmed output.
+// Commits are made by the mirror; env can set the author.
+func (m *Mirror) git(ctx context.Context, env []string, args ...string) (string, error) {
+	command := args[0]
+	args = append([]string{"-c", "commit.gpgsign=false", "-c", "core.quotepath=false"}, args...)
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = m.dir
+	cmd.Env = append(os.Environ(),
+		"GIT_COMMITTER_NAME="+committerName,
+		"GIT_COMMITTER_EMAIL="+committerEmail,
+		"GIT_AUTHOR_NAME="+committerName,
+		"GIT_AUTHOR_EMAIL="+committerEmail,
+	)
+	cmd.Env = append(cmd.Env, env...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %w: %s", command, err, msg)
+		}
+		return "", fmt.Errorf("git %s: %w", command, err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// authorEnv sets a Scrapbox user and edit time as the commit author. Users
+// have no public address, so the email is a placeholder naming the user.
+func authorEnv(user *scrapbox.User, at time.Time) []string {
+	name := user.DisplayName
+	if name == "" {
+		name = user.Name
+	}
+	login := user.Name
+	if login == "" {
+		login = user.ID
+	}
+	return []string{
+		"GIT_AUTHOR_NAME=" + name,
+		"GIT_AUTHOR_EMAIL=" + login + "@users.scrapbox.invalid",
+		"GIT_AUTHOR_DATE=" + at.UTC().Format(time.RFC3339),
+	}
+}
+
+// fileName picks the file of a new page: its title as a file name (see
+// markdown.FileBase) with a numeric suffix when that is already taken,
+// ignoring case
+func fileName(title string, taken map[string]bool) string {
+	base := markdown.FileBase(title)
+	name := base + ".txt"
+	for n := 2; taken[strings.ToLower(name)]; n++ {
+		name = fmt.Sprintf("%s-%d.txt", base, n)
+	}
+	return name
+}
@@ -0,0 +1,217 @@
+// Package graph builds the link graph of a project from the page index and
+// computes PageRank, degrees, communities and connected components over it.
+package graph
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/hiroki/scrapbox_mcp/internal/index"
+	"github.com/hiroki/scrapbox_mcp/pkg/notation"
+)
+
+const (
+	// Damping is the PageRank damping factor
+	Damping = 0.85
+	// pageRankIterations and pageRankTolerance bound the power iteration
+	pageRankIterations = 100
+	pageRankTolerance  = 1e-9
+	// labelIterations bounds label propagation
+	labelIterations = 30
+)
+
+// Graph is the directed link graph between the existing pages of a project.
+// Links to pages that do not exist and links of a page to itself are left out.
+type Graph struct {
+	Titles []string
+	// Out and In hold the node indexes each node links to and is linked from
+	Out [][]int
+	In  [][]int
+}
+
+// Options controls which links become edges
+type Options struct {
+	// Tags counts #tags as links to the tag's page
+	Tags bool
+}
+
+// Build creates the graph of pages, with nodes in title order
+func Build(pages []*index.Page, opts Options) *Graph {
+	g := &Graph{
+		Titles: make([]string, len(pages)),
+		Out:    make([][]int, len(pages)),
+		In:     make([][]int, len(pages)),
+	}
+	nodes := make(map[string]int, len(pages))
+	for i, page := range pages {
+		g.Titles[i] = page.Title
+		nodes[key(page.Title)] = i
+	}
+
+	for from, page := range pages {
+		links := notation.ExtractLinks(page.Lines)
+		targets := links.InternalLinks
+		if opts.Tags {
+			targets = append(targets, links.Tags...)
+		}
+		seen := make(map[int]bool)
+		for _, target := range targets {
+			to, ok := nodes[key(target)]
+			if !ok || to == from || seen[to] {
+				continue
+			}
+			seen[to] = true
+			g.Out[from] = append(g.Out[from], to)
+			g.In[to] = append(g.In[to], from)
+		}
+	}
+	return g
+}
+
+// Edges returns the number of links
+func (g *Graph) Edges() int {
+	n := 0
+	for _, out := range g.Out {
+		n += len(out)
+	}
+	return n
+}
+
+// PageRank returns the PageRank of each node. Pages without outgoing links
+// spread their rank over all pages, so the ranks sum to 1.
+func (g *Graph) PageRank() []float64 {
+	n := len(g.Titles)
+	if n == 0 {
+		return nil
+	}
+	rank := make([]float64, n)
+	for i := range rank {
+		rank[i] = 1 / float64(n)
+	}
+
+	next := make([]float64, n)
+	for iter := 0; iter < pageRankIterations; iter++ {
+		dangling := 0.0
+		for i, out := range g.Out {
+			if len(out) == 0 {
+				dangling += rank[i]
+			}
+		}
+		base := (1-Damping)/float64(n) + Damping*dangling/float64(n)
+		for i := range next {
+			next[i] = base
+		}
+		for i, out := range g.Out {
+			if len(out) == 0 {
+				continue
+			}
+			share := Damping * rank[i] / float64(len(out))
+			for _, to := range out {
+				next[to] += share
+			}
+		}
+
+		delta := 0.0
+		for i := range rank {
+			delta += math.Abs(next[i] - rank[i])
+		}
+		rank, next = next, rank
+		if delta < pageRankTolerance {
+			break
+		}
+	}
+	return rank
+}
+
+// Communities assigns each node a community by label propagation over the
+// undirected graph: every node repeatedly takes the label most common among
+// its neighbours (ties go to the smallest label). Nodes are visited in title
+// order, so the result is deterministic. It returns the label of each node,
+// which is the index of one of the community's nodes.
+func (g *Graph) Communities() []int {
+	n := len(g.Titles)
+	neighbours := g.undirected()
+	labels := make([]int, n)
+	for i := range labels {
+		labels[i] = i
+	}
+
+	for iter := 0; iter < labelIterations; iter++ {
+		changed := false
+		for i := 0; i < n; i++ {
+			if len(neighbours[i]) == 0 {
+				continue
+			}
+			counts := make(map[int]int)
+			for _, j := range neighbours[i] {
+				counts[labels[j]]++
+			}
+			best, bestCount := labels[i], counts[labels[i]]
+			for label, count := range counts {
+				if count > bestCount || count == bestCount && label < best {
+					best, bestCount = label, count
+				}
+			}
+			if best != labels[i] {
+				labels[i] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	return labels
+}
+
+// Components returns the weakly connected components, largest first (ties
+// in order of their first title), each in title order
+func (g *Graph) Components() [][]int {
+	neighbours := g.undirected()
+	seen := make([]bool, len(g.Titles))
+	var components [][]int
+	for start := range g.Titles {
+		if seen[start] {
+			continue
+		}
+		seen[start] = true
+		component := []int{start}
+		for queue := []int{start}; len(queue) > 0; queue = queue[1:] {
+			for _, j := range neighbours[queue[0]] {
+				if !seen[j] {
+					seen[j] = true
+					component = append(component, j)
+					queue = append(queue, j)
+				}
+			}
+		}
+		sort.Ints(component)
+		components = append(components, component)
+	}
+	sort.SliceStable(components, func(a, b int) bool { return len(components[a]) > len(components[b]) })
+	return components
+}
+
+// undirected returns the neighbours of each node ignoring link direction
+func (g *Graph) undirected() [][]int {
+	neighbours := make([][]int, len(g.Titles))
+	for i := range g.Titles {
+		seen := make(map[int]bool)
+		for _, list := range [][]int{g.Out[i], g.In[i]} {
+			for _, j := range list {
+				if !seen[j] {
+					seen[j] = true
+					neighbours[i] = append(neighbours[i], j)
+				}
+			}
+		}
+	}
+	return neighbours
+}
+
+// key is the lookup key of a title; Scrapbox titles are case-insensitive
+// and treat "_" as a space
+func key(title string) string {
+	return strings.ToLower(strings.ReplaceAll(title, "_", " "))
+}
@@ -0,0 +1,92 @@
+// Package gyazo uploads images to Gyazo.
+package gyazo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"time"
+)
+
+// DefaultUploadURL is the Gyazo upload API endpoint
+const DefaultUploadURL = "https://upload.gyazo.com/api/upload"
+
+// Client uploads images with a Gyazo access token
+type Client struct {
+	uploadURL   string
+	accessToken string
+	httpClient  *http.Client
+}
+
+// NewClient creates a new Gyazo client
+func NewClient(accessToken string, timeout time.Duration) *Client {
+	return &Client{
+		uploadURL:   DefaultUploadURL,
+		accessToken: accessToken,
+		httpClient:  &http.Client{Timeout: timeout},
+	}
+}
+
+// uploadResponse is the response of the upload API
+type uploadResponse struct {
+	ImageID      string `json:"image_id"`
+	PermalinkURL string `json:"permalink_url"`
+	URL          string `json:"url"`
+}
+
+// Upload uploads an image and returns its permalink, which Scrapbox embeds as an image
+func (c *Client) Upload(ctx context.Context, name, contentType string, data []byte) (string, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err := writer.WriteField("access_token", c.accessToken); err != nil {
+		return "", err
+	}
+	part, err := writer.CreateFormFile("imagedata", name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := part.Write(data); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.uploadURL, &body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create Gyazo request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload to Gyazo: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Gyazo response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Gyazo upload failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result uploadResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("failed to parse Gyazo response: %w", err)
+	}
+	if result.PermalinkURL == "" {
+		return result.URL, nil
+	}
+	return result.PermalinkURL, nil
+}
+
+// String describes the upload destination
+func (c *Client) String() string {
+	return "Gyazo"
+}
@@ -0,0 +1,167 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Status values reported by checks
+const (
+	StatusHealthy   = "healthy"
+	StatusUnhealthy = "unhealthy"
+)
+
+// CheckFunc performs a single health check. The returned details (may be nil)
+// are included in the report.
+type CheckFunc func(ctx context.Context) (interface{}, error)
+
+// CheckResult is the outcome of a single check
+type CheckResult struct {
+	Status    string      `json:"status"`
+	LatencyMs int64       `json:"latencyMs"`
+	Details   interface{} `json:"details,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+// Report is the response body of deep health checks
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+type namedCheck struct {
+	name string
+	fn   CheckFunc
+	// readiness checks also gate /ready
+	readiness bool
+}
+
+// Checker runs registered health checks
+type Checker struct {
+	mu      sync.RWMutex
+	checks  []namedCheck
+	timeout time.Duration
+}
+
+// NewChecker creates a new Checker; each check is abandoned after timeout
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Register adds a check reported by deep /health.
+// If readiness is true, a failure also makes /ready return 503.
+func (c *Checker) Register(name string, readiness bool, fn CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, fn: fn, readiness: readiness})
+}
+
+// Run executes checks concurrently. If readinessOnly is true only readiness checks run.
+func (c *Checker) Run(ctx context.Context, readinessOnly bool) Report {
+	c.mu.RLock()
+	checks := make([]namedCheck, 0, len(c.checks))
+	for _, check := range c.checks {
+		if !readinessOnly || check.readiness {
+			checks = append(checks, check)
+		}
+	}
+	c.mu.RUnlock()
+
+	report := Report{
+		Status: StatusHealthy,
+		Checks: make(map[string]CheckResult, len(checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Add(1)
+		go func(check namedCheck) {
+			defer wg.Done()
+			result := c.runCheck(ctx, check.fn)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[check.name] = result
+			if result.Status != StatusHealthy {
+				report.Status = StatusUnhealthy
+			}
+		}(check)
+	}
+	wg.Wait()
+
+	return report
+}
+
+// runCheck executes fn with the checker timeout and measures its latency
+func (c *Checker) runCheck(ctx context.Context, fn CheckFunc) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	type outcome struct {
+		details interface{}
+		err     error
+	}
+	done := make(chan outcome, 1)
+
+	start := time.Now()
+	go func() {
+		details, err := fn(ctx)
+		done <- outcome{details: details, err: err}
+	}()
+
+	result := CheckResult{Status: StatusHealthy}
+	select {
+	case o := <-done:
+		result.Details = o.details
+		if o.err != nil {
+			result.Status = StatusUnhealthy
+			result.Error = o.err.Error()
+		}
+	case <-ctx.Done():
+		result.Status = StatusUnhealthy
+		result.Error = "check timed out"
+	}
+	result.LatencyMs = time.Since(start).Milliseconds()
+	return result
+}
+
+// HealthHandler serves /health. With ?deep=1 (or true) every check is run;
+// otherwise it only reports that the process is serving.
+func (c *Checker) HealthHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		deep := r.URL.Query().Get("deep")
+		if deep != "1" && deep != "true" {
+			writeReport(w, Report{Status: StatusHealthy})
+			return
+		}
+		writeReport(w, c.Run(r.Context(), false))
+	}
+}
+
+// LiveHandler serves /live: the process is up and able to handle requests
+func (c *Checker) LiveHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, Report{Status: StatusHealthy})
+	}
+}
+
+// ReadyHandler serves /ready: readiness checks pass and traffic can be routed here
+func (c *Checker) ReadyHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, c.Run(r.Context(), true))
+	}
+}
+
+func writeReport(w http.ResponseWriter, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	if report.Status != StatusHealthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	json.NewEncoder(w).Encode(report)
+}
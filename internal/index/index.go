@@ -0,0 +1,194 @@
+// Package index keeps a local, incrementally refreshed copy of page text so
+// that tools can scan whole projects without going through the search API.
+package index
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+const (
+	// listPageSize is the page size used to walk the page list (the API maximum)
+	listPageSize = 1000
+	// fetchWorkers is the number of pages fetched concurrently during a refresh
+	fetchWorkers = 4
+)
+
+// Page is the cached text of one page
+type Page struct {
+	Title   string
+	Updated int64
+	Lines   []string
+}
+
+// projectIndex holds the cached pages of one project
+type projectIndex struct {
+	mu        sync.Mutex
+	pages     map[string]*Page
+	refreshed time.Time
+}
+
+// Index caches page text per project. Pages are refetched only when their
+// updated timestamp in the page list changes.
+type Index struct {
+	reader   scrapbox.Reader
+	maxAge   time.Duration
+	mu       sync.Mutex
+	projects map[string]*projectIndex
+}
+
+// New creates an index over reader. The page list is re-checked when the
+// cached copy is older than maxAge (0 re-checks on every call).
+func New(reader scrapbox.Reader, maxAge time.Duration) *Index {
+	return &Index{
+		reader:   reader,
+		maxAge:   maxAge,
+		projects: make(map[string]*projectIndex),
+	}
+}
+
+// Pages returns the cached pages of project sorted by title, refreshing the
+// cache first when it is stale
+func (i *Index) Pages(ctx context.Context, project string) ([]*Page, error) {
+	p := i.project(project)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pages == nil || time.Since(p.refreshed) >= i.maxAge {
+		if err := i.refresh(ctx, project, p); err != nil {
+			if p.pages == nil {
+				return nil, err
+			}
+			// Serve the stale copy rather than failing the whole scan
+			log.Printf("[INDEX] Refresh of %s failed, using cached pages: %v", project, err)
+		}
+	}
+
+	pages := make([]*Page, 0, len(p.pages))
+	for _, page := range p.pages {
+		pages = append(pages, page)
+	}
+	sort.Slice(pages, func(a, b int) bool { return pages[a].Title < pages[b].Title })
+	return pages, nil
+}
+
+func (i *Index) project(name string) *projectIndex {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	p, ok := i.projects[name]
+	if !ok {
+		p = &projectIndex{}
+		i.projects[name] = p
+	}
+	return p
+}
+
+// refresh walks the page list and fetches new or updated pages. Must be
+// called with p.mu held.
+func (i *Index) refresh(ctx context.Context, project string, p *projectIndex) error {
+	var infos []scrapbox.PageInfo
+	for skip := 0; ; skip += listPageSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		resp, err := i.reader.ListPages(project, listPageSize, skip)
+		if err != nil {
+			return err
+		}
+		infos = append(infos, resp.Pages...)
+		if len(resp.Pages) < listPageSize || len(infos) >= resp.Count {
+			break
+		}
+	}
+
+	pages := make(map[string]*Page, len(infos))
+	var stale []scrapbox.PageInfo
+	for _, info := range infos {
+		if cached, ok := p.pages[info.Title]; ok && cached.Updated == info.Updated {
+			pages[info.Title] = cached
+			continue
+		}
+		stale = append(stale, info)
+	}
+
+	fetched, err := i.fetch(ctx, project, stale)
+	if err != nil {
+		return err
+	}
+	for _, page := range fetched {
+		pages[page.Title] = page
+	}
+
+	if len(stale) > 0 {
+		log.Printf("[INDEX] %s: %d pages cached, %d fetched", project, len(pages), len(stale))
+	}
+	p.pages = pages
+	p.refreshed = time.Now()
+	return nil
+}
+
+// fetch retrieves the given pages with a small pool of workers
+func (i *Index) fetch(ctx context.Context, project string, infos []scrapbox.PageInfo) ([]*Page, error) {
+	jobs := make(chan scrapbox.PageInfo)
+	results := make([]*Page, 0, len(infos))
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	for w := 0; w < fetchWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for info := range jobs {
+				page, err := i.reader.GetPage(project, info.Title)
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = fmt.Errorf("failed to fetch %q: %w", info.Title, err)
+					}
+				} else {
+					results = append(results, newPage(page, info.Updated))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, info := range infos {
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed || ctx.Err() != nil {
+			break
+		}
+		jobs <- info
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func newPage(page *scrapbox.Page, updated int64) *Page {
+	lines := make([]string, len(page.Lines))
+	for n, line := range page.Lines {
+		lines[n] = line.Text
+	}
+	return &Page{Title: page.Title, Updated: updated, Lines: lines}
+}
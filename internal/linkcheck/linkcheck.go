@@ -0,0 +1,174 @@
+// Package linkcheck inventories the external URLs of a project and checks
+// whether they still resolve.
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/index"
+	"github.com/hiroki/scrapbox_mcp/internal/safehttp"
+	"github.com/hiroki/scrapbox_mcp/pkg/notation"
+)
+
+const (
+	// DefaultConcurrency is the number of URLs checked at once by default
+	DefaultConcurrency = 8
+	// DefaultTimeout bounds each check request by default
+	DefaultTimeout = 10 * time.Second
+)
+
+// Link is an external URL and the pages citing it
+type Link struct {
+	URL    string
+	Domain string
+	Pages  []string
+}
+
+// Collect returns the http(s) URLs cited by pages, sorted by domain then URL.
+// Each link lists its citing pages in title order.
+func Collect(pages []*index.Page) []*Link {
+	byURL := make(map[string]*Link)
+	for _, page := range pages {
+		for _, u := range notation.ExtractLinks(page.Lines).ExternalURLs {
+			link, ok := byURL[u]
+			if !ok {
+				link = &Link{URL: u, Domain: Domain(u)}
+				byURL[u] = link
+			}
+			link.Pages = append(link.Pages, page.Title)
+		}
+	}
+
+	links := make([]*Link, 0, len(byURL))
+	for _, link := range byURL {
+		sort.Strings(link.Pages)
+		links = append(links, link)
+	}
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].Domain != links[j].Domain {
+			return links[i].Domain < links[j].Domain
+		}
+		return links[i].URL < links[j].URL
+	})
+	return links
+}
+
+// Domain returns the lower-cased host of rawURL without "www.", or "" when it
+// does not parse
+func Domain(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+}
+
+// Status is the outcome of checking one URL
+type Status struct {
+	// Code is the HTTP status, 0 when the request failed
+	Code int
+	// Error describes a failed request
+	Error string
+	// Broken reports a URL that is gone: 404, 410, or a request that failed
+	Broken bool
+	// Skipped reports a URL on a non-public address, which is not requested
+	Skipped bool
+}
+
+// Checker requests URLs to find the broken ones
+type Checker struct {
+	httpClient  *http.Client
+	concurrency int
+}
+
+// NewChecker creates a checker running at most concurrency requests at once,
+// each bounded by timeout. Zero values use the defaults. Requests only reach
+// public addresses, since anyone editing a page chooses the URLs.
+func NewChecker(concurrency int, timeout time.Duration) *Checker {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Checker{
+		httpClient:  safehttp.NewClient(timeout),
+		concurrency: concurrency,
+	}
+}
+
+// Check requests every URL and returns the status of each
+func (c *Checker) Check(ctx context.Context, urls []string) map[string]Status {
+	statuses := make(map[string]Status, len(urls))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, c.concurrency)
+
+	for _, u := range urls {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return statuses
+		}
+		wg.Add(1)
+		go func(u string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			status := c.check(ctx, u)
+			mu.Lock()
+			statuses[u] = status
+			mu.Unlock()
+		}(u)
+	}
+
+	wg.Wait()
+	return statuses
+}
+
+// check requests u with HEAD, retrying with GET when the server rejects HEAD.
+// URLs on non-public addresses are skipped without a request, so their
+// outcome cannot reveal what is reachable from the server.
+func (c *Checker) check(ctx context.Context, u string) Status {
+	if parsed, err := url.Parse(u); err == nil {
+		if err := safehttp.CheckHost(ctx, parsed.Hostname()); errors.Is(err, safehttp.ErrNotPublic) {
+			return Status{Skipped: true}
+		}
+	}
+	code, err := c.request(ctx, http.MethodHead, u)
+	if err == nil && (code == http.StatusMethodNotAllowed || code == http.StatusNotImplemented || code == http.StatusForbidden) {
+		code, err = c.request(ctx, http.MethodGet, u)
+	}
+	if err != nil {
+		return Status{Error: err.Error(), Broken: true}
+	}
+	return Status{Code: code, Broken: code == http.StatusNotFound || code == http.StatusGone}
+}
+
+func (c *Checker) request(ctx context.Context, method, u string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return 0, fmt.Errorf("invalid URL: %v", err)
+	}
+	req.Header.Set("User-Agent", "scrapbox-mcp-linkcheck")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Drain a little of the body so the connection can be reused
+	io.CopyN(io.Discard, resp.Body, 4096)
+	return resp.StatusCode, nil
+}
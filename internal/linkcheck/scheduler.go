@@ -0,0 +1,177 @@
+package linkcheck
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/index"
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+// DefaultReportPage is the page the scheduler writes broken links to
+const DefaultReportPage = "Broken Links"
+
+// BrokenLink is a URL that failed its check and the pages citing it
+type BrokenLink struct {
+	URL    string   `json:"url"`
+	Domain string   `json:"domain"`
+	Status int      `json:"status,omitempty"`
+	Error  string   `json:"error,omitempty"`
+	Pages  []string `json:"pages"`
+}
+
+// Report is the outcome of one check of a project's external links
+type Report struct {
+	Project   string       `json:"project"`
+	CheckedAt time.Time    `json:"checkedAt"`
+	URLs      int          `json:"urls"`
+	Broken    []BrokenLink `json:"broken"`
+	// Skipped counts URLs on non-public addresses, which are not checked
+	Skipped int `json:"skipped,omitempty"`
+	// Page is the result of writing the report page, nil when it was not written
+	Page *scrapbox.WriteResult `json:"-"`
+}
+
+// Scheduler periodically checks the external links of the default project and
+// writes the broken ones to a report page
+type Scheduler struct {
+	client   scrapbox.API
+	index    *index.Index
+	checker  *Checker
+	interval time.Duration
+	page     string
+	mu       sync.Mutex // serializes check runs
+}
+
+// NewScheduler creates a link check scheduler writing to page (empty uses
+// DefaultReportPage). An interval of 0 disables scheduled runs (manual
+// triggers still work).
+func NewScheduler(client scrapbox.API, pageIndex *index.Index, checker *Checker, interval time.Duration, page string) *Scheduler {
+	if page == "" {
+		page = DefaultReportPage
+	}
+	return &Scheduler{
+		client:   client,
+		index:    pageIndex,
+		checker:  checker,
+		interval: interval,
+		page:     page,
+	}
+}
+
+// Run checks the links and updates the report page every interval until ctx is done
+func (s *Scheduler) Run(ctx context.Context) {
+	if s.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := s.Check(ctx, true)
+			if err != nil {
+				log.Printf("[LINKCHECK] Scheduled check failed: %v", err)
+				continue
+			}
+			log.Printf("[LINKCHECK] %d of %d URLs broken, wrote %s", len(report.Broken), report.URLs, s.page)
+		}
+	}
+}
+
+// ReportPage returns the title of the report page
+func (s *Scheduler) ReportPage() string {
+	return s.page
+}
+
+// Check requests every external URL of the default project and, when
+// writeReport is set, replaces the report page with the broken ones
+func (s *Scheduler) Check(ctx context.Context, writeReport bool) (*Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project := s.client.DefaultProject()
+	pages, err := s.index.Pages(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	// The report cites every broken URL, so it must not count as a source
+	sources := make([]*index.Page, 0, len(pages))
+	for _, page := range pages {
+		if page.Title != s.page {
+			sources = append(sources, page)
+		}
+	}
+	links := Collect(sources)
+
+	urls := make([]string, len(links))
+	for i, link := range links {
+		urls[i] = link.URL
+	}
+	statuses := s.checker.Check(ctx, urls)
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("link check interrupted: %w", err)
+	}
+
+	report := &Report{
+		Project:   project,
+		CheckedAt: time.Now().UTC(),
+		URLs:      len(links),
+		Broken:    []BrokenLink{},
+	}
+	for _, link := range links {
+		status := statuses[link.URL]
+		if status.Skipped {
+			report.Skipped++
+		}
+		if !status.Broken {
+			continue
+		}
+		report.Broken = append(report.Broken, BrokenLink{
+			URL:    link.URL,
+			Domain: link.Domain,
+			Status: status.Code,
+			Error:  status.Error,
+			Pages:  link.Pages,
+		})
+	}
+
+	if writeReport {
+		report.Page, err = s.client.CreatePage(s.page, reportLines(report), scrapbox.IfExistsOverwrite)
+		if err != nil {
+			return report, fmt.Errorf("failed to write %s: %w", s.page, err)
+		}
+	}
+	return report, nil
+}
+
+// reportLines renders the body of the report page: a summary line, then each
+// broken URL with its status and the pages citing it indented below
+func reportLines(report *Report) []string {
+	summary := fmt.Sprintf("Last checked: %s - %d of %d external URLs broken",
+		report.CheckedAt.Format("2006-01-02 15:04 UTC"), len(report.Broken), report.URLs)
+	if report.Skipped > 0 {
+		summary += fmt.Sprintf(", %d on non-public addresses not checked", report.Skipped)
+	}
+	lines := []string{summary}
+	for _, link := range report.Broken {
+		// Request errors quote the URL; keep the page to one link per entry
+		status := "unreachable"
+		if link.Status != 0 {
+			status = fmt.Sprintf("%d", link.Status)
+		}
+		lines = append(lines, "", link.URL+" "+status)
+		for _, title := range link.Pages {
+			lines = append(lines, " ["+title+"]")
+		}
+	}
+	return lines
+}
@@ -0,0 +1,172 @@
+// Package markdown converts Scrapbox pages to Markdown files and writes them
+// to a directory or a zip archive.
+package markdown
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/pkg/notation"
+)
+
+// Page is the text of a page to export; Lines[0] is the title line
+type Page struct {
+	Title   string
+	Lines   []string
+	Created time.Time
+	Updated time.Time
+}
+
+// File is one exported Markdown file
+type File struct {
+	Title string
+	// Name is the slash-separated path of the file
+	Name    string
+	Content []byte
+}
+
+// Options controls where links that leave the exported set point. A nil
+// function leaves such links as plain text.
+type Options struct {
+	// PageURL returns the URL of a page of the project that is not exported
+	PageURL func(title string) string
+	// ProjectPageURL returns the URL of a page of another project
+	ProjectPageURL func(project, title string) string
+	// Profile shapes the files; nil is PlainProfile
+	Profile Profile
+}
+
+// Export converts pages to Markdown files. Links between exported pages
+// become relative links to their files; other links use opts.
+func Export(pages []Page, opts Options) []File {
+	profile := opts.Profile
+	if profile == nil {
+		profile = PlainProfile{}
+	}
+	names := FileNames(pages, profile)
+
+	linkURL := func(title string) string {
+		if name, ok := names[titleKey(title)]; ok {
+			return profile.LinkURL(name)
+		}
+		if opts.PageURL != nil {
+			return opts.PageURL(title)
+		}
+		return ""
+	}
+	mdOpts := notation.MarkdownOptions{
+		LinkURL: linkURL,
+	}
+	if opts.ProjectPageURL != nil {
+		mdOpts.ProjectLinkURL = func(path string) string {
+			project, title, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+			return opts.ProjectPageURL(project, title)
+		}
+	}
+
+	files := make([]File, 0, len(pages))
+	for _, page := range pages {
+		body := page.Lines
+		if len(body) > 0 {
+			body = body[1:]
+		}
+		var content bytes.Buffer
+		content.WriteString(profile.Header(page, notation.ExtractLinks(body).Tags))
+		content.WriteString(notation.Markdown(body, mdOpts))
+		files = append(files, File{
+			Title:   page.Title,
+			Name:    names[titleKey(page.Title)],
+			Content: content.Bytes(),
+		})
+	}
+	return files
+}
+
+// FileNames assigns each page a file name from the profile, keyed by
+// titleKey. Names are unique even on case-insensitive file systems.
+func FileNames(pages []Page, profile Profile) map[string]string {
+	names := make(map[string]string, len(pages))
+	taken := make(map[string]bool, len(pages))
+	for _, page := range pages {
+		key := titleKey(page.Title)
+		if _, ok := names[key]; ok {
+			continue
+		}
+		name := profile.FileName(page.Title)
+		base, ext := strings.TrimSuffix(name, path.Ext(name)), path.Ext(name)
+		for n := 2; taken[strings.ToLower(name)]; n++ {
+			name = fmt.Sprintf("%s-%d%s", base, n, ext)
+		}
+		taken[strings.ToLower(name)] = true
+		names[key] = name
+	}
+	return names
+}
+
+// FileBase turns a title into a file name without extension: spaces become
+// "_" as in Scrapbox URLs, and path separators and characters that are
+// invalid on common file systems become "-"
+func FileBase(title string) string {
+	base := strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ':
+			return '_'
+		case strings.ContainsRune(`/\:*?"<>|`, r), r < 0x20:
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(title))
+	if base == "" || strings.Trim(base, ".") == "" {
+		base = "untitled"
+	}
+	return base
+}
+
+// titleKey is the lookup key of a title; Scrapbox titles are
+// case-insensitive and treat "_" as a space
+func titleKey(title string) string {
+	return strings.ToLower(strings.ReplaceAll(title, "_", " "))
+}
+
+// WriteDir writes files into dir, creating it if needed and overwriting
+// existing files of the same name
+func WriteDir(dir string, files []File) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create export directory: %w", err)
+	}
+	for _, file := range files {
+		name := filepath.Join(dir, filepath.FromSlash(file.Name))
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			return fmt.Errorf("failed to create export directory: %w", err)
+		}
+		if err := os.WriteFile(name, file.Content, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.Name, err)
+		}
+	}
+	return nil
+}
+
+// Zip returns files as a zip archive
+func Zip(files []File) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, file := range files {
+		w, err := zw.Create(file.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add %s to archive: %w", file.Name, err)
+		}
+		if _, err := w.Write(file.Content); err != nil {
+			return nil, fmt.Errorf("failed to add %s to archive: %w", file.Name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	return buf.Bytes(), nil
+}
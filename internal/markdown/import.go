@@ -0,0 +1,188 @@
+package markdown
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hiroki/scrapbox_mcp/pkg/notation"
+)
+
+// Note is a Markdown file converted to page lines
+type Note struct {
+	// Path is the file's slash-separated path relative to the imported directory
+	Path  string
+	Title string
+	// Lines is the page body, without the title line
+	Lines []string
+}
+
+// note is a Markdown file read from disk, before conversion
+type note struct {
+	path  string
+	title string
+	body  string
+	tags  []string
+}
+
+// ReadDir reads the .md files under root, like an Obsidian vault: titles
+// come from front matter, a leading # heading matching the file name, or the
+// file name. [[Wiki links]] and relative links to other files of the
+// directory become links to their titles. Hidden files and directories
+// (.obsidian, .git) are skipped.
+func ReadDir(root string) ([]Note, error) {
+	var notes []*note
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != root && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(p), ".md") {
+			return nil
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		notes = append(notes, parseNote(filepath.ToSlash(rel), string(data)))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", root, err)
+	}
+	sort.Slice(notes, func(i, j int) bool { return notes[i].path < notes[j].path })
+
+	// Links name a file by its name or its path, without .md
+	titles := make(map[string]string, 2*len(notes))
+	for _, n := range notes {
+		for _, key := range []string{strings.TrimSuffix(n.path, path.Ext(n.path)), noteName(n.path)} {
+			if _, ok := titles[titleKey(key)]; !ok {
+				titles[titleKey(key)] = n.title
+			}
+		}
+	}
+
+	converted := make([]Note, 0, len(notes))
+	for _, n := range notes {
+		dir := path.Dir(n.path)
+		opts := notation.FromMarkdownOptions{
+			ResolveLink: func(target string) string {
+				if strings.EqualFold(path.Ext(target), ".md") {
+					target = strings.TrimSuffix(target, path.Ext(target))
+				}
+				if title, ok := titles[titleKey(path.Join(dir, target))]; ok {
+					return title
+				}
+				return titles[titleKey(path.Base(target))]
+			},
+		}
+		lines := notation.FromMarkdown(n.body, opts)
+		if len(n.tags) > 0 {
+			lines = append(lines, "", strings.Join(n.tags, " "))
+		}
+		converted = append(converted, Note{Path: n.path, Title: n.title, Lines: lines})
+	}
+	return converted, nil
+}
+
+// parseNote splits off front matter and picks the title of a file
+func parseNote(p, text string) *note {
+	n := &note{path: p, title: noteName(p)}
+	text = strings.TrimPrefix(strings.ReplaceAll(text, "\r\n", "\n"), "\ufeff")
+
+	if strings.HasPrefix(text, "---\n") {
+		if end := strings.Index(text[4:], "\n---"); end >= 0 {
+			title, tags := parseFrontMatter(text[4 : 4+end])
+			if title != "" {
+				n.title = title
+			}
+			for _, tag := range tags {
+				n.tags = append(n.tags, "#"+strings.ReplaceAll(strings.TrimPrefix(tag, "#"), " ", "_"))
+			}
+			text = text[4+end+len("\n---"):]
+			text = strings.TrimPrefix(text, "\n")
+		}
+	}
+
+	// A leading "# Title" repeats the title; drop it
+	trimmed := strings.TrimLeft(text, "\n")
+	first, rest, _ := strings.Cut(trimmed, "\n")
+	if heading, ok := strings.CutPrefix(first, "# "); ok {
+		heading = strings.TrimSpace(heading)
+		if titleKey(heading) == titleKey(n.title) {
+			n.title = heading
+			text = rest
+		}
+	}
+	n.body = text
+	return n
+}
+
+// parseFrontMatter reads title and tags from YAML front matter. Tags may be
+// a flow list ([a, b]), a comma- or space-separated string or a block list.
+func parseFrontMatter(yaml string) (title string, tags []string) {
+	inTags := false
+	for _, line := range strings.Split(yaml, "\n") {
+		if inTags {
+			if item, ok := strings.CutPrefix(strings.TrimSpace(line), "- "); ok {
+				tags = append(tags, unquote(item))
+				continue
+			}
+			inTags = false
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || strings.HasPrefix(line, " ") {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "title":
+			title = unquote(value)
+		case "tags", "tag":
+			if value == "" {
+				inTags = true
+				continue
+			}
+			// A flow list is split on commas only, so quoted tags keep their spaces
+			sep := func(r rune) bool { return r == ',' || r == ' ' }
+			if strings.HasPrefix(value, "[") {
+				value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+				sep = func(r rune) bool { return r == ',' }
+			}
+			for _, tag := range strings.FieldsFunc(value, sep) {
+				if tag = unquote(tag); tag != "" {
+					tags = append(tags, tag)
+				}
+			}
+		}
+	}
+	return title, tags
+}
+
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// noteName is a file's name without directory and extension
+func noteName(p string) string {
+	return strings.TrimSuffix(path.Base(p), path.Ext(p))
+}
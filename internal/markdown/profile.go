@@ -0,0 +1,204 @@
+package markdown
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Profile shapes exported files for the site generator that consumes them:
+// file names, links between files and the header written before the body
+type Profile interface {
+	Name() string
+	// FileName returns the slash-separated path of a page's file
+	FileName(title string) string
+	// LinkURL returns the target of a link to the file name from another
+	// exported file
+	LinkURL(name string) string
+	// Header returns the text written before the page body; tags are the
+	// page's hashtags
+	Header(page Page, tags []string) string
+}
+
+// PlainProfile writes "Title.md" files starting with a # Title heading
+type PlainProfile struct{}
+
+func (PlainProfile) Name() string { return "plain" }
+
+func (PlainProfile) FileName(title string) string { return FileBase(title) + ".md" }
+
+func (PlainProfile) LinkURL(name string) string { return escapePath(name) }
+
+func (PlainProfile) Header(page Page, tags []string) string {
+	return "# " + page.Title + "\n\n"
+}
+
+// HugoProfile writes content files with YAML front matter (title, date,
+// lastmod, tags). Links use the ref shortcode so Hugo resolves them to the
+// published URLs.
+type HugoProfile struct{}
+
+func (HugoProfile) Name() string { return "hugo" }
+
+func (HugoProfile) FileName(title string) string { return FileBase(title) + ".md" }
+
+func (HugoProfile) LinkURL(name string) string {
+	return `{{< ref "` + name + `" >}}`
+}
+
+func (HugoProfile) Header(page Page, tags []string) string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	b.WriteString("title: " + strconv.Quote(page.Title) + "\n")
+	if !page.Created.IsZero() {
+		b.WriteString("date: " + page.Created.UTC().Format(time.RFC3339) + "\n")
+	}
+	if !page.Updated.IsZero() {
+		b.WriteString("lastmod: " + page.Updated.UTC().Format(time.RFC3339) + "\n")
+	}
+	if len(tags) > 0 {
+		b.WriteString("tags: " + yamlList(tags) + "\n")
+	}
+	b.WriteString("---\n\n")
+	return b.String()
+}
+
+// ZennProfile writes Zenn articles: articles/<slug>.md with Zenn's front
+// matter. Articles are exported unpublished, for review before publishing.
+type ZennProfile struct{}
+
+const (
+	// zennMaxTopics is the number of topics Zenn accepts per article
+	zennMaxTopics = 5
+	zennEmoji     = "📝"
+	zennType      = "tech"
+)
+
+// zennTimezone is the zone Zenn reads published_at in
+var zennTimezone = time.FixedZone("JST", 9*60*60)
+
+func (ZennProfile) Name() string { return "zenn" }
+
+func (ZennProfile) FileName(title string) string { return "articles/" + zennSlug(title) + ".md" }
+
+// LinkURL links to the article's slug; Zenn serves articles of a user side
+// by side, so a relative slug reaches the other article
+func (ZennProfile) LinkURL(name string) string {
+	return strings.TrimSuffix(path.Base(name), path.Ext(name))
+}
+
+func (ZennProfile) Header(page Page, tags []string) string {
+	var topics []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		topic := zennTopic(tag)
+		if topic == "" || seen[topic] || len(topics) == zennMaxTopics {
+			continue
+		}
+		seen[topic] = true
+		topics = append(topics, topic)
+	}
+
+	var b strings.Builder
+	b.WriteString("---\n")
+	b.WriteString("title: " + strconv.Quote(page.Title) + "\n")
+	b.WriteString("emoji: " + strconv.Quote(zennEmoji) + "\n")
+	b.WriteString("type: " + strconv.Quote(zennType) + "\n")
+	b.WriteString("topics: " + yamlList(topics) + "\n")
+	b.WriteString("published: false\n")
+	if !page.Created.IsZero() {
+		b.WriteString("published_at: " + page.Created.In(zennTimezone).Format("2006-01-02 15:04") + "\n")
+	}
+	b.WriteString("---\n\n")
+	return b.String()
+}
+
+// zennSlug turns a title into a Zenn slug (12-50 characters of a-z, 0-9, -
+// and _). Titles without enough ASCII get a hash of the title, so the slug
+// stays the same across exports.
+func zennSlug(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	slug := strings.TrimSuffix(b.String(), "-")
+	if len(slug) >= 12 && len(slug) <= 50 {
+		return slug
+	}
+
+	sum := sha256.Sum256([]byte(title))
+	hash := hex.EncodeToString(sum[:])[:12]
+	if slug == "" {
+		return hash
+	}
+	return strings.TrimSuffix(slug[:min(len(slug), 37)], "-") + "-" + hash
+}
+
+// zennTopic reduces a tag to Zenn's topic characters (lowercase a-z and 0-9)
+func zennTopic(tag string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return -1
+	}, tag)
+}
+
+// yamlList formats strings as a YAML flow sequence
+func yamlList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = strconv.Quote(item)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// escapePath escapes each segment of a slash-separated path for a link
+func escapePath(name string) string {
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+var profiles = map[string]Profile{
+	"plain": PlainProfile{},
+	"hugo":  HugoProfile{},
+	"zenn":  ZennProfile{},
+}
+
+// LookupProfile returns the profile with the given name
+func LookupProfile(name string) (Profile, error) {
+	profile, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown export profile: %s (expected %s)", name, strings.Join(ProfileNames(), ", "))
+	}
+	return profile, nil
+}
+
+// ProfileNames lists the available profiles
+func ProfileNames() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,73 @@
+package mcp
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientAccess limits the MCP endpoints to clients from allowed addresses
+// and, with mutual TLS, to clients presenting a verified certificate
+type ClientAccess struct {
+	allowed        []*net.IPNet
+	trustedProxies TrustedProxies
+	requireCert    bool
+}
+
+// ParseAllowedIPs parses the IP addresses and CIDR ranges clients may connect from
+func ParseAllowedIPs(entries []string) ([]*net.IPNet, error) {
+	return parseIPNets(entries, "allowed IP")
+}
+
+// NewClientAccess returns the access check. An empty allowed list accepts
+// every address. Behind trusted proxies the client address is taken from
+// X-Forwarded-For.
+func NewClientAccess(allowed []*net.IPNet, trustedProxies TrustedProxies, requireCert bool) *ClientAccess {
+	return &ClientAccess{allowed: allowed, trustedProxies: trustedProxies, requireCert: requireCert}
+}
+
+// Wrap refuses requests from other addresses or without a verified client
+// certificate with 403 before next sees them
+func (a *ClientAccess) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ip := a.clientIP(r)
+		if len(a.allowed) > 0 && !containsIP(a.allowed, ip) {
+			log.Printf("[ACCESS] Refused %s %s from %s: address not allowed", r.Method, r.URL.Path, ip)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		if a.requireCert && (r.TLS == nil || len(r.TLS.VerifiedChains) == 0) {
+			log.Printf("[ACCESS] Refused %s %s from %s: no verified client certificate", r.Method, r.URL.Path, ip)
+			http.Error(w, "Client certificate required", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// clientIP returns the address of the client. Behind trusted proxies it is
+// the nearest X-Forwarded-For entry that is not itself a trusted proxy, since
+// entries further left can be forged by the client. Proxies may append their
+// own header line instead of extending the client's, so all lines are read
+// in order as one list.
+func (a *ClientAccess) clientIP(r *http.Request) net.IP {
+	ip := remoteIP(r)
+	forwarded := strings.Join(r.Header.Values("X-Forwarded-For"), ",")
+	if forwarded == "" || !a.trustedProxies.Contains(r) {
+		return ip
+	}
+	hops := strings.Split(forwarded, ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		// An unreadable hop leaves the client unknown rather than the proxy
+		ip = hop
+		if hop == nil {
+			break
+		}
+		if !containsIP(a.trustedProxies, hop) {
+			break
+		}
+	}
+	return ip
+}
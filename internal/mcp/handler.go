@@ -4,7 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
+	"github.com/hiroki/scrapbox_mcp/internal/changes"
+	"github.com/hiroki/scrapbox_mcp/internal/rbac"
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
 	"github.com/hiroki/scrapbox_mcp/internal/tools"
 	mcperrors "github.com/hiroki/scrapbox_mcp/pkg/errors"
 )
@@ -12,13 +16,55 @@ import (
 type MessageHandler struct {
 	toolRegistry   *tools.Registry
 	sessionManager *SessionManager
+	changeWatcher  *changes.Watcher
+	tenants        *Tenants
+	requireRoots   bool
 }
 
 func NewMessageHandler(registry *tools.Registry, sessionMgr *SessionManager) *MessageHandler {
-	return &MessageHandler{
+	h := &MessageHandler{
 		toolRegistry:   registry,
 		sessionManager: sessionMgr,
 	}
+
+	// Tell connected clients to refetch tools/list when tools change at runtime
+	registry.OnChange(func() {
+		sessionMgr.Broadcast(&JSONRPCNotification{
+			JSONRPC: "2.0",
+			Method:  "notifications/tools/list_changed",
+		})
+	})
+
+	return h
+}
+
+// SetChangeWatcher enables resources/subscribe backed by the Scrapbox project updates stream
+func (h *MessageHandler) SetChangeWatcher(watcher *changes.Watcher) {
+	h.changeWatcher = watcher
+	watcher.SetNotifier(func(sessionID, uri string) bool {
+		params, err := json.Marshal(ResourceUpdatedParams{URI: uri})
+		if err != nil {
+			return true
+		}
+		return h.sessionManager.Notify(sessionID, &JSONRPCNotification{
+			JSONRPC: "2.0",
+			Method:  "notifications/resources/updated",
+			Params:  params,
+		})
+	})
+}
+
+// SetTenants lets clients supply their own Scrapbox credentials (initialize
+// "credentials" or set_credentials); their sessions' tool calls go to the
+// tenant registry for those credentials
+func (h *MessageHandler) SetTenants(tenants *Tenants) {
+	h.tenants = tenants
+}
+
+// SetRequireRoots rejects tool calls from sessions whose roots grant no
+// Scrapbox project instead of leaving them unrestricted
+func (h *MessageHandler) SetRequireRoots(require bool) {
+	h.requireRoots = require
 }
 
 func (h *MessageHandler) HandleRequest(ctx context.Context, req *JSONRPCRequest, sessionID string) *JSONRPCResponse {
@@ -36,16 +82,41 @@ func (h *MessageHandler) HandleRequest(ctx context.Context, req *JSONRPCRequest,
 			response.Result = result
 		}
 
-	case "initialized":
+	case "initialized", "notifications/initialized":
 		// Notification - no response needed
+		h.refreshRootsAsync(sessionID)
+		return nil
+
+	case "notifications/roots/list_changed":
+		h.refreshRootsAsync(sessionID)
 		return nil
 
 	case "tools/list":
-		result := h.handleToolsList()
-		response.Result = result
+		result, err := h.handleToolsList(ctx, sessionID)
+		if err != nil {
+			response.Error = h.toRPCError(err)
+		} else {
+			response.Result = result
+		}
 
 	case "tools/call":
-		result, err := h.handleToolsCall(ctx, req.Params)
+		result, err := h.handleToolsCall(h.toolContext(ctx, sessionID), req.Params, sessionID)
+		if err != nil {
+			response.Error = h.toRPCError(err)
+		} else {
+			response.Result = result
+		}
+
+	case "resources/subscribe":
+		result, err := h.handleResourcesSubscribe(req.Params, sessionID)
+		if err != nil {
+			response.Error = h.toRPCError(err)
+		} else {
+			response.Result = result
+		}
+
+	case "resources/unsubscribe":
+		result, err := h.handleResourcesUnsubscribe(req.Params, sessionID)
 		if err != nil {
 			response.Error = h.toRPCError(err)
 		} else {
@@ -70,12 +141,17 @@ func (h *MessageHandler) handleInitialize(ctx context.Context, params json.RawMe
 	if err := json.Unmarshal(params, &initReq); err != nil {
 		return nil, mcperrors.NewMCPError(mcperrors.ErrCodeInvalidParams, "Invalid initialize params", err.Error())
 	}
+	if initReq.Credentials != nil {
+		if err := h.checkCredentials(*initReq.Credentials); err != nil {
+			return nil, err
+		}
+	}
 
 	result := &InitializeResult{
 		ProtocolVersion: "2024-11-05",
 		Capabilities: ServerCapabilities{
 			Tools: &ToolsCapability{
-				ListChanged: false,
+				ListChanged: true,
 			},
 		},
 		ServerInfo: ServerInfo{
@@ -84,6 +160,12 @@ func (h *MessageHandler) handleInitialize(ctx context.Context, params json.RawMe
 		},
 	}
 
+	if h.changeWatcher != nil {
+		result.Capabilities.Resources = map[string]interface{}{
+			"subscribe": true,
+		}
+	}
+
 	// Store session
 	if sessionID != "" {
 		session, exists := h.sessionManager.Get(sessionID)
@@ -95,10 +177,56 @@ func (h *MessageHandler) handleInitialize(ctx context.Context, params json.RawMe
 	return result, nil
 }
 
-func (h *MessageHandler) handleToolsList() *ToolsListResult {
-	toolsList := h.toolRegistry.List()
+// checkCredentials validates credentials a client supplied in initialize
+func (h *MessageHandler) checkCredentials(credentials scrapbox.Credentials) error {
+	if h.tenants == nil {
+		return mcperrors.NewMCPError(mcperrors.ErrCodeInvalidParams, "Client-supplied credentials are not enabled on this server", nil)
+	}
+	if credentials.Project == "" || credentials.SessionCookie == "" {
+		return mcperrors.NewMCPError(mcperrors.ErrCodeInvalidParams, "Invalid initialize params", "credentials need both project and sid")
+	}
+	if err := h.tenants.Check(credentials); err != nil {
+		return mcperrors.NewMCPError(mcperrors.ErrCodeInvalidParams, "Invalid credentials", mcperrors.Redact(err.Error()))
+	}
+	return nil
+}
+
+// registryFor returns the tool registry serving a session: the tenant
+// registry of its credentials, or the server's
+func (h *MessageHandler) registryFor(sessionID string) (*tools.Registry, error) {
+	session, exists := h.sessionManager.Get(sessionID)
+	if sessionID == "" || !exists {
+		return h.toolRegistry, nil
+	}
+	credentials := session.Credentials()
+	if credentials == nil {
+		return h.toolRegistry, nil
+	}
+	// Never fall back to the server's credentials for a session that brought its own
+	if h.tenants == nil {
+		return nil, mcperrors.NewMCPError(mcperrors.ErrCodeInvalidRequest, "The session's credentials are no longer accepted by this server; start a new session", nil)
+	}
+	registry, err := h.tenants.Registry(*credentials)
+	if err != nil {
+		return nil, mcperrors.NewMCPError(mcperrors.ErrCodeInvalidRequest, "The session's credentials are no longer valid; call set_credentials or start a new session", mcperrors.Redact(err.Error()))
+	}
+	return registry, nil
+}
+
+// handleToolsList lists the tools of the session's registry that its role
+// may call
+func (h *MessageHandler) handleToolsList(ctx context.Context, sessionID string) (*ToolsListResult, error) {
+	registry, err := h.registryFor(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	role := h.role(ctx, sessionID)
+	toolsList := registry.List()
 	mcpTools := make([]Tool, 0, len(toolsList))
 	for _, t := range toolsList {
+		if handler, err := registry.Get(t.Name); err == nil && !rbac.Allows(role, handler) {
+			continue
+		}
 		mcpTools = append(mcpTools, Tool{
 			Name:        t.Name,
 			Description: t.Description,
@@ -107,16 +235,117 @@ func (h *MessageHandler) handleToolsList() *ToolsListResult {
 	}
 	return &ToolsListResult{
 		Tools: mcpTools,
+	}, nil
+}
+
+// role returns the role a session's calls are checked against: the lower of
+// the role it was initialized with and the role of the request's token, so
+// neither a stronger token nor another client's session ID raises it
+func (h *MessageHandler) role(ctx context.Context, sessionID string) string {
+	role := tools.RoleFromContext(ctx)
+	if session, ok := h.sessionManager.Get(sessionID); ok {
+		role = rbac.Lower(session.Role(), role)
 	}
+	return role
 }
 
-func (h *MessageHandler) handleToolsCall(ctx context.Context, params json.RawMessage) (*ToolsCallResult, error) {
+// toolContext attaches the session ID, session state, role, elicitation and
+// project scope for tool execution
+func (h *MessageHandler) toolContext(ctx context.Context, sessionID string) context.Context {
+	ctx = tools.WithSessionID(ctx, sessionID)
+	session, exists := h.sessionManager.Get(sessionID)
+	if sessionID == "" || !exists {
+		if h.requireRoots {
+			ctx = tools.WithProjectScope(ctx, []string{})
+		}
+		return ctx
+	}
+
+	ctx = tools.WithSessionState(ctx, session)
+	ctx = tools.WithRole(ctx, h.role(ctx, sessionID))
+	if session.SupportsElicitation() && reachable(ctx, session) {
+		ctx = tools.WithElicitation(ctx, h.elicitor(sessionID))
+	}
+	if projects, ok := h.projectScope(ctx, session); ok {
+		ctx = tools.WithProjectScope(ctx, projects)
+	}
+	return ctx
+}
+
+// elicitationTimeout bounds how long a tool call waits for the user to answer
+const elicitationTimeout = 2 * time.Minute
+
+// elicitor sends elicitation/create requests on the session's stream
+func (h *MessageHandler) elicitor(sessionID string) tools.ElicitFunc {
+	return func(ctx context.Context, message string, schema map[string]interface{}) (string, map[string]interface{}, error) {
+		ctx, cancel := context.WithTimeout(ctx, elicitationTimeout)
+		defer cancel()
+
+		raw, err := h.sessionManager.Request(ctx, sessionID, "elicitation/create", ElicitRequestParams{
+			Message:         message,
+			RequestedSchema: schema,
+		})
+		if err != nil {
+			return "", nil, err
+		}
+		var result ElicitResult
+		if err := json.Unmarshal(raw, &result); err != nil {
+			return "", nil, fmt.Errorf("invalid elicitation result: %v", err)
+		}
+		return result.Action, result.Content, nil
+	}
+}
+
+// progressNotifier sends notifications/progress for token (see notify)
+func (h *MessageHandler) progressNotifier(ctx context.Context, sessionID string, token interface{}) tools.ProgressFunc {
+	return func(progress, total float64, message string) {
+		params, err := json.Marshal(ProgressParams{
+			ProgressToken: token,
+			Progress:      progress,
+			Total:         total,
+			Message:       message,
+		})
+		if err != nil {
+			return
+		}
+		h.notify(ctx, sessionID, &JSONRPCNotification{
+			JSONRPC: "2.0",
+			Method:  "notifications/progress",
+			Params:  params,
+		})
+	}
+}
+
+// notify sends a notification about the request being handled: on the
+// request's SSE response when it has one, otherwise on the session's stream
+func (h *MessageHandler) notify(ctx context.Context, sessionID string, notification *JSONRPCNotification) {
+	stream := requestStream(ctx)
+	if stream == nil {
+		h.sessionManager.Notify(sessionID, notification)
+		return
+	}
+	select {
+	case stream <- notification:
+	default:
+		// Drop the notification rather than block the tool call
+	}
+}
+
+func (h *MessageHandler) handleToolsCall(ctx context.Context, params json.RawMessage, sessionID string) (*ToolsCallResult, error) {
 	var callReq ToolsCallRequest
 	if err := json.Unmarshal(params, &callReq); err != nil {
 		return nil, mcperrors.NewMCPError(mcperrors.ErrCodeInvalidParams, "Invalid tools/call params", err.Error())
 	}
 
-	result, err := h.toolRegistry.Execute(ctx, callReq.Name, callReq.Arguments)
+	if callReq.Meta != nil && callReq.Meta.ProgressToken != nil {
+		ctx = tools.WithProgress(ctx, h.progressNotifier(ctx, tools.SessionIDFromContext(ctx), callReq.Meta.ProgressToken))
+	}
+
+	registry, err := h.registryFor(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	result, err := registry.Execute(ctx, callReq.Name, callReq.Arguments)
 	if err != nil {
 		return nil, err
 	}
@@ -124,10 +353,27 @@ func (h *MessageHandler) handleToolsCall(ctx context.Context, params json.RawMes
 	// Convert tools.ToolCallResult to mcp.ToolsCallResult
 	mcpContent := make([]ContentBlock, 0, len(result.Content))
 	for _, c := range result.Content {
-		mcpContent = append(mcpContent, ContentBlock{
-			Type: c.Type,
-			Text: c.Text,
-		})
+		block := ContentBlock{
+			Type:     c.Type,
+			Text:     c.Text,
+			Data:     c.Data,
+			MimeType: c.MimeType,
+		}
+		if c.Resource != nil {
+			block.Resource = &ResourceContents{
+				URI:      c.Resource.URI,
+				MimeType: c.Resource.MimeType,
+				Text:     c.Resource.Text,
+				Blob:     c.Resource.Blob,
+			}
+		}
+		if c.Annotations != nil {
+			block.Annotations = &Annotations{
+				Audience: c.Annotations.Audience,
+				Priority: c.Annotations.Priority,
+			}
+		}
+		mcpContent = append(mcpContent, block)
 	}
 
 	return &ToolsCallResult{
@@ -136,6 +382,45 @@ func (h *MessageHandler) handleToolsCall(ctx context.Context, params json.RawMes
 	}, nil
 }
 
+func (h *MessageHandler) handleResourcesSubscribe(params json.RawMessage, sessionID string) (interface{}, error) {
+	if h.changeWatcher == nil {
+		return nil, mcperrors.NewMCPError(mcperrors.ErrCodeMethodNotFound, "Resource subscriptions are not enabled", nil)
+	}
+	if sessionID == "" {
+		return nil, mcperrors.NewMCPError(mcperrors.ErrCodeInvalidRequest, "Session ID required for subscriptions", nil)
+	}
+
+	// The watcher follows the server's project with the server's credentials
+	if session, ok := h.sessionManager.Get(sessionID); ok && session.Credentials() != nil {
+		return nil, mcperrors.NewMCPError(mcperrors.ErrCodeInvalidRequest, "Resource subscriptions are not available with client-supplied credentials", nil)
+	}
+
+	var subReq ResourcesSubscribeRequest
+	if err := json.Unmarshal(params, &subReq); err != nil || subReq.URI == "" {
+		return nil, mcperrors.NewMCPError(mcperrors.ErrCodeInvalidParams, "Invalid resources/subscribe params", nil)
+	}
+
+	if err := h.changeWatcher.Subscribe(sessionID, subReq.URI); err != nil {
+		return nil, mcperrors.NewMCPError(mcperrors.ErrCodeInvalidParams, "Failed to subscribe", err.Error())
+	}
+
+	return struct{}{}, nil
+}
+
+func (h *MessageHandler) handleResourcesUnsubscribe(params json.RawMessage, sessionID string) (interface{}, error) {
+	if h.changeWatcher == nil {
+		return nil, mcperrors.NewMCPError(mcperrors.ErrCodeMethodNotFound, "Resource subscriptions are not enabled", nil)
+	}
+
+	var unsubReq ResourcesUnsubscribeRequest
+	if err := json.Unmarshal(params, &unsubReq); err != nil || unsubReq.URI == "" {
+		return nil, mcperrors.NewMCPError(mcperrors.ErrCodeInvalidParams, "Invalid resources/unsubscribe params", nil)
+	}
+
+	h.changeWatcher.Unsubscribe(sessionID, unsubReq.URI)
+	return struct{}{}, nil
+}
+
 func (h *MessageHandler) toRPCError(err error) *RPCError {
 	if mcpErr, ok := err.(*mcperrors.MCPError); ok {
 		return &RPCError{
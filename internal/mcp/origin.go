@@ -0,0 +1,121 @@
+package mcp
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// OriginPolicy controls how the Origin header of incoming requests is validated
+type OriginPolicy string
+
+const (
+	// OriginPolicyDisabled accepts every origin
+	OriginPolicyDisabled OriginPolicy = "disabled"
+	// OriginPolicyAllowlist accepts requests without an Origin header and origins in
+	// the allowlist; an empty allowlist accepts every origin
+	OriginPolicyAllowlist OriginPolicy = "allowlist"
+	// OriginPolicyStrict accepts same-origin requests and origins in the allowlist only
+	OriginPolicyStrict OriginPolicy = "strict"
+)
+
+// ParseOriginPolicy validates an origin policy name
+func ParseOriginPolicy(s string) (OriginPolicy, error) {
+	switch policy := OriginPolicy(strings.ToLower(s)); policy {
+	case OriginPolicyDisabled, OriginPolicyAllowlist, OriginPolicyStrict:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown origin policy: %s (expected disabled, allowlist or strict)", s)
+	}
+}
+
+// TrustedProxies is a set of proxy addresses whose X-Forwarded-* headers are honored
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses IP addresses and CIDR ranges
+func ParseTrustedProxies(entries []string) (TrustedProxies, error) {
+	proxies, err := parseIPNets(entries, "trusted proxy")
+	return TrustedProxies(proxies), err
+}
+
+// parseIPNets parses IP addresses (as single-address ranges) and CIDR ranges;
+// what names the setting in errors
+func parseIPNets(entries []string, what string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", what, entry, err)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// Contains reports whether the request came directly from a trusted proxy
+func (tp TrustedProxies) Contains(r *http.Request) bool {
+	return len(tp) > 0 && containsIP(tp, remoteIP(r))
+}
+
+// remoteIP returns the address of the peer that sent r, or nil
+func remoteIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// externalOrigin returns the scheme://host the client used to reach the server.
+// X-Forwarded-Proto and X-Forwarded-Host are only honored from trusted proxies.
+func (tp TrustedProxies) externalOrigin(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	host := r.Host
+
+	if tp.Contains(r) {
+		if proto := firstHeaderValue(r.Header.Get("X-Forwarded-Proto")); proto != "" {
+			scheme = proto
+		}
+		if fwdHost := firstHeaderValue(r.Header.Get("X-Forwarded-Host")); fwdHost != "" {
+			host = fwdHost
+		}
+	}
+
+	return scheme + "://" + host
+}
+
+// firstHeaderValue returns the first entry of a comma-separated header value,
+// which is the one set by the proxy closest to the client
+func firstHeaderValue(value string) string {
+	if i := strings.Index(value, ","); i >= 0 {
+		value = value[:i]
+	}
+	return strings.TrimSpace(value)
+}
@@ -0,0 +1,95 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// rootsTimeout bounds how long a roots/list request waits for the client
+const rootsTimeout = 10 * time.Second
+
+// rootProject returns the Scrapbox project a root grants: scrapbox://project,
+// or https://scrapbox.io/project and https://cosen.se/project. Other roots,
+// such as file:// workspace folders, grant no project.
+func rootProject(uri string) (string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", false
+	}
+
+	var project string
+	switch {
+	case u.Scheme == "scrapbox":
+		project = u.Host
+	case u.Scheme == "https" && (u.Host == "scrapbox.io" || u.Host == "cosen.se"):
+		project = strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 2)[0]
+	}
+	return project, project != ""
+}
+
+// refreshRoots asks the client for its roots and records the projects they grant
+func (h *MessageHandler) refreshRoots(ctx context.Context, session *Session) error {
+	ctx, cancel := context.WithTimeout(ctx, rootsTimeout)
+	defer cancel()
+
+	raw, err := h.sessionManager.Request(ctx, session.ID, "roots/list", struct{}{})
+	if err != nil {
+		return err
+	}
+	var result ListRootsResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return fmt.Errorf("invalid roots/list result: %v", err)
+	}
+
+	projects := make([]string, 0, len(result.Roots))
+	for _, root := range result.Roots {
+		if project, ok := rootProject(root.URI); ok {
+			projects = append(projects, project)
+		}
+	}
+	session.SetRootProjects(projects)
+	log.Printf("[ROOTS] Session %s granted projects: %v", session.ID, projects)
+	return nil
+}
+
+// refreshRootsAsync refetches the roots in the background, e.g. after
+// initialization or notifications/roots/list_changed
+func (h *MessageHandler) refreshRootsAsync(sessionID string) {
+	session, exists := h.sessionManager.Get(sessionID)
+	if !exists || !session.SupportsRoots() {
+		return
+	}
+	session.InvalidateRoots()
+	go func() {
+		if err := h.refreshRoots(context.Background(), session); err != nil {
+			log.Printf("[ROOTS] Failed to list roots of session %s: %v", sessionID, err)
+		}
+	}()
+}
+
+// projectScope returns the projects tool calls of session are limited to.
+// Calls are limited when the client's roots include Scrapbox projects, or
+// always when REQUIRE_ROOTS is set (an empty scope then rejects every call).
+func (h *MessageHandler) projectScope(ctx context.Context, session *Session) ([]string, bool) {
+	if session.SupportsRoots() {
+		projects, known := session.RootProjects()
+		if !known && reachable(ctx, session) {
+			if err := h.refreshRoots(ctx, session); err != nil {
+				log.Printf("[ROOTS] Failed to list roots of session %s: %v", session.ID, err)
+			}
+			projects, _ = session.RootProjects()
+		}
+		if len(projects) > 0 {
+			return projects, true
+		}
+	}
+	if h.requireRoots {
+		return []string{}, true
+	}
+	return nil, false
+}
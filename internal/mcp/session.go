@@ -1,10 +1,17 @@
 package mcp
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	"github.com/hiroki/scrapbox_mcp/internal/secrets"
 )
 
 type Session struct {
@@ -12,17 +19,138 @@ type Session struct {
 	CreatedAt        time.Time
 	LastAccessAt     time.Time
 	InitializeResult *InitializeResult
-	mu               sync.RWMutex
+	// Outgoing holds notifications and server-initiated requests waiting for
+	// delivery on the SSE stream
+	Outgoing       chan interface{}
+	defaultProject string
+	credentials    *scrapbox.Credentials
+	role           string
+	clientCaps     ClientCapabilities
+	rootProjects   []string
+	rootsKnown     bool
+	streams        int32
+	dropped        int64
+	pending        map[string]chan *JSONRPCClientResponse
+	mu             sync.RWMutex
 }
 
+// DefaultProject returns the project set with set_default_project, if any
+func (s *Session) DefaultProject() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.defaultProject
+}
+
+// SetDefaultProject sets the project used by tool calls that omit "project"
+func (s *Session) SetDefaultProject(project string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.defaultProject = project
+}
+
+// Credentials returns the Scrapbox credentials the client supplied for the
+// session, or nil when it uses the server's
+func (s *Session) Credentials() *scrapbox.Credentials {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.credentials
+}
+
+// SetCredentials sets the credentials tool calls of the session use; nil
+// returns to the server's credentials
+func (s *Session) SetCredentials(credentials *scrapbox.Credentials) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.credentials = credentials
+}
+
+// Role returns the role the session was initialized with (see internal/rbac),
+// or "" when roles are not in use
+func (s *Session) Role() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.role
+}
+
+// SetRole sets the role the session's tool calls are checked against
+func (s *Session) SetRole(role string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.role = role
+}
+
+// SetClientCapabilities records the capabilities the client sent in initialize
+func (s *Session) SetClientCapabilities(caps ClientCapabilities) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clientCaps = caps
+}
+
+// SupportsElicitation reports whether the client declared the elicitation capability
+func (s *Session) SupportsElicitation() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.clientCaps.Elicitation != nil
+}
+
+// SupportsRoots reports whether the client declared the roots capability
+func (s *Session) SupportsRoots() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.clientCaps.Roots != nil
+}
+
+// HasStream reports whether an SSE stream is open for the session
+func (s *Session) HasStream() bool {
+	return atomic.LoadInt32(&s.streams) > 0
+}
+
+// SetRootProjects records the Scrapbox projects granted by the client's roots
+func (s *Session) SetRootProjects(projects []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rootProjects = projects
+	s.rootsKnown = true
+}
+
+// InvalidateRoots forgets the client's roots after notifications/roots/list_changed
+func (s *Session) InvalidateRoots() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rootsKnown = false
+}
+
+// RootProjects returns the projects granted by the client's roots and whether
+// the roots have been fetched
+func (s *Session) RootProjects() ([]string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rootProjects, s.rootsKnown
+}
+
+// AttachStream marks an SSE stream as open until the returned function is called
+func (s *Session) AttachStream() func() {
+	atomic.AddInt32(&s.streams, 1)
+	return func() { atomic.AddInt32(&s.streams, -1) }
+}
+
+// notificationBufferSize is the number of notifications queued per session
+// before new ones are dropped
+const notificationBufferSize = 64
+
 type SessionManager struct {
-	sessions sync.Map
-	ttl      time.Duration
+	sessions    sync.Map
+	ttl         time.Duration
+	closing     chan struct{}
+	closeOnce   sync.Once
+	deadStreams int64
+	keyring     *secrets.Keyring
 }
 
 func NewSessionManager(ttl time.Duration) *SessionManager {
 	sm := &SessionManager{
-		ttl: ttl,
+		ttl:     ttl,
+		closing: make(chan struct{}),
 	}
 
 	// Start cleanup goroutine
@@ -37,6 +165,8 @@ func (sm *SessionManager) Create(initResult *InitializeResult) *Session {
 		CreatedAt:        time.Now(),
 		LastAccessAt:     time.Now(),
 		InitializeResult: initResult,
+		Outgoing:         make(chan interface{}, notificationBufferSize),
+		pending:          make(map[string]chan *JSONRPCClientResponse),
 	}
 
 	sm.sessions.Store(session.ID, session)
@@ -59,10 +189,171 @@ func (sm *SessionManager) Get(sessionID string) (*Session, bool) {
 	return session, true
 }
 
+// Notify queues a server-initiated notification for delivery on the session's SSE stream.
+// It returns false if the session does not exist.
+func (sm *SessionManager) Notify(sessionID string, notification *JSONRPCNotification) bool {
+	value, ok := sm.sessions.Load(sessionID)
+	if !ok {
+		return false
+	}
+
+	session := value.(*Session)
+	select {
+	case session.Outgoing <- notification:
+	default:
+		// Drop the notification rather than block the caller when no stream is reading
+		atomic.AddInt64(&session.dropped, 1)
+	}
+	return true
+}
+
+// Request sends a server-initiated request on the request's or the session's
+// SSE stream and waits for the client's response or for ctx to end
+func (sm *SessionManager) Request(ctx context.Context, sessionID, method string, params interface{}) (json.RawMessage, error) {
+	value, ok := sm.sessions.Load(sessionID)
+	if !ok {
+		return nil, errors.New("session not found")
+	}
+	session := value.(*Session)
+
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s params: %v", method, err)
+	}
+
+	id := uuid.New().String()
+	responses := make(chan *JSONRPCClientResponse, 1)
+	session.mu.Lock()
+	session.pending[id] = responses
+	session.mu.Unlock()
+	defer func() {
+		session.mu.Lock()
+		delete(session.pending, id)
+		session.mu.Unlock()
+	}()
+
+	// Requests made while handling a POST with an SSE response go on that response
+	var out chan<- interface{} = session.Outgoing
+	if stream := requestStream(ctx); stream != nil {
+		out = stream
+	}
+	select {
+	case out <- &JSONRPCRequest{JSONRPC: "2.0", ID: id, Method: method, Params: encoded}:
+	default:
+		atomic.AddInt64(&session.dropped, 1)
+		return nil, fmt.Errorf("cannot send %s: the session's stream is full", method)
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case response := <-responses:
+		if response.Error != nil {
+			return nil, fmt.Errorf("client returned an error for %s: %s", method, response.Error.Message)
+		}
+		return response.Result, nil
+	}
+}
+
+// Respond delivers a client's response to the pending request with the same ID.
+// It returns false if no such request is waiting.
+func (sm *SessionManager) Respond(sessionID string, response *JSONRPCClientResponse) bool {
+	value, ok := sm.sessions.Load(sessionID)
+	if !ok {
+		return false
+	}
+
+	session := value.(*Session)
+	session.mu.Lock()
+	responses, ok := session.pending[fmt.Sprint(response.ID)]
+	session.mu.Unlock()
+	if !ok {
+		return false
+	}
+	select {
+	case responses <- response:
+	default:
+		// A response for this ID was already delivered
+	}
+	return true
+}
+
+// Count returns the number of active sessions
+func (sm *SessionManager) Count() int {
+	count := 0
+	sm.sessions.Range(func(key, value interface{}) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+// StreamStats reports the state of the sessions' outgoing message queues
+type StreamStats struct {
+	Sessions    int `json:"sessions"`
+	OpenStreams int `json:"openStreams"`
+	// QueuedMessages is the number of messages waiting for a stream, and
+	// MaxQueueDepth the longest queue of one session (capacity QueueCapacity)
+	QueuedMessages int `json:"queuedMessages"`
+	MaxQueueDepth  int `json:"maxQueueDepth"`
+	QueueCapacity  int `json:"queueCapacity"`
+	// DroppedMessages were discarded because a session's queue was full
+	DroppedMessages int64 `json:"droppedMessages"`
+	// DeadStreams were closed because a write or heartbeat failed
+	DeadStreams int64 `json:"deadStreams"`
+}
+
+// StreamStats returns a snapshot of the outgoing queue metrics
+func (sm *SessionManager) StreamStats() StreamStats {
+	stats := StreamStats{
+		QueueCapacity: notificationBufferSize,
+		DeadStreams:   atomic.LoadInt64(&sm.deadStreams),
+	}
+	sm.sessions.Range(func(key, value interface{}) bool {
+		session := value.(*Session)
+		stats.Sessions++
+		if session.HasStream() {
+			stats.OpenStreams++
+		}
+		depth := len(session.Outgoing)
+		stats.QueuedMessages += depth
+		if depth > stats.MaxQueueDepth {
+			stats.MaxQueueDepth = depth
+		}
+		stats.DroppedMessages += atomic.LoadInt64(&session.dropped)
+		return true
+	})
+	return stats
+}
+
+// recordDeadStream counts a stream closed because its client stopped responding
+func (sm *SessionManager) recordDeadStream() {
+	atomic.AddInt64(&sm.deadStreams, 1)
+}
+
+// Broadcast queues a notification for every active session
+func (sm *SessionManager) Broadcast(notification *JSONRPCNotification) {
+	sm.sessions.Range(func(key, value interface{}) bool {
+		sm.Notify(key.(string), notification)
+		return true
+	})
+}
+
 func (sm *SessionManager) Delete(sessionID string) {
 	sm.sessions.Delete(sessionID)
 }
 
+// CloseStreams ends every open stream so clients reconnect, e.g. to the next
+// process during a restart. Sessions themselves are kept.
+func (sm *SessionManager) CloseStreams() {
+	sm.closeOnce.Do(func() { close(sm.closing) })
+}
+
+// Closing is closed when streams should end (see CloseStreams)
+func (sm *SessionManager) Closing() <-chan struct{} {
+	return sm.closing
+}
+
 func (sm *SessionManager) cleanupExpiredSessions() {
 	ticker := time.NewTicker(1 * time.Minute)
 	defer ticker.Stop()
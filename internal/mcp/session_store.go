@@ -0,0 +1,211 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	"github.com/hiroki/scrapbox_mcp/internal/secrets"
+)
+
+// persistedSession is the saved form of a session. Streams and pending
+// server requests are not saved; clients reconnect and retry them.
+type persistedSession struct {
+	ID                 string                `json:"id,omitempty"`
+	CreatedAt          time.Time             `json:"createdAt"`
+	LastAccessAt       time.Time             `json:"lastAccessAt"`
+	InitializeResult   *InitializeResult     `json:"initializeResult"`
+	ClientCapabilities ClientCapabilities    `json:"clientCapabilities"`
+	DefaultProject     string                `json:"defaultProject,omitempty"`
+	Credentials        *scrapbox.Credentials `json:"credentials,omitempty"`
+	Role               string                `json:"role,omitempty"`
+	RootProjects       []string              `json:"rootProjects,omitempty"`
+	RootsKnown         bool                  `json:"rootsKnown,omitempty"`
+	// Sealed holds the encrypted sealedFields instead of the fields they
+	// replace when a keyring is set
+	Sealed string `json:"sealed,omitempty"`
+}
+
+// sealedFields are the session values that grant or scope access: the
+// session ID, the client's Scrapbox cookie, its role, the projects it may
+// use and the times its TTL is counted from. Sealing them all keeps anyone
+// able to edit the file from changing what a restored session may do or how
+// long it lives.
+type sealedFields struct {
+	ID             string                `json:"id"`
+	CreatedAt      time.Time             `json:"createdAt"`
+	LastAccessAt   time.Time             `json:"lastAccessAt"`
+	Credentials    *scrapbox.Credentials `json:"credentials,omitempty"`
+	DefaultProject string                `json:"defaultProject,omitempty"`
+	Role           string                `json:"role,omitempty"`
+	RootProjects   []string              `json:"rootProjects,omitempty"`
+	RootsKnown     bool                  `json:"rootsKnown,omitempty"`
+}
+
+// SetKeyring encrypts the session IDs, credentials, roles, projects and
+// access times Save writes with the keyring's newest key. Load opens them
+// with any of its keys, so restarting with a new key first rotates the file,
+// and skips sessions saved unencrypted.
+func (sm *SessionManager) SetKeyring(keyring *secrets.Keyring) {
+	sm.keyring = keyring
+}
+
+// seal moves the sealedFields of p into p.Sealed
+func (sm *SessionManager) seal(p *persistedSession) error {
+	data, err := json.Marshal(sealedFields{
+		ID:             p.ID,
+		CreatedAt:      p.CreatedAt,
+		LastAccessAt:   p.LastAccessAt,
+		Credentials:    p.Credentials,
+		DefaultProject: p.DefaultProject,
+		Role:           p.Role,
+		RootProjects:   p.RootProjects,
+		RootsKnown:     p.RootsKnown,
+	})
+	if err != nil {
+		return err
+	}
+	sealed, err := sm.keyring.Seal(data)
+	if err != nil {
+		return err
+	}
+	p.ID, p.Credentials, p.Sealed = "", nil, sealed
+	p.DefaultProject, p.Role, p.RootProjects, p.RootsKnown = "", "", nil, false
+	p.CreatedAt, p.LastAccessAt = time.Time{}, time.Time{}
+	return nil
+}
+
+// unseal restores the sealedFields of a sealed p. Values stored outside the
+// seal are ignored; sessions sealed before the access times were have none
+// and count as expired.
+func (sm *SessionManager) unseal(p *persistedSession) error {
+	if sm.keyring == nil {
+		return fmt.Errorf("no SESSION_ENCRYPTION_KEYS to decrypt it")
+	}
+	data, err := sm.keyring.Open(p.Sealed)
+	if err != nil {
+		return err
+	}
+	var fields sealedFields
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	p.ID, p.Credentials = fields.ID, fields.Credentials
+	p.CreatedAt, p.LastAccessAt = fields.CreatedAt, fields.LastAccessAt
+	p.DefaultProject, p.Role = fields.DefaultProject, fields.Role
+	p.RootProjects, p.RootsKnown = fields.RootProjects, fields.RootsKnown
+	return nil
+}
+
+// Save writes the active sessions to path so the next process can restore
+// them with Load. The file holds session IDs and client-supplied session
+// cookies, so it is only readable by the owner, and they are encrypted when
+// a keyring is set.
+func (sm *SessionManager) Save(path string) error {
+	var saved []persistedSession
+	var sealErr error
+	sm.sessions.Range(func(key, value interface{}) bool {
+		session := value.(*Session)
+		session.mu.RLock()
+		saved = append(saved, persistedSession{
+			ID:                 session.ID,
+			CreatedAt:          session.CreatedAt,
+			LastAccessAt:       session.LastAccessAt,
+			InitializeResult:   session.InitializeResult,
+			ClientCapabilities: session.clientCaps,
+			DefaultProject:     session.defaultProject,
+			Credentials:        session.credentials,
+			Role:               session.role,
+			RootProjects:       session.rootProjects,
+			RootsKnown:         session.rootsKnown,
+		})
+		session.mu.RUnlock()
+		if sm.keyring != nil {
+			if err := sm.seal(&saved[len(saved)-1]); err != nil {
+				sealErr = err
+				return false
+			}
+		}
+		return true
+	})
+	if sealErr != nil {
+		return fmt.Errorf("failed to encrypt sessions: %v", sealErr)
+	}
+
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return fmt.Errorf("failed to encode sessions: %v", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write sessions: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write sessions: %v", err)
+	}
+	return nil
+}
+
+// Load restores sessions saved with Save, skipping expired ones. With a
+// keyring set, sessions that are not sealed are skipped too: anyone able to
+// edit the file could otherwise plant one. A missing file restores nothing.
+// It returns the number of restored sessions.
+func (sm *SessionManager) Load(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read sessions: %v", err)
+	}
+
+	var saved []persistedSession
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return 0, fmt.Errorf("failed to decode sessions: %v", err)
+	}
+
+	restored, unreadable, unsealed := 0, 0, 0
+	var unsealErr error
+	for _, p := range saved {
+		switch {
+		case p.Sealed != "":
+			if err := sm.unseal(&p); err != nil {
+				unsealErr = err
+				unreadable++
+				continue
+			}
+		case sm.keyring != nil:
+			unsealed++
+			continue
+		}
+		if time.Since(p.LastAccessAt) > sm.ttl {
+			continue
+		}
+		sm.sessions.Store(p.ID, &Session{
+			ID:               p.ID,
+			CreatedAt:        p.CreatedAt,
+			LastAccessAt:     p.LastAccessAt,
+			InitializeResult: p.InitializeResult,
+			Outgoing:         make(chan interface{}, notificationBufferSize),
+			defaultProject:   p.DefaultProject,
+			credentials:      p.Credentials,
+			role:             p.Role,
+			clientCaps:       p.ClientCapabilities,
+			rootProjects:     p.RootProjects,
+			rootsKnown:       p.RootsKnown,
+			pending:          make(map[string]chan *JSONRPCClientResponse),
+		})
+		restored++
+	}
+	if unreadable > 0 {
+		log.Printf("Skipped %d saved sessions that could not be decrypted (%v); their clients must initialize again", unreadable, unsealErr)
+	}
+	if unsealed > 0 {
+		log.Printf("Skipped %d saved sessions that are not encrypted although SESSION_ENCRYPTION_KEYS is set; their clients must initialize again", unsealed)
+	}
+	return restored, nil
+}
@@ -0,0 +1,99 @@
+package mcp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/secrets"
+)
+
+func testKeyring(t *testing.T) *secrets.Keyring {
+	t.Helper()
+	keyring, err := secrets.ParseKeys([]string{base64.StdEncoding.EncodeToString(make([]byte, 32))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keyring
+}
+
+func TestLoadRejectsUnsealedSessionsWithKeyring(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	planted := []persistedSession{{
+		ID:           "planted-session",
+		CreatedAt:    time.Now(),
+		LastAccessAt: time.Now(),
+		Role:         "admin",
+	}}
+	data, err := json.Marshal(planted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	sm := NewSessionManager(time.Hour)
+	sm.SetKeyring(testKeyring(t))
+	restored, err := sm.Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if restored != 0 {
+		t.Errorf("restored %d sessions, want 0", restored)
+	}
+	if _, ok := sm.Get("planted-session"); ok {
+		t.Error("unsealed session was restored")
+	}
+}
+
+func TestSaveSealsSessionFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	keyring := testKeyring(t)
+
+	sm := NewSessionManager(time.Hour)
+	sm.SetKeyring(keyring)
+	session := sm.Create(&InitializeResult{})
+	session.SetRole("viewer")
+	if err := sm.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved []persistedSession
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 1 {
+		t.Fatalf("saved %d sessions, want 1", len(saved))
+	}
+	if p := saved[0]; p.Sealed == "" || p.ID != "" || p.Role != "" || !p.LastAccessAt.IsZero() || !p.CreatedAt.IsZero() {
+		t.Errorf("fields left outside the seal: %+v", p)
+	}
+	if strings.Contains(string(data), session.ID) {
+		t.Error("session ID saved in plain text")
+	}
+
+	restoredMgr := NewSessionManager(time.Hour)
+	restoredMgr.SetKeyring(keyring)
+	if restored, err := restoredMgr.Load(path); err != nil || restored != 1 {
+		t.Fatalf("Load = %d, %v; want 1 session", restored, err)
+	}
+	restoredSession, ok := restoredMgr.Get(session.ID)
+	if !ok {
+		t.Fatal("sealed session was not restored")
+	}
+	if restoredSession.Role() != "viewer" {
+		t.Errorf("role = %q, want viewer", restoredSession.Role())
+	}
+	if !restoredSession.CreatedAt.Equal(session.CreatedAt) {
+		t.Errorf("CreatedAt = %v, want %v", restoredSession.CreatedAt, session.CreatedAt)
+	}
+}
@@ -0,0 +1,125 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type requestStreamKey struct{}
+
+// withRequestStream returns a context whose notifications and server requests
+// go to stream, the SSE response of the POST being handled
+func withRequestStream(ctx context.Context, stream chan<- interface{}) context.Context {
+	return context.WithValue(ctx, requestStreamKey{}, stream)
+}
+
+// requestStream returns the SSE response stream of the request, or nil
+func requestStream(ctx context.Context) chan<- interface{} {
+	stream, _ := ctx.Value(requestStreamKey{}).(chan<- interface{})
+	return stream
+}
+
+// reachable reports whether server requests made while handling ctx can
+// reach the client: on the request's own stream or the session's GET stream
+func reachable(ctx context.Context, session *Session) bool {
+	return requestStream(ctx) != nil || session.HasStream()
+}
+
+// acceptsEventStream reports whether the client accepts an SSE response to a POST
+func acceptsEventStream(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaType := range strings.Split(accept, ",") {
+			if strings.TrimSpace(strings.SplitN(mediaType, ";", 2)[0]) == "text/event-stream" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// streamWriteTimeout bounds each write to a stream, so a client that stops
+// reading is detected instead of blocking the stream forever
+const streamWriteTimeout = 10 * time.Second
+
+// writeEvent writes message as one SSE message event
+func writeEvent(rc *http.ResponseController, w http.ResponseWriter, message interface{}) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("Failed to encode message: %v", err)
+		return nil
+	}
+	return writeStream(rc, w, []byte(fmt.Sprintf("event: message\ndata: %s\n\n", data)))
+}
+
+// writeStream writes and flushes raw SSE data with a fresh write deadline
+func writeStream(rc *http.ResponseController, w http.ResponseWriter, data []byte) error {
+	// Not every ResponseWriter supports deadlines; the write still goes ahead
+	_ = rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	return rc.Flush()
+}
+
+// streamResponse runs process with a request stream and answers the POST as
+// SSE: interim notifications and server requests as they happen, then the
+// final response. A nil final response (notifications only) ends the stream
+// without a message.
+func (t *Transport) streamResponse(w http.ResponseWriter, r *http.Request, process func(ctx context.Context) interface{}) {
+	if _, ok := w.(http.Flusher); !ok {
+		t.sendJSONResponse(w, process(r.Context()))
+		return
+	}
+	rc := http.NewResponseController(w)
+
+	events := make(chan interface{}, notificationBufferSize)
+	done := make(chan interface{}, 1)
+	go func() {
+		done <- process(withRequestStream(r.Context(), events))
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	rc.Flush()
+
+	for {
+		select {
+		case message := <-events:
+			writeEvent(rc, w, message)
+		case response := <-done:
+			// Deliver everything sent before the response, then the response
+			for {
+				select {
+				case message := <-events:
+					writeEvent(rc, w, message)
+					continue
+				default:
+				}
+				break
+			}
+			if !isNilResponse(response) {
+				writeEvent(rc, w, response)
+			}
+			return
+		}
+	}
+}
+
+// isNilResponse reports whether a processed POST produced nothing to send
+func isNilResponse(response interface{}) bool {
+	switch r := response.(type) {
+	case nil:
+		return true
+	case *JSONRPCResponse:
+		return r == nil
+	case []*JSONRPCResponse:
+		return len(r) == 0
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+package mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	"github.com/hiroki/scrapbox_mcp/internal/tools"
+)
+
+// TenantFactory builds the tool registry serving a set of client-supplied
+// credentials, after checking that they are valid. The returned function
+// closes the registry's Scrapbox connections.
+type TenantFactory func(credentials scrapbox.Credentials) (*tools.Registry, func(), error)
+
+// tenantDrainTimeout bounds how long an idle tenant's in-flight calls are
+// waited for before its connections are closed
+const tenantDrainTimeout = 30 * time.Second
+
+// Tenants keeps one tool registry per set of client-supplied credentials.
+// Sessions with the same credentials share a registry; registries unused for
+// the idle timeout are closed and rebuilt on the next call.
+type Tenants struct {
+	factory  TenantFactory
+	idle     time.Duration
+	mu       sync.Mutex
+	entries  map[string]*tenant
+	building map[string]*tenantBuild
+}
+
+type tenant struct {
+	registry *tools.Registry
+	close    func()
+	lastUsed time.Time
+}
+
+// tenantBuild is a registry being built by the factory. Calls for the same
+// credentials wait for it instead of building another.
+type tenantBuild struct {
+	done     chan struct{}
+	registry *tools.Registry
+	err      error
+}
+
+func NewTenants(factory TenantFactory, idle time.Duration) *Tenants {
+	return &Tenants{
+		factory:  factory,
+		idle:     idle,
+		entries:  make(map[string]*tenant),
+		building: make(map[string]*tenantBuild),
+	}
+}
+
+// Registry returns the registry for credentials, building it on first use.
+// The factory logs in to Scrapbox, so it runs without holding the lock: a
+// slow login only delays the calls waiting for the same credentials.
+func (t *Tenants) Registry(credentials scrapbox.Credentials) (*tools.Registry, error) {
+	key := tenantKey(credentials)
+	t.mu.Lock()
+	if entry, ok := t.entries[key]; ok {
+		entry.lastUsed = time.Now()
+		t.mu.Unlock()
+		return entry.registry, nil
+	}
+	if build, ok := t.building[key]; ok {
+		t.mu.Unlock()
+		<-build.done
+		return build.registry, build.err
+	}
+	build := &tenantBuild{done: make(chan struct{})}
+	t.building[key] = build
+	t.mu.Unlock()
+
+	registry, closeFn, err := t.factory(credentials)
+
+	t.mu.Lock()
+	delete(t.building, key)
+	if err == nil {
+		t.entries[key] = &tenant{registry: registry, close: closeFn, lastUsed: time.Now()}
+		log.Printf("[TENANT] Opened tenant for project %s (%d active)", credentials.Project, len(t.entries))
+	}
+	t.mu.Unlock()
+
+	build.registry, build.err = registry, err
+	close(build.done)
+	if err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
+
+// Check validates credentials by building (or reusing) their registry
+func (t *Tenants) Check(credentials scrapbox.Credentials) error {
+	_, err := t.Registry(credentials)
+	return err
+}
+
+// Run closes idle tenants until ctx ends
+func (t *Tenants) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.closeIdle(t.idle)
+		}
+	}
+}
+
+// Close closes every tenant, waiting for their in-flight calls
+func (t *Tenants) Close() {
+	t.closeIdle(0)
+}
+
+// closeIdle closes the tenants unused for longer than idle, after their
+// in-flight calls finish
+func (t *Tenants) closeIdle(idle time.Duration) {
+	now := time.Now()
+	t.mu.Lock()
+	var closing []*tenant
+	for key, entry := range t.entries {
+		if now.Sub(entry.lastUsed) >= idle {
+			closing = append(closing, entry)
+			delete(t.entries, key)
+		}
+	}
+	t.mu.Unlock()
+
+	for _, entry := range closing {
+		ctx, cancel := context.WithTimeout(context.Background(), tenantDrainTimeout)
+		if err := entry.registry.Wait(ctx); err != nil {
+			log.Printf("[TENANT] Closing tenant with calls still running: %v", err)
+		}
+		cancel()
+		entry.close()
+	}
+	if len(closing) > 0 {
+		log.Printf("[TENANT] Closed %d idle tenants", len(closing))
+	}
+}
+
+// tenantKey identifies credentials without keeping the cookie as a map key
+func tenantKey(credentials scrapbox.Credentials) string {
+	sum := sha256.Sum256([]byte(credentials.Project + "\x00" + credentials.SessionCookie))
+	return hex.EncodeToString(sum[:])
+}
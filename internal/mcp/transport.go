@@ -1,19 +1,41 @@
 package mcp
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/rbac"
+	"github.com/hiroki/scrapbox_mcp/internal/tools"
+	mcperrors "github.com/hiroki/scrapbox_mcp/pkg/errors"
 )
 
 type Transport struct {
 	handler        *MessageHandler
 	sessionManager *SessionManager
 	allowedOrigins []string
+	originsMu      sync.RWMutex
 	enableCORS     bool
+	maxBodyBytes   int64
+	originPolicy   OriginPolicy
+	trustedProxies TrustedProxies
+	heartbeat      time.Duration
+	recorder       MessageRecorder
+	roles          *rbac.Policy
+}
+
+// MessageRecorder receives every dispatched request and its response (nil
+// for notifications), for debug captures
+type MessageRecorder interface {
+	RecordMessage(sessionID string, request, response interface{})
 }
 
 func NewTransport(handler *MessageHandler, sessionMgr *SessionManager, allowedOrigins []string, enableCORS bool) *Transport {
@@ -22,9 +44,60 @@ func NewTransport(handler *MessageHandler, sessionMgr *SessionManager, allowedOr
 		sessionManager: sessionMgr,
 		allowedOrigins: allowedOrigins,
 		enableCORS:     enableCORS,
+		originPolicy:   OriginPolicyAllowlist,
 	}
 }
 
+// SetOriginPolicy sets how Origin headers are validated and which proxies'
+// X-Forwarded-Host/X-Forwarded-Proto headers are trusted for same-origin checks
+func (t *Transport) SetOriginPolicy(policy OriginPolicy, trustedProxies TrustedProxies) {
+	t.originPolicy = policy
+	t.trustedProxies = trustedProxies
+}
+
+// SetHeartbeat sets how often idle streams send a heartbeat (0 disables)
+func (t *Transport) SetHeartbeat(interval time.Duration) {
+	t.heartbeat = interval
+}
+
+// SetRoles assigns each request the role of its bearer token; a session's
+// calls run with the lower of that role and the one it was initialized with
+func (t *Transport) SetRoles(policy *rbac.Policy) {
+	t.roles = policy
+}
+
+// authorize attaches the role of r's bearer token to its context, or answers
+// 401 when the token is unknown or missing but required
+func (t *Transport) authorize(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	if t.roles == nil {
+		return r, true
+	}
+	role, err := t.roles.Resolve(r.Header.Get("Authorization"))
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="mcp"`)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return nil, false
+	}
+	return r.WithContext(tools.WithRole(r.Context(), role)), true
+}
+
+// SetRecorder records dispatched messages to recorder
+func (t *Transport) SetRecorder(recorder MessageRecorder) {
+	t.recorder = recorder
+}
+
+// SetMaxBodyBytes limits the size of POST request bodies (0 disables the limit)
+func (t *Transport) SetMaxBodyBytes(maxBodyBytes int64) {
+	t.maxBodyBytes = maxBodyBytes
+}
+
+// SetAllowedOrigins replaces the list of allowed origins (used on config reload)
+func (t *Transport) SetAllowedOrigins(allowedOrigins []string) {
+	t.originsMu.Lock()
+	defer t.originsMu.Unlock()
+	t.allowedOrigins = allowedOrigins
+}
+
 func (t *Transport) HandlePOST(w http.ResponseWriter, r *http.Request) {
 	// CORS handling
 	if t.enableCORS {
@@ -41,6 +114,11 @@ func (t *Transport) HandlePOST(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	r, ok := t.authorize(w, r)
+	if !ok {
+		return
+	}
+
 	// Get or create session
 	sessionID := r.Header.Get("Mcp-Session-Id")
 	if sessionID != "" {
@@ -52,13 +130,42 @@ func (t *Transport) HandlePOST(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Read request body
+	if t.maxBodyBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, t.maxBodyBytes)
+	}
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			json.NewEncoder(w).Encode(&JSONRPCResponse{
+				JSONRPC: "2.0",
+				ID:      nil,
+				Error: &RPCError{
+					Code:    mcperrors.ErrCodeInvalidRequest,
+					Message: fmt.Sprintf("Request body too large (limit: %d bytes)", maxBytesErr.Limit),
+				},
+			})
+			return
+		}
 		http.Error(w, "Failed to read request body", http.StatusBadRequest)
 		return
 	}
 	defer r.Body.Close()
 
+	// JSON-RPC batch: an array of requests
+	if trimmed := bytes.TrimLeft(body, " \t\r\n"); len(trimmed) > 0 && trimmed[0] == '[' {
+		t.handleBatch(w, r, trimmed, sessionID)
+		return
+	}
+
+	// A response to a server-initiated request, e.g. elicitation/create
+	if response, ok := parseClientResponse(body); ok {
+		t.handleClientResponse(w, response, sessionID)
+		return
+	}
+
 	// Parse JSON-RPC request
 	var req JSONRPCRequest
 	if err := json.Unmarshal(body, &req); err != nil {
@@ -73,17 +180,18 @@ func (t *Transport) HandlePOST(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Handle the request
-	response := t.handler.HandleRequest(r.Context(), &req, sessionID)
-
-	// For initialize method, create a new session
-	if req.Method == "initialize" && response != nil && response.Error == nil {
-		if initResult, ok := response.Result.(*InitializeResult); ok {
-			newSession := t.sessionManager.Create(initResult)
-			w.Header().Set("Mcp-Session-Id", newSession.ID)
-		}
+	// Tool calls may stream progress and server requests before the result
+	if sessionID != "" && req.Method == "tools/call" && req.ID != nil && acceptsEventStream(r) {
+		t.streamResponse(w, r, func(ctx context.Context) interface{} {
+			response, _ := t.dispatch(ctx, &req, sessionID)
+			return response
+		})
+		return
 	}
 
+	// Handle the request
+	response := t.handleMessage(w, r, &req, sessionID)
+
 	// Send response
 	if response != nil {
 		t.sendJSONResponse(w, response)
@@ -93,12 +201,147 @@ func (t *Transport) HandlePOST(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleBatch dispatches each request in a JSON-RPC batch and responds with
+// an array of responses. Notifications produce no entry in the array.
+func (t *Transport) handleBatch(w http.ResponseWriter, r *http.Request, body []byte, sessionID string) {
+	var messages []json.RawMessage
+	if err := json.Unmarshal(body, &messages); err != nil {
+		t.sendJSONResponse(w, &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      nil,
+			Error: &RPCError{
+				Code:    mcperrors.ErrCodeParseError,
+				Message: "Parse error",
+			},
+		})
+		return
+	}
+
+	if len(messages) == 0 {
+		t.sendJSONResponse(w, &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      nil,
+			Error: &RPCError{
+				Code:    mcperrors.ErrCodeInvalidRequest,
+				Message: "Invalid Request: empty batch",
+			},
+		})
+		return
+	}
+
+	responses := make([]*JSONRPCResponse, 0, len(messages))
+	for _, msg := range messages {
+		if response, ok := parseClientResponse(msg); ok {
+			t.sessionManager.Respond(sessionID, response)
+			continue
+		}
+
+		var req JSONRPCRequest
+		if err := json.Unmarshal(msg, &req); err != nil || req.Method == "" {
+			responses = append(responses, &JSONRPCResponse{
+				JSONRPC: "2.0",
+				ID:      nil,
+				Error: &RPCError{
+					Code:    mcperrors.ErrCodeInvalidRequest,
+					Message: "Invalid Request",
+				},
+			})
+			continue
+		}
+
+		if response := t.handleMessage(w, r, &req, sessionID); response != nil {
+			responses = append(responses, response)
+		}
+	}
+
+	if len(responses) == 0 {
+		// Batch contained only notifications
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	t.sendJSONResponse(w, responses)
+}
+
+// parseClientResponse decodes body if it is a JSON-RPC response rather than a
+// request or notification
+func parseClientResponse(body []byte) (*JSONRPCClientResponse, bool) {
+	var probe struct {
+		Method string          `json:"method"`
+		Result json.RawMessage `json:"result"`
+		Error  json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(body, &probe); err != nil || probe.Method != "" {
+		return nil, false
+	}
+	if probe.Result == nil && probe.Error == nil {
+		return nil, false
+	}
+
+	var response JSONRPCClientResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, false
+	}
+	return &response, true
+}
+
+// handleClientResponse hands a client's response to the request waiting for it
+func (t *Transport) handleClientResponse(w http.ResponseWriter, response *JSONRPCClientResponse, sessionID string) {
+	if sessionID == "" {
+		http.Error(w, "Session ID required", http.StatusBadRequest)
+		return
+	}
+	if !t.sessionManager.Respond(sessionID, response) {
+		log.Printf("Ignoring response to unknown request %v", response.ID)
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handleMessage dispatches a single request to the handler.
+// For a successful initialize it creates a session and sets the Mcp-Session-Id header.
+func (t *Transport) handleMessage(w http.ResponseWriter, r *http.Request, req *JSONRPCRequest, sessionID string) *JSONRPCResponse {
+	response, newSession := t.dispatch(r.Context(), req, sessionID)
+	if newSession != nil {
+		w.Header().Set("Mcp-Session-Id", newSession.ID)
+	}
+	return response
+}
+
+// dispatch hands a single request to the handler. A successful initialize
+// creates a session, which is returned.
+func (t *Transport) dispatch(ctx context.Context, req *JSONRPCRequest, sessionID string) (*JSONRPCResponse, *Session) {
+	response := t.handler.HandleRequest(ctx, req, sessionID)
+	if t.recorder != nil {
+		t.recorder.RecordMessage(sessionID, req, response)
+	}
+
+	// For initialize method, create a new session
+	if req.Method == "initialize" && response != nil && response.Error == nil {
+		if initResult, ok := response.Result.(*InitializeResult); ok {
+			newSession := t.sessionManager.Create(initResult)
+			var initReq InitializeRequest
+			if err := json.Unmarshal(req.Params, &initReq); err == nil {
+				newSession.SetClientCapabilities(initReq.Capabilities)
+				newSession.SetCredentials(initReq.Credentials)
+			}
+			newSession.SetRole(tools.RoleFromContext(ctx))
+			return response, newSession
+		}
+	}
+
+	return response, nil
+}
+
 func (t *Transport) HandleGET(w http.ResponseWriter, r *http.Request) {
 	// CORS handling
 	if t.enableCORS {
 		t.setCORSHeaders(w, r)
 	}
 
+	r, ok := t.authorize(w, r)
+	if !ok {
+		return
+	}
+
 	// Validate session
 	sessionID := r.Header.Get("Mcp-Session-Id")
 	if sessionID == "" {
@@ -106,7 +349,7 @@ func (t *Transport) HandleGET(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, exists := t.sessionManager.Get(sessionID)
+	session, exists := t.sessionManager.Get(sessionID)
 	if !exists {
 		http.Error(w, "Session not found", http.StatusUnauthorized)
 		return
@@ -117,8 +360,6 @@ func (t *Transport) HandleGET(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 
-	// For now, just keep the connection open
-	// In a full implementation, this would stream server-initiated messages
 	flusher, ok := w.(http.Flusher)
 	if !ok {
 		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
@@ -129,8 +370,39 @@ func (t *Transport) HandleGET(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, ": connected\n\n")
 	flusher.Flush()
 
-	// Keep connection alive (in a real implementation, listen for server events)
-	<-r.Context().Done()
+	detach := session.AttachStream()
+	defer detach()
+
+	// Heartbeats keep proxies from closing an idle stream and reveal clients
+	// that disappeared without closing the connection
+	var heartbeat <-chan time.Time
+	if t.heartbeat > 0 {
+		ticker := time.NewTicker(t.heartbeat)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	// Stream server-initiated messages until the client disconnects
+	rc := http.NewResponseController(w)
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case <-t.sessionManager.Closing():
+			// Server is shutting down; the client reconnects to the next process
+			return
+		case <-heartbeat:
+			err = writeStream(rc, w, []byte(": ping\n\n"))
+		case message := <-session.Outgoing:
+			err = writeEvent(rc, w, message)
+		}
+		if err != nil {
+			log.Printf("Closing dead stream of session %s: %v", sessionID, err)
+			t.sessionManager.recordDeadStream()
+			return
+		}
+	}
 }
 
 func (t *Transport) HandleDELETE(w http.ResponseWriter, r *http.Request) {
@@ -139,6 +411,11 @@ func (t *Transport) HandleDELETE(w http.ResponseWriter, r *http.Request) {
 		t.setCORSHeaders(w, r)
 	}
 
+	r, ok := t.authorize(w, r)
+	if !ok {
+		return
+	}
+
 	sessionID := r.Header.Get("Mcp-Session-Id")
 	if sessionID == "" {
 		http.Error(w, "Session ID required", http.StatusBadRequest)
@@ -158,33 +435,38 @@ func (t *Transport) sendJSONResponse(w http.ResponseWriter, response interface{}
 
 func (t *Transport) setCORSHeaders(w http.ResponseWriter, r *http.Request) {
 	origin := r.Header.Get("Origin")
-	if origin != "" && t.isOriginAllowed(origin) {
+	if origin != "" && t.isOriginAllowed(r, origin) {
 		w.Header().Set("Access-Control-Allow-Origin", origin)
 	}
 	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Mcp-Session-Id")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Mcp-Session-Id, Authorization")
 	w.Header().Set("Access-Control-Expose-Headers", "Mcp-Session-Id")
 	w.Header().Set("Access-Control-Max-Age", "86400")
 }
 
 func (t *Transport) validateOrigin(r *http.Request) bool {
-	// For localhost, always allow
-	host := r.Host
-	if strings.HasPrefix(host, "localhost:") || strings.HasPrefix(host, "127.0.0.1:") {
-		return true
-	}
-
 	origin := r.Header.Get("Origin")
 	if origin == "" {
-		// No origin header in same-origin requests
+		// Non-browser clients and same-origin requests omit the Origin header
 		return true
 	}
 
-	return t.isOriginAllowed(origin)
+	return t.isOriginAllowed(r, origin)
 }
 
-func (t *Transport) isOriginAllowed(origin string) bool {
-	if len(t.allowedOrigins) == 0 {
+func (t *Transport) isOriginAllowed(r *http.Request, origin string) bool {
+	if t.originPolicy == OriginPolicyDisabled {
+		return true
+	}
+
+	t.originsMu.RLock()
+	defer t.originsMu.RUnlock()
+
+	if t.originPolicy == OriginPolicyStrict {
+		if strings.EqualFold(origin, t.trustedProxies.externalOrigin(r)) {
+			return true
+		}
+	} else if len(t.allowedOrigins) == 0 {
 		return true
 	}
 
@@ -0,0 +1,43 @@
+package mcp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/rbac"
+)
+
+func TestCORSPreflightAllowsAuthorization(t *testing.T) {
+	transport := NewTransport(nil, NewSessionManager(time.Hour), []string{"https://app.example"}, true)
+	roles, err := rbac.NewPolicy([]string{"team-token=editor"}, rbac.None)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport.SetRoles(roles)
+
+	req := httptest.NewRequest(http.MethodOptions, "/mcp", nil)
+	req.Header.Set("Origin", "https://app.example")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "authorization, content-type, mcp-session-id")
+	rec := httptest.NewRecorder()
+	transport.HandlePOST(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("preflight status = %d, want 200", rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example" {
+		t.Errorf("Access-Control-Allow-Origin = %q", got)
+	}
+	allowed := map[string]bool{}
+	for _, header := range strings.Split(rec.Header().Get("Access-Control-Allow-Headers"), ",") {
+		allowed[strings.ToLower(strings.TrimSpace(header))] = true
+	}
+	for _, header := range strings.Split(req.Header.Get("Access-Control-Request-Headers"), ", ") {
+		if !allowed[header] {
+			t.Errorf("preflight does not allow header %q", header)
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package mcp
 
-import "encoding/json"
+import (
+	"encoding/json"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
 
 // JSON-RPC 2.0 message types
 
@@ -24,6 +28,14 @@ type JSONRPCNotification struct {
 	Params  json.RawMessage `json:"params,omitempty"`
 }
 
+// JSONRPCClientResponse is a client's response to a server-initiated request
+type JSONRPCClientResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      interface{}     `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
 type RPCError struct {
 	Code    int         `json:"code"`
 	Message string      `json:"message"`
@@ -37,6 +49,8 @@ type InitializeRequest struct {
 	Capabilities    ClientCapabilities     `json:"capabilities"`
 	ClientInfo      ClientInfo             `json:"clientInfo"`
 	Meta            map[string]interface{} `json:"meta,omitempty"`
+	// Credentials are Scrapbox credentials for the session (see MessageHandler.SetTenants)
+	Credentials *scrapbox.Credentials `json:"credentials,omitempty"`
 }
 
 type InitializeResult struct {
@@ -46,8 +60,9 @@ type InitializeResult struct {
 }
 
 type ClientCapabilities struct {
-	Sampling map[string]interface{} `json:"sampling,omitempty"`
-	Roots    *RootsCapability       `json:"roots,omitempty"`
+	Sampling    map[string]interface{} `json:"sampling,omitempty"`
+	Roots       *RootsCapability       `json:"roots,omitempty"`
+	Elicitation map[string]interface{} `json:"elicitation,omitempty"`
 }
 
 type RootsCapability struct {
@@ -92,6 +107,13 @@ type Tool struct {
 type ToolsCallRequest struct {
 	Name      string                 `json:"name"`
 	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	Meta      *RequestMeta           `json:"_meta,omitempty"`
+}
+
+// RequestMeta is the _meta object of a request
+type RequestMeta struct {
+	// ProgressToken asks for notifications/progress while the request runs
+	ProgressToken interface{} `json:"progressToken,omitempty"`
 }
 
 type ToolsCallResult struct {
@@ -100,8 +122,70 @@ type ToolsCallResult struct {
 }
 
 type ContentBlock struct {
-	Type string `json:"type"`
-	Text string `json:"text,omitempty"`
+	Type        string            `json:"type"`
+	Text        string            `json:"text,omitempty"`
+	Data        string            `json:"data,omitempty"`
+	MimeType    string            `json:"mimeType,omitempty"`
+	Resource    *ResourceContents `json:"resource,omitempty"`
+	Annotations *Annotations      `json:"annotations,omitempty"`
+}
+
+// Annotations describe the intended audience and priority of a content block
+type Annotations struct {
+	Audience []string `json:"audience,omitempty"` // user, assistant
+	Priority float64  `json:"priority"`
+}
+
+// ResourceContents is the resource of an embedded resource content block
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+// Resource types
+
+type ResourcesSubscribeRequest struct {
+	URI string `json:"uri"`
+}
+
+type ResourcesUnsubscribeRequest struct {
+	URI string `json:"uri"`
+}
+
+type ResourceUpdatedParams struct {
+	URI string `json:"uri"`
+}
+
+type ProgressParams struct {
+	ProgressToken interface{} `json:"progressToken"`
+	Progress      float64     `json:"progress"`
+	Total         float64     `json:"total,omitempty"`
+	Message       string      `json:"message,omitempty"`
+}
+
+// Roots types
+
+type Root struct {
+	URI  string `json:"uri"`
+	Name string `json:"name,omitempty"`
+}
+
+type ListRootsResult struct {
+	Roots []Root `json:"roots"`
+}
+
+// Elicitation types
+
+type ElicitRequestParams struct {
+	Message         string                 `json:"message"`
+	RequestedSchema map[string]interface{} `json:"requestedSchema"`
+}
+
+type ElicitResult struct {
+	Action  string                 `json:"action"` // accept, decline or cancel
+	Content map[string]interface{} `json:"content,omitempty"`
 }
 
 // Ping types
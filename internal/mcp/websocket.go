@@ -0,0 +1,269 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	mcperrors "github.com/hiroki/scrapbox_mcp/pkg/errors"
+)
+
+// wsConn is one MCP-over-WebSocket connection. Each text message carries one
+// JSON-RPC message or batch; server-initiated messages for the connection's
+// session are written to the same socket.
+type wsConn struct {
+	transport *Transport
+	conn      *websocket.Conn
+	writeMu   sync.Mutex
+
+	mu      sync.Mutex
+	session *Session
+	owned   bool
+}
+
+// HandleWebSocket serves MCP over a WebSocket. The client starts with
+// initialize, or resumes an existing session by sending its Mcp-Session-Id
+// header with the upgrade request. Sessions created on the socket end with it.
+func (t *Transport) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
+	r, ok := t.authorize(w, r)
+	if !ok {
+		return
+	}
+	var resumed *Session
+	if sessionID := r.Header.Get("Mcp-Session-Id"); sessionID != "" {
+		session, exists := t.sessionManager.Get(sessionID)
+		if !exists {
+			http.Error(w, "Session not found", http.StatusUnauthorized)
+			return
+		}
+		resumed = session
+	}
+
+	upgrader := websocket.Upgrader{CheckOrigin: t.validateOrigin}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already written an error response
+		log.Printf("WebSocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+	if t.maxBodyBytes > 0 {
+		conn.SetReadLimit(t.maxBodyBytes)
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	c := &wsConn{transport: t, conn: conn}
+	if resumed != nil {
+		c.attach(ctx, resumed, false)
+	}
+
+	// On disconnect, cancel in-flight requests and wait for them before
+	// ending the session
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+		c.close()
+	}()
+
+	// Ask the client to reconnect when the server shuts down, and ping it
+	// on every heartbeat; a client that answers neither pings nor anything
+	// else within two heartbeats is treated as gone
+	var ticks <-chan time.Time
+	if t.heartbeat > 0 {
+		ticker := time.NewTicker(t.heartbeat)
+		defer ticker.Stop()
+		ticks = ticker.C
+		conn.SetReadDeadline(time.Now().Add(2 * t.heartbeat))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(2 * t.heartbeat))
+		})
+	}
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticks:
+				if err := c.control(websocket.PingMessage, nil); err != nil {
+					log.Printf("WebSocket ping failed: %v", err)
+					return
+				}
+			case <-t.sessionManager.Closing():
+				message := websocket.FormatCloseMessage(websocket.CloseServiceRestart, "server restarting")
+				c.control(websocket.CloseMessage, message)
+				return
+			}
+		}
+	}()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				log.Printf("Closing dead WebSocket of session %s: no heartbeat reply", c.sessionID())
+				t.sessionManager.recordDeadStream()
+			} else if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Printf("WebSocket read failed: %v", err)
+			}
+			return
+		}
+		if t.heartbeat > 0 {
+			conn.SetReadDeadline(time.Now().Add(2 * t.heartbeat))
+		}
+
+		// Initialize runs before anything else so the session exists for
+		// later messages; other requests run concurrently so a tool call
+		// waiting for the client (e.g. elicitation) does not block reads.
+		if isInitialize(data) {
+			c.handle(ctx, data)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.handle(ctx, data)
+		}()
+	}
+}
+
+// isInitialize reports whether data is a single initialize request
+func isInitialize(data []byte) bool {
+	var probe struct {
+		Method string `json:"method"`
+	}
+	return json.Unmarshal(data, &probe) == nil && probe.Method == "initialize"
+}
+
+// handle processes one WebSocket message and writes the response, if any
+func (c *wsConn) handle(ctx context.Context, data []byte) {
+	if trimmed := bytes.TrimLeft(data, " \t\r\n"); len(trimmed) > 0 && trimmed[0] == '[' {
+		var messages []json.RawMessage
+		if err := json.Unmarshal(trimmed, &messages); err != nil || len(messages) == 0 {
+			c.write(&JSONRPCResponse{
+				JSONRPC: "2.0",
+				Error:   &RPCError{Code: mcperrors.ErrCodeInvalidRequest, Message: "Invalid Request"},
+			})
+			return
+		}
+		responses := make([]*JSONRPCResponse, 0, len(messages))
+		for _, msg := range messages {
+			if response := c.handleOne(ctx, msg); response != nil {
+				responses = append(responses, response)
+			}
+		}
+		if len(responses) > 0 {
+			c.write(responses)
+		}
+		return
+	}
+
+	if response := c.handleOne(ctx, data); response != nil {
+		c.write(response)
+	}
+}
+
+// handleOne processes a single JSON-RPC message: a client response is handed
+// to the waiting server request, anything else is dispatched to the handler
+func (c *wsConn) handleOne(ctx context.Context, data []byte) *JSONRPCResponse {
+	sessionID := c.sessionID()
+	if response, ok := parseClientResponse(data); ok {
+		if !c.transport.sessionManager.Respond(sessionID, response) {
+			log.Printf("Ignoring response to unknown request %v", response.ID)
+		}
+		return nil
+	}
+
+	var req JSONRPCRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			Error:   &RPCError{Code: mcperrors.ErrCodeParseError, Message: "Parse error"},
+		}
+	}
+	switch {
+	case req.Method == "initialize" && sessionID != "":
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error:   &RPCError{Code: mcperrors.ErrCodeInvalidRequest, Message: "Session already initialized"},
+		}
+	case req.Method != "initialize" && sessionID == "":
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error:   &RPCError{Code: mcperrors.ErrCodeInvalidRequest, Message: "Session not initialized"},
+		}
+	}
+
+	response, newSession := c.transport.dispatch(ctx, &req, sessionID)
+	if newSession != nil {
+		c.attach(ctx, newSession, true)
+	}
+	return response
+}
+
+// attach binds the connection to session and forwards the session's
+// notifications and server requests to the socket until ctx ends
+func (c *wsConn) attach(ctx context.Context, session *Session, owned bool) {
+	c.mu.Lock()
+	c.session, c.owned = session, owned
+	c.mu.Unlock()
+
+	detach := session.AttachStream()
+	go func() {
+		defer detach()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message := <-session.Outgoing:
+				c.write(message)
+			}
+		}
+	}()
+}
+
+// sessionID returns the ID of the connection's session, if initialized
+func (c *wsConn) sessionID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.session == nil {
+		return ""
+	}
+	return c.session.ID
+}
+
+// close deletes the session if it was created on this connection
+func (c *wsConn) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.session != nil && c.owned {
+		c.transport.sessionManager.Delete(c.session.ID)
+	}
+}
+
+// write sends one JSON message; writes from concurrent requests are serialized
+func (c *wsConn) write(message interface{}) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	if err := c.conn.WriteJSON(message); err != nil {
+		log.Printf("WebSocket write failed: %v", err)
+	}
+}
+
+// control sends a ping or close frame
+func (c *wsConn) control(messageType int, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteControl(messageType, data, time.Now().Add(streamWriteTimeout))
+}
@@ -0,0 +1,203 @@
+// Package pagelist writes the metadata of every page of a project as CSV or
+// JSON, one page list batch at a time, so large projects are never held in
+// memory.
+package pagelist
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+
+	// batchSize is the page size used to walk the page list (the API maximum)
+	batchSize = 1000
+)
+
+// utf8BOM lets spreadsheet applications detect UTF-8 in a CSV file
+const utf8BOM = "\ufeff"
+
+// Columns are the fields written for each page, in CSV column order
+var Columns = []string{"id", "title", "created", "updated", "accessed", "views", "linked", "pin", "author", "last_editor"}
+
+// Row is the metadata of one page; times are RFC 3339 in UTC
+type Row struct {
+	ID         string `json:"id"`
+	Title      string `json:"title"`
+	Created    string `json:"created"`
+	Updated    string `json:"updated"`
+	Accessed   string `json:"accessed,omitempty"`
+	Views      int    `json:"views"`
+	Linked     int    `json:"linked"`
+	Pin        bool   `json:"pin"`
+	Author     string `json:"author,omitempty"`
+	LastEditor string `json:"last_editor,omitempty"`
+}
+
+// Options controls a page list export
+type Options struct {
+	Format string
+	// BOM starts CSV output with a UTF-8 byte order mark for Excel
+	BOM bool
+	// Progress is called after each batch with the pages written so far
+	// and the project's page count
+	Progress func(written, total int)
+}
+
+// Write walks the page list of project and writes every page to w. It
+// returns the number of pages written.
+func Write(ctx context.Context, reader scrapbox.Reader, project string, w io.Writer, opts Options) (int, error) {
+	enc, err := newEncoder(w, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	written := 0
+	for skip := 0; ; skip += batchSize {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+		resp, err := reader.ListPages(project, batchSize, skip)
+		if err != nil {
+			return written, err
+		}
+		for _, info := range resp.Pages {
+			if err := enc.write(newRow(info)); err != nil {
+				return written, fmt.Errorf("failed to write page list: %w", err)
+			}
+			written++
+		}
+		if err := enc.flush(); err != nil {
+			return written, fmt.Errorf("failed to write page list: %w", err)
+		}
+		if opts.Progress != nil {
+			opts.Progress(written, resp.Count)
+		}
+		if len(resp.Pages) < batchSize || written >= resp.Count {
+			break
+		}
+	}
+
+	if err := enc.close(); err != nil {
+		return written, fmt.Errorf("failed to write page list: %w", err)
+	}
+	return written, nil
+}
+
+func newRow(info scrapbox.PageInfo) Row {
+	row := Row{
+		ID:      info.ID,
+		Title:   info.Title,
+		Created: formatTime(info.Created),
+		Updated: formatTime(info.Updated),
+		Views:   info.Views,
+		Linked:  info.Linked,
+		Pin:     info.Pin != 0,
+	}
+	if info.Accessed > 0 {
+		row.Accessed = formatTime(info.Accessed)
+	}
+	if info.User != nil {
+		row.Author = info.User.Name
+	}
+	if info.LastUpdateUser != nil {
+		row.LastEditor = info.LastUpdateUser.Name
+	}
+	return row
+}
+
+func formatTime(unix int64) string {
+	return time.Unix(unix, 0).UTC().Format(time.RFC3339)
+}
+
+// encoder writes rows in one format
+type encoder interface {
+	write(row Row) error
+	// flush pushes buffered rows to the writer
+	flush() error
+	// close ends the output
+	close() error
+}
+
+func newEncoder(w io.Writer, opts Options) (encoder, error) {
+	switch opts.Format {
+	case FormatCSV, "":
+		if opts.BOM {
+			if _, err := io.WriteString(w, utf8BOM); err != nil {
+				return nil, fmt.Errorf("failed to write page list: %w", err)
+			}
+		}
+		enc := &csvEncoder{w: csv.NewWriter(w)}
+		if err := enc.w.Write(Columns); err != nil {
+			return nil, fmt.Errorf("failed to write page list: %w", err)
+		}
+		return enc, nil
+	case FormatJSON:
+		return &jsonEncoder{w: w}, nil
+	default:
+		return nil, fmt.Errorf("invalid format: %s (expected csv or json)", opts.Format)
+	}
+}
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvEncoder) write(row Row) error {
+	return e.w.Write([]string{
+		row.ID, row.Title, row.Created, row.Updated, row.Accessed,
+		strconv.Itoa(row.Views), strconv.Itoa(row.Linked), strconv.FormatBool(row.Pin),
+		row.Author, row.LastEditor,
+	})
+}
+
+func (e *csvEncoder) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvEncoder) close() error {
+	return e.flush()
+}
+
+// jsonEncoder writes a JSON array one element at a time
+type jsonEncoder struct {
+	w       io.Writer
+	started bool
+}
+
+func (e *jsonEncoder) write(row Row) error {
+	data, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	sep := ",\n  "
+	if !e.started {
+		sep = "[\n  "
+		e.started = true
+	}
+	_, err = io.WriteString(e.w, sep+string(data))
+	return err
+}
+
+func (e *jsonEncoder) flush() error {
+	return nil
+}
+
+func (e *jsonEncoder) close() error {
+	end := "\n]\n"
+	if !e.started {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(e.w, end)
+	return err
+}
@@ -0,0 +1,149 @@
+// Package quota limits how often each MCP session may call tools, as a
+// circuit breaker for autonomous agents editing a shared project.
+package quota
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/tools"
+	mcperrors "github.com/hiroki/scrapbox_mcp/pkg/errors"
+)
+
+// Rule scopes besides tool names
+const (
+	// ScopeWrites matches every write tool
+	ScopeWrites = "writes"
+	// ScopeAll matches every tool
+	ScopeAll = "*"
+)
+
+// Rule allows Limit calls to the tools in Scope per Window and session
+type Rule struct {
+	Scope  string
+	Limit  int
+	Window time.Duration
+}
+
+func (r Rule) String() string {
+	return fmt.Sprintf("%s=%d/%s", r.Scope, r.Limit, r.Window)
+}
+
+// ParseRules parses scope=limit/window entries, e.g. "writes=50/1h" or
+// "create_page=10/24h". The scope is a tool name, "writes" or "*".
+func ParseRules(entries []string) ([]Rule, error) {
+	rules := make([]Rule, 0, len(entries))
+	for _, entry := range entries {
+		scope, spec, ok := strings.Cut(entry, "=")
+		scope = strings.TrimSpace(scope)
+		limitStr, windowStr, ok2 := strings.Cut(strings.TrimSpace(spec), "/")
+		if !ok || !ok2 || scope == "" {
+			return nil, fmt.Errorf("invalid tool quota %q (expected scope=limit/window, e.g. writes=50/1h)", entry)
+		}
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return nil, fmt.Errorf("invalid tool quota %q: limit must be a positive integer", entry)
+		}
+		window, err := time.ParseDuration(windowStr)
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("invalid tool quota %q: window must be a duration such as 1h", entry)
+		}
+		rules = append(rules, Rule{Scope: scope, Limit: limit, Window: window})
+	}
+	return rules, nil
+}
+
+// Limiter counts calls per session and rule over sliding windows
+type Limiter struct {
+	rules []Rule
+
+	mu        sync.Mutex
+	calls     map[string][]time.Time
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewLimiter(rules []Rule) *Limiter {
+	return &Limiter{
+		rules: rules,
+		calls: make(map[string][]time.Time),
+		now:   time.Now,
+	}
+}
+
+// Allow records a call of tool by session, or returns an error naming the
+// exhausted quota and when it frees up. A refused call is not counted.
+func (l *Limiter) Allow(session, tool string, write bool) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	var keys []string
+	for i, rule := range l.rules {
+		if !rule.matches(tool, write) {
+			continue
+		}
+		key := fmt.Sprintf("%s\x00%d", session, i)
+		calls := pruned(l.calls[key], now.Add(-rule.Window))
+		l.calls[key] = calls
+		if len(calls) >= rule.Limit {
+			retryIn := calls[0].Add(rule.Window).Sub(now).Round(time.Second)
+			return mcperrors.NewToolError(mcperrors.ErrCodeUsageQuota,
+				fmt.Sprintf("quota %s exceeded for this session; next call allowed in %s", rule, retryIn), false)
+		}
+		keys = append(keys, key)
+	}
+
+	for _, key := range keys {
+		l.calls[key] = append(l.calls[key], now)
+	}
+	return nil
+}
+
+// Middleware refuses tool calls over quota
+func (l *Limiter) Middleware(next tools.ToolHandler) tools.ToolHandler {
+	return tools.WrapExecute(next, func(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+		if err := l.Allow(tools.SessionIDFromContext(ctx), next.Name(), tools.IsWriteTool(next)); err != nil {
+			log.Printf("[QUOTA] Refused %s: %v", next.Name(), err)
+			return nil, err
+		}
+		return next.Execute(ctx, arguments)
+	})
+}
+
+// sweep drops the counters of idle sessions once per longest window
+func (l *Limiter) sweep(now time.Time) {
+	var longest time.Duration
+	for _, rule := range l.rules {
+		longest = max(longest, rule.Window)
+	}
+	if now.Sub(l.lastSweep) < longest {
+		return
+	}
+	l.lastSweep = now
+	for key, calls := range l.calls {
+		if len(calls) == 0 || now.Sub(calls[len(calls)-1]) >= longest {
+			delete(l.calls, key)
+		}
+	}
+}
+
+func (r Rule) matches(tool string, write bool) bool {
+	return r.Scope == ScopeAll || r.Scope == tool || (r.Scope == ScopeWrites && write)
+}
+
+// pruned drops the calls made before since
+func pruned(calls []time.Time, since time.Time) []time.Time {
+	i := 0
+	for i < len(calls) && !calls[i].After(since) {
+		i++
+	}
+	return calls[i:]
+}
@@ -0,0 +1,157 @@
+// Package rbac limits the tools an MCP session may call by its role: viewers
+// read, editors also write, and admins also run project-wide writes and
+// server operations.
+package rbac
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hiroki/scrapbox_mcp/internal/tools"
+	mcperrors "github.com/hiroki/scrapbox_mcp/pkg/errors"
+)
+
+// Roles, from least to most privileged
+const (
+	Viewer = "viewer"
+	Editor = "editor"
+	Admin  = "admin"
+)
+
+// None as the default role requires every request to carry a known token
+const None = "none"
+
+// ParseRole checks that role is viewer, editor or admin
+func ParseRole(role string) (string, error) {
+	role = strings.ToLower(strings.TrimSpace(role))
+	switch role {
+	case Viewer, Editor, Admin:
+		return role, nil
+	}
+	return "", fmt.Errorf("invalid role %q (expected viewer, editor or admin)", role)
+}
+
+// rank orders the roles by privilege
+var rank = map[string]int{Viewer: 1, Editor: 2, Admin: 3}
+
+// Lower returns the less privileged of two roles. An empty role (no role
+// checks) yields the other one.
+func Lower(a, b string) string {
+	switch {
+	case a == "":
+		return b
+	case b == "":
+		return a
+	case rank[b] < rank[a]:
+		return b
+	}
+	return a
+}
+
+// token is a bearer token and the role it grants
+type token struct {
+	value string
+	role  string
+}
+
+// Policy assigns roles to requests by their bearer token and refuses tool
+// calls the caller's role does not allow
+type Policy struct {
+	tokens      []token
+	defaultRole string
+}
+
+// NewPolicy parses token=role entries, e.g. "s3cret=editor". Requests
+// without a token get defaultRole, or are refused when it is None.
+func NewPolicy(entries []string, defaultRole string) (*Policy, error) {
+	p := &Policy{}
+	if strings.EqualFold(strings.TrimSpace(defaultRole), None) {
+		p.defaultRole = None
+	} else {
+		role, err := ParseRole(defaultRole)
+		if err != nil {
+			return nil, fmt.Errorf("invalid default role: %w", err)
+		}
+		p.defaultRole = role
+	}
+	for _, entry := range entries {
+		// Tokens may contain "=", roles do not
+		i := strings.LastIndex(entry, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid role token entry (expected token=role)")
+		}
+		value := strings.TrimSpace(entry[:i])
+		role, err := ParseRole(entry[i+1:])
+		if value == "" || err != nil {
+			return nil, fmt.Errorf("invalid role token entry for role %q (expected token=role with role viewer, editor or admin)", strings.TrimSpace(entry[i+1:]))
+		}
+		mcperrors.RegisterSecret(value)
+		p.tokens = append(p.tokens, token{value: value, role: role})
+	}
+	if p.defaultRole == None && len(p.tokens) == 0 {
+		return nil, fmt.Errorf("default role none requires at least one role token")
+	}
+	return p, nil
+}
+
+// Resolve returns the role granted by an Authorization header. Unknown
+// tokens are refused rather than given the default role.
+func (p *Policy) Resolve(authorization string) (string, error) {
+	value, ok := strings.CutPrefix(strings.TrimSpace(authorization), "Bearer ")
+	value = strings.TrimSpace(value)
+	if !ok || value == "" {
+		if p.defaultRole == None {
+			return "", fmt.Errorf("a bearer token is required")
+		}
+		return p.defaultRole, nil
+	}
+	for _, t := range p.tokens {
+		if subtle.ConstantTimeCompare([]byte(value), []byte(t.value)) == 1 {
+			return t.role, nil
+		}
+	}
+	return "", fmt.Errorf("unknown bearer token")
+}
+
+// Allows reports whether role may call tool. An empty role (a call not made
+// through MCP, e.g. the call command) is not restricted.
+func Allows(role string, tool tools.ToolHandler) bool {
+	switch role {
+	case "", Admin:
+		return true
+	case Editor:
+		return !tools.IsAdminTool(tool)
+	default:
+		return !tools.IsAdminTool(tool) && !tools.IsWriteTool(tool)
+	}
+}
+
+// AllowsCall reports whether role may call tool with arguments. Tools open
+// to every role may still reserve some arguments for admins.
+func AllowsCall(role string, tool tools.ToolHandler, arguments map[string]interface{}) bool {
+	if !Allows(role, tool) {
+		return false
+	}
+	return role == "" || role == Admin || !tools.IsAdminCall(tool, arguments)
+}
+
+// Middleware refuses tool calls the caller's role does not allow
+func (p *Policy) Middleware(next tools.ToolHandler) tools.ToolHandler {
+	return tools.WrapExecute(next, func(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+		role := tools.RoleFromContext(ctx)
+		if !Allows(role, next) {
+			log.Printf("[RBAC] Refused %s for role %s", next.Name(), role)
+			return nil, mcperrors.NewToolError(mcperrors.ErrCodeForbidden,
+				fmt.Sprintf("role %s may not call %s", role, next.Name()), false)
+		}
+		if !AllowsCall(role, next, arguments) {
+			log.Printf("[RBAC] Refused %s with admin-only arguments for role %s", next.Name(), role)
+			return nil, mcperrors.NewToolError(mcperrors.ErrCodeForbidden,
+				fmt.Sprintf("role %s may not call %s with these arguments (admin only)", role, next.Name()), false)
+		}
+		return next.Execute(ctx, arguments)
+	})
+}
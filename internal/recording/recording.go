@@ -0,0 +1,148 @@
+// Package recording writes debug captures of the traffic between the server,
+// Scrapbox and MCP clients, and replays them for reproducible bug reports.
+package recording
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	mcperrors "github.com/hiroki/scrapbox_mcp/pkg/errors"
+)
+
+// Entry kinds
+const (
+	// KindMeta is the first entry of a capture, describing the recording server
+	KindMeta = "meta"
+	// KindHTTP is a Scrapbox REST request and its response
+	KindHTTP = "http"
+	// KindWebSocket is a Scrapbox WebSocket frame
+	KindWebSocket = "ws"
+	// KindMCP is an MCP request and the server's response
+	KindMCP = "mcp"
+)
+
+// Entry is one line of a capture file
+type Entry struct {
+	Time time.Time `json:"time"`
+	Kind string    `json:"kind"`
+
+	// Meta entries
+	Project string `json:"project,omitempty"`
+	APIURL  string `json:"apiUrl,omitempty"`
+
+	// HTTP entries
+	Method       string `json:"method,omitempty"`
+	URL          string `json:"url,omitempty"`
+	Status       int    `json:"status,omitempty"`
+	RequestBody  string `json:"requestBody,omitempty"`
+	ResponseBody string `json:"responseBody,omitempty"`
+	Error        string `json:"error,omitempty"`
+
+	// WebSocket entries
+	Conn      int64  `json:"conn,omitempty"`
+	Direction string `json:"direction,omitempty"`
+	Data      string `json:"data,omitempty"`
+
+	// MCP entries
+	Session  string          `json:"session,omitempty"`
+	Request  json.RawMessage `json:"request,omitempty"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// csrfTokenPattern matches the CSRF token in /users/me responses
+var csrfTokenPattern = regexp.MustCompile(`"csrfToken"\s*:\s*"[^"]*"`)
+
+// sanitize removes secrets from recorded text: registered secrets such as the
+// session cookie, and CSRF tokens. Headers are never recorded.
+func sanitize(text string) string {
+	return csrfTokenPattern.ReplaceAllString(mcperrors.Redact(text), `"csrfToken":"[REDACTED]"`)
+}
+
+// Recorder appends entries to a capture file. It implements
+// scrapbox.Recorder and mcp.MessageRecorder.
+type Recorder struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+var _ scrapbox.Recorder = (*Recorder)(nil)
+
+// Open creates (or truncates) the capture file at path. The file is only
+// readable by the owner since it holds page content.
+func Open(path, project, apiURL string) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open capture file: %v", err)
+	}
+	r := &Recorder{file: file, enc: json.NewEncoder(file)}
+	r.write(Entry{Kind: KindMeta, Project: project, APIURL: apiURL})
+	return r, nil
+}
+
+// Close closes the capture file
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// RecordHTTP records a REST request and its response
+func (r *Recorder) RecordHTTP(req *http.Request, requestBody []byte, resp *http.Response, responseBody []byte, err error) {
+	entry := Entry{
+		Kind:         KindHTTP,
+		Method:       req.Method,
+		URL:          sanitize(req.URL.String()),
+		RequestBody:  sanitize(string(requestBody)),
+		ResponseBody: sanitize(string(responseBody)),
+	}
+	if resp != nil {
+		entry.Status = resp.StatusCode
+	}
+	if err != nil {
+		entry.Error = sanitize(err.Error())
+	}
+	r.write(entry)
+}
+
+// RecordFrame records a WebSocket frame
+func (r *Recorder) RecordFrame(connID int64, direction string, data []byte) {
+	r.write(Entry{
+		Kind:      KindWebSocket,
+		Conn:      connID,
+		Direction: direction,
+		Data:      sanitize(string(data)),
+	})
+}
+
+// RecordMessage records an MCP request and its response
+func (r *Recorder) RecordMessage(sessionID string, request, response interface{}) {
+	entry := Entry{Kind: KindMCP, Session: sessionID}
+	entry.Request = encodeSanitized(request)
+	entry.Response = encodeSanitized(response)
+	r.write(entry)
+}
+
+// encodeSanitized encodes v as JSON with secrets removed
+func encodeSanitized(v interface{}) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return json.RawMessage(sanitize(string(data)))
+}
+
+// write appends entry to the capture; failures are ignored so debugging
+// never breaks request handling
+func (r *Recorder) write(entry Entry) {
+	entry.Time = time.Now().UTC()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.enc.Encode(entry)
+}
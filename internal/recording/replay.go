@@ -0,0 +1,224 @@
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+// lineIDPattern matches Scrapbox line IDs; new lines get fresh IDs on every
+// run, so frames are compared with line IDs masked
+var lineIDPattern = regexp.MustCompile(`\b[0-9a-f]{26}\b`)
+
+// maxEntryBytes bounds one line of a capture file (page exports can be large)
+const maxEntryBytes = 64 << 20
+
+// Load reads the entries of a capture file
+func Load(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open capture file: %v", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEntryBytes)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("invalid capture entry on line %d: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read capture file: %v", err)
+	}
+	return entries, nil
+}
+
+// Meta returns the meta entry of a capture, or an empty entry if there is none
+func Meta(entries []Entry) Entry {
+	for _, entry := range entries {
+		if entry.Kind == KindMeta {
+			return entry
+		}
+	}
+	return Entry{Kind: KindMeta}
+}
+
+// Server plays back the Scrapbox side of a capture. REST requests are
+// answered with the recorded responses for the same method and path, in
+// recorded order; WebSocket connections replay the recorded connections in
+// order, checking the client's frames against the recorded ones.
+type Server struct {
+	mu         sync.Mutex
+	responses  map[string][]Entry
+	conns      [][]Entry
+	nextConn   int
+	mismatches []string
+}
+
+// NewServer creates a Server for the upstream traffic in entries
+func NewServer(entries []Entry) *Server {
+	s := &Server{responses: make(map[string][]Entry)}
+	connIndex := make(map[int64]int)
+	for _, entry := range entries {
+		switch entry.Kind {
+		case KindHTTP:
+			u, err := url.Parse(entry.URL)
+			if err != nil {
+				continue
+			}
+			key := entry.Method + " " + u.RequestURI()
+			s.responses[key] = append(s.responses[key], entry)
+		case KindWebSocket:
+			i, ok := connIndex[entry.Conn]
+			if !ok {
+				i = len(s.conns)
+				connIndex[entry.Conn] = i
+				s.conns = append(s.conns, nil)
+			}
+			s.conns[i] = append(s.conns[i], entry)
+		}
+	}
+	return s
+}
+
+// Mismatches describes the requests and frames that differed from the capture
+func (s *Server) Mismatches() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.mismatches...)
+}
+
+func (s *Server) mismatch(format string, args ...interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mismatches = append(s.mismatches, fmt.Sprintf(format, args...))
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if websocket.IsWebSocketUpgrade(r) {
+		s.serveWebSocket(w, r)
+		return
+	}
+
+	key := r.Method + " " + r.URL.RequestURI()
+	s.mu.Lock()
+	queue := s.responses[key]
+	var entry Entry
+	found := len(queue) > 0
+	if found {
+		entry = queue[0]
+		// The last response answers any further identical requests
+		if len(queue) > 1 {
+			s.responses[key] = queue[1:]
+		}
+	}
+	s.mu.Unlock()
+
+	if !found {
+		s.mismatch("unrecorded request: %s", key)
+		http.Error(w, "not in capture", http.StatusNotFound)
+		return
+	}
+	if entry.Error != "" {
+		// The recorded request failed without a response; fail the connection
+		if hijacker, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hijacker.Hijack(); err == nil {
+				conn.Close()
+				return
+			}
+		}
+		http.Error(w, entry.Error, http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(entry.Status)
+	w.Write([]byte(entry.ResponseBody))
+}
+
+// serveWebSocket replays the next recorded WebSocket connection
+func (s *Server) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	var frames []Entry
+	if s.nextConn < len(s.conns) {
+		frames = s.conns[s.nextConn]
+		s.nextConn++
+	}
+	s.mu.Unlock()
+	if frames == nil {
+		s.mismatch("unrecorded WebSocket connection")
+		http.Error(w, "not in capture", http.StatusNotFound)
+		return
+	}
+
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("[REPLAY] WebSocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	for _, frame := range frames {
+		if frame.Data == "41" {
+			// Disconnects depend on how the recording process ended
+			continue
+		}
+		if frame.Direction == scrapbox.FrameReceive {
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(frame.Data)); err != nil {
+				return
+			}
+			continue
+		}
+		data, err := s.readFrame(conn)
+		if err != nil {
+			s.mismatch("WebSocket closed before frame: %s", frame.Data)
+			return
+		}
+		// Recorded frames are sanitized, so compare the replayed one the same way
+		if maskLineIDs(sanitize(data)) != maskLineIDs(frame.Data) {
+			s.mismatch("WebSocket frame differs:\n  recorded: %s\n  replayed: %s", frame.Data, data)
+		}
+	}
+
+	// Drain until the client disconnects
+	for {
+		data, err := s.readFrame(conn)
+		if err != nil {
+			return
+		}
+		s.mismatch("unrecorded WebSocket frame: %s", data)
+	}
+}
+
+// readFrame reads the next client frame, skipping Engine.IO pongs and disconnects
+func (s *Server) readFrame(conn *websocket.Conn) (string, error) {
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return "", err
+		}
+		if string(data) != "3" && string(data) != "41" {
+			return string(data), nil
+		}
+	}
+}
+
+func maskLineIDs(data string) string {
+	return lineIDPattern.ReplaceAllString(data, "<line-id>")
+}
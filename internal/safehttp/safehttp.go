@@ -0,0 +1,102 @@
+// Package safehttp fetches URLs supplied by users or written on pages
+// without letting them reach the server's own network: connections to
+// loopback, private, link-local and unspecified addresses are refused.
+package safehttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// maxRedirects matches the limit of the default http.Client
+const maxRedirects = 10
+
+// ErrNotPublic is returned by CheckHost for hosts on non-public addresses
+var ErrNotPublic = errors.New("not a public address")
+
+// IsPublic reports whether ip may be fetched: not loopback, private,
+// link-local, multicast or unspecified
+func IsPublic(ip net.IP) bool {
+	return ip != nil &&
+		!ip.IsLoopback() &&
+		!ip.IsPrivate() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() &&
+		!ip.IsUnspecified()
+}
+
+// CheckHost resolves host and returns an error unless all of its addresses
+// are public, so a caller can skip a URL before requesting it. Hosts on
+// non-public addresses give an error wrapping ErrNotPublic; other errors are
+// lookup failures.
+func CheckHost(ctx context.Context, host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		if !IsPublic(ip) {
+			return fmt.Errorf("%s: %w", ip, ErrNotPublic)
+		}
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if !IsPublic(addr.IP) {
+			return fmt.Errorf("%s resolves to %s: %w", host, addr.IP, ErrNotPublic)
+		}
+	}
+	return nil
+}
+
+// NewClient returns an HTTP client bounded by timeout whose connections,
+// including those made while following redirects, only reach public
+// addresses. The check runs on the resolved address being dialed, so DNS
+// names pointing inside the network are refused as well.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   refuseNonPublic,
+	}
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+}
+
+// refuseNonPublic runs after DNS resolution with the address about to be
+// dialed
+func refuseNonPublic(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); !IsPublic(ip) {
+		return fmt.Errorf("refusing to connect to non-public address %s", host)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package scrapbox
+
+// Reader is the set of read operations used by tools
+type Reader interface {
+	// DefaultProject returns the project used when a tool call does not specify one
+	DefaultProject() string
+	GetPage(project, title string) (*Page, error)
+	ListPages(project string, limit, skip int) (*PagesResponse, error)
+	SearchPages(project, query string, limit, skip int) (*SearchResponse, error)
+}
+
+// Writer is the set of write operations used by tools.
+// Writes always target the default project. A non-empty expectedCommitID
+// makes the write fail with a StaleCommitError if the page has moved on.
+type Writer interface {
+	InsertLines(pageTitle, targetLine string, newLines []string, expectedCommitID string) (*WriteResult, error)
+	// InsertLinesBefore inserts before the line with the given ID ("" appends)
+	InsertLinesBefore(pageTitle, beforeLineID string, newLines []string, expectedCommitID string) (*WriteResult, error)
+	PatchPage(pageTitle string, newTexts []string, expectedCommitID string) (*WriteResult, error)
+	CreatePage(title string, bodyLines []string, ifExists IfExists) (*WriteResult, error)
+	DeletePage(title string, expectedCommitID string) (*WriteResult, error)
+	// UpdateLine replaces the text of one line, addressed by its ID
+	UpdateLine(pageTitle, lineID, text string, expectedCommitID string) (*WriteResult, error)
+}
+
+// IfExists selects what CreatePage does when the page already exists
+type IfExists string
+
+const (
+	// IfExistsError fails with ErrCodePageExists
+	IfExistsError IfExists = "error"
+	// IfExistsAppend adds the body lines to the end of the page
+	IfExistsAppend IfExists = "append"
+	// IfExistsOverwrite replaces the page content with the body lines
+	IfExistsOverwrite IfExists = "overwrite"
+)
+
+// API combines read and write operations.
+// *Client is the live implementation; fakes and alternate backends implement it too.
+type API interface {
+	Reader
+	Writer
+}
+
+var _ API = (*Client)(nil)
+
+// DefaultProject returns the configured project name
+func (c *Client) DefaultProject() string {
+	return c.ProjectName
+}
+
+// CurrentUser returns the user the session cookie belongs to
+func (c *Client) CurrentUser() (*User, error) {
+	return c.RESTClient.GetMe()
+}
+
+// GetProject retrieves project metadata via the REST API
+func (c *Client) GetProject(project string) (*ProjectInfo, error) {
+	return c.RESTClient.GetProject(project)
+}
+
+// GetPage retrieves a page by title via the REST API
+func (c *Client) GetPage(project, title string) (*Page, error) {
+	return c.RESTClient.GetPage(project, title)
+}
+
+// ListPages retrieves a list of pages via the REST API
+func (c *Client) ListPages(project string, limit, skip int) (*PagesResponse, error) {
+	return c.RESTClient.ListPages(project, limit, skip)
+}
+
+// SearchPages searches for pages via the REST API
+func (c *Client) SearchPages(project, query string, limit, skip int) (*SearchResponse, error) {
+	return c.RESTClient.SearchPages(project, query, limit, skip)
+}
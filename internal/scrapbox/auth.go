@@ -1,10 +1,14 @@
 package scrapbox
 
-import "net/http"
+import (
+	"net/http"
+	"sync"
+)
 
 // Auth handles Scrapbox authentication
 type Auth struct {
 	sessionCookie string
+	mu            sync.RWMutex
 }
 
 // NewAuth creates a new Auth instance
@@ -14,12 +18,26 @@ func NewAuth(sessionCookie string) *Auth {
 	}
 }
 
+// SessionCookie returns the current session cookie value
+func (a *Auth) SessionCookie() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.sessionCookie
+}
+
+// SetSessionCookie replaces the session cookie used for subsequent requests
+func (a *Auth) SetSessionCookie(sessionCookie string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.sessionCookie = sessionCookie
+}
+
 // AddAuthHeaders adds authentication headers to the request
 func (a *Auth) AddAuthHeaders(req *http.Request) {
-	if a.sessionCookie != "" {
+	if sessionCookie := a.SessionCookie(); sessionCookie != "" {
 		req.AddCookie(&http.Cookie{
 			Name:  "connect.sid",
-			Value: a.sessionCookie,
+			Value: sessionCookie,
 		})
 	}
 }
@@ -0,0 +1,151 @@
+package scrapbox_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	"github.com/hiroki/scrapbox_mcp/internal/scrapboxtest"
+	mcperrors "github.com/hiroki/scrapbox_mcp/pkg/errors"
+)
+
+// newTestClient returns a client writing to a fake Scrapbox over transport
+func newTestClient(tb testing.TB, transport scrapbox.Transport) (*scrapbox.Client, *scrapboxtest.Server) {
+	tb.Helper()
+	fake := scrapboxtest.New("test-project")
+	client := scrapbox.NewClient(fake.Project(), fake.SessionCookie, fake.APIURL(), 10*time.Second)
+	client.SetTransport(transport)
+	client.EnsureWebSocket(fake.WebSocketURL())
+	tb.Cleanup(func() {
+		client.Close()
+		fake.Close()
+	})
+	return client, fake
+}
+
+// pageTexts returns the text of each line of the fake's page
+func pageTexts(tb testing.TB, fake *scrapboxtest.Server, title string) []string {
+	tb.Helper()
+	page, ok := fake.Page(title)
+	if !ok {
+		tb.Fatalf("page %q does not exist", title)
+	}
+	texts := make([]string, len(page.Lines))
+	for i, line := range page.Lines {
+		texts[i] = line.Text
+	}
+	return texts
+}
+
+func TestClientWrites(t *testing.T) {
+	for _, transport := range []scrapbox.Transport{scrapbox.TransportWebSocket, scrapbox.TransportPolling} {
+		t.Run(string(transport), func(t *testing.T) {
+			client, fake := newTestClient(t, transport)
+
+			created, err := client.CreatePage("Page", []string{"one", "three"}, scrapbox.IfExistsError)
+			if err != nil {
+				t.Fatalf("CreatePage: %v", err)
+			}
+			if want := []string{"Page", "one", "three"}; !reflect.DeepEqual(pageTexts(t, fake, "Page"), want) {
+				t.Fatalf("after CreatePage: %q, want %q", pageTexts(t, fake, "Page"), want)
+			}
+			if len(created.InsertedLineIDs) != 2 {
+				t.Errorf("InsertedLineIDs = %q, want 2 IDs", created.InsertedLineIDs)
+			}
+
+			if _, err := client.InsertLines("Page", "one", []string{"two"}, ""); err != nil {
+				t.Fatalf("InsertLines: %v", err)
+			}
+			if want := []string{"Page", "one", "two", "three"}; !reflect.DeepEqual(pageTexts(t, fake, "Page"), want) {
+				t.Fatalf("after InsertLines: %q, want %q", pageTexts(t, fake, "Page"), want)
+			}
+
+			page, _ := fake.Page("Page")
+			if _, err := client.UpdateLine("Page", page.Lines[3].ID, "3", ""); err != nil {
+				t.Fatalf("UpdateLine: %v", err)
+			}
+			if want := []string{"Page", "one", "two", "3"}; !reflect.DeepEqual(pageTexts(t, fake, "Page"), want) {
+				t.Fatalf("after UpdateLine: %q, want %q", pageTexts(t, fake, "Page"), want)
+			}
+
+			if _, err := client.PatchPage("Page", []string{"Page", "1", "2"}, ""); err != nil {
+				t.Fatalf("PatchPage: %v", err)
+			}
+			if want := []string{"Page", "1", "2"}; !reflect.DeepEqual(pageTexts(t, fake, "Page"), want) {
+				t.Fatalf("after PatchPage: %q, want %q", pageTexts(t, fake, "Page"), want)
+			}
+		})
+	}
+}
+
+func TestClientWriteErrors(t *testing.T) {
+	client, fake := newTestClient(t, scrapbox.TransportWebSocket)
+	fake.AddPage("Existing", "body")
+
+	tests := []struct {
+		name  string
+		write func() error
+		code  string
+	}{
+		{
+			name: "create existing page",
+			write: func() error {
+				_, err := client.CreatePage("Existing", []string{"new"}, scrapbox.IfExistsError)
+				return err
+			},
+			code: mcperrors.ErrCodePageExists,
+		},
+		{
+			name: "patch against an outdated commit",
+			write: func() error {
+				_, err := client.PatchPage("Existing", []string{"Existing", "changed"}, "outdated-commit")
+				return err
+			},
+			code: mcperrors.ErrCodeCommitConflict,
+		},
+		{
+			name: "update a missing line",
+			write: func() error {
+				_, err := client.UpdateLine("Existing", "no-such-line", "text", "")
+				return err
+			},
+			code: mcperrors.ErrCodeNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.write()
+			var sbErr *mcperrors.ScrapboxError
+			if !errors.As(err, &sbErr) || sbErr.Code != tt.code {
+				t.Errorf("error = %v, want code %s", err, tt.code)
+			}
+			if want := []string{"Existing", "body"}; !reflect.DeepEqual(pageTexts(t, fake, "Existing"), want) {
+				t.Errorf("page changed to %q", pageTexts(t, fake, "Existing"))
+			}
+		})
+	}
+}
+
+// BenchmarkCreatePage measures creating large pages, which used to sleep
+// for every generated line ID
+func BenchmarkCreatePage(b *testing.B) {
+	for _, lines := range []int{100, 2000} {
+		b.Run(fmt.Sprintf("%d lines", lines), func(b *testing.B) {
+			client, _ := newTestClient(b, scrapbox.TransportWebSocket)
+			body := make([]string, lines)
+			for i := range body {
+				body[i] = fmt.Sprintf("line %d", i)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := client.CreatePage(fmt.Sprintf("Page %d", i), body, scrapbox.IfExistsError); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
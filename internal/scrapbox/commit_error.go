@@ -0,0 +1,116 @@
+package scrapbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	mcperrors "github.com/hiroki/scrapbox_mcp/pkg/errors"
+)
+
+// commitErrorPayload is the error object in a commit ACK, e.g.
+// 43N[{"error":{"name":"NotFastForwardError","message":"..."}}]
+type commitErrorPayload struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+// commitErrorCodes maps Scrapbox commit error names to error codes
+var commitErrorCodes = map[string]string{
+	"NotFastForwardError": mcperrors.ErrCodeCommitConflict,
+	"DuplicateTitleError": mcperrors.ErrCodeCommitConflict,
+	"NotLoggedInError":    mcperrors.ErrCodeSessionExpired,
+	"NotMemberError":      mcperrors.ErrCodePermissionDenied,
+	"NotPrivilegeError":   mcperrors.ErrCodePermissionDenied,
+	"PermissionError":     mcperrors.ErrCodePermissionDenied,
+	"InvalidChangeError":  mcperrors.ErrCodeInvalidChange,
+	"TooLongLineError":    mcperrors.ErrCodeInvalidChange,
+	"ArrayTooLongError":   mcperrors.ErrCodeInvalidChange,
+	"ValidationError":     mcperrors.ErrCodeInvalidChange,
+	"QuotaExceededError":  mcperrors.ErrCodeQuotaExceeded,
+	"TooManyPagesError":   mcperrors.ErrCodeQuotaExceeded,
+	"TooManyRequests":     mcperrors.ErrCodeRateLimit,
+}
+
+// newCommitError converts the error value of a commit ACK into a typed ScrapboxError
+func newCommitError(errData interface{}) error {
+	var payload commitErrorPayload
+	switch v := errData.(type) {
+	case string:
+		payload.Message = v
+	default:
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return mcperrors.NewScrapboxError(mcperrors.ErrCodeWebSocketFail, "Commit error (failed to marshal)", nil)
+		}
+		if err := json.Unmarshal(raw, &payload); err != nil || (payload.Name == "" && payload.Message == "") {
+			payload.Message = string(raw)
+		}
+	}
+
+	code := classifyCommitError(payload)
+	message := "Commit error"
+	if payload.Name != "" {
+		message = fmt.Sprintf("Commit error %s", payload.Name)
+	}
+	if payload.Message != "" {
+		message += ": " + payload.Message
+	}
+	return mcperrors.NewScrapboxError(code, message, nil)
+}
+
+// classifyCommitError picks an error code from the error name, falling back
+// to keywords in the message for servers that only send a message
+func classifyCommitError(payload commitErrorPayload) string {
+	if code, ok := commitErrorCodes[payload.Name]; ok {
+		return code
+	}
+
+	msg := strings.ToLower(payload.Name + " " + payload.Message)
+	switch {
+	case strings.Contains(msg, "fast-forward"), strings.Contains(msg, "fastforward"),
+		strings.Contains(msg, "parent"), strings.Contains(msg, "conflict"):
+		return mcperrors.ErrCodeCommitConflict
+	case strings.Contains(msg, "permission"), strings.Contains(msg, "privilege"),
+		strings.Contains(msg, "not a member"), strings.Contains(msg, "forbidden"):
+		return mcperrors.ErrCodePermissionDenied
+	case strings.Contains(msg, "login"), strings.Contains(msg, "logged in"):
+		return mcperrors.ErrCodeSessionExpired
+	case strings.Contains(msg, "unauthorized"):
+		return mcperrors.ErrCodeAuthFailed
+	case strings.Contains(msg, "quota"), strings.Contains(msg, "limit exceeded"):
+		return mcperrors.ErrCodeQuotaExceeded
+	case strings.Contains(msg, "too many"):
+		return mcperrors.ErrCodeRateLimit
+	case strings.Contains(msg, "invalid"), strings.Contains(msg, "too long"):
+		return mcperrors.ErrCodeInvalidChange
+	default:
+		return mcperrors.ErrCodeWebSocketFail
+	}
+}
+
+// StaleCommitError is returned by writes whose expected commit ID no longer
+// matches the page, i.e. someone else edited it in the meantime. Page holds the
+// current content.
+type StaleCommitError struct {
+	Expected string
+	Page     *Page
+}
+
+func (e *StaleCommitError) Error() string {
+	return fmt.Sprintf("page %q was modified concurrently (expected commit %s, current commit %s)", e.Page.Title, e.Expected, e.Page.CommitID)
+}
+
+// Unwrap exposes the error as a commit conflict
+func (e *StaleCommitError) Unwrap() error {
+	return mcperrors.NewScrapboxError(mcperrors.ErrCodeCommitConflict, e.Error(), nil)
+}
+
+// checkExpectedCommit fails with a StaleCommitError when expected is set and
+// differs from the page's current commit
+func checkExpectedCommit(page *Page, expected string) error {
+	if expected == "" || page.CommitID == expected {
+		return nil
+	}
+	return &StaleCommitError{Expected: expected, Page: page}
+}
@@ -0,0 +1,130 @@
+package scrapbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	mcperrors "github.com/hiroki/scrapbox_mcp/pkg/errors"
+)
+
+// Commit is one entry of a page's history
+type Commit struct {
+	ID        string `json:"id"`
+	ParentID  string `json:"parentId"`
+	ProjectID string `json:"projectId"`
+	PageID    string `json:"pageId"`
+	UserID    string `json:"userId"`
+	Kind      string `json:"kind"`
+	Created   int64  `json:"created"`
+	// Changes are the raw changes: _insert, _update, _delete, title, deleted
+	// and derived data such as links and descriptions
+	Changes []map[string]interface{} `json:"changes"`
+}
+
+// CommitsResponse represents the response from /api/commits/:project/:pageId
+type CommitsResponse struct {
+	Commits []Commit `json:"commits"`
+}
+
+// Line change types
+const (
+	LineInserted = "insert"
+	LineUpdated  = "update"
+	LineDeleted  = "delete"
+)
+
+// LineChange is a change to one line in a commit
+type LineChange struct {
+	Type   string
+	LineID string
+	// Text is the new text of inserted and updated lines
+	Text string
+	// Before is the line an inserted line was placed before, or "_end"
+	Before string
+}
+
+// LineChanges returns the line changes of the commit in order; title changes
+// and derived data are skipped
+func (c Commit) LineChanges() []LineChange {
+	var changes []LineChange
+	for _, change := range c.Changes {
+		lines, _ := change["lines"].(map[string]interface{})
+		text, _ := lines["text"].(string)
+		switch {
+		case change["_insert"] != nil:
+			before, _ := change["_insert"].(string)
+			id, _ := lines["id"].(string)
+			changes = append(changes, LineChange{Type: LineInserted, LineID: id, Text: text, Before: before})
+		case change["_update"] != nil:
+			id, _ := change["_update"].(string)
+			changes = append(changes, LineChange{Type: LineUpdated, LineID: id, Text: text})
+		case change["_delete"] != nil:
+			id, _ := change["_delete"].(string)
+			changes = append(changes, LineChange{Type: LineDeleted, LineID: id})
+		}
+	}
+	return changes
+}
+
+// Title returns the title the commit gave the page, if it changed it
+func (c Commit) Title() (string, bool) {
+	for _, change := range c.Changes {
+		if title, ok := change["title"].(string); ok {
+			return title, true
+		}
+	}
+	return "", false
+}
+
+// Deleted reports whether the commit deleted the page
+func (c Commit) Deleted() bool {
+	for _, change := range c.Changes {
+		if change["deleted"] == true {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPageCommits retrieves the commit history of a page, oldest first
+func (c *RESTClient) GetPageCommits(project, pageID string) ([]Commit, error) {
+	endpoint := fmt.Sprintf("%s/commits/%s/%s", c.baseURLFor(project), project, url.PathEscape(pageID))
+
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, "Failed to create request", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, "Failed to fetch commits", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, mcperrors.NewScrapboxStatusError(mcperrors.ErrCodeNotFound, fmt.Sprintf("Page not found: %s", pageID), resp.StatusCode)
+	}
+	if err := checkResponseStatus(resp); err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, "Failed to read response", err)
+	}
+
+	var commitsResp CommitsResponse
+	if err := json.Unmarshal(body, &commitsResp); err != nil {
+		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, "Failed to parse response", err)
+	}
+
+	return commitsResp.Commits, nil
+}
+
+// GetPageCommits retrieves the commit history of a page via the REST API
+func (c *Client) GetPageCommits(project, pageID string) ([]Commit, error) {
+	return c.RESTClient.GetPageCommits(project, pageID)
+}
@@ -0,0 +1,58 @@
+package scrapbox
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// CredentialProvider supplies a fresh session cookie when the current one has expired.
+// Implementations may re-read a mounted secret, call an external script, etc.
+type CredentialProvider interface {
+	SessionCookie(ctx context.Context) (string, error)
+}
+
+// FileCredentialProvider re-reads the session cookie from a file, such as a
+// Kubernetes secret that is rotated in place
+type FileCredentialProvider struct {
+	path string
+}
+
+// NewFileCredentialProvider creates a provider reading the cookie from path
+func NewFileCredentialProvider(path string) *FileCredentialProvider {
+	return &FileCredentialProvider{path: path}
+}
+
+// SessionCookie returns the trimmed contents of the file
+func (p *FileCredentialProvider) SessionCookie(ctx context.Context) (string, error) {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read session cookie file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// CommandCredentialProvider runs a shell command that prints a fresh session cookie to stdout
+type CommandCredentialProvider struct {
+	command string
+}
+
+// NewCommandCredentialProvider creates a provider running command with sh -c
+func NewCommandCredentialProvider(command string) *CommandCredentialProvider {
+	return &CommandCredentialProvider{command: command}
+}
+
+// SessionCookie runs the command and returns its trimmed output
+func (p *CommandCredentialProvider) SessionCookie(ctx context.Context) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", p.command)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("session cookie refresh command failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,54 @@
+package scrapbox
+
+import (
+	"errors"
+	"fmt"
+
+	mcperrors "github.com/hiroki/scrapbox_mcp/pkg/errors"
+)
+
+// Credentials are a project and the connect.sid session cookie to access it
+// with, e.g. supplied by an MCP client for its session
+type Credentials struct {
+	Project       string `json:"project"`
+	SessionCookie string `json:"sid"`
+}
+
+// CredentialsInfo describes the user and project the configured credentials resolve to
+type CredentialsInfo struct {
+	User    string `json:"user"`
+	Project string `json:"project"`
+}
+
+// ValidateCredentials checks that the session cookie belongs to a logged-in user
+// and that the configured project exists and is accessible to that user.
+// The returned errors explain which setting needs to be fixed.
+func (c *Client) ValidateCredentials() (*CredentialsInfo, error) {
+	user, err := c.RESTClient.GetMe()
+	if err != nil {
+		return nil, err
+	}
+	// /users/me answers 200 with a guest user when the cookie is missing or expired
+	if user.IsGuest || user.ID == "" {
+		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeSessionExpired,
+			"Session cookie is invalid or expired; set COSENSE_SID to a fresh connect.sid cookie", nil)
+	}
+
+	project, err := c.RESTClient.GetProject(c.ProjectName)
+	if err != nil {
+		var sbErr *mcperrors.ScrapboxError
+		if errors.As(err, &sbErr) {
+			switch sbErr.Code {
+			case mcperrors.ErrCodeNotFound:
+				return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNotFound,
+					fmt.Sprintf("Project %q not found; check COSENSE_PROJECT_NAME", c.ProjectName), nil)
+			case mcperrors.ErrCodeAuthFailed:
+				return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodePermissionDenied,
+					fmt.Sprintf("User %q cannot access project %q; join the project or use another account's cookie", user.Name, c.ProjectName), nil)
+			}
+		}
+		return nil, err
+	}
+
+	return &CredentialsInfo{User: user.Name, Project: project.Name}, nil
+}
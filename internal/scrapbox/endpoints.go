@@ -0,0 +1,94 @@
+package scrapbox
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ParseProjectURLs parses project=URL entries overriding the API or
+// WebSocket base URL of individual projects
+func ParseProjectURLs(entries []string) (map[string]string, error) {
+	urls := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		project, rawURL, ok := strings.Cut(entry, "=")
+		project, rawURL = strings.TrimSpace(project), strings.TrimSpace(rawURL)
+		if !ok || project == "" || rawURL == "" {
+			return nil, fmt.Errorf("invalid project URL %q (expected project=URL)", entry)
+		}
+		u, err := url.Parse(rawURL)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid project URL %q: not an absolute URL", entry)
+		}
+		urls[project] = strings.TrimSuffix(rawURL, "/")
+	}
+	return urls, nil
+}
+
+// webSocketURLFor derives the Socket.IO URL of the instance serving apiURL,
+// e.g. https://cosense.example.com/api -> wss://cosense.example.com/socket.io/
+func webSocketURLFor(apiURL string) string {
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		return ""
+	}
+	if u.Scheme == "http" {
+		u.Scheme = "ws"
+	} else {
+		u.Scheme = "wss"
+	}
+	u.Path = strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), "/api") + "/socket.io/"
+	return u.String()
+}
+
+// SetProjectEndpoints serves the projects in apiURLs from their own API base
+// URL, and in wsURLs from their own WebSocket URL. A project with only an API
+// URL uses the Socket.IO endpoint of the same host. The session cookie belongs
+// to the default project's instance and is only sent there.
+func (c *Client) SetProjectEndpoints(apiURLs, wsURLs map[string]string) {
+	c.RESTClient.setProjectURLs(apiURLs, c.ProjectName)
+
+	c.wsURLs = make(map[string]string, len(apiURLs)+len(wsURLs))
+	for project, apiURL := range apiURLs {
+		c.wsURLs[project] = webSocketURLFor(apiURL)
+	}
+	for project, wsURL := range wsURLs {
+		c.wsURLs[project] = wsURL
+	}
+}
+
+// webSocketURL returns the WebSocket URL of the default project, or fallback
+func (c *Client) webSocketURL(fallback string) string {
+	if wsURL, ok := c.wsURLs[c.ProjectName]; ok {
+		return wsURL
+	}
+	return fallback
+}
+
+// setProjectURLs sets the per-project API base URLs. The session cookie
+// belongs to the instance serving defaultProject.
+func (c *RESTClient) setProjectURLs(urls map[string]string, defaultProject string) {
+	c.projectURLs = urls
+	c.sessionProject = defaultProject
+}
+
+// baseURLFor returns the API base URL serving project
+func (c *RESTClient) baseURLFor(project string) string {
+	if baseURL, ok := c.projectURLs[project]; ok {
+		return baseURL
+	}
+	return c.baseURL
+}
+
+// sessionBaseURL returns the API base URL of the instance that issued the
+// session cookie, used for project-independent requests such as /users/me
+func (c *RESTClient) sessionBaseURL() string {
+	return c.baseURLFor(c.sessionProject)
+}
+
+// authenticates reports whether the session cookie is sent to u: only the
+// session's instance issued it, so other hosts are accessed anonymously
+func (c *RESTClient) authenticates(u *url.URL) bool {
+	base, err := url.Parse(c.sessionBaseURL())
+	return err == nil && strings.EqualFold(base.Host, u.Host)
+}
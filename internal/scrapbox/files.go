@@ -0,0 +1,138 @@
+package scrapbox
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	mcperrors "github.com/hiroki/scrapbox_mcp/pkg/errors"
+)
+
+// gcsUploadRequest is the body of /api/gcs/:projectId/upload-request
+type gcsUploadRequest struct {
+	Size        int    `json:"size"`
+	ContentType string `json:"contentType"`
+	Name        string `json:"name"`
+	MD5         string `json:"md5"`
+}
+
+// gcsUploadResponse is either a signed URL for a new file or the embed URL of an
+// identical file that was uploaded before
+type gcsUploadResponse struct {
+	SignedURL string `json:"signedUrl"`
+	FileID    string `json:"fileId"`
+	EmbedURL  string `json:"embedUrl"`
+}
+
+// gcsFile is the response of /api/gcs/:projectId/verify
+type gcsFile struct {
+	EmbedURL string `json:"embedUrl"`
+}
+
+// FileUploader uploads files to the Scrapbox file storage of the client's default project
+type FileUploader struct {
+	client *Client
+}
+
+// NewFileUploader creates a FileUploader for client
+func NewFileUploader(client *Client) *FileUploader {
+	return &FileUploader{client: client}
+}
+
+// Upload stores a file in the project's file storage and returns its embeddable URL
+func (u *FileUploader) Upload(ctx context.Context, name, contentType string, data []byte) (string, error) {
+	rest := u.client.RESTClient
+
+	user, err := rest.GetMe()
+	if err != nil {
+		return "", err
+	}
+	project, err := rest.GetProject(u.client.ProjectName)
+	if err != nil {
+		return "", err
+	}
+
+	sum := md5.Sum(data)
+	var uploadResp gcsUploadResponse
+	err = rest.postJSON(ctx, fmt.Sprintf("%s/gcs/%s/upload-request", rest.baseURLFor(u.client.ProjectName), project.ID), user.CsrfToken, gcsUploadRequest{
+		Size:        len(data),
+		ContentType: contentType,
+		Name:        name,
+		MD5:         base64.StdEncoding.EncodeToString(sum[:]),
+	}, &uploadResp)
+	if err != nil {
+		return "", err
+	}
+	if uploadResp.EmbedURL != "" {
+		return uploadResp.EmbedURL, nil
+	}
+
+	// Upload the content to the signed Cloud Storage URL
+	req, err := http.NewRequestWithContext(ctx, "PUT", uploadResp.SignedURL, bytes.NewReader(data))
+	if err != nil {
+		return "", mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, "Failed to create upload request", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := rest.httpClient.Do(req)
+	if err != nil {
+		return "", mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, "Failed to upload file", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, fmt.Sprintf("File upload failed with status code: %d", resp.StatusCode), nil)
+	}
+
+	var file gcsFile
+	err = rest.postJSON(ctx, fmt.Sprintf("%s/gcs/%s/verify", rest.baseURLFor(u.client.ProjectName), project.ID), user.CsrfToken, map[string]interface{}{
+		"uploaded": true,
+		"fileId":   uploadResp.FileID,
+	}, &file)
+	if err != nil {
+		return "", err
+	}
+	return file.EmbedURL, nil
+}
+
+// String describes the upload destination
+func (u *FileUploader) String() string {
+	return "Scrapbox files"
+}
+
+// postJSON sends an authenticated JSON POST with a CSRF token and decodes the response into out
+func (c *RESTClient) postJSON(ctx context.Context, endpoint, csrfToken string, in, out interface{}) error {
+	payload, err := json.Marshal(in)
+	if err != nil {
+		return mcperrors.NewScrapboxError(mcperrors.ErrCodeInvalidInput, "Failed to marshal request", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, "Failed to create request", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-CSRF-TOKEN", csrfToken)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, "Failed to send request", err)
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponseStatus(resp); err != nil {
+		return err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, "Failed to read response", err)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, "Failed to parse response", err)
+	}
+	return nil
+}
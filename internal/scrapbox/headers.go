@@ -0,0 +1,46 @@
+package scrapbox
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// reservedHeaders are set by the client itself and cannot be configured
+var reservedHeaders = map[string]bool{
+	"Cookie":                   true,
+	"Host":                     true,
+	"Connection":               true,
+	"Upgrade":                  true,
+	"Sec-Websocket-Key":        true,
+	"Sec-Websocket-Version":    true,
+	"Sec-Websocket-Extensions": true,
+	"Sec-Websocket-Protocol":   true,
+}
+
+// ParseHeaders parses Name=value entries into extra headers for upstream
+// requests. The same header may be given more than once.
+func ParseHeaders(entries []string) (http.Header, error) {
+	headers := http.Header{}
+	for _, entry := range entries {
+		name, value, ok := strings.Cut(entry, "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || !httpguts.ValidHeaderFieldName(name) || !httpguts.ValidHeaderFieldValue(value) {
+			return nil, fmt.Errorf("invalid header %q (expected Name=value)", entry)
+		}
+		if reservedHeaders[http.CanonicalHeaderKey(name)] {
+			return nil, fmt.Errorf("header %s cannot be configured", name)
+		}
+		headers.Add(name, value)
+	}
+	return headers, nil
+}
+
+// addHeaders sets every header in extra on dst, replacing existing values
+func addHeaders(dst, extra http.Header) {
+	for name, values := range extra {
+		dst[name] = append([]string(nil), values...)
+	}
+}
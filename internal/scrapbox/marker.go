@@ -0,0 +1,37 @@
+package scrapbox
+
+// LineMarker rewrites the lines of a page about to be written. previous is
+// the current content (nil for a new page) and texts the new content, title
+// line included. It is used to attribute the lines the server writes.
+type LineMarker func(previous, texts []string) []string
+
+// SetLineMarker passes the content of every write through marker (nil for none)
+func (c *Client) SetLineMarker(marker LineMarker) {
+	c.lineMarker = marker
+}
+
+// markLines applies the line marker to the new content of page (nil for a new page)
+func (c *Client) markLines(page *Page, texts []string) []string {
+	if c.lineMarker == nil {
+		return texts
+	}
+	var previous []string
+	if page != nil {
+		previous = make([]string, len(page.Lines))
+		for i, line := range page.Lines {
+			previous[i] = line.Text
+		}
+	}
+	return c.lineMarker(previous, texts)
+}
+
+// WithoutLineMarker returns a client sharing c's connections and write queue
+// that writes content unchanged, e.g. to restore earlier content
+func (c *Client) WithoutLineMarker() *Client {
+	if c.lineMarker == nil {
+		return c
+	}
+	unmarked := *c
+	unmarked.lineMarker = nil
+	return &unmarked
+}
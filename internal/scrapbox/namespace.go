@@ -0,0 +1,74 @@
+package scrapbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hiroki/scrapbox_mcp/pkg/sio"
+)
+
+// ParseNamespace validates a Socket.IO namespace; empty means the default "/"
+func ParseNamespace(namespace string) (string, error) {
+	namespace = strings.TrimSpace(namespace)
+	if namespace == "" {
+		return sio.DefaultNamespace, nil
+	}
+	if !strings.HasPrefix(namespace, "/") || strings.ContainsAny(namespace, ", \t?#") {
+		return "", fmt.Errorf("invalid Socket.IO namespace %q (expected a path such as /cosense)", namespace)
+	}
+	return namespace, nil
+}
+
+// ParseSocketAuth validates the auth payload sent in the Socket.IO CONNECT
+// packet. It must be a JSON object; empty means no payload.
+func ParseSocketAuth(auth string) (json.RawMessage, error) {
+	auth = strings.TrimSpace(auth)
+	if auth == "" {
+		return nil, nil
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(auth), &object); err != nil {
+		return nil, fmt.Errorf("invalid Socket.IO auth payload (expected a JSON object): %v", err)
+	}
+	return json.RawMessage(auth), nil
+}
+
+// SetNamespace connects to namespace, sending auth (nil for none) in the
+// CONNECT packet of new connections
+func (c *Client) SetNamespace(namespace string, auth json.RawMessage) {
+	c.namespace = namespace
+	c.socketAuth = auth
+	if c.WebSocketClient != nil {
+		c.WebSocketClient.SetNamespace(namespace, auth)
+	}
+}
+
+// SetNamespace connects new connections to namespace, sending auth (nil for
+// none) in the CONNECT packet
+func (wsc *WebSocketClient) SetNamespace(namespace string, auth json.RawMessage) {
+	wsc.mu.Lock()
+	defer wsc.mu.Unlock()
+	if namespace == "" {
+		namespace = sio.DefaultNamespace
+	}
+	wsc.namespace = namespace
+	wsc.auth = auth
+}
+
+// packet returns a packet of type t in the connection's namespace
+func (wsc *WebSocketClient) packet(t sio.PacketType) *sio.Packet {
+	return &sio.Packet{Type: t, Namespace: wsc.namespace}
+}
+
+// connectErrorMessage returns the message of a CONNECT_ERROR packet
+// ({"message": "..."}), or its raw payload
+func connectErrorMessage(packet *sio.Packet) string {
+	var payload struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(packet.Data, &payload) == nil && payload.Message != "" {
+		return payload.Message
+	}
+	return string(packet.Data)
+}
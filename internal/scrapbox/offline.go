@@ -0,0 +1,317 @@
+package scrapbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	mcperrors "github.com/hiroki/scrapbox_mcp/pkg/errors"
+)
+
+// exportFile represents a project export (pages.json) downloaded from Scrapbox
+type exportFile struct {
+	Name  string       `json:"name"`
+	Pages []exportPage `json:"pages"`
+}
+
+// exportPage represents a page in a project export.
+// Lines are plain strings, or objects when exported with metadata.
+type exportPage struct {
+	ID      string            `json:"id"`
+	Title   string            `json:"title"`
+	Created int64             `json:"created"`
+	Updated int64             `json:"updated"`
+	Views   int               `json:"views"`
+	Lines   []json.RawMessage `json:"lines"`
+}
+
+// OfflineClient is a read-only backend that serves pages from a local project export
+type OfflineClient struct {
+	projectName string
+	pages       []*Page
+	byTitle     map[string]*Page
+}
+
+var _ Reader = (*OfflineClient)(nil)
+
+// NewOfflineClient loads a project export file.
+// If projectName is empty, the project name recorded in the export is used.
+func NewOfflineClient(path, projectName string) (*OfflineClient, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export file: %w", err)
+	}
+
+	var export exportFile
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("failed to parse export file: %w", err)
+	}
+
+	if projectName == "" {
+		projectName = export.Name
+	}
+
+	c := &OfflineClient{
+		projectName: projectName,
+		pages:       make([]*Page, 0, len(export.Pages)),
+		byTitle:     make(map[string]*Page, len(export.Pages)),
+	}
+
+	for _, ep := range export.Pages {
+		page := &Page{
+			ID:      ep.ID,
+			Title:   ep.Title,
+			Views:   ep.Views,
+			Created: ep.Created,
+			Updated: ep.Updated,
+			Lines:   make([]Line, 0, len(ep.Lines)),
+		}
+		for _, raw := range ep.Lines {
+			var line Line
+			var text string
+			if err := json.Unmarshal(raw, &text); err == nil {
+				line.Text = text
+			} else if err := json.Unmarshal(raw, &line); err != nil {
+				return nil, fmt.Errorf("failed to parse lines of page %q: %w", ep.Title, err)
+			}
+			page.Lines = append(page.Lines, line)
+		}
+		page.Descriptions = pageDescriptions(page.Lines)
+
+		c.pages = append(c.pages, page)
+		c.byTitle[normalizeTitle(page.Title)] = page
+	}
+
+	// Match the live API's default order: most recently updated first
+	sort.SliceStable(c.pages, func(i, j int) bool {
+		return c.pages[i].Updated > c.pages[j].Updated
+	})
+
+	return c, nil
+}
+
+// DefaultProject returns the project name of the export
+func (c *OfflineClient) DefaultProject() string {
+	return c.projectName
+}
+
+// PageCount returns the number of pages loaded from the export
+func (c *OfflineClient) PageCount() int {
+	return len(c.pages)
+}
+
+// GetPage returns the page with the given title
+func (c *OfflineClient) GetPage(project, title string) (*Page, error) {
+	if err := c.checkProject(project); err != nil {
+		return nil, err
+	}
+
+	page, ok := c.byTitle[normalizeTitle(title)]
+	if !ok {
+		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNotFound, fmt.Sprintf("Page not found: %s", title), nil)
+	}
+	// Callers may modify the page, so they get their own copy
+	return clonePage(page), nil
+}
+
+// clonePage returns a copy of page sharing no slices or pointers with it
+func clonePage(page *Page) *Page {
+	c := *page
+	c.Descriptions = slices.Clone(page.Descriptions)
+	c.Lines = slices.Clone(page.Lines)
+	c.Collaborators = slices.Clone(page.Collaborators)
+	if page.LastUpdateUser != nil {
+		user := *page.LastUpdateUser
+		c.LastUpdateUser = &user
+	}
+	return &c
+}
+
+// ListPages returns pages ordered by last update
+func (c *OfflineClient) ListPages(project string, limit, skip int) (*PagesResponse, error) {
+	if err := c.checkProject(project); err != nil {
+		return nil, err
+	}
+
+	resp := &PagesResponse{
+		ProjectName: c.projectName,
+		Skip:        skip,
+		Limit:       limit,
+		Count:       len(c.pages),
+		Pages:       []PageInfo{},
+	}
+
+	for i := skip; i < len(c.pages) && (limit <= 0 || len(resp.Pages) < limit); i++ {
+		resp.Pages = append(resp.Pages, pageInfo(c.pages[i]))
+	}
+	return resp, nil
+}
+
+// SearchPages returns pages containing every query word and none of the
+// excluded (-word) terms, matched case-insensitively against title and lines.
+func (c *OfflineClient) SearchPages(project, query string, limit, skip int) (*SearchResponse, error) {
+	if err := c.checkProject(project); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+
+	parsed := ParseSearchQuery(query)
+	words, excludes := parsed.Words, parsed.Excludes
+
+	resp := &SearchResponse{
+		ProjectName: c.projectName,
+		SearchQuery: query,
+		Limit:       limit,
+		Skip:        skip,
+		Pages:       []SearchPageInfo{},
+		Query:       SearchQuery{Words: words, Excludes: excludes},
+		Backend:     "offline",
+	}
+
+	for _, page := range c.pages {
+		text := strings.ToLower(pageText(page))
+		if !containsAll(text, words) || containsAny(text, excludes) {
+			continue
+		}
+		if strings.EqualFold(page.Title, query) {
+			resp.ExistsExactTitleMatch = true
+		}
+
+		resp.Count++
+		if resp.Count <= skip || len(resp.Pages) >= limit {
+			continue
+		}
+
+		info := SearchPageInfo{
+			ID:    page.ID,
+			Title: page.Title,
+			Words: words,
+		}
+		for _, line := range page.Lines {
+			if containsAny(strings.ToLower(line.Text), words) {
+				info.Lines = append(info.Lines, line.Text)
+			}
+		}
+		resp.Pages = append(resp.Pages, info)
+	}
+
+	return resp, nil
+}
+
+// checkProject rejects requests for projects other than the exported one
+func (c *OfflineClient) checkProject(project string) error {
+	if project != "" && project != c.projectName {
+		return mcperrors.NewScrapboxError(mcperrors.ErrCodeNotFound, fmt.Sprintf("Project not available offline: %s", project), nil)
+	}
+	return nil
+}
+
+// normalizeTitle applies Scrapbox title matching rules: case-insensitive,
+// with spaces and underscores treated as equivalent
+func normalizeTitle(title string) string {
+	return strings.ToLower(strings.ReplaceAll(title, " ", "_"))
+}
+
+// pageDescriptions returns the first few non-empty body lines, like the live API
+func pageDescriptions(lines []Line) []string {
+	var descriptions []string
+	for i := 1; i < len(lines) && len(descriptions) < 5; i++ {
+		if strings.TrimSpace(lines[i].Text) != "" {
+			descriptions = append(descriptions, lines[i].Text)
+		}
+	}
+	return descriptions
+}
+
+func pageInfo(page *Page) PageInfo {
+	return PageInfo{
+		ID:           page.ID,
+		Title:        page.Title,
+		Image:        page.Image,
+		Descriptions: slices.Clone(page.Descriptions),
+		Views:        page.Views,
+		Created:      page.Created,
+		Updated:      page.Updated,
+	}
+}
+
+// ParseSearchQuery splits a search query the way Scrapbox does, lower-cased:
+// terms are separated by spaces, a "quoted phrase" is one term and a leading
+// '-' excludes a term (-word or -"a phrase").
+func ParseSearchQuery(query string) SearchQuery {
+	parsed := SearchQuery{Words: []string{}, Excludes: []string{}}
+	rest := strings.ToLower(query)
+	for {
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+		if rest == "" {
+			return parsed
+		}
+		exclude := false
+		if strings.HasPrefix(rest, "-") {
+			// A lone "-" is a word
+			if next, _ := utf8.DecodeRuneInString(rest[1:]); next != utf8.RuneError && !unicode.IsSpace(next) {
+				exclude = true
+				rest = rest[1:]
+			}
+		}
+
+		var term string
+		if rest[0] == '"' {
+			// A phrase runs to the closing quote, or to the end if there is none
+			var ok bool
+			term, rest, ok = strings.Cut(rest[1:], `"`)
+			if !ok {
+				rest = ""
+			}
+		} else {
+			end := strings.IndexFunc(rest, unicode.IsSpace)
+			if end < 0 {
+				end = len(rest)
+			}
+			term, rest = rest[:end], rest[end:]
+		}
+		if term == "" {
+			continue
+		}
+		if exclude {
+			parsed.Excludes = append(parsed.Excludes, term)
+		} else {
+			parsed.Words = append(parsed.Words, term)
+		}
+	}
+}
+
+func pageText(page *Page) string {
+	var b strings.Builder
+	b.WriteString(page.Title)
+	for _, line := range page.Lines {
+		b.WriteByte('\n')
+		b.WriteString(line.Text)
+	}
+	return b.String()
+}
+
+func containsAll(text string, words []string) bool {
+	for _, w := range words {
+		if !strings.Contains(text, w) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsAny(text string, words []string) bool {
+	for _, w := range words {
+		if strings.Contains(text, w) {
+			return true
+		}
+	}
+	return false
+}
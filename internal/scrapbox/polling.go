@@ -0,0 +1,325 @@
+package scrapbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	mcperrors "github.com/hiroki/scrapbox_mcp/pkg/errors"
+	"github.com/hiroki/scrapbox_mcp/pkg/sio"
+)
+
+// Transport selects how the Socket.IO connection reaches Scrapbox
+type Transport string
+
+const (
+	// TransportAuto uses WebSocket and falls back to long-polling when the
+	// WebSocket upgrade fails (e.g. behind proxies that block it)
+	TransportAuto      Transport = "auto"
+	TransportWebSocket Transport = "websocket"
+	TransportPolling   Transport = "polling"
+)
+
+// ParseTransport validates a transport name; empty means auto
+func ParseTransport(transport string) (Transport, error) {
+	switch t := Transport(strings.ToLower(strings.TrimSpace(transport))); t {
+	case "":
+		return TransportAuto, nil
+	case TransportAuto, TransportWebSocket, TransportPolling:
+		return t, nil
+	}
+	return "", fmt.Errorf("invalid Socket.IO transport %q (expected auto, websocket or polling)", transport)
+}
+
+// SetTransport selects the transport of new connections
+func (c *Client) SetTransport(transport Transport) {
+	c.transport = transport
+	if c.WebSocketClient != nil {
+		c.WebSocketClient.SetTransport(transport)
+	}
+}
+
+// SetTransport selects the transport of new connections
+func (wsc *WebSocketClient) SetTransport(transport Transport) {
+	wsc.mu.Lock()
+	defer wsc.mu.Unlock()
+	wsc.transport = transport
+	wsc.retryWebSocket, wsc.fallbackBackoff = time.Time{}, 0
+}
+
+// Transport returns the transport of the current or last connection
+func (wsc *WebSocketClient) Transport() Transport {
+	wsc.mu.Lock()
+	defer wsc.mu.Unlock()
+	if _, ok := wsc.conn.(*pollingConn); ok {
+		return TransportPolling
+	}
+	return TransportWebSocket
+}
+
+// frameConn is an Engine.IO connection; *websocket.Conn and *pollingConn
+// implement it
+type frameConn interface {
+	ReadMessage() (messageType int, data []byte, err error)
+	WriteMessage(messageType int, data []byte) error
+	WriteControl(messageType int, data []byte, deadline time.Time) error
+	SetReadDeadline(t time.Time) error
+	SetPongHandler(h func(appData string) error)
+	Close() error
+}
+
+// Backoff before auto mode retries WebSocket after falling back to polling;
+// it doubles with each failed retry
+const (
+	minWebSocketRetry = time.Minute
+	maxWebSocketRetry = 30 * time.Minute
+)
+
+// dial opens the Engine.IO connection for u (without the transport parameter).
+// In auto mode a failed WebSocket dial falls back to long-polling, and
+// connections made within the backoff after that keep using polling; the
+// first one after it tries WebSocket again. The caller must hold wsc.mu.
+func (wsc *WebSocketClient) dial(u *url.URL, header http.Header) (frameConn, error) {
+	if wsc.transport != TransportPolling && !time.Now().Before(wsc.retryWebSocket) {
+		conn, _, err := newDialer(wsc.proxy).Dial(withTransport(u, "websocket").String(), header)
+		if err == nil {
+			if !wsc.retryWebSocket.IsZero() {
+				log.Printf("[WS] WebSocket connection restored; leaving HTTP long-polling")
+			}
+			wsc.retryWebSocket, wsc.fallbackBackoff = time.Time{}, 0
+			return conn, nil
+		}
+		if wsc.transport == TransportWebSocket {
+			return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeWebSocketFail, "Failed to connect to WebSocket", err)
+		}
+		wsc.fallbackBackoff = min(max(2*wsc.fallbackBackoff, minWebSocketRetry), maxWebSocketRetry)
+		wsc.retryWebSocket = time.Now().Add(wsc.fallbackBackoff)
+		log.Printf("[WS] WebSocket connection failed (%v); falling back to HTTP long-polling, retrying WebSocket in %s", err, wsc.fallbackBackoff)
+	}
+
+	conn, err := dialPolling(withTransport(u, "polling"), header, wsc.proxy)
+	if err != nil {
+		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeWebSocketFail, "Failed to connect with HTTP long-polling", err)
+	}
+	return conn, nil
+}
+
+// withTransport returns a copy of u with the Engine.IO transport parameter
+func withTransport(u *url.URL, transport string) *url.URL {
+	c := *u
+	q := c.Query()
+	q.Set("transport", transport)
+	c.RawQuery = q.Encode()
+	return &c
+}
+
+// pollingPayloadSeparator separates the packets of a long-polling payload
+const pollingPayloadSeparator = "\x1e"
+
+// pollingConn is an Engine.IO v4 long-polling connection: frames from the
+// server are read with GET requests held open until there is data, frames to
+// the server are sent with POST requests. There are no control frames; the
+// Engine.IO ping/pong packets keep the session alive.
+type pollingConn struct {
+	client *http.Client
+	url    string
+	header http.Header
+
+	// queue holds the frames of the last poll that have not been read yet
+	queue [][]byte
+
+	// writes are serialized; Engine.IO allows one POST at a time
+	writeMu sync.Mutex
+
+	mu       sync.Mutex
+	deadline time.Time
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	closeOnce sync.Once
+}
+
+// dialPolling performs the Engine.IO handshake over long-polling. The open
+// packet is returned by the first ReadMessage, as on a WebSocket.
+func dialPolling(u *url.URL, header http.Header, proxy ProxyFunc) (*pollingConn, error) {
+	pu := *u
+	switch pu.Scheme {
+	case "ws":
+		pu.Scheme = "http"
+	case "wss":
+		pu.Scheme = "https"
+	}
+
+	// The jar keeps load balancer affinity cookies for the session
+	jar, _ := cookiejar.New(nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	pc := &pollingConn{
+		client: &http.Client{Transport: newHTTPTransport(proxy), Jar: jar},
+		url:    pu.String(),
+		header: header,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	handshakeCtx, handshakeCancel := context.WithTimeout(ctx, 45*time.Second)
+	defer handshakeCancel()
+	frames, err := pc.poll(handshakeCtx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	params, err := sio.DecodeOpen(frames[0])
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	q := pu.Query()
+	q.Set("sid", params.SID)
+	pu.RawQuery = q.Encode()
+	pc.url = pu.String()
+	pc.queue = frames
+	return pc, nil
+}
+
+// ReadMessage returns the next frame, polling the server when none is queued
+func (pc *pollingConn) ReadMessage() (int, []byte, error) {
+	for len(pc.queue) == 0 {
+		ctx, cancel := pc.pollContext()
+		frames, err := pc.poll(ctx)
+		cancel()
+		if err != nil {
+			return 0, nil, err
+		}
+		pc.queue = frames
+	}
+
+	frame := pc.queue[0]
+	pc.queue = pc.queue[1:]
+	// Binary frames are base64 encoded with a "b" prefix
+	if len(frame) > 0 && frame[0] == 'b' {
+		data, err := base64.StdEncoding.DecodeString(string(frame[1:]))
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid binary frame: %v", err)
+		}
+		return websocket.BinaryMessage, data, nil
+	}
+	return websocket.TextMessage, frame, nil
+}
+
+// pollContext bounds a poll by the read deadline
+func (pc *pollingConn) pollContext() (context.Context, context.CancelFunc) {
+	pc.mu.Lock()
+	deadline := pc.deadline
+	pc.mu.Unlock()
+	if deadline.IsZero() {
+		return context.WithCancel(pc.ctx)
+	}
+	return context.WithDeadline(pc.ctx, deadline)
+}
+
+// poll performs one GET request and returns the frames of its payload
+func (pc *pollingConn) poll(ctx context.Context) ([][]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pc.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	body, err := pc.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var frames [][]byte
+	for _, frame := range bytes.Split(body, []byte(pollingPayloadSeparator)) {
+		if len(frame) > 0 {
+			frames = append(frames, frame)
+		}
+	}
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("empty polling payload")
+	}
+	return frames, nil
+}
+
+// WriteMessage sends one frame with a POST request
+func (pc *pollingConn) WriteMessage(messageType int, data []byte) error {
+	if messageType == websocket.BinaryMessage {
+		data = append([]byte("b"), base64.StdEncoding.EncodeToString(data)...)
+	}
+
+	pc.writeMu.Lock()
+	defer pc.writeMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(pc.ctx, 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pc.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain;charset=UTF-8")
+	_, err = pc.do(req)
+	return err
+}
+
+// do sends req with the handshake headers and returns the response body
+func (pc *pollingConn) do(req *http.Request) ([]byte, error) {
+	addHeaders(req.Header, pc.header)
+	resp, err := pc.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		// e.g. 400 {"code":1,"message":"Session ID unknown"} after the server dropped the session
+		return nil, fmt.Errorf("polling request failed: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// WriteControl is a no-op; long-polling has no control frames
+func (pc *pollingConn) WriteControl(messageType int, data []byte, deadline time.Time) error {
+	return nil
+}
+
+// SetPongHandler is a no-op; long-polling has no control frames
+func (pc *pollingConn) SetPongHandler(h func(appData string) error) {}
+
+// SetReadDeadline bounds the polls of following ReadMessage calls
+func (pc *pollingConn) SetReadDeadline(t time.Time) error {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.deadline = t
+	return nil
+}
+
+// Close aborts a pending poll and tells the server to close the session
+func (pc *pollingConn) Close() error {
+	pc.closeOnce.Do(func() {
+		go func() {
+			// Best effort, so closing never waits for the server
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, pc.url, bytes.NewReader(sio.CloseFrame))
+			if err == nil {
+				pc.do(req)
+			}
+		}()
+		pc.cancel()
+	})
+	return nil
+}
@@ -0,0 +1,70 @@
+package scrapbox
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// ProxyFunc selects the proxy for a request to Scrapbox, as used by
+// http.Transport and websocket.Dialer
+type ProxyFunc func(*http.Request) (*url.URL, error)
+
+// NewProxyFunc returns the proxy selection for upstream connections. An empty
+// proxyURL uses HTTPS_PROXY/HTTP_PROXY/NO_PROXY from the environment; otherwise
+// every request goes through proxyURL except hosts listed in NO_PROXY.
+func NewProxyFunc(proxyURL string) (ProxyFunc, error) {
+	if proxyURL == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %v", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid proxy URL %q: scheme must be http, https or socks5", u.Redacted())
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: missing host", u.Redacted())
+	}
+
+	noProxy := os.Getenv("NO_PROXY")
+	if noProxy == "" {
+		noProxy = os.Getenv("no_proxy")
+	}
+	proxy := (&httpproxy.Config{
+		HTTPProxy:  u.String(),
+		HTTPSProxy: u.String(),
+		NoProxy:    noProxy,
+	}).ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxy(req.URL)
+	}, nil
+}
+
+// newHTTPTransport returns the default transport routed through proxy
+func newHTTPTransport(proxy ProxyFunc) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	return transport
+}
+
+// newDialer returns the WebSocket dialer routed through proxy. gorilla's
+// dialer only uses a proxy when one is set explicitly.
+func newDialer(proxy ProxyFunc) *websocket.Dialer {
+	if proxy == nil {
+		proxy = http.ProxyFromEnvironment
+	}
+	return &websocket.Dialer{
+		Proxy:            proxy,
+		HandshakeTimeout: 45 * time.Second,
+	}
+}
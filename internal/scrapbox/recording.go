@@ -0,0 +1,79 @@
+package scrapbox
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// Recorder receives copies of the traffic exchanged with Scrapbox, for debug
+// captures. Implementations are responsible for redacting secrets.
+type Recorder interface {
+	// RecordHTTP records a REST request and its response (nil with err on failure)
+	RecordHTTP(req *http.Request, requestBody []byte, resp *http.Response, responseBody []byte, err error)
+	// RecordFrame records a WebSocket text frame; direction is "send" or "receive"
+	// and connID distinguishes the connections of one process
+	RecordFrame(connID int64, direction string, data []byte)
+}
+
+// Frame directions passed to Recorder.RecordFrame
+const (
+	FrameSend    = "send"
+	FrameReceive = "receive"
+)
+
+// lastConnID numbers WebSocket connections for recordings
+var lastConnID int64
+
+// SetRecorder records REST requests and WebSocket frames to recorder
+func (c *Client) SetRecorder(recorder Recorder) {
+	c.recorder = recorder
+	c.RESTClient.recorder = recorder
+	if c.WebSocketClient != nil {
+		c.WebSocketClient.SetRecorder(recorder)
+	}
+}
+
+// SetRecorder records the frames of new connections to recorder
+func (wsc *WebSocketClient) SetRecorder(recorder Recorder) {
+	wsc.mu.Lock()
+	defer wsc.mu.Unlock()
+	wsc.recorder = recorder
+}
+
+// send performs req and records it when a recorder is set. The response body
+// is buffered so it can be recorded and still read by the caller.
+func (c *RESTClient) send(req *http.Request) (*http.Response, error) {
+	if c.recorder == nil {
+		return c.httpClient.Do(req)
+	}
+
+	var requestBody []byte
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			requestBody, _ = io.ReadAll(body)
+			body.Close()
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.recorder.RecordHTTP(req, requestBody, nil, nil, err)
+		return nil, err
+	}
+	responseBody, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+	c.recorder.RecordHTTP(req, requestBody, resp, responseBody, readErr)
+	return resp, nil
+}
+
+// recordFrame records a frame of the current connection. Engine.IO pings and
+// pongs are left out; they carry no data and depend on timing.
+func (wsc *WebSocketClient) recordFrame(direction string, data []byte) {
+	if wsc.recorder == nil || string(data) == "2" || string(data) == "3" {
+		return
+	}
+	wsc.recorder.RecordFrame(atomic.LoadInt64(&wsc.connID), direction, data)
+}
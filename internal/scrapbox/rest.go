@@ -1,11 +1,15 @@
 package scrapbox
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 
 	mcperrors "github.com/hiroki/scrapbox_mcp/pkg/errors"
@@ -16,6 +20,20 @@ type RESTClient struct {
 	baseURL    string
 	httpClient *http.Client
 	auth       *Auth
+	// Extra headers sent with every request (e.g. User-Agent)
+	headers http.Header
+	// Per-project API base URLs overriding baseURL, and the project whose
+	// instance issued the session cookie
+	projectURLs    map[string]string
+	sessionProject string
+
+	// Optional debug capture of requests
+	recorder Recorder
+
+	// Optional session cookie refresh on expiry
+	refreshMu sync.Mutex
+	provider  CredentialProvider
+	onRefresh func(sessionCookie string)
 }
 
 // NewRESTClient creates a new REST client
@@ -32,33 +50,131 @@ func NewRESTClient(baseURL, sessionCookie string, timeout time.Duration) *RESTCl
 // checkResponseStatus handles common HTTP status code errors
 func checkResponseStatus(resp *http.Response) error {
 	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
-		return mcperrors.NewScrapboxError(mcperrors.ErrCodeAuthFailed, "Authentication failed", nil)
+		if isSessionExpired(resp) {
+			return mcperrors.NewScrapboxStatusError(mcperrors.ErrCodeSessionExpired, "Session cookie expired; set COSENSE_SID to a fresh connect.sid cookie", resp.StatusCode)
+		}
+		return mcperrors.NewScrapboxStatusError(mcperrors.ErrCodeAuthFailed, "Authentication failed", resp.StatusCode)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return mcperrors.NewScrapboxStatusError(mcperrors.ErrCodeRateLimit, "Too many requests", resp.StatusCode)
 	}
 	if resp.StatusCode != http.StatusOK {
-		return mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, fmt.Sprintf("Unexpected status code: %d", resp.StatusCode), nil)
+		return mcperrors.NewScrapboxStatusError(mcperrors.ErrCodeNetworkError, fmt.Sprintf("Unexpected status code: %d", resp.StatusCode), resp.StatusCode)
 	}
 	return nil
 }
 
+// isSessionExpired reports whether a 401/403 response means the session is no
+// longer logged in (as opposed to lacking permission). The body is left readable.
+func isSessionExpired(resp *http.Response) bool {
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	var apiErr struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(body, &apiErr); err == nil && apiErr.Name != "" {
+		return apiErr.Name == "NotLoggedInError"
+	}
+	return resp.StatusCode == http.StatusUnauthorized
+}
+
+// SetCredentialProvider enables refreshing the session cookie when a request fails
+// because it expired. onRefresh is called with each new cookie.
+func (c *RESTClient) SetCredentialProvider(provider CredentialProvider, onRefresh func(sessionCookie string)) {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	c.provider = provider
+	c.onRefresh = onRefresh
+}
+
+// do sends an authenticated request. If the session has expired and a credential
+// provider is configured, the cookie is refreshed and the request retried once.
+func (c *RESTClient) do(req *http.Request) (*http.Response, error) {
+	used := c.auth.SessionCookie()
+	addHeaders(req.Header, c.headers)
+	authenticated := c.authenticates(req.URL)
+	if authenticated {
+		c.auth.AddAuthHeaders(req)
+	}
+
+	resp, err := c.send(req)
+	if err != nil || !authenticated || (resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusForbidden) {
+		return resp, err
+	}
+	if !isSessionExpired(resp) || !c.refreshSessionCookie(req.Context(), used) {
+		return resp, nil
+	}
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		retry.Body = body
+	} else if req.Body != nil && req.Body != http.NoBody {
+		// The body was consumed and cannot be replayed
+		return resp, nil
+	}
+	retry.Header.Del("Cookie")
+	c.auth.AddAuthHeaders(retry)
+
+	resp.Body.Close()
+	return c.send(retry)
+}
+
+// refreshSessionCookie obtains a new cookie from the provider unless another
+// request already replaced the expired one. It reports whether a different
+// cookie is now in use.
+func (c *RESTClient) refreshSessionCookie(ctx context.Context, expired string) bool {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	if c.provider == nil {
+		return false
+	}
+	if current := c.auth.SessionCookie(); current != expired {
+		return true
+	}
+
+	cookie, err := c.provider.SessionCookie(ctx)
+	if err != nil {
+		log.Printf("[AUTH] Failed to refresh session cookie: %v", err)
+		return false
+	}
+	if cookie == "" || cookie == expired {
+		log.Printf("[AUTH] Session cookie expired and the credential provider returned no new cookie")
+		return false
+	}
+
+	mcperrors.RegisterSecret(cookie)
+	c.auth.SetSessionCookie(cookie)
+	if c.onRefresh != nil {
+		c.onRefresh(cookie)
+	}
+	log.Printf("[AUTH] Session cookie refreshed")
+	return true
+}
+
 // GetPage retrieves a page by title
 func (c *RESTClient) GetPage(project, title string) (*Page, error) {
-	endpoint := fmt.Sprintf("%s/pages/%s/%s", c.baseURL, project, url.PathEscape(title))
+	endpoint := fmt.Sprintf("%s/pages/%s/%s", c.baseURLFor(project), project, url.PathEscape(title))
 
 	req, err := http.NewRequest("GET", endpoint, nil)
 	if err != nil {
 		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, "Failed to create request", err)
 	}
 
-	c.auth.AddAuthHeaders(req)
-
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, "Failed to fetch page", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusNotFound {
-		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNotFound, fmt.Sprintf("Page not found: %s", title), nil)
+		return nil, mcperrors.NewScrapboxStatusError(mcperrors.ErrCodeNotFound, fmt.Sprintf("Page not found: %s", title), resp.StatusCode)
 	}
 	if err := checkResponseStatus(resp); err != nil {
 		return nil, err
@@ -79,16 +195,16 @@ func (c *RESTClient) GetPage(project, title string) (*Page, error) {
 
 // ListPages retrieves a list of pages
 func (c *RESTClient) ListPages(project string, limit, skip int) (*PagesResponse, error) {
-	endpoint := fmt.Sprintf("%s/pages/%s?limit=%d&skip=%d", c.baseURL, project, limit, skip)
+	// Most recently updated first (after pinned pages), which callers walking
+	// recent changes rely on
+	endpoint := fmt.Sprintf("%s/pages/%s?sort=updated&limit=%d&skip=%d", c.baseURLFor(project), project, limit, skip)
 
 	req, err := http.NewRequest("GET", endpoint, nil)
 	if err != nil {
 		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, "Failed to create request", err)
 	}
 
-	c.auth.AddAuthHeaders(req)
-
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, "Failed to list pages", err)
 	}
@@ -112,20 +228,21 @@ func (c *RESTClient) ListPages(project string, limit, skip int) (*PagesResponse,
 }
 
 // SearchPages searches for pages matching the query
-func (c *RESTClient) SearchPages(project, query string, limit int) (*SearchResponse, error) {
-	endpoint := fmt.Sprintf("%s/pages/%s/search/query?q=%s", c.baseURL, project, url.QueryEscape(query))
+func (c *RESTClient) SearchPages(project, query string, limit, skip int) (*SearchResponse, error) {
+	endpoint := fmt.Sprintf("%s/pages/%s/search/query?q=%s", c.baseURLFor(project), project, url.QueryEscape(query))
 	if limit > 0 {
 		endpoint += fmt.Sprintf("&limit=%d", limit)
 	}
+	if skip > 0 {
+		endpoint += fmt.Sprintf("&skip=%d", skip)
+	}
 
 	req, err := http.NewRequest("GET", endpoint, nil)
 	if err != nil {
 		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, "Failed to create request", err)
 	}
 
-	c.auth.AddAuthHeaders(req)
-
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, "Failed to search pages", err)
 	}
@@ -150,16 +267,14 @@ func (c *RESTClient) SearchPages(project, query string, limit int) (*SearchRespo
 
 // GetMe retrieves the current user information
 func (c *RESTClient) GetMe() (*User, error) {
-	endpoint := fmt.Sprintf("%s/users/me", c.baseURL)
+	endpoint := fmt.Sprintf("%s/users/me", c.sessionBaseURL())
 
 	req, err := http.NewRequest("GET", endpoint, nil)
 	if err != nil {
 		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, "Failed to create request", err)
 	}
 
-	c.auth.AddAuthHeaders(req)
-
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, "Failed to fetch user", err)
 	}
@@ -190,23 +305,21 @@ type ProjectInfo struct {
 
 // GetProject retrieves project information
 func (c *RESTClient) GetProject(projectName string) (*ProjectInfo, error) {
-	endpoint := fmt.Sprintf("%s/projects/%s", c.baseURL, projectName)
+	endpoint := fmt.Sprintf("%s/projects/%s", c.baseURLFor(projectName), projectName)
 
 	req, err := http.NewRequest("GET", endpoint, nil)
 	if err != nil {
 		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, "Failed to create request", err)
 	}
 
-	c.auth.AddAuthHeaders(req)
-
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, "Failed to fetch project", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusNotFound {
-		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNotFound, fmt.Sprintf("Project not found: %s", projectName), nil)
+		return nil, mcperrors.NewScrapboxStatusError(mcperrors.ErrCodeNotFound, fmt.Sprintf("Project not found: %s", projectName), resp.StatusCode)
 	}
 	if err := checkResponseStatus(resp); err != nil {
 		return nil, err
@@ -224,3 +337,38 @@ func (c *RESTClient) GetProject(projectName string) (*ProjectInfo, error) {
 
 	return &projectInfo, nil
 }
+
+// ExportPages retrieves the full project export (pages with lines) as raw JSON.
+// The session user must be an admin of the project.
+func (c *RESTClient) ExportPages(projectName string) ([]byte, error) {
+	endpoint := fmt.Sprintf("%s/page-data/export/%s.json", c.baseURLFor(projectName), projectName)
+
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, "Failed to create request", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, "Failed to export project", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, mcperrors.NewScrapboxStatusError(mcperrors.ErrCodeNotFound, fmt.Sprintf("Project not found: %s", projectName), resp.StatusCode)
+	}
+	if err := checkResponseStatus(resp); err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, "Failed to read response", err)
+	}
+
+	if !json.Valid(body) {
+		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, "Failed to parse response", nil)
+	}
+
+	return body, nil
+}
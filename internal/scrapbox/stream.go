@@ -0,0 +1,62 @@
+package scrapbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	mcperrors "github.com/hiroki/scrapbox_mcp/pkg/errors"
+)
+
+// StreamPage is a recently updated page in the project stream; Lines holds
+// the page's lines, from which the recent edits are picked
+type StreamPage struct {
+	PageInfo
+	Lines []Line `json:"lines,omitempty"`
+}
+
+// StreamResponse represents the response from /api/stream/:project/
+type StreamResponse struct {
+	Pages []StreamPage `json:"pages"`
+}
+
+// GetStream retrieves the project's activity stream, most recent first
+func (c *RESTClient) GetStream(project string) ([]StreamPage, error) {
+	endpoint := fmt.Sprintf("%s/stream/%s/", c.baseURLFor(project), project)
+
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, "Failed to create request", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, "Failed to fetch stream", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, mcperrors.NewScrapboxStatusError(mcperrors.ErrCodeNotFound, fmt.Sprintf("Project not found: %s", project), resp.StatusCode)
+	}
+	if err := checkResponseStatus(resp); err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, "Failed to read response", err)
+	}
+
+	var streamResp StreamResponse
+	if err := json.Unmarshal(body, &streamResp); err != nil {
+		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNetworkError, "Failed to parse response", err)
+	}
+
+	return streamResp.Pages, nil
+}
+
+// GetStream retrieves the project's activity stream via the REST API
+func (c *Client) GetStream(project string) ([]StreamPage, error) {
+	return c.RESTClient.GetStream(project)
+}
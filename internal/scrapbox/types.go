@@ -1,6 +1,10 @@
 package scrapbox
 
-import "time"
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
 
 // Page represents a Scrapbox page
 type Page struct {
@@ -17,6 +21,10 @@ type Page struct {
 	Updated     int64     `json:"updated"`
 	Accessed    int64     `json:"accessed"`
 	Lines       []Line    `json:"lines"`
+
+	// LastUpdateUser made the latest edit; Collaborators are the other editors
+	LastUpdateUser *User  `json:"lastUpdateUser,omitempty"`
+	Collaborators  []User `json:"collaborators,omitempty"`
 }
 
 // Line represents a line in a Scrapbox page
@@ -34,6 +42,9 @@ type User struct {
 	Name        string `json:"name"`
 	DisplayName string `json:"displayName"`
 	Photo       string `json:"photo"`
+	IsGuest     bool   `json:"isGuest,omitempty"`
+	// CsrfToken is only returned by /users/me and is required for POST APIs
+	CsrfToken string `json:"csrfToken,omitempty"`
 }
 
 // PageInfo represents basic page information from list/search
@@ -48,6 +59,9 @@ type PageInfo struct {
 	Created     int64    `json:"created"`
 	Updated     int64    `json:"updated"`
 	Accessed    int64    `json:"accessed"`
+	// User created the page; LastUpdateUser made the latest edit (list API only)
+	User           *User `json:"user,omitempty"`
+	LastUpdateUser *User `json:"lastUpdateUser,omitempty"`
 }
 
 // PagesResponse represents the response from /api/pages/:project
@@ -79,6 +93,7 @@ type SearchResponse struct {
 	ProjectName           string           `json:"projectName"`
 	SearchQuery           string           `json:"searchQuery"`
 	Limit                 int              `json:"limit"`
+	Skip                  int              `json:"skip"`
 	Count                 int              `json:"count"`
 	Pages                 []SearchPageInfo `json:"pages"`
 	ExistsExactTitleMatch bool             `json:"existsExactTitleMatch"`
@@ -87,11 +102,35 @@ type SearchResponse struct {
 	Backend               string           `json:"backend"`
 }
 
+// CommitEvent represents a change notification from the project updates stream
+type CommitEvent struct {
+	ID        string `json:"id"`
+	Kind      string `json:"kind"`
+	ProjectID string `json:"projectId"`
+	PageID    string `json:"pageId"`
+	UserID    string `json:"userId"`
+	Created   int64  `json:"created"`
+}
+
 // Client represents the main Scrapbox client
 type Client struct {
 	ProjectName     string
 	RESTClient      *RESTClient
 	WebSocketClient *WebSocketClient
+
+	writes  *writeQueue
+	proxy   ProxyFunc
+	headers http.Header
+	// Per-project WebSocket URLs; only the default project's is used for writes
+	wsURLs   map[string]string
+	recorder Recorder
+	// Socket.IO namespace and CONNECT auth payload for new connections
+	namespace  string
+	socketAuth json.RawMessage
+	// Socket.IO transport of new connections
+	transport Transport
+	// lineMarker rewrites written content, e.g. to attribute it
+	lineMarker LineMarker
 }
 
 // NewClient creates a new Scrapbox client
@@ -99,5 +138,6 @@ func NewClient(projectName, sessionCookie, baseURL string, timeout time.Duration
 	return &Client{
 		ProjectName: projectName,
 		RESTClient:  NewRESTClient(baseURL, sessionCookie, timeout),
+		writes:      projectWriteQueue(baseURL, projectName),
 	}
 }
@@ -2,17 +2,19 @@ package scrapbox
 
 import (
 	"crypto/rand"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"net/url"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
 	mcperrors "github.com/hiroki/scrapbox_mcp/pkg/errors"
+	"github.com/hiroki/scrapbox_mcp/pkg/sio"
 )
 
 // userIDSuffix returns the last 6 characters of userID for line ID generation.
@@ -24,8 +26,19 @@ func userIDSuffix(userID string) string {
 	return userID[len(userID)-6:]
 }
 
+// lineIDCounter makes line IDs generated by this process unique and increasing
+// within the same second without waiting for the clock. It starts at a random
+// value so IDs from different processes are unlikely to collide.
+var lineIDCounter = func() *atomic.Uint32 {
+	var c atomic.Uint32
+	seed := make([]byte, 3)
+	rand.Read(seed)
+	c.Store(uint32(seed[0])<<16 | uint32(seed[1])<<8 | uint32(seed[2]))
+	return &c
+}()
+
 // createLineId generates a new line ID in Scrapbox format.
-// Format: 8-char timestamp (seconds, hex) + 6-char userID suffix + 4-char fixed + 8-char random
+// Format: 8-char timestamp (seconds, hex) + 6-char userID suffix + 4-char fixed + 8-char counter
 // Total: 26 characters
 func createLineId(userID string) string {
 	// 8 characters: current time in seconds (hex)
@@ -37,12 +50,10 @@ func createLineId(userID string) string {
 	// 4 characters: fixed padding
 	fixed := "0000"
 
-	// 8 characters: random hex
-	randomBytes := make([]byte, 4)
-	rand.Read(randomBytes)
-	randomHex := hex.EncodeToString(randomBytes)
+	// 8 characters: process-wide counter (hex)
+	counter := fmt.Sprintf("%08x", lineIDCounter.Add(1))
 
-	return timestamp + userSuffix + fixed + randomHex
+	return timestamp + userSuffix + fixed + counter
 }
 
 // WebSocketClient handles WebSocket connections for write operations
@@ -50,20 +61,59 @@ type WebSocketClient struct {
 	wsURL       string
 	projectName string
 	cookie      string
-	conn        *websocket.Conn
+	cookieMu    sync.RWMutex
+	conn        frameConn
 	mu          sync.Mutex
 	connected   bool
 	ackID       int
-	ackChan     chan []byte
+	// pending maps ACK IDs to the requests waiting for them
+	pending map[int]chan *sio.Packet
+
+	// Engine.IO heartbeat parameters from the handshake and idle handling
+	pingInterval time.Duration
+	pingTimeout  time.Duration
+	idleTimeout  time.Duration
+	lastActivity time.Time
+
+	// Project updates stream state; the room is rejoined on every reconnect
+	updatesProjectID string
+	commitHandlers   []func(CommitEvent)
+
+	// Upstream proxy (nil uses the environment) and extra handshake headers
+	proxy   ProxyFunc
+	headers http.Header
+
+	// Socket.IO namespace and the auth payload of its CONNECT packet
+	namespace string
+	auth      json.RawMessage
+
+	// Transport of new connections. Once auto mode has fallen back to
+	// long-polling, reconnects skip the WebSocket attempt until retryWebSocket;
+	// fallbackBackoff is the wait after the latest failure.
+	transport       Transport
+	retryWebSocket  time.Time
+	fallbackBackoff time.Duration
+
+	// Debug capture of frames; connID numbers the current connection
+	recorder Recorder
+	connID   int64
 }
 
+// Engine.IO defaults used when the handshake does not specify them
+const (
+	defaultPingInterval = 25 * time.Second
+	defaultPingTimeout  = 20 * time.Second
+)
+
 // NewWebSocketClient creates a new WebSocket client
 func NewWebSocketClient(wsURL, projectName, cookie string) *WebSocketClient {
 	return &WebSocketClient{
 		wsURL:       wsURL,
 		projectName: projectName,
 		cookie:      cookie,
-		ackChan:     make(chan []byte, 1),
+		pending:     make(map[int]chan *sio.Packet),
+		namespace:   sio.DefaultNamespace,
+		transport:   TransportAuto,
 	}
 }
 
@@ -84,24 +134,29 @@ func (wsc *WebSocketClient) Connect() error {
 
 	q := u.Query()
 	q.Set("EIO", "4")
-	q.Set("transport", "websocket")
 	u.RawQuery = q.Encode()
 
 	// Prepare headers with authentication cookie
 	header := http.Header{}
-	if wsc.cookie != "" {
-		header.Set("Cookie", fmt.Sprintf("connect.sid=%s", wsc.cookie))
+	addHeaders(header, wsc.headers)
+	wsc.cookieMu.RLock()
+	cookie := wsc.cookie
+	wsc.cookieMu.RUnlock()
+	if cookie != "" {
+		header.Set("Cookie", fmt.Sprintf("connect.sid=%s", cookie))
 	}
 
-	// Establish WebSocket connection
-	conn, _, err := websocket.DefaultDialer.Dial(u.String(), header)
+	// Establish the connection (WebSocket or long-polling)
+	conn, err := wsc.dial(u, header)
 	if err != nil {
-		return mcperrors.NewScrapboxError(mcperrors.ErrCodeWebSocketFail, "Failed to connect to WebSocket", err)
+		return err
 	}
 
 	wsc.conn = conn
 	wsc.connected = true
+	atomic.StoreInt64(&wsc.connID, atomic.AddInt64(&lastConnID, 1))
 	wsc.ackID = 0
+	wsc.lastActivity = time.Now()
 
 	// Handle Engine.IO handshake
 	if err := wsc.handleHandshake(); err != nil {
@@ -110,12 +165,114 @@ func (wsc *WebSocketClient) Connect() error {
 		return err
 	}
 
-	// Start message handler
-	go wsc.messageHandler()
+	// A connection that sends nothing (not even a ping) within
+	// pingInterval + pingTimeout is considered dead
+	conn.SetReadDeadline(time.Now().Add(wsc.pingInterval + wsc.pingTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsc.pingInterval + wsc.pingTimeout))
+	})
+
+	// Start message handler and keepalive
+	done := make(chan struct{})
+	go wsc.messageHandler(conn, done)
+	go wsc.keepalive(conn, done)
+
+	// Rejoin the project updates room after a reconnect.
+	// This runs asynchronously because joining waits for an ACK and needs the lock.
+	if wsc.updatesProjectID != "" {
+		go func() {
+			if err := wsc.joinProjectUpdates(); err != nil {
+				log.Printf("[WS] Failed to rejoin project updates stream: %v", err)
+			}
+		}()
+	}
 
 	return nil
 }
 
+// SetCookie replaces the session cookie used for new connections.
+// An open connection keeps its existing session until it is re-established.
+func (wsc *WebSocketClient) SetCookie(cookie string) {
+	wsc.cookieMu.Lock()
+	defer wsc.cookieMu.Unlock()
+	wsc.cookie = cookie
+}
+
+// SetIdleTimeout sets how long a connection may go without requests before it
+// is closed; it is reopened on the next write. 0 keeps connections open forever.
+// Connections subscribed to the project updates stream are never closed as idle.
+func (wsc *WebSocketClient) SetIdleTimeout(timeout time.Duration) {
+	wsc.mu.Lock()
+	defer wsc.mu.Unlock()
+	wsc.idleTimeout = timeout
+}
+
+// SetProxy routes new connections through proxy
+func (wsc *WebSocketClient) SetProxy(proxy ProxyFunc) {
+	wsc.mu.Lock()
+	defer wsc.mu.Unlock()
+	wsc.proxy = proxy
+}
+
+// SetHeaders sends headers with the handshake of new connections
+func (wsc *WebSocketClient) SetHeaders(headers http.Header) {
+	wsc.mu.Lock()
+	defer wsc.mu.Unlock()
+	wsc.headers = headers
+}
+
+// Connected reports whether the WebSocket connection is currently open
+func (wsc *WebSocketClient) Connected() bool {
+	wsc.mu.Lock()
+	defer wsc.mu.Unlock()
+	return wsc.connected && wsc.conn != nil
+}
+
+// AddCommitHandler adds a function called for every commit received from the project updates stream
+func (wsc *WebSocketClient) AddCommitHandler(fn func(CommitEvent)) {
+	wsc.mu.Lock()
+	defer wsc.mu.Unlock()
+	wsc.commitHandlers = append(wsc.commitHandlers, fn)
+}
+
+// JoinProjectUpdates joins the project updates room so that commits made to any
+// page in the project are delivered to the commit handlers.
+func (wsc *WebSocketClient) JoinProjectUpdates(projectID string) error {
+	wsc.mu.Lock()
+	wsc.updatesProjectID = projectID
+	wsc.mu.Unlock()
+
+	if err := wsc.Connect(); err != nil {
+		return err
+	}
+	return wsc.joinProjectUpdates()
+}
+
+// joinProjectUpdates sends the room:join request for the configured project
+func (wsc *WebSocketClient) joinProjectUpdates() error {
+	wsc.mu.Lock()
+	projectID := wsc.updatesProjectID
+	wsc.mu.Unlock()
+
+	payload := map[string]interface{}{
+		"method": "room:join",
+		"data": map[string]interface{}{
+			"projectId":            projectID,
+			"pageId":               nil,
+			"projectUpdatesStream": true,
+		},
+	}
+
+	reqBody := []interface{}{"socket.io-request", payload}
+	reqJSON, err := json.Marshal(reqBody)
+	if err != nil {
+		return mcperrors.NewScrapboxError(mcperrors.ErrCodeWebSocketFail, "Failed to marshal request", err)
+	}
+
+	_, err = wsc.sendRequestAndWaitACK(reqJSON)
+	return err
+}
+
 // handleHandshake processes the Engine.IO handshake
 func (wsc *WebSocketClient) handleHandshake() error {
 	// Read Engine.IO open packet (type 0)
@@ -123,14 +280,27 @@ func (wsc *WebSocketClient) handleHandshake() error {
 	if err != nil {
 		return mcperrors.NewScrapboxError(mcperrors.ErrCodeWebSocketFail, "Failed to read handshake", err)
 	}
+	wsc.recordFrame(FrameReceive, message)
 
-	// Message should start with "0{...}"
-	if len(message) < 2 || message[0] != '0' {
-		return mcperrors.NewScrapboxError(mcperrors.ErrCodeWebSocketFail, "Invalid handshake packet", nil)
+	params, err := sio.DecodeOpen(message)
+	if err != nil {
+		return mcperrors.NewScrapboxError(mcperrors.ErrCodeWebSocketFail, "Invalid handshake packet", err)
+	}
+	wsc.pingInterval = defaultPingInterval
+	if params.PingInterval > 0 {
+		wsc.pingInterval = time.Duration(params.PingInterval) * time.Millisecond
+	}
+	wsc.pingTimeout = defaultPingTimeout
+	if params.PingTimeout > 0 {
+		wsc.pingTimeout = time.Duration(params.PingTimeout) * time.Millisecond
 	}
 
-	// Send Socket.IO CONNECT packet (type 40)
-	if err := wsc.conn.WriteMessage(websocket.TextMessage, []byte("40")); err != nil {
+	// Send Socket.IO CONNECT packet (type 40) to the namespace, with the auth payload
+	request := wsc.packet(sio.Connect)
+	request.Data = wsc.auth
+	connect := request.Encode()
+	wsc.recordFrame(FrameSend, connect)
+	if err := wsc.conn.WriteMessage(websocket.TextMessage, connect); err != nil {
 		return mcperrors.NewScrapboxError(mcperrors.ErrCodeWebSocketFail, "Failed to send connect packet", err)
 	}
 
@@ -139,42 +309,155 @@ func (wsc *WebSocketClient) handleHandshake() error {
 	if err != nil {
 		return mcperrors.NewScrapboxError(mcperrors.ErrCodeWebSocketFail, "Failed to read connect response", err)
 	}
+	wsc.recordFrame(FrameReceive, response)
 
-	// Response should start with "40" (can be "40" or "40{...}")
-	if len(response) < 2 || response[0] != '4' || response[1] != '0' {
-		return mcperrors.NewScrapboxError(mcperrors.ErrCodeWebSocketFail, fmt.Sprintf("Invalid connect response: %s", string(response)), nil)
+	// The response is a CONNECT packet ("40" or "40{...}"), or CONNECT_ERROR
+	// when the namespace rejects the connection (usually the auth payload)
+	packet, err := sio.Decode(response)
+	if err == nil && packet.Type == sio.ConnectError {
+		return mcperrors.NewScrapboxError(mcperrors.ErrCodeAuthFailed, fmt.Sprintf("Socket.IO namespace %s refused the connection: %s", packet.Namespace, connectErrorMessage(packet)), nil)
+	}
+	if err != nil || packet.Type != sio.Connect || packet.Namespace != wsc.namespace {
+		return mcperrors.NewScrapboxError(mcperrors.ErrCodeWebSocketFail, fmt.Sprintf("Invalid connect response: %s", string(response)), err)
 	}
 
 	return nil
 }
 
-// messageHandler handles incoming messages
-func (wsc *WebSocketClient) messageHandler() {
-	for wsc.connected {
-		_, message, err := wsc.conn.ReadMessage()
+// messageHandler handles incoming messages until the connection fails or is closed
+func (wsc *WebSocketClient) messageHandler(conn frameConn, done chan struct{}) {
+	defer close(done)
+
+	wsc.mu.Lock()
+	namespace := wsc.namespace
+	wsc.mu.Unlock()
+
+	var decoder sio.Decoder
+	for {
+		messageType, message, err := conn.ReadMessage()
 		if err != nil {
-			wsc.connected = false
+			wsc.mu.Lock()
+			if wsc.conn == conn && wsc.connected {
+				log.Printf("[WS] Connection lost: %v", err)
+				wsc.connected = false
+				conn.Close()
+			}
+			wsc.mu.Unlock()
 			return
 		}
 
-		if len(message) == 0 {
+		// Any packet proves the server is alive
+		conn.SetReadDeadline(time.Now().Add(wsc.pingInterval + wsc.pingTimeout))
+		wsc.recordFrame(FrameReceive, message)
+
+		var packet *sio.Packet
+		if messageType == websocket.BinaryMessage {
+			packet, err = decoder.DecodeBinary(message)
+		} else {
+			var engineType sio.EngineType
+			engineType, _, err = sio.DecodeEngine(message)
+			switch {
+			case err != nil:
+			case engineType == sio.EnginePing:
+				wsc.mu.Lock()
+				conn.WriteMessage(websocket.TextMessage, sio.PongFrame)
+				wsc.mu.Unlock()
+				continue
+			case engineType != sio.EngineMessage:
+				// Pong, noop and upgrade packets carry nothing for us
+				continue
+			default:
+				packet, err = decoder.DecodeText(message)
+			}
+		}
+		if err != nil {
+			log.Printf("[WS] Ignoring malformed packet: %v", err)
+			continue
+		}
+		if packet == nil {
+			// Waiting for binary attachments
 			continue
 		}
 
-		// Engine.IO ping packet (type 2)
-		if message[0] == '2' {
+		if packet.Namespace != namespace {
+			continue
+		}
+		switch {
+		case packet.Type == sio.Ack || packet.Type == sio.BinaryAck:
+			// Delivered to the request with the same ACK ID
 			wsc.mu.Lock()
-			wsc.conn.WriteMessage(websocket.TextMessage, []byte("3"))
+			ch, ok := wsc.pending[packet.ID]
 			wsc.mu.Unlock()
-			continue
+			if ok {
+				select {
+				case ch <- packet:
+				default:
+				}
+			}
+		case packet.Type == sio.Event && !packet.HasID:
+			// Server push
+			wsc.handleEvent(packet)
 		}
+	}
+}
 
-		// Socket.IO ACK packet (type 43)
-		if len(message) >= 2 && message[0] == '4' && message[1] == '3' {
-			select {
-			case wsc.ackChan <- message:
-			default:
-			}
+// keepalive sends WebSocket pings every pingInterval so dead connections are
+// detected even when the server stops pinging, and closes the connection once
+// it has been idle for longer than idleTimeout
+func (wsc *WebSocketClient) keepalive(conn frameConn, done chan struct{}) {
+	ticker := time.NewTicker(wsc.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		wsc.mu.Lock()
+		if wsc.conn != conn || !wsc.connected {
+			wsc.mu.Unlock()
+			return
+		}
+		if wsc.idleTimeout > 0 && wsc.updatesProjectID == "" && time.Since(wsc.lastActivity) > wsc.idleTimeout {
+			log.Printf("[WS] Closing connection idle for %s", time.Since(wsc.lastActivity).Round(time.Second))
+			disconnect := wsc.packet(sio.Disconnect).Encode()
+			wsc.recordFrame(FrameSend, disconnect)
+			conn.WriteMessage(websocket.TextMessage, disconnect)
+			wsc.connected = false
+			conn.Close()
+			wsc.mu.Unlock()
+			return
+		}
+		wsc.mu.Unlock()
+
+		if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsc.pingTimeout)); err != nil {
+			log.Printf("[WS] Failed to send ping: %v", err)
+		}
+	}
+}
+
+// handleEvent dispatches a server-pushed Socket.IO event
+func (wsc *WebSocketClient) handleEvent(packet *sio.Packet) {
+	args := packet.Args()
+	if len(args) == 0 {
+		return
+	}
+
+	switch packet.Name() {
+	case "projectUpdatesStream:commit", "projectUpdatesStream:event", "commit":
+		var commit CommitEvent
+		if err := json.Unmarshal(args[0], &commit); err != nil || commit.PageID == "" {
+			return
+		}
+
+		wsc.mu.Lock()
+		handlers := wsc.commitHandlers
+		wsc.mu.Unlock()
+
+		for _, handler := range handlers {
+			handler(commit)
 		}
 	}
 }
@@ -182,18 +465,15 @@ func (wsc *WebSocketClient) messageHandler() {
 // diffToChanges computes the changes needed to transform oldLines into newLines.
 // It generates _insert, _update, and _delete operations.
 // The algorithm processes line by line, tracking positions in both old and new arrays.
-func diffToChanges(oldLines []Line, newTexts []string, userID string) []map[string]interface{} {
+// It also returns the lines the page will have once the changes are applied.
+func diffToChanges(oldLines []Line, newTexts []string, userID string) ([]map[string]interface{}, *WriteResult) {
 	changes := make([]map[string]interface{}, 0)
 
 	oldLen := len(oldLines)
 	newLen := len(newTexts)
-
-	// Track the last valid line ID for insertion chaining
-	// This is used when inserting multiple consecutive lines
-	var lastLineID string
-	if oldLen > 0 {
-		lastLineID = oldLines[oldLen-1].ID
-	}
+	lines := make([]Line, newLen)
+	var changed []int
+	var inserted []string
 
 	// First pass: handle updates and track which old lines to keep
 	// For simplicity, we use a position-based approach:
@@ -208,7 +488,9 @@ func diffToChanges(oldLines []Line, newTexts []string, userID string) []map[stri
 
 	// Update existing lines where text differs
 	for i := 0; i < minLen; i++ {
+		lines[i] = Line{ID: oldLines[i].ID, Text: newTexts[i]}
 		if oldLines[i].Text != newTexts[i] {
+			changed = append(changed, i)
 			changes = append(changes, map[string]interface{}{
 				"_update": oldLines[i].ID,
 				"lines": map[string]interface{}{
@@ -218,6 +500,13 @@ func diffToChanges(oldLines []Line, newTexts []string, userID string) []map[stri
 		}
 	}
 
+	// Changing the first line renames the page
+	if oldLen > 0 && newLen > 0 && oldLines[0].Text != newTexts[0] {
+		changes = append(changes, map[string]interface{}{
+			"title": newTexts[0],
+		})
+	}
+
 	// Delete extra old lines (from end to avoid index issues)
 	for i := oldLen - 1; i >= newLen; i-- {
 		changes = append(changes, map[string]interface{}{
@@ -226,113 +515,135 @@ func diffToChanges(oldLines []Line, newTexts []string, userID string) []map[stri
 		})
 	}
 
-	// Insert extra new lines
-	if newLen > oldLen {
-		// Determine insert position
-		if oldLen > 0 {
-			lastLineID = oldLines[oldLen-1].ID
-		}
-
-		for i := oldLen; i < newLen; i++ {
-			newLineID := createLineId(userID)
-			changes = append(changes, map[string]interface{}{
-				"_insert": lastLineID,
-				"lines": map[string]interface{}{
-					"id":   newLineID,
-					"text": newTexts[i],
-				},
-			})
-			// Chain: next insert happens after this new line
-			lastLineID = newLineID
-		}
+	// Append extra new lines. "_insert" places a line before the given line ID,
+	// so appended lines use "_end" and keep their order.
+	for i := oldLen; i < newLen; i++ {
+		lines[i] = Line{ID: createLineId(userID), Text: newTexts[i]}
+		changed = append(changed, i)
+		inserted = append(inserted, lines[i].ID)
+		changes = append(changes, map[string]interface{}{
+			"_insert": "_end",
+			"lines": map[string]interface{}{
+				"id":   lines[i].ID,
+				"text": newTexts[i],
+			},
+		})
 	}
 
-	return changes
+	return changes, &WriteResult{Lines: lines, Changed: changed, InsertedLineIDs: inserted}
 }
 
 // PatchPage applies a patch to a page using diff-based changes.
 // This is the core function that computes the diff between old and new content
 // and generates the appropriate _insert, _update, _delete operations.
-func (wsc *WebSocketClient) PatchPage(page *Page, projectID, userID string, newTexts []string) error {
+func (wsc *WebSocketClient) PatchPage(page *Page, projectID, userID string, newTexts []string) (*WriteResult, error) {
 	// Ensure connection
 	if err := wsc.Connect(); err != nil {
-		return err
+		return nil, err
 	}
 
 	// Generate changes using diff
-	changes := diffToChanges(page.Lines, newTexts, userID)
+	changes, result := diffToChanges(page.Lines, newTexts, userID)
+	result.Title, result.PageID, result.CommitID = page.Title, page.ID, page.CommitID
+	if len(result.Lines) > 0 {
+		result.Title = result.Lines[0].Text
+	}
 
 	if len(changes) == 0 {
 		// No changes needed
-		return nil
-	}
-
-	// Build commit data
-	commitData := map[string]interface{}{
-		"kind":      "page",
-		"projectId": projectID,
-		"pageId":    page.ID,
-		"parentId":  page.CommitID,
-		"userId":    userID,
-		"changes":   changes,
-		"cursor":    nil,
-		"freeze":    true,
-	}
-
-	// Build socket.io-request payload
-	payload := map[string]interface{}{
-		"method": "commit",
-		"data":   commitData,
+		return result, nil
 	}
 
-	reqBody := []interface{}{"socket.io-request", payload}
-	reqJSON, err := json.Marshal(reqBody)
+	commitID, err := wsc.sendCommit(projectID, page.ID, page.CommitID, userID, changes)
 	if err != nil {
-		return mcperrors.NewScrapboxError(mcperrors.ErrCodeWebSocketFail, "Failed to marshal request", err)
+		return nil, err
 	}
-
-	return wsc.sendCommitAndWaitACK(reqJSON)
+	result.CommitID = commitID
+	return result, nil
 }
 
 // InsertLines inserts lines into a page after a target line.
 // If targetLine is empty, lines are appended to the end.
-// This uses the diff-based approach to properly handle line changes.
-func (wsc *WebSocketClient) InsertLines(page *Page, projectID, userID, targetLine string, newLines []string) error {
-	// Build the new content by inserting lines at the appropriate position
-	var newTexts []string
+// The lines are inserted with "_insert" changes, so the existing lines keep
+// their IDs and the new ones get fresh IDs.
+func (wsc *WebSocketClient) InsertLines(page *Page, projectID, userID, targetLine string, newLines []string) (*WriteResult, error) {
+	return wsc.insertAt(page, projectID, userID, insertIndex(page, targetLine), newLines)
+}
 
-	if targetLine == "" {
-		// Append to end: keep all existing lines and add new ones
+// insertAt inserts texts before the line at index at (at the end when at is
+// the page length)
+func (wsc *WebSocketClient) insertAt(page *Page, projectID, userID string, at int, texts []string) (*WriteResult, error) {
+	if at == 0 {
+		// An empty page has no line to insert before; the first line becomes its title
+		newTexts := make([]string, 0, len(page.Lines)+len(texts))
+		newTexts = append(newTexts, texts...)
 		for _, line := range page.Lines {
 			newTexts = append(newTexts, line.Text)
 		}
-		newTexts = append(newTexts, newLines...)
-	} else {
-		// Find target line and insert after it
-		inserted := false
-		for _, line := range page.Lines {
-			newTexts = append(newTexts, line.Text)
-			if line.Text == targetLine && !inserted {
-				newTexts = append(newTexts, newLines...)
-				inserted = true
+		return wsc.PatchPage(page, projectID, userID, newTexts)
+	}
+
+	// Ensure connection
+	if err := wsc.Connect(); err != nil {
+		return nil, err
+	}
+
+	before := "_end"
+	if at < len(page.Lines) {
+		before = page.Lines[at].ID
+	}
+
+	// Each line goes before the same following line, so they keep their order
+	result := &WriteResult{Title: page.Title, PageID: page.ID, CommitID: page.CommitID, Changed: lineRange(at, len(texts))}
+	result.Lines = make([]Line, 0, len(page.Lines)+len(texts))
+	result.Lines = append(result.Lines, page.Lines[:at]...)
+	changes := make([]map[string]interface{}, 0, len(texts))
+	for _, text := range texts {
+		line := Line{ID: createLineId(userID), Text: text}
+		changes = append(changes, map[string]interface{}{
+			"_insert": before,
+			"lines": map[string]interface{}{
+				"id":   line.ID,
+				"text": line.Text,
+			},
+		})
+		result.Lines = append(result.Lines, line)
+		result.InsertedLineIDs = append(result.InsertedLineIDs, line.ID)
+	}
+	result.Lines = append(result.Lines, page.Lines[at:]...)
+
+	if len(changes) == 0 {
+		return result, nil
+	}
+
+	commitID, err := wsc.sendCommit(projectID, page.ID, page.CommitID, userID, changes)
+	if err != nil {
+		return nil, err
+	}
+	result.CommitID = commitID
+	return result, nil
+}
+
+// insertIndex returns the index after the first line equal to targetLine, or
+// the page length (the end) when targetLine is empty or not found
+func insertIndex(page *Page, targetLine string) int {
+	if targetLine != "" {
+		for i, line := range page.Lines {
+			if line.Text == targetLine {
+				return i + 1
 			}
 		}
-		// If target not found, append to end
-		if !inserted {
-			newTexts = append(newTexts, newLines...)
-		}
 	}
-
-	return wsc.PatchPage(page, projectID, userID, newTexts)
+	return len(page.Lines)
 }
 
 // CreatePage creates a new page with the given title and body lines.
 // pageID should be the ID obtained from Scrapbox's GetPage API (pre-generated by server).
 // This uses the correct line ID format for Scrapbox compatibility.
-func (wsc *WebSocketClient) CreatePage(pageID, projectID, userID, title string, bodyLines []string) error {
+func (wsc *WebSocketClient) CreatePage(pageID, projectID, userID, title string, bodyLines []string) (*WriteResult, error) {
 	// Ensure connection
 	if err := wsc.Connect(); err != nil {
-		return err
+		return nil, err
 	}
 
 	// Build changes using _insert operations
@@ -346,9 +657,14 @@ func (wsc *WebSocketClient) CreatePage(pageID, projectID, userID, title string,
 	// Body lines - build insert changes in reverse order
 	// Scrapbox processes changes in reverse, so we build them backwards
 	bodyChanges := make([]map[string]interface{}, 0, len(bodyLines))
+	lines := make([]Line, 1+len(bodyLines))
+	lines[0] = Line{Text: title}
+	var insertedLineIDs []string
 	var lastLineID string
 	for i := len(bodyLines) - 1; i >= 0; i-- {
 		lineID := createLineId(userID)
+		lines[1+i] = Line{ID: lineID, Text: bodyLines[i]}
+		insertedLineIDs = append([]string{lineID}, insertedLineIDs...)
 		insertPos := "_end"
 		if lastLineID != "" {
 			insertPos = lastLineID
@@ -361,17 +677,107 @@ func (wsc *WebSocketClient) CreatePage(pageID, projectID, userID, title string,
 			},
 		})
 		lastLineID = lineID
-		// Small delay to ensure unique timestamps
-		time.Sleep(time.Millisecond)
 	}
 	changes = append(changes, bodyChanges...)
+	result := &WriteResult{Title: title, PageID: pageID, Lines: lines, InsertedLineIDs: insertedLineIDs}
+	for i := 1; i < len(lines); i++ {
+		result.Changed = append(result.Changed, i)
+	}
+
+	commitID, err := wsc.sendCommit(projectID, pageID, nil, userID, changes)
+	if err != nil {
+		return nil, err
+	}
+	result.CommitID = commitID
+	return result, nil
+}
+
+// UpdateLine replaces the text of the line at index with a single "_update"
+// change; updating the title line also renames the page
+func (wsc *WebSocketClient) UpdateLine(page *Page, projectID, userID string, index int, text string) (*WriteResult, error) {
+	// Ensure connection
+	if err := wsc.Connect(); err != nil {
+		return nil, err
+	}
+
+	line := page.Lines[index]
+	result := &WriteResult{Title: page.Title, PageID: page.ID, CommitID: page.CommitID, Changed: []int{index}}
+	result.Lines = make([]Line, len(page.Lines))
+	copy(result.Lines, page.Lines)
+	result.Lines[index].Text = text
+	if line.Text == text {
+		// No changes needed
+		return result, nil
+	}
 
-	// Build commit data for new page
+	changes := []map[string]interface{}{{
+		"_update": line.ID,
+		"lines": map[string]interface{}{
+			"text": text,
+		},
+	}}
+	if index == 0 {
+		result.Title = text
+		changes = append(changes, map[string]interface{}{
+			"title": text,
+		})
+	}
+
+	commitID, err := wsc.sendCommit(projectID, page.ID, page.CommitID, userID, changes)
+	if err != nil {
+		return nil, err
+	}
+	result.CommitID = commitID
+	return result, nil
+}
+
+// DeletePage deletes a page with a "deleted" commit
+func (wsc *WebSocketClient) DeletePage(page *Page, projectID, userID string) (*WriteResult, error) {
+	// Ensure connection
+	if err := wsc.Connect(); err != nil {
+		return nil, err
+	}
+	result := &WriteResult{Title: page.Title, PageID: page.ID, Deleted: true}
+
+	commitID, err := wsc.sendCommit(projectID, page.ID, page.CommitID, userID, []map[string]interface{}{{"deleted": true}})
+	if err != nil {
+		return nil, err
+	}
+	result.CommitID = commitID
+	return result, nil
+}
+
+// SetPin pins (pin > 0) or unpins (pin == 0) a page with a "pin" change
+func (wsc *WebSocketClient) SetPin(page *Page, projectID, userID string, pin int) (*WriteResult, error) {
+	// Ensure connection
+	if err := wsc.Connect(); err != nil {
+		return nil, err
+	}
+	result := &WriteResult{Title: page.Title, PageID: page.ID, CommitID: page.CommitID}
+	result.Lines = make([]Line, len(page.Lines))
+	copy(result.Lines, page.Lines)
+	if page.Pin == pin || (page.Pin != 0 && pin != 0) {
+		// No changes needed
+		return result, nil
+	}
+
+	commitID, err := wsc.sendCommit(projectID, page.ID, page.CommitID, userID, []map[string]interface{}{{"pin": pin}})
+	if err != nil {
+		return nil, err
+	}
+	result.CommitID = commitID
+	return result, nil
+}
+
+// sendCommit sends a page commit and returns the new commit ID reported by
+// its ACK. parentID is nil for a new page.
+func (wsc *WebSocketClient) sendCommit(projectID, pageID string, parentID interface{}, userID string, changes []map[string]interface{}) (string, error) {
+	// Build commit data
 	commitData := map[string]interface{}{
 		"kind":      "page",
 		"projectId": projectID,
 		"pageId":    pageID,
-		"parentId":  nil, // null for new page
+		"parentId":  parentID,
 		"userId":    userID,
 		"changes":   changes,
 		"cursor":    nil,
@@ -387,61 +793,68 @@ func (wsc *WebSocketClient) CreatePage(pageID, projectID, userID, title string,
 	reqBody := []interface{}{"socket.io-request", payload}
 	reqJSON, err := json.Marshal(reqBody)
 	if err != nil {
-		return mcperrors.NewScrapboxError(mcperrors.ErrCodeWebSocketFail, "Failed to marshal request", err)
+		return "", mcperrors.NewScrapboxError(mcperrors.ErrCodeWebSocketFail, "Failed to marshal request", err)
 	}
 
-	return wsc.sendCommitAndWaitACK(reqJSON)
+	ack, err := wsc.sendRequestAndWaitACK(reqJSON)
+	if err != nil {
+		return "", err
+	}
+	return commitIDFromACK(ack), nil
 }
 
-// sendCommitAndWaitACK sends a commit request and waits for ACK response
-func (wsc *WebSocketClient) sendCommitAndWaitACK(reqJSON []byte) error {
+// sendRequestAndWaitACK sends a socket.io-request (commit, room:join, ...) and waits for ACK response
+func (wsc *WebSocketClient) sendRequestAndWaitACK(reqJSON []byte) (*sio.Packet, error) {
 	// Socket.IO EVENT packet with ACK: 42<ackId>["socket.io-request", {...}]
 	wsc.mu.Lock()
 	wsc.ackID++
-	packet := fmt.Sprintf("42%d%s", wsc.ackID, string(reqJSON))
-	err := wsc.conn.WriteMessage(websocket.TextMessage, []byte(packet))
+	ackID := wsc.ackID
+	ackChan := make(chan *sio.Packet, 1)
+	wsc.pending[ackID] = ackChan
+	wsc.lastActivity = time.Now()
+	request := wsc.packet(sio.Event)
+	request.ID, request.HasID, request.Data = ackID, true, reqJSON
+	packet := request.Encode()
+	wsc.recordFrame(FrameSend, packet)
+	err := wsc.conn.WriteMessage(websocket.TextMessage, packet)
 	wsc.mu.Unlock()
 
+	defer func() {
+		wsc.mu.Lock()
+		delete(wsc.pending, ackID)
+		wsc.mu.Unlock()
+	}()
+
 	if err != nil {
-		return mcperrors.NewScrapboxError(mcperrors.ErrCodeWebSocketFail, "Failed to send commit", err)
+		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeWebSocketFail, "Failed to send request", err)
 	}
 
 	// Wait for ACK response
 	select {
-	case ackMsg := <-wsc.ackChan:
-		return parseACKError(ackMsg)
+	case ack := <-ackChan:
+		if err := parseACKError(ack); err != nil {
+			return nil, err
+		}
+		return ack, nil
 	case <-time.After(30 * time.Second):
-		return mcperrors.NewScrapboxError(mcperrors.ErrCodeWebSocketFail, "Timeout waiting for commit response", nil)
+		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeWebSocketFail, "Timeout waiting for request response", nil)
 	}
 }
 
-// parseACKError parses an ACK message and returns an error if it contains one
-func parseACKError(ackMsg []byte) error {
-	if len(ackMsg) <= 3 {
+// parseACKError returns the error carried by an ACK packet, if any
+func parseACKError(ack *sio.Packet) error {
+	args := ack.Args()
+	if len(args) == 0 {
 		return nil
 	}
 
-	// Find the JSON array start (skip "43" and ackId digits)
-	jsonStart := 2
-	for jsonStart < len(ackMsg) && ackMsg[jsonStart] >= '0' && ackMsg[jsonStart] <= '9' {
-		jsonStart++
-	}
-
-	if jsonStart >= len(ackMsg) {
+	var ackData map[string]interface{}
+	if err := json.Unmarshal(args[0], &ackData); err != nil {
 		return nil
 	}
 
-	var ackData []map[string]interface{}
-	if err := json.Unmarshal(ackMsg[jsonStart:], &ackData); err != nil || len(ackData) == 0 {
-		return nil
-	}
-
-	if errData, ok := ackData[0]["error"]; ok {
-		errJSON, err := json.Marshal(errData)
-		if err != nil {
-			return mcperrors.NewScrapboxError(mcperrors.ErrCodeWebSocketFail, "Commit error (failed to marshal)", nil)
-		}
-		return mcperrors.NewScrapboxError(mcperrors.ErrCodeWebSocketFail, fmt.Sprintf("Commit error: %s", string(errJSON)), nil)
+	if errData, ok := ackData["error"]; ok {
+		return newCommitError(errData)
 	}
 
 	return nil
@@ -453,6 +866,12 @@ func (wsc *WebSocketClient) Close() error {
 	defer wsc.mu.Unlock()
 
 	if wsc.conn != nil {
+		// Send Socket.IO DISCONNECT packet (type 41) so the server closes the session cleanly
+		if wsc.connected {
+			disconnect := wsc.packet(sio.Disconnect).Encode()
+			wsc.recordFrame(FrameSend, disconnect)
+			wsc.conn.WriteMessage(websocket.TextMessage, disconnect)
+		}
 		wsc.connected = false
 		return wsc.conn.Close()
 	}
@@ -460,37 +879,159 @@ func (wsc *WebSocketClient) Close() error {
 	return nil
 }
 
-// Update the Client type to include WebSocket client
+// EnsureWebSocket initializes the WebSocket client used for writes.
+// The connection itself is established lazily on the first write.
 func (c *Client) EnsureWebSocket(wsURL string) {
 	if c.WebSocketClient == nil {
-		sessionCookie := ""
-		if c.RESTClient != nil && c.RESTClient.auth != nil {
-			sessionCookie = c.RESTClient.auth.sessionCookie
+		c.WebSocketClient = c.newWebSocketClient(wsURL)
+	}
+}
+
+// newWebSocketClient creates a WebSocket client with the client's cookie,
+// proxy and headers
+func (c *Client) newWebSocketClient(wsURL string) *WebSocketClient {
+	sessionCookie := ""
+	if c.RESTClient != nil && c.RESTClient.auth != nil {
+		sessionCookie = c.RESTClient.auth.SessionCookie()
+	}
+	wsc := NewWebSocketClient(c.webSocketURL(wsURL), c.ProjectName, sessionCookie)
+	wsc.proxy = c.proxy
+	wsc.headers = c.headers
+	wsc.recorder = c.recorder
+	if c.namespace != "" {
+		wsc.namespace = c.namespace
+	}
+	wsc.auth = c.socketAuth
+	if c.transport != "" {
+		wsc.transport = c.transport
+	}
+	return wsc
+}
+
+// CheckWebSocket opens and closes a separate Socket.IO connection to verify
+// that the endpoint is reachable and accepts the session cookie. It returns the
+// transport the connection used.
+func (c *Client) CheckWebSocket(wsURL string) (Transport, error) {
+	wsc := c.newWebSocketClient(wsURL)
+	if err := wsc.Connect(); err != nil {
+		return "", err
+	}
+	transport := wsc.Transport()
+	return transport, wsc.Close()
+}
+
+// SetProxy routes REST requests and WebSocket connections through proxy
+func (c *Client) SetProxy(proxy ProxyFunc) {
+	c.proxy = proxy
+	c.RESTClient.httpClient.Transport = newHTTPTransport(proxy)
+	if c.WebSocketClient != nil {
+		c.WebSocketClient.SetProxy(proxy)
+	}
+}
+
+// SetHeaders sends headers (e.g. User-Agent) with every REST request and
+// WebSocket handshake
+func (c *Client) SetHeaders(headers http.Header) {
+	c.headers = headers
+	c.RESTClient.headers = headers
+	if c.WebSocketClient != nil {
+		c.WebSocketClient.SetHeaders(headers)
+	}
+}
+
+// SetSessionCookie rotates the session cookie used for REST requests and new WebSocket connections
+func (c *Client) SetSessionCookie(sessionCookie string) {
+	c.RESTClient.auth.SetSessionCookie(sessionCookie)
+	if c.WebSocketClient != nil {
+		c.WebSocketClient.SetCookie(sessionCookie)
+	}
+}
+
+// WriteQueueStats returns queue depth and wait time metrics for writes to
+// the client's project, counting writes of every Client sharing its queue
+func (c *Client) WriteQueueStats() WriteQueueStats {
+	return c.writes.stats()
+}
+
+// SetCredentialProvider refreshes the session cookie from provider when Scrapbox
+// reports it expired. The new cookie is used for REST requests and new WebSocket connections.
+func (c *Client) SetCredentialProvider(provider CredentialProvider) {
+	c.RESTClient.SetCredentialProvider(provider, func(sessionCookie string) {
+		if c.WebSocketClient != nil {
+			c.WebSocketClient.SetCookie(sessionCookie)
 		}
-		c.WebSocketClient = NewWebSocketClient(wsURL, c.ProjectName, sessionCookie)
+	})
+}
+
+// Close closes the WebSocket connection if one has been opened
+func (c *Client) Close() error {
+	if c.WebSocketClient == nil {
+		return nil
 	}
+	return c.WebSocketClient.Close()
 }
 
 // InsertLines is a convenience method on Client.
 // It inserts lines into a page after a specified target line.
 // If targetLine is empty, lines are appended to the end.
-func (c *Client) InsertLines(pageTitle, targetLine string, newLines []string) error {
+func (c *Client) InsertLines(pageTitle, targetLine string, newLines []string, expectedCommitID string) (*WriteResult, error) {
+	return c.insertLines(pageTitle, newLines, expectedCommitID, func(page *Page) (int, error) {
+		return insertIndex(page, targetLine), nil
+	})
+}
+
+// InsertLinesBefore inserts lines before the line with beforeLineID, or
+// appends them when beforeLineID is empty
+func (c *Client) InsertLinesBefore(pageTitle, beforeLineID string, newLines []string, expectedCommitID string) (*WriteResult, error) {
+	return c.insertLines(pageTitle, newLines, expectedCommitID, func(page *Page) (int, error) {
+		if beforeLineID == "" {
+			return len(page.Lines), nil
+		}
+		for i, line := range page.Lines {
+			if line.ID == beforeLineID {
+				if i == 0 {
+					return 0, mcperrors.NewScrapboxError(mcperrors.ErrCodeInvalidInput, "Cannot insert lines before the title line", nil)
+				}
+				return i, nil
+			}
+		}
+		return 0, mcperrors.NewScrapboxError(mcperrors.ErrCodeNotFound, fmt.Sprintf("Line not found in page %s: %s", pageTitle, beforeLineID), nil)
+	})
+}
+
+// insertLines inserts newLines into a page at the index locate picks
+func (c *Client) insertLines(pageTitle string, newLines []string, expectedCommitID string, locate func(page *Page) (int, error)) (*WriteResult, error) {
+	if c.WebSocketClient == nil {
+		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeWebSocketFail, "WebSocket client not initialized", nil)
+	}
+
+	// Serialize with other writes so each one diffs against the latest page
+	release := c.writes.acquire()
+	defer release()
+
 	// Get the current page
 	page, err := c.RESTClient.GetPage(c.ProjectName, pageTitle)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if err := checkExpectedCommit(page, expectedCommitID); err != nil {
+		return nil, err
+	}
+	at, err := locate(page)
+	if err != nil {
+		return nil, err
 	}
 
 	// Get user ID
 	user, err := c.RESTClient.GetMe()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Get project ID
 	projectInfo, err := c.RESTClient.GetProject(c.ProjectName)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Parse newLines if it's a single string with newlines
@@ -499,43 +1040,77 @@ func (c *Client) InsertLines(pageTitle, targetLine string, newLines []string) er
 		lines = strings.Split(newLines[0], "\n")
 	}
 
-	// Insert via WebSocket using diff-based approach
-	return c.WebSocketClient.InsertLines(page, projectInfo.ID, user.ID, targetLine, lines)
+	// Mark the new lines in the context of the page
+	newTexts := make([]string, 0, len(page.Lines)+len(lines))
+	for _, line := range page.Lines[:at] {
+		newTexts = append(newTexts, line.Text)
+	}
+	newTexts = append(newTexts, lines...)
+	for _, line := range page.Lines[at:] {
+		newTexts = append(newTexts, line.Text)
+	}
+	newTexts = c.markLines(page, newTexts)
+
+	// Insert via WebSocket
+	return c.describeWrite(c.WebSocketClient.insertAt(page, projectInfo.ID, user.ID, at, newTexts[at:at+len(lines)]))
 }
 
 // PatchPage is a convenience method on Client.
 // It replaces the entire page content with new lines.
 // The first line in newTexts becomes the page title.
-func (c *Client) PatchPage(pageTitle string, newTexts []string) error {
+func (c *Client) PatchPage(pageTitle string, newTexts []string, expectedCommitID string) (*WriteResult, error) {
+	if c.WebSocketClient == nil {
+		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeWebSocketFail, "WebSocket client not initialized", nil)
+	}
+
+	// Serialize with other writes so each one diffs against the latest page
+	release := c.writes.acquire()
+	defer release()
+
 	// Get the current page
 	page, err := c.RESTClient.GetPage(c.ProjectName, pageTitle)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if err := checkExpectedCommit(page, expectedCommitID); err != nil {
+		return nil, err
 	}
 
 	// Get user ID
 	user, err := c.RESTClient.GetMe()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Get project ID
 	projectInfo, err := c.RESTClient.GetProject(c.ProjectName)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Patch via WebSocket using diff-based approach
-	return c.WebSocketClient.PatchPage(page, projectInfo.ID, user.ID, newTexts)
+	return c.describeWrite(c.WebSocketClient.PatchPage(page, projectInfo.ID, user.ID, c.markLines(page, newTexts)))
 }
 
 // CreatePage is a convenience method on Client to create a new page.
-// If the page already exists, it updates the page content instead.
-func (c *Client) CreatePage(title string, bodyLines []string) error {
+// ifExists decides whether an existing page is an error, appended to or overwritten.
+func (c *Client) CreatePage(title string, bodyLines []string, ifExists IfExists) (*WriteResult, error) {
+	if c.WebSocketClient == nil {
+		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeWebSocketFail, "WebSocket client not initialized", nil)
+	}
+
+	// Serialize with other writes so each one diffs against the latest page
+	release := c.writes.acquire()
+	defer release()
+
 	// Get page info - Scrapbox returns page info even for non-existent pages
 	existingPage, err := c.RESTClient.GetPage(c.ProjectName, title)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	exists := existingPage.CommitID != ""
+	if exists && ifExists != IfExistsAppend && ifExists != IfExistsOverwrite {
+		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodePageExists, fmt.Sprintf("Page already exists: %s", title), nil)
 	}
 
 	// Parse bodyLines if it's a single string with newlines
@@ -547,23 +1122,169 @@ func (c *Client) CreatePage(title string, bodyLines []string) error {
 	// Get user ID
 	user, err := c.RESTClient.GetMe()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Get project ID
 	projectInfo, err := c.RESTClient.GetProject(c.ProjectName)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// If page exists (has commitId), update it using PatchPage
-	if existingPage.CommitID != "" {
-		// Build new content: title + body lines
+	if exists {
+		// Overwrite keeps only the title; append keeps the current lines
 		newTexts := []string{title}
+		if ifExists == IfExistsAppend {
+			newTexts = newTexts[:0]
+			for _, line := range existingPage.Lines {
+				newTexts = append(newTexts, line.Text)
+			}
+		}
 		newTexts = append(newTexts, lines...)
-		return c.WebSocketClient.PatchPage(existingPage, projectInfo.ID, user.ID, newTexts)
+		return c.describeWrite(c.WebSocketClient.PatchPage(existingPage, projectInfo.ID, user.ID, c.markLines(existingPage, newTexts)))
 	}
 
 	// New page: create with all lines at once
-	return c.WebSocketClient.CreatePage(existingPage.ID, projectInfo.ID, user.ID, title, lines)
+	if c.lineMarker != nil {
+		lines = c.markLines(nil, append([]string{title}, lines...))[1:]
+	}
+	return c.describeWrite(c.WebSocketClient.CreatePage(existingPage.ID, projectInfo.ID, user.ID, title, lines))
+}
+
+// DeletePage is a convenience method on Client to delete a page
+func (c *Client) DeletePage(title string, expectedCommitID string) (*WriteResult, error) {
+	if c.WebSocketClient == nil {
+		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeWebSocketFail, "WebSocket client not initialized", nil)
+	}
+
+	// Serialize with other writes so each one diffs against the latest page
+	release := c.writes.acquire()
+	defer release()
+
+	page, err := c.RESTClient.GetPage(c.ProjectName, title)
+	if err != nil {
+		return nil, err
+	}
+	if page.CommitID == "" {
+		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNotFound, fmt.Sprintf("Page not found: %s", title), nil)
+	}
+	if err := checkExpectedCommit(page, expectedCommitID); err != nil {
+		return nil, err
+	}
+
+	// Get user ID
+	user, err := c.RESTClient.GetMe()
+	if err != nil {
+		return nil, err
+	}
+
+	// Get project ID
+	projectInfo, err := c.RESTClient.GetProject(c.ProjectName)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.describeWrite(c.WebSocketClient.DeletePage(page, projectInfo.ID, user.ID))
+}
+
+// SetPin is a convenience method on Client to pin or unpin a page. Like the
+// Scrapbox UI, newly pinned pages get a pin value that sorts them first.
+func (c *Client) SetPin(title string, pinned bool, expectedCommitID string) (*WriteResult, error) {
+	if c.WebSocketClient == nil {
+		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeWebSocketFail, "WebSocket client not initialized", nil)
+	}
+
+	// Serialize with other writes so each one diffs against the latest page
+	release := c.writes.acquire()
+	defer release()
+
+	page, err := c.RESTClient.GetPage(c.ProjectName, title)
+	if err != nil {
+		return nil, err
+	}
+	if page.CommitID == "" {
+		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNotFound, fmt.Sprintf("Page not found: %s", title), nil)
+	}
+	if err := checkExpectedCommit(page, expectedCommitID); err != nil {
+		return nil, err
+	}
+
+	// Get user ID
+	user, err := c.RESTClient.GetMe()
+	if err != nil {
+		return nil, err
+	}
+
+	// Get project ID
+	projectInfo, err := c.RESTClient.GetProject(c.ProjectName)
+	if err != nil {
+		return nil, err
+	}
+
+	pin := 0
+	if pinned {
+		pin = pinValue(time.Now())
+	}
+	return c.describeWrite(c.WebSocketClient.SetPin(page, projectInfo.ID, user.ID, pin))
+}
+
+// pinValue is the pin of a page pinned at t: Number.MAX_SAFE_INTEGER minus
+// the Unix time, so the most recently pinned page comes first
+func pinValue(t time.Time) int {
+	return 1<<53 - 1 - int(t.Unix())
+}
+
+// UpdateLine is a convenience method on Client to replace the text of the
+// line with lineID
+func (c *Client) UpdateLine(pageTitle, lineID, text string, expectedCommitID string) (*WriteResult, error) {
+	if c.WebSocketClient == nil {
+		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeWebSocketFail, "WebSocket client not initialized", nil)
+	}
+
+	// Serialize with other writes so each one diffs against the latest page
+	release := c.writes.acquire()
+	defer release()
+
+	page, err := c.RESTClient.GetPage(c.ProjectName, pageTitle)
+	if err != nil {
+		return nil, err
+	}
+	if page.CommitID == "" {
+		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNotFound, fmt.Sprintf("Page not found: %s", pageTitle), nil)
+	}
+	if err := checkExpectedCommit(page, expectedCommitID); err != nil {
+		return nil, err
+	}
+	index := -1
+	for i, line := range page.Lines {
+		if line.ID == lineID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, mcperrors.NewScrapboxError(mcperrors.ErrCodeNotFound, fmt.Sprintf("Line not found in page %s: %s", pageTitle, lineID), nil)
+	}
+
+	// Get user ID
+	user, err := c.RESTClient.GetMe()
+	if err != nil {
+		return nil, err
+	}
+
+	// Get project ID
+	projectInfo, err := c.RESTClient.GetProject(c.ProjectName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Mark the new text in the context of the page
+	newTexts := make([]string, len(page.Lines))
+	for i, line := range page.Lines {
+		newTexts[i] = line.Text
+	}
+	newTexts[index] = text
+	text = c.markLines(page, newTexts)[index]
+
+	return c.describeWrite(c.WebSocketClient.UpdateLine(page, projectInfo.ID, user.ID, index, text))
 }
@@ -0,0 +1,91 @@
+package scrapbox
+
+import (
+	"sync"
+	"time"
+)
+
+// WriteQueueStats reports how write operations to a project are queueing
+type WriteQueueStats struct {
+	// Depth is the number of writes currently waiting for their turn
+	Depth int `json:"depth"`
+	// Completed is the number of writes that have acquired the queue
+	Completed int64 `json:"completed"`
+	// AvgWaitMs and MaxWaitMs are the average and longest time spent waiting
+	AvgWaitMs float64 `json:"avgWaitMs"`
+	MaxWaitMs int64   `json:"maxWaitMs"`
+}
+
+// writeQueue serializes write operations on a project so that concurrent tool
+// calls do not interleave their page fetch, diff and commit on the shared
+// connection (which would otherwise commit against an outdated parent).
+type writeQueue struct {
+	slot chan struct{}
+
+	mu        sync.Mutex
+	waiting   int
+	completed int64
+	totalWait time.Duration
+	maxWait   time.Duration
+}
+
+// writeQueues holds the queue of each project, keyed by API base URL and
+// project name, so every Client writing to a project (the server's and those
+// of clients that supplied their own credentials) shares one queue. There is
+// one small entry per project ever written to.
+var writeQueues = struct {
+	mu     sync.Mutex
+	queues map[string]*writeQueue
+}{queues: make(map[string]*writeQueue)}
+
+// projectWriteQueue returns the shared write queue of a project
+func projectWriteQueue(baseURL, project string) *writeQueue {
+	key := baseURL + "\x00" + project
+	writeQueues.mu.Lock()
+	defer writeQueues.mu.Unlock()
+	q, ok := writeQueues.queues[key]
+	if !ok {
+		q = &writeQueue{slot: make(chan struct{}, 1)}
+		writeQueues.queues[key] = q
+	}
+	return q
+}
+
+// acquire blocks until it is the caller's turn and returns the release function
+func (q *writeQueue) acquire() func() {
+	start := time.Now()
+
+	q.mu.Lock()
+	q.waiting++
+	q.mu.Unlock()
+
+	q.slot <- struct{}{}
+	wait := time.Since(start)
+
+	q.mu.Lock()
+	q.waiting--
+	q.completed++
+	q.totalWait += wait
+	if wait > q.maxWait {
+		q.maxWait = wait
+	}
+	q.mu.Unlock()
+
+	return func() { <-q.slot }
+}
+
+// stats returns a snapshot of the queue metrics
+func (q *writeQueue) stats() WriteQueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := WriteQueueStats{
+		Depth:     q.waiting,
+		Completed: q.completed,
+		MaxWaitMs: q.maxWait.Milliseconds(),
+	}
+	if q.completed > 0 {
+		stats.AvgWaitMs = float64(q.totalWait.Microseconds()) / float64(q.completed) / 1000
+	}
+	return stats
+}
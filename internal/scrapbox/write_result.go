@@ -0,0 +1,87 @@
+package scrapbox
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/hiroki/scrapbox_mcp/pkg/sio"
+)
+
+// WriteResult describes the page a successful write left behind
+type WriteResult struct {
+	Project string
+	Title   string
+	PageID  string
+	// CommitID is the page's commit after the write; unchanged when the write
+	// had nothing to do, empty when the server did not report it
+	CommitID string
+	Deleted  bool
+	// Lines are the page's lines after the write, empty for a deleted page.
+	// The title line of a newly created page has no ID.
+	Lines []Line
+	// Changed holds the indexes in Lines of the lines the write inserted or
+	// rewrote; for InsertLines, those of the inserted lines
+	Changed []int
+	// InsertedLineIDs are the IDs generated for the lines the write inserted,
+	// in page order
+	InsertedLineIDs []string
+	// URL is the page's web URL
+	URL string
+}
+
+// LineURL returns the URL of the page scrolled to the line with the given ID
+func (r *WriteResult) LineURL(lineID string) string {
+	if lineID == "" {
+		return r.URL
+	}
+	return r.URL + "#" + lineID
+}
+
+// lineRange returns the indexes from at to at+n-1
+func lineRange(at, n int) []int {
+	indexes := make([]int, n)
+	for i := range indexes {
+		indexes[i] = at + i
+	}
+	return indexes
+}
+
+// PageURL returns the web URL of a page, on the instance serving project
+func (c *RESTClient) PageURL(project, title string) string {
+	base := strings.TrimSuffix(strings.TrimSuffix(c.baseURLFor(project), "/"), "/api")
+	return base + "/" + url.PathEscape(project) + "/" + url.PathEscape(strings.ReplaceAll(title, " ", "_"))
+}
+
+// PageURL returns the web URL of a page
+func (c *Client) PageURL(project, title string) string {
+	return c.RESTClient.PageURL(project, title)
+}
+
+// describeWrite completes the result of a write to the default project
+func (c *Client) describeWrite(result *WriteResult, err error) (*WriteResult, error) {
+	if err != nil {
+		return nil, err
+	}
+	result.Project = c.ProjectName
+	result.URL = c.PageURL(c.ProjectName, result.Title)
+	return result, nil
+}
+
+// commitIDFromACK returns the commit ID a commit's ACK reports, if any
+func commitIDFromACK(ack *sio.Packet) string {
+	args := ack.Args()
+	if len(args) == 0 {
+		return ""
+	}
+
+	var ackData struct {
+		Data struct {
+			CommitID string `json:"commitId"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(args[0], &ackData); err != nil {
+		return ""
+	}
+	return ackData.Data.CommitID
+}
@@ -0,0 +1,115 @@
+package scrapboxtest
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/pkg/sio"
+)
+
+// pollingSession is a Socket.IO connection over Engine.IO long-polling.
+// Frames for the client wait in frames until a GET request collects them.
+type pollingSession struct {
+	conn      *socketConn
+	frames    chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// payloadSeparator separates the packets of a long-polling payload
+var payloadSeparator = []byte{0x1e}
+
+// servePolling answers the requests of the long-polling transport: a GET
+// without sid opens a session, GET waits for frames, POST delivers frames
+func (s *Server) servePolling(w http.ResponseWriter, r *http.Request) {
+	sid := r.URL.Query().Get("sid")
+	if sid == "" {
+		if r.Method != http.MethodGet {
+			http.Error(w, `{"code":2,"message":"Bad handshake method"}`, http.StatusBadRequest)
+			return
+		}
+		session := &pollingSession{frames: make(chan []byte, 256), done: make(chan struct{})}
+		session.conn = s.newSocketConn(r, func(frame []byte) error {
+			select {
+			case <-session.done:
+				return errors.New("session closed")
+			case session.frames <- frame:
+				return nil
+			default:
+				return errors.New("polling buffer full")
+			}
+		})
+		s.mu.Lock()
+		s.polls[session.conn.sid] = session
+		s.mu.Unlock()
+		go s.ping(session.conn, session.done)
+		w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+		w.Write(s.openFrame(session.conn))
+		return
+	}
+
+	s.mu.Lock()
+	session := s.polls[sid]
+	s.mu.Unlock()
+	if session == nil {
+		http.Error(w, `{"code":1,"message":"Session ID unknown"}`, http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		var frames [][]byte
+		select {
+		case frame := <-session.frames:
+			frames = append(frames, frame)
+		case <-session.done:
+			frames = append(frames, sio.CloseFrame)
+		case <-r.Context().Done():
+			return
+		case <-time.After(pingInterval + pingTimeout):
+			// The client stopped answering pings
+			s.closePolling(session)
+			frames = append(frames, sio.CloseFrame)
+		}
+		for more := true; more; {
+			select {
+			case frame := <-session.frames:
+				frames = append(frames, frame)
+			default:
+				more = false
+			}
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+		w.Write(bytes.Join(frames, payloadSeparator))
+	case http.MethodPost:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return
+		}
+		for _, frame := range bytes.Split(body, payloadSeparator) {
+			if !s.receive(session.conn, frame) {
+				s.closePolling(session)
+				break
+			}
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+		w.Write([]byte("ok"))
+	default:
+		http.Error(w, `{"code":2,"message":"Bad request"}`, http.StatusBadRequest)
+	}
+}
+
+// closePolling ends a long-polling session
+func (s *Server) closePolling(session *pollingSession) {
+	session.closeOnce.Do(func() {
+		close(session.done)
+		s.mu.Lock()
+		delete(s.polls, session.conn.sid)
+		s.mu.Unlock()
+		s.dropConn(session.conn)
+	})
+}
@@ -0,0 +1,489 @@
+// Package scrapboxtest provides an in-memory fake of the Scrapbox REST API and
+// Socket.IO endpoint, so the whole MCP flow can run without real credentials.
+package scrapboxtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+// DefaultSessionCookie is the connect.sid value accepted by a new Server
+const DefaultSessionCookie = "fake-session"
+
+// Server is a fake Scrapbox instance hosting one private project. Requests
+// must carry the session cookie; commits are applied to the in-memory pages
+// and pushed to connections that joined the project updates stream.
+type Server struct {
+	// SessionCookie is the connect.sid value the server accepts
+	SessionCookie string
+	// DisableWebSocket rejects WebSocket upgrades like a proxy that blocks
+	// them, so clients have to use long-polling
+	DisableWebSocket bool
+
+	httpServer *httptest.Server
+	project    string
+	projectID  string
+	user       scrapbox.User
+
+	mu     sync.Mutex
+	pages  map[string]*scrapbox.Page // keyed by lower-cased title
+	nextID int64
+	rooms  map[*socketConn]bool
+	polls  map[string]*pollingSession // keyed by session ID
+	// commits is the history of each page, keyed by page ID, oldest first
+	commits map[string][]scrapbox.Commit
+}
+
+// New starts a fake Scrapbox serving project on a local port. Close it when done.
+func New(project string) *Server {
+	s := &Server{
+		SessionCookie: DefaultSessionCookie,
+		project:       project,
+		pages:         make(map[string]*scrapbox.Page),
+		rooms:         make(map[*socketConn]bool),
+		polls:         make(map[string]*pollingSession),
+		commits:       make(map[string][]scrapbox.Commit),
+	}
+	s.projectID = s.newID()
+	s.user = scrapbox.User{
+		ID:          s.newID(),
+		Name:        "fake-user",
+		DisplayName: "Fake User",
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/", s.serveAPI)
+	mux.HandleFunc("/socket.io/", s.serveSocket)
+	s.httpServer = httptest.NewServer(mux)
+	return s
+}
+
+// Close shuts the server down
+func (s *Server) Close() {
+	s.httpServer.CloseClientConnections()
+	s.httpServer.Close()
+}
+
+// APIURL is the REST base URL (SCRAPBOX_API_URL)
+func (s *Server) APIURL() string {
+	return s.httpServer.URL + "/api"
+}
+
+// WebSocketURL is the Socket.IO URL (SCRAPBOX_WS_URL)
+func (s *Server) WebSocketURL() string {
+	return "ws" + strings.TrimPrefix(s.httpServer.URL, "http") + "/socket.io/"
+}
+
+// Project returns the name of the hosted project
+func (s *Server) Project() string {
+	return s.project
+}
+
+// AddPage creates or replaces a page; the title becomes the first line
+func (s *Server) AddPage(title string, body ...string) *scrapbox.Page {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().Unix()
+	page := &scrapbox.Page{
+		ID:       s.newID(),
+		Title:    title,
+		User:     s.user,
+		CommitID: s.newID(),
+		Created:  now,
+		Updated:  now,
+		Accessed: now,
+	}
+	// The history starts with a commit creating the lines
+	changes := []map[string]interface{}{{"title": title}}
+	for _, text := range append([]string{title}, body...) {
+		line := s.newLine(text, now)
+		page.Lines = append(page.Lines, line)
+		changes = append(changes, map[string]interface{}{
+			"_insert": "_end",
+			"lines":   map[string]interface{}{"id": line.ID, "text": line.Text},
+		})
+	}
+	s.pages[strings.ToLower(title)] = page
+	s.commits[page.ID] = []scrapbox.Commit{{
+		ID:        page.CommitID,
+		ProjectID: s.projectID,
+		PageID:    page.ID,
+		UserID:    s.user.ID,
+		Kind:      "page",
+		Created:   now,
+		Changes:   changes,
+	}}
+	return copyPage(page)
+}
+
+// Page returns a copy of the page with title
+func (s *Server) Page(title string) (*scrapbox.Page, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	page, ok := s.pages[strings.ToLower(title)]
+	if !ok {
+		return nil, false
+	}
+	return copyPage(page), true
+}
+
+// newID returns a new 24-digit hex ID like the ones Scrapbox generates.
+// The caller must hold s.mu or be the constructor.
+func (s *Server) newID() string {
+	s.nextID++
+	return fmt.Sprintf("%08x%016x", time.Now().Unix(), s.nextID)
+}
+
+func (s *Server) newLine(text string, now int64) scrapbox.Line {
+	return scrapbox.Line{ID: s.newID(), Text: text, UserID: s.user.ID, Created: now, Updated: now}
+}
+
+func copyPage(page *scrapbox.Page) *scrapbox.Page {
+	c := *page
+	c.Lines = append([]scrapbox.Line(nil), page.Lines...)
+	return &c
+}
+
+// authenticated reports whether r carries the session cookie
+func (s *Server) authenticated(r *http.Request) bool {
+	cookie, err := r.Cookie("connect.sid")
+	return err == nil && cookie.Value == s.SessionCookie
+}
+
+// apiError writes an error in the shape of the Scrapbox API
+func apiError(w http.ResponseWriter, status int, name, message string) {
+	writeJSON(w, status, map[string]string{"name": name, "message": message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// serveAPI routes the REST endpoints used by the client
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apiError(w, http.StatusNotFound, "NotFoundError", "Not supported by the fake server")
+		return
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(strings.TrimPrefix(r.URL.EscapedPath(), "/api/"), "/") {
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil {
+			apiError(w, http.StatusBadRequest, "BadRequestError", "Invalid path")
+			return
+		}
+		segments = append(segments, unescaped)
+	}
+
+	if len(segments) == 2 && segments[0] == "users" && segments[1] == "me" {
+		s.serveMe(w, r)
+		return
+	}
+	if !s.authenticated(r) {
+		apiError(w, http.StatusUnauthorized, "NotLoggedInError", "Log in to access this project")
+		return
+	}
+
+	switch {
+	case len(segments) == 2 && segments[0] == "projects":
+		if !s.checkProject(w, segments[1]) {
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"id":            s.projectID,
+			"name":          s.project,
+			"displayName":   s.project,
+			"publicVisible": false,
+		})
+	case len(segments) == 2 && segments[0] == "pages":
+		if s.checkProject(w, segments[1]) {
+			s.serveList(w, r)
+		}
+	case len(segments) == 4 && segments[0] == "pages" && segments[2] == "search" && segments[3] == "query":
+		if s.checkProject(w, segments[1]) {
+			s.serveSearch(w, r)
+		}
+	case len(segments) == 3 && segments[0] == "pages":
+		if s.checkProject(w, segments[1]) {
+			s.servePage(w, segments[2])
+		}
+	case segments[0] == "stream" && (len(segments) == 2 || len(segments) == 3 && segments[2] == ""):
+		if s.checkProject(w, segments[1]) {
+			s.serveStream(w)
+		}
+	case len(segments) == 3 && segments[0] == "commits":
+		if s.checkProject(w, segments[1]) {
+			s.serveCommits(w, segments[2])
+		}
+	case len(segments) == 3 && segments[0] == "page-data" && segments[1] == "export" && strings.HasSuffix(segments[2], ".json"):
+		if s.checkProject(w, strings.TrimSuffix(segments[2], ".json")) {
+			s.serveExport(w)
+		}
+	default:
+		apiError(w, http.StatusNotFound, "NotFoundError", "Not found")
+	}
+}
+
+func (s *Server) checkProject(w http.ResponseWriter, project string) bool {
+	if project != s.project {
+		apiError(w, http.StatusNotFound, "NotFoundError", "Project not found")
+		return false
+	}
+	return true
+}
+
+func (s *Server) serveMe(w http.ResponseWriter, r *http.Request) {
+	if !s.authenticated(r) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"isGuest": true})
+		return
+	}
+	// Only /users/me carries the CSRF token
+	me := s.user
+	me.CsrfToken = "fake-csrf-token"
+	writeJSON(w, http.StatusOK, me)
+}
+
+// sortedPages returns the pages, most recently updated first. The caller must hold s.mu.
+func (s *Server) sortedPages() []*scrapbox.Page {
+	pages := make([]*scrapbox.Page, 0, len(s.pages))
+	for _, page := range s.pages {
+		pages = append(pages, page)
+	}
+	sort.Slice(pages, func(i, j int) bool {
+		if pages[i].Updated != pages[j].Updated {
+			return pages[i].Updated > pages[j].Updated
+		}
+		return pages[i].Title < pages[j].Title
+	})
+	return pages
+}
+
+func (s *Server) serveList(w http.ResponseWriter, r *http.Request) {
+	limit := queryInt(r, "limit", 100)
+	skip := queryInt(r, "skip", 0)
+
+	s.mu.Lock()
+	// Like Scrapbox, pinned pages are listed first whatever the sort order
+	pages := s.sortedPages()
+	sort.SliceStable(pages, func(i, j int) bool {
+		return pages[i].Pin != 0 && pages[j].Pin == 0
+	})
+	resp := scrapbox.PagesResponse{ProjectName: s.project, Skip: skip, Limit: limit, Count: len(pages), Pages: []scrapbox.PageInfo{}}
+	for i := skip; i < len(pages) && i < skip+limit; i++ {
+		page := pages[i]
+		resp.Pages = append(resp.Pages, scrapbox.PageInfo{
+			ID:             page.ID,
+			Title:          page.Title,
+			Descriptions:   descriptions(page),
+			Views:          page.Views,
+			Linked:         page.Linked,
+			Created:        page.Created,
+			Updated:        page.Updated,
+			Accessed:       page.Accessed,
+			Pin:            page.Pin,
+			User:           &scrapbox.User{ID: s.user.ID, Name: s.user.Name, DisplayName: s.user.DisplayName},
+			LastUpdateUser: &scrapbox.User{ID: s.user.ID, Name: s.user.Name, DisplayName: s.user.DisplayName},
+		})
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// serveSearch matches pages containing every word of the query, case-insensitively.
+// Words starting with "-" exclude pages; "quoted phrases" match as a whole.
+func (s *Server) serveSearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	limit := queryInt(r, "limit", 100)
+	skip := queryInt(r, "skip", 0)
+
+	resp := scrapbox.SearchResponse{
+		ProjectName: s.project,
+		SearchQuery: query,
+		Limit:       limit,
+		Skip:        skip,
+		Pages:       []scrapbox.SearchPageInfo{},
+		Field:       "title,lines",
+		Query:       scrapbox.ParseSearchQuery(query),
+		Backend:     "fake",
+	}
+
+	s.mu.Lock()
+	var matches []scrapbox.SearchPageInfo
+	for _, page := range s.sortedPages() {
+		if strings.EqualFold(page.Title, query) {
+			resp.ExistsExactTitleMatch = true
+		}
+		if match, ok := matchPage(page, resp.Query); ok {
+			matches = append(matches, match)
+		}
+	}
+	s.mu.Unlock()
+
+	resp.Count = len(matches)
+	for i := skip; i < len(matches) && i < skip+limit; i++ {
+		resp.Pages = append(resp.Pages, matches[i])
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func matchPage(page *scrapbox.Page, query scrapbox.SearchQuery) (scrapbox.SearchPageInfo, bool) {
+	var texts []string
+	for _, line := range page.Lines {
+		texts = append(texts, strings.ToLower(line.Text))
+	}
+	content := strings.Join(texts, "\n")
+	if len(query.Words) == 0 {
+		return scrapbox.SearchPageInfo{}, false
+	}
+	for _, word := range query.Words {
+		if !strings.Contains(content, word) {
+			return scrapbox.SearchPageInfo{}, false
+		}
+	}
+	for _, word := range query.Excludes {
+		if strings.Contains(content, word) {
+			return scrapbox.SearchPageInfo{}, false
+		}
+	}
+
+	match := scrapbox.SearchPageInfo{ID: page.ID, Title: page.Title, Words: query.Words}
+	for i, line := range page.Lines {
+		if i == 0 {
+			continue
+		}
+		for _, word := range query.Words {
+			if strings.Contains(texts[i], word) {
+				match.Lines = append(match.Lines, line.Text)
+				break
+			}
+		}
+	}
+	return match, true
+}
+
+// servePage returns the page, or like Scrapbox a new unsaved page with a
+// fresh ID and an empty commit ID when it does not exist
+func (s *Server) servePage(w http.ResponseWriter, title string) {
+	s.mu.Lock()
+	page, ok := s.pages[strings.ToLower(title)]
+	if ok {
+		page.Views++
+		page.Accessed = time.Now().Unix()
+		page = copyPage(page)
+		user := s.user
+		page.LastUpdateUser = &user
+	} else {
+		now := time.Now().Unix()
+		page = &scrapbox.Page{ID: s.newID(), Title: title, User: s.user, Lines: []scrapbox.Line{s.newLine(title, now)}}
+	}
+	s.mu.Unlock()
+
+	page.Descriptions = descriptions(page)
+	writeJSON(w, http.StatusOK, page)
+}
+
+// serveStream returns the most recently updated pages with their lines
+func (s *Server) serveStream(w http.ResponseWriter) {
+	s.mu.Lock()
+	resp := scrapbox.StreamResponse{Pages: []scrapbox.StreamPage{}}
+	for i, page := range s.sortedPages() {
+		if i == 50 {
+			break
+		}
+		user := s.user
+		resp.Pages = append(resp.Pages, scrapbox.StreamPage{
+			PageInfo: scrapbox.PageInfo{
+				ID:             page.ID,
+				Title:          page.Title,
+				Descriptions:   descriptions(page),
+				Created:        page.Created,
+				Updated:        page.Updated,
+				Accessed:       page.Accessed,
+				User:           &user,
+				LastUpdateUser: &user,
+			},
+			Lines: append([]scrapbox.Line(nil), page.Lines...),
+		})
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// serveCommits returns the history of the page with pageID, oldest first
+func (s *Server) serveCommits(w http.ResponseWriter, pageID string) {
+	s.mu.Lock()
+	commits, ok := s.commits[pageID]
+	commits = append([]scrapbox.Commit(nil), commits...)
+	s.mu.Unlock()
+	if !ok {
+		apiError(w, http.StatusNotFound, "NotFoundError", "Page not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, scrapbox.CommitsResponse{Commits: commits})
+}
+
+func (s *Server) serveExport(w http.ResponseWriter) {
+	type exportPage struct {
+		ID      string          `json:"id"`
+		Title   string          `json:"title"`
+		Created int64           `json:"created"`
+		Updated int64           `json:"updated"`
+		Views   int             `json:"views"`
+		Lines   []scrapbox.Line `json:"lines"`
+	}
+
+	s.mu.Lock()
+	export := struct {
+		Name        string       `json:"name"`
+		DisplayName string       `json:"displayName"`
+		Exported    int64        `json:"exported"`
+		Pages       []exportPage `json:"pages"`
+	}{Name: s.project, DisplayName: s.project, Exported: time.Now().Unix(), Pages: []exportPage{}}
+	for _, page := range s.sortedPages() {
+		export.Pages = append(export.Pages, exportPage{
+			ID:      page.ID,
+			Title:   page.Title,
+			Created: page.Created,
+			Updated: page.Updated,
+			Views:   page.Views,
+			Lines:   append([]scrapbox.Line(nil), page.Lines...),
+		})
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, export)
+}
+
+// descriptions returns the first body lines of a page, like the Scrapbox list API
+func descriptions(page *scrapbox.Page) []string {
+	descriptions := []string{}
+	for i := 1; i < len(page.Lines) && len(descriptions) < 5; i++ {
+		if text := strings.TrimSpace(page.Lines[i].Text); text != "" {
+			descriptions = append(descriptions, page.Lines[i].Text)
+		}
+	}
+	return descriptions
+}
+
+func queryInt(r *http.Request, name string, fallback int) int {
+	if value, err := strconv.Atoi(r.URL.Query().Get(name)); err == nil && value >= 0 {
+		return value
+	}
+	return fallback
+}
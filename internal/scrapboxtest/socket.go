@@ -0,0 +1,434 @@
+package scrapboxtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	"github.com/hiroki/scrapbox_mcp/pkg/sio"
+)
+
+// Engine.IO timing announced in the open packet
+const (
+	pingInterval = 25 * time.Second
+	pingTimeout  = 20 * time.Second
+)
+
+// socketConn is one Socket.IO connection over WebSocket or long-polling;
+// writes come from the request handlers, the pinger and project updates broadcasts
+type socketConn struct {
+	mu            sync.Mutex
+	write         func(frame []byte) error
+	sid           string
+	authenticated bool
+	// connected is set by the Socket.IO CONNECT packet
+	connected bool
+	// namespace is the one the client connected to; every packet uses it
+	namespace string
+}
+
+func (c *socketConn) send(frame []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.write(frame)
+}
+
+// ack answers the request with ackID with a data or error payload
+func (c *socketConn) ack(ackID int, payload interface{}) error {
+	packet, err := sio.NewAck(ackID, payload)
+	if err != nil {
+		return err
+	}
+	packet.Namespace = c.namespace
+	return c.send(packet.Encode())
+}
+
+// commitError is the error object of a rejected commit, e.g. NotFastForwardError
+type commitError struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+// socketRequest is the body of a "socket.io-request" event
+type socketRequest struct {
+	Method string          `json:"method"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// commitRequest is the data of a commit request
+type commitRequest struct {
+	Kind      string                   `json:"kind"`
+	ProjectID string                   `json:"projectId"`
+	PageID    string                   `json:"pageId"`
+	ParentID  *string                  `json:"parentId"`
+	UserID    string                   `json:"userId"`
+	Changes   []map[string]interface{} `json:"changes"`
+}
+
+// serveSocket speaks Engine.IO v4 / Socket.IO over WebSocket or long-polling
+// and answers socket.io-request events with ACKs
+func (s *Server) serveSocket(w http.ResponseWriter, r *http.Request) {
+	transport := r.URL.Query().Get("transport")
+	switch {
+	case r.URL.Query().Get("EIO") != "4":
+	case transport == "websocket" && s.DisableWebSocket:
+		http.Error(w, "WebSocket upgrades are blocked", http.StatusForbidden)
+		return
+	case transport == "websocket":
+		s.serveWebSocket(w, r)
+		return
+	case transport == "polling":
+		s.servePolling(w, r)
+		return
+	}
+	http.Error(w, `{"code":0,"message":"Transport unknown"}`, http.StatusBadRequest)
+}
+
+// newSocketConn creates a connection with a new session ID that writes frames with write
+func (s *Server) newSocketConn(r *http.Request, write func([]byte) error) *socketConn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &socketConn{write: write, sid: s.newID(), authenticated: s.authenticated(r)}
+}
+
+// openFrame is the Engine.IO open packet of c
+func (s *Server) openFrame(c *socketConn) []byte {
+	return sio.EncodeOpen(sio.OpenParams{
+		SID:          c.sid,
+		PingInterval: int(pingInterval.Milliseconds()),
+		PingTimeout:  int(pingTimeout.Milliseconds()),
+		MaxPayload:   1000000,
+	})
+}
+
+// ping sends Engine.IO pings to c every pingInterval until done is closed
+func (s *Server) ping(c *socketConn, done chan struct{}) {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if c.send(sio.PingFrame) != nil {
+				return
+			}
+		}
+	}
+}
+
+// dropConn removes a closed connection from the project updates room
+func (s *Server) dropConn(c *socketConn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.rooms, c)
+}
+
+// receive handles one frame from the client and reports whether the
+// connection stays open
+func (s *Server) receive(c *socketConn, message []byte) bool {
+	engineType, _, err := sio.DecodeEngine(message)
+	if err != nil || engineType == sio.EngineClose {
+		return false
+	}
+	if engineType == sio.EnginePing {
+		c.send(sio.PongFrame)
+		return true
+	}
+	if engineType != sio.EngineMessage {
+		return true
+	}
+	packet, err := sio.Decode(message)
+	if err != nil {
+		// Like Socket.IO, a malformed packet ends the connection
+		return false
+	}
+
+	if !c.connected {
+		// Socket.IO CONNECT; any namespace is accepted and the auth payload is ignored
+		if packet.Type != sio.Connect {
+			return false
+		}
+		c.namespace = packet.Namespace
+		c.connected = true
+		connected := &sio.Packet{Type: sio.Connect, Namespace: c.namespace, Data: json.RawMessage(fmt.Sprintf(`{"sid":"%s"}`, c.sid))}
+		return c.send(connected.Encode()) == nil
+	}
+	if packet.Namespace != c.namespace {
+		return true
+	}
+	switch packet.Type {
+	case sio.Disconnect:
+		return false
+	case sio.Event:
+		s.handleEvent(c, packet)
+	}
+	return true
+}
+
+// serveWebSocket runs one connection over WebSocket
+func (s *Server) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	c := s.newSocketConn(r, func(frame []byte) error {
+		conn.SetWriteDeadline(time.Now().Add(pingTimeout))
+		return conn.WriteMessage(websocket.TextMessage, frame)
+	})
+	defer func() {
+		s.dropConn(c)
+		conn.Close()
+	}()
+
+	if c.send(s.openFrame(c)) != nil {
+		return
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go s.ping(c, done)
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil || !s.receive(c, message) {
+			return
+		}
+	}
+}
+
+// handleEvent handles a `42<ackId>["socket.io-request", {...}]` event
+func (s *Server) handleEvent(c *socketConn, packet *sio.Packet) {
+	if !packet.HasID {
+		// Requests without an ACK ID get no answer
+		return
+	}
+	ackID := packet.ID
+
+	args := packet.Args()
+	var req socketRequest
+	if packet.Name() != "socket.io-request" || len(args) == 0 || json.Unmarshal(args[0], &req) != nil {
+		c.ack(ackID, map[string]interface{}{"error": commitError{Name: "InvalidRequestError", Message: "Malformed request"}})
+		return
+	}
+	if !c.authenticated {
+		c.ack(ackID, map[string]interface{}{"error": commitError{Name: "NotLoggedInError", Message: "Log in to edit this project"}})
+		return
+	}
+
+	switch req.Method {
+	case "room:join":
+		var join struct {
+			ProjectID            string `json:"projectId"`
+			ProjectUpdatesStream bool   `json:"projectUpdatesStream"`
+		}
+		json.Unmarshal(req.Data, &join)
+		if join.ProjectID != s.projectID {
+			c.ack(ackID, map[string]interface{}{"error": commitError{Name: "NotMemberError", Message: "Not a member of the project"}})
+			return
+		}
+		if join.ProjectUpdatesStream {
+			s.mu.Lock()
+			s.rooms[c] = true
+			s.mu.Unlock()
+		}
+		c.ack(ackID, map[string]interface{}{"data": map[string]bool{"success": true}})
+	case "commit":
+		var commit commitRequest
+		if err := json.Unmarshal(req.Data, &commit); err != nil {
+			c.ack(ackID, map[string]interface{}{"error": commitError{Name: "InvalidChangeError", Message: "Malformed commit"}})
+			return
+		}
+		event, cerr := s.applyCommit(commit)
+		if cerr != nil {
+			c.ack(ackID, map[string]interface{}{"error": cerr})
+			return
+		}
+		c.ack(ackID, map[string]interface{}{"data": map[string]string{"commitId": event.ID}})
+		s.broadcast(event)
+	default:
+		c.ack(ackID, map[string]interface{}{"error": commitError{Name: "InvalidRequestError", Message: "Unknown method: " + req.Method}})
+	}
+}
+
+// broadcast pushes a commit to the connections in the project updates room
+func (s *Server) broadcast(event scrapbox.CommitEvent) {
+	packet, err := sio.NewEvent("projectUpdatesStream:commit", event)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	conns := make([]*socketConn, 0, len(s.rooms))
+	for c := range s.rooms {
+		conns = append(conns, c)
+	}
+	s.mu.Unlock()
+	for _, c := range conns {
+		packet.Namespace = c.namespace
+		c.send(packet.Encode())
+	}
+}
+
+// applyCommit applies the changes of a commit to the page. Like Scrapbox, the
+// commit must be based on the latest commit of the page (no parent for a new
+// page), and "_insert" places the line before the given line ID or at "_end".
+func (s *Server) applyCommit(commit commitRequest) (scrapbox.CommitEvent, *commitError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if commit.ProjectID != s.projectID {
+		return scrapbox.CommitEvent{}, &commitError{Name: "NotMemberError", Message: "Not a member of the project"}
+	}
+	var current *scrapbox.Page
+	for _, page := range s.pages {
+		if page.ID == commit.PageID {
+			current = page
+			break
+		}
+	}
+
+	now := time.Now().Unix()
+	var page *scrapbox.Page
+	switch {
+	case current == nil && commit.ParentID == nil:
+		page = &scrapbox.Page{ID: commit.PageID, User: s.user, Created: now}
+	case current != nil && commit.ParentID != nil && *commit.ParentID == current.CommitID:
+		page = copyPage(current)
+	default:
+		return scrapbox.CommitEvent{}, &commitError{Name: "NotFastForwardError", Message: "The commit is not based on the latest commit of the page"}
+	}
+
+	deleted := false
+	for _, change := range commit.Changes {
+		if err := s.applyChange(page, change, now, &deleted); err != nil {
+			return scrapbox.CommitEvent{}, err
+		}
+	}
+
+	if !deleted {
+		if page.Title == "" {
+			return scrapbox.CommitEvent{}, &commitError{Name: "InvalidChangeError", Message: "The page has no title"}
+		}
+		if other, ok := s.pages[strings.ToLower(page.Title)]; ok && other.ID != page.ID {
+			return scrapbox.CommitEvent{}, &commitError{Name: "DuplicateTitleError", Message: "A page with this title already exists: " + page.Title}
+		}
+	}
+	if current != nil {
+		delete(s.pages, strings.ToLower(current.Title))
+	}
+	if !deleted {
+		page.CommitID = s.newID()
+		page.Updated = now
+		s.pages[strings.ToLower(page.Title)] = page
+	}
+
+	// The history shows the title line of a new page as inserted by the commit
+	changes := commit.Changes
+	if current == nil && !deleted {
+		insert := map[string]interface{}{
+			"_insert": "_end",
+			"lines":   map[string]interface{}{"id": page.Lines[0].ID, "text": page.Lines[0].Text},
+		}
+		changes = append([]map[string]interface{}{insert}, changes...)
+	}
+
+	event := scrapbox.CommitEvent{
+		ID:        s.newID(),
+		Kind:      "page",
+		ProjectID: s.projectID,
+		PageID:    page.ID,
+		UserID:    commit.UserID,
+		Created:   now,
+	}
+	if !deleted {
+		event.ID = page.CommitID
+	}
+	parentID := ""
+	if commit.ParentID != nil {
+		parentID = *commit.ParentID
+	}
+	s.commits[page.ID] = append(s.commits[page.ID], scrapbox.Commit{
+		ID:        event.ID,
+		ParentID:  parentID,
+		ProjectID: s.projectID,
+		PageID:    page.ID,
+		UserID:    commit.UserID,
+		Kind:      "page",
+		Created:   now,
+		Changes:   changes,
+	})
+	return event, nil
+}
+
+// applyChange applies one change of a commit to page
+func (s *Server) applyChange(page *scrapbox.Page, change map[string]interface{}, now int64, deleted *bool) *commitError {
+	lineText := func() string {
+		lines, _ := change["lines"].(map[string]interface{})
+		text, _ := lines["text"].(string)
+		return text
+	}
+	lineIndex := func(id string) (int, *commitError) {
+		for i, line := range page.Lines {
+			if line.ID == id {
+				return i, nil
+			}
+		}
+		return -1, &commitError{Name: "InvalidChangeError", Message: "Line not found: " + id}
+	}
+
+	switch {
+	case change["deleted"] == true:
+		*deleted = true
+	case change["title"] != nil:
+		title, _ := change["title"].(string)
+		page.Title = title
+		// A new page starts with its title line
+		if len(page.Lines) == 0 {
+			page.Lines = append(page.Lines, s.newLine(title, now))
+		}
+	case change["pin"] != nil:
+		pin, _ := change["pin"].(float64)
+		page.Pin = int(pin)
+	case change["_insert"] != nil:
+		position, _ := change["_insert"].(string)
+		index := len(page.Lines)
+		if position != "_end" {
+			i, err := lineIndex(position)
+			if err != nil {
+				return err
+			}
+			index = i
+		}
+		line := s.newLine(lineText(), now)
+		if lines, ok := change["lines"].(map[string]interface{}); ok {
+			if id, ok := lines["id"].(string); ok && id != "" {
+				line.ID = id
+			}
+		}
+		page.Lines = append(page.Lines, scrapbox.Line{})
+		copy(page.Lines[index+1:], page.Lines[index:])
+		page.Lines[index] = line
+	case change["_update"] != nil:
+		id, _ := change["_update"].(string)
+		i, err := lineIndex(id)
+		if err != nil {
+			return err
+		}
+		page.Lines[i].Text = lineText()
+		page.Lines[i].Updated = now
+	case change["_delete"] != nil:
+		id, _ := change["_delete"].(string)
+		i, err := lineIndex(id)
+		if err != nil {
+			return err
+		}
+		page.Lines = append(page.Lines[:i], page.Lines[i+1:]...)
+	}
+	// Other changes (links, descriptions, ...) are derived data and ignored
+	return nil
+}
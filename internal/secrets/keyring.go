@@ -0,0 +1,100 @@
+// Package secrets encrypts values stored at rest, such as saved sessions
+// carrying Scrapbox cookies, with AES-256-GCM keys that can be rotated.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// sealedPrefix marks sealed values and the format version
+const sealedPrefix = "v1:"
+
+// keyIDLength is the number of bytes of a key's hash naming it in sealed values
+const keyIDLength = 4
+
+// Keyring seals with its first key and opens values sealed with any of its
+// keys, so a new key can be put first while older values still open
+type Keyring struct {
+	keys []key
+}
+
+type key struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// ParseKeys builds a keyring from base64-encoded 32-byte keys (e.g. from
+// openssl rand -base64 32), newest first
+func ParseKeys(encoded []string) (*Keyring, error) {
+	k := &Keyring{}
+	for i, entry := range encoded {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(entry)
+		if err != nil || len(raw) != 32 {
+			return nil, fmt.Errorf("key %d is not a base64-encoded 32-byte key", i+1)
+		}
+		block, err := aes.NewCipher(raw)
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %v", i+1, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %v", i+1, err)
+		}
+		sum := sha256.Sum256(raw)
+		k.keys = append(k.keys, key{id: hex.EncodeToString(sum[:keyIDLength]), aead: aead})
+	}
+	if len(k.keys) == 0 {
+		return nil, fmt.Errorf("no keys given")
+	}
+	return k, nil
+}
+
+// Seal encrypts plaintext with the newest key as v1:<key id>:<base64>
+func (k *Keyring) Seal(plaintext []byte) (string, error) {
+	current := k.keys[0]
+	nonce := make([]byte, current.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %v", err)
+	}
+	sealed := current.aead.Seal(nonce, nonce, plaintext, []byte(current.id))
+	return sealedPrefix + current.id + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a value returned by Seal with the key that sealed it
+func (k *Keyring) Open(value string) ([]byte, error) {
+	rest, ok := strings.CutPrefix(value, sealedPrefix)
+	id, payload, ok2 := strings.Cut(rest, ":")
+	if !ok || !ok2 {
+		return nil, fmt.Errorf("not a sealed value")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, fmt.Errorf("not a sealed value")
+	}
+	for _, candidate := range k.keys {
+		if candidate.id != id {
+			continue
+		}
+		size := candidate.aead.NonceSize()
+		if len(sealed) < size {
+			return nil, fmt.Errorf("sealed value is truncated")
+		}
+		plaintext, err := candidate.aead.Open(nil, sealed[:size], sealed[size:], []byte(id))
+		if err != nil {
+			return nil, fmt.Errorf("sealed value failed authentication")
+		}
+		return plaintext, nil
+	}
+	return nil, fmt.Errorf("sealed with unknown key %s", id)
+}
@@ -0,0 +1,159 @@
+// Package tasks finds todo items in page lines and flips their state. What
+// counts as a task is configurable: a marker at the start of a line (such as
+// "[ ]" and "✅") or a hashtag anywhere in it (such as #todo and #done).
+package tasks
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hiroki/scrapbox_mcp/pkg/notation"
+)
+
+// Convention is a pair of markers for open and done tasks. Markers starting
+// with "#" are tags; anything else is a line prefix.
+type Convention struct {
+	Open string
+	Done string
+}
+
+// DefaultConventions are used when none are configured
+var DefaultConventions = []Convention{
+	{Open: "[ ]", Done: "✅"},
+	{Open: "☐", Done: "☑"},
+	{Open: "#todo", Done: "#done"},
+}
+
+// ParseConventions parses open=done marker pairs, returning the defaults for an empty list
+func ParseConventions(entries []string) ([]Convention, error) {
+	if len(entries) == 0 {
+		return DefaultConventions, nil
+	}
+	conventions := make([]Convention, 0, len(entries))
+	for _, entry := range entries {
+		open, done, ok := strings.Cut(entry, "=")
+		open, done = strings.TrimSpace(open), strings.TrimSpace(done)
+		if !ok || open == "" || done == "" || open == done {
+			return nil, fmt.Errorf("invalid task marker %q (expected open=done, e.g. [ ]=✅ or #todo=#done)", entry)
+		}
+		if strings.HasPrefix(open, "#") != strings.HasPrefix(done, "#") {
+			return nil, fmt.Errorf("invalid task marker %q (both markers must be tags or both prefixes)", entry)
+		}
+		conventions = append(conventions, Convention{Open: open, Done: done})
+	}
+	return conventions, nil
+}
+
+func (c Convention) isTag() bool {
+	return strings.HasPrefix(c.Open, "#")
+}
+
+// Task is a todo item found in a page
+type Task struct {
+	// Line is the index of the line in the page (line 0 is the title)
+	Line int `json:"line"`
+	// Text is the line without indentation and the task marker
+	Text string `json:"text"`
+	Done bool   `json:"done"`
+}
+
+// Matcher recognizes tasks written in any of its conventions
+type Matcher struct {
+	conventions []Convention
+}
+
+func NewMatcher(conventions []Convention) *Matcher {
+	return &Matcher{conventions: conventions}
+}
+
+// Find returns the tasks in page lines. The title line, code blocks and
+// tables are skipped.
+func (m *Matcher) Find(lines []string) []Task {
+	var tasks []Task
+	if len(lines) <= 1 {
+		return tasks
+	}
+	lineIndex := 1
+	for _, block := range notation.Parse(lines[1:]).Blocks {
+		if block.Type == notation.BlockLine {
+			if task, ok := m.match(block.Line); ok {
+				task.Line = lineIndex
+				tasks = append(tasks, task)
+			}
+		}
+		lineIndex += block.Len()
+	}
+	return tasks
+}
+
+// Set returns line with its task marked done or open, and false if the line
+// is not a task
+func (m *Matcher) Set(line string, done bool) (string, bool) {
+	blocks := notation.Parse([]string{line}).Blocks
+	if len(blocks) != 1 || blocks[0].Type != notation.BlockLine {
+		return line, false
+	}
+	parsed := blocks[0].Line
+	for _, c := range m.conventions {
+		from, to := c.Open, c.Done
+		if !done {
+			from, to = c.Done, c.Open
+		}
+		if c.isTag() {
+			for _, state := range []string{from, to} {
+				if i := tagIndex(parsed.Nodes, state); i >= 0 {
+					parsed.Nodes[i].Text = to[1:]
+					return parsed.String(), true
+				}
+			}
+			continue
+		}
+		body := notation.FormatInline(parsed.Nodes)
+		for _, state := range []string{from, to} {
+			if strings.HasPrefix(body, state) {
+				parsed.Nodes = notation.ParseInline(to + body[len(state):])
+				return parsed.String(), true
+			}
+		}
+	}
+	return line, false
+}
+
+// match reports whether a parsed line is a task in any convention
+func (m *Matcher) match(line notation.Line) (Task, bool) {
+	body := notation.FormatInline(line.Nodes)
+	for _, c := range m.conventions {
+		if c.isTag() {
+			if i := tagIndex(line.Nodes, c.Open); i >= 0 {
+				return Task{Text: withoutNode(line.Nodes, i)}, true
+			}
+			if i := tagIndex(line.Nodes, c.Done); i >= 0 {
+				return Task{Text: withoutNode(line.Nodes, i), Done: true}, true
+			}
+			continue
+		}
+		if strings.HasPrefix(body, c.Open) {
+			return Task{Text: strings.TrimSpace(body[len(c.Open):])}, true
+		}
+		if strings.HasPrefix(body, c.Done) {
+			return Task{Text: strings.TrimSpace(body[len(c.Done):]), Done: true}, true
+		}
+	}
+	return Task{}, false
+}
+
+// tagIndex returns the index of the hashtag node for tag ("#todo"), or -1
+func tagIndex(nodes []notation.Node, tag string) int {
+	for i, node := range nodes {
+		if node.Type == notation.NodeHashtag && strings.EqualFold(node.Text, tag[1:]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// withoutNode formats nodes without the i-th one
+func withoutNode(nodes []notation.Node, i int) string {
+	text := notation.FormatInline(nodes[:i]) + notation.FormatInline(nodes[i+1:])
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,228 @@
+// Package titles resolves page titles written in different forms (full-width
+// characters, case, spacing, aliases) to the title of an existing page.
+package titles
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// listPageSize is the page size used to walk the page list (the API maximum)
+	listPageSize = 1000
+	// maxTitles bounds the title list kept per project
+	maxTitles = 20000
+)
+
+// Normalize returns the comparison key of a title: NFKC (so full-width
+// letters and half-width kana fold to their usual forms), lower case, and
+// underscores and runs of whitespace collapsed to a single space.
+func Normalize(title string) string {
+	title = strings.ToLower(norm.NFKC.String(title))
+
+	var b strings.Builder
+	space := false
+	for _, r := range title {
+		if r == '_' || unicode.IsSpace(r) {
+			space = true
+			continue
+		}
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// ParseAliases parses "alias=Title" entries into a map keyed by the
+// normalized alias
+func ParseAliases(entries []string) (map[string]string, error) {
+	aliases := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		alias, title, ok := strings.Cut(entry, "=")
+		alias, title = strings.TrimSpace(alias), strings.TrimSpace(title)
+		if !ok || alias == "" || title == "" {
+			return nil, fmt.Errorf("invalid title alias %q (expected alias=Title)", entry)
+		}
+		aliases[Normalize(alias)] = title
+	}
+	return aliases, nil
+}
+
+// titleList is the cached title list of one project
+type titleList struct {
+	mu      sync.Mutex
+	titles  []string
+	exact   map[string]bool
+	byKey   map[string][]string
+	fetched time.Time
+}
+
+// Resolver maps titles to existing pages using a cached title list per project
+type Resolver struct {
+	reader   scrapbox.Reader
+	maxAge   time.Duration
+	aliases  map[string]string
+	mu       sync.Mutex
+	projects map[string]*titleList
+}
+
+// NewResolver creates a resolver. The title list is refetched when older than
+// maxAge. aliases maps normalized aliases to titles (see ParseAliases).
+func NewResolver(reader scrapbox.Reader, maxAge time.Duration, aliases map[string]string) *Resolver {
+	return &Resolver{
+		reader:   reader,
+		maxAge:   maxAge,
+		aliases:  aliases,
+		projects: make(map[string]*titleList),
+	}
+}
+
+// Resolve returns the existing page title that title refers to. Aliases are
+// applied first; a title with no existing match is returned unchanged.
+func (r *Resolver) Resolve(ctx context.Context, project, title string) string {
+	matches := r.Matches(ctx, project, title)
+	if len(matches) == 0 {
+		if alias, ok := r.aliases[Normalize(title)]; ok {
+			return alias
+		}
+		return title
+	}
+	return matches[0]
+}
+
+// Matches returns the existing page titles that title may refer to, in list
+// order. An exact match (after applying aliases) is the only match; otherwise
+// every title with the same normalized key matches.
+func (r *Resolver) Matches(ctx context.Context, project, title string) []string {
+	key := Normalize(title)
+	if alias, ok := r.aliases[key]; ok {
+		title, key = alias, Normalize(alias)
+	}
+
+	// An exact match wins over a normalized one, and looking it up is much
+	// cheaper than listing every title of the project
+	if r.exists(ctx, project, title) {
+		return []string{title}
+	}
+
+	list, err := r.list(ctx, project)
+	if err != nil {
+		log.Printf("[TITLES] Failed to load titles of %s: %v", project, err)
+		return nil
+	}
+
+	list.mu.Lock()
+	defer list.mu.Unlock()
+	// An exact match wins over a normalized one
+	if list.exact[title] {
+		return []string{title}
+	}
+	return append([]string(nil), list.byKey[key]...)
+}
+
+// exists reports whether a page titled exactly title exists, from the cached
+// title list while it is fresh and otherwise by fetching the page
+func (r *Resolver) exists(ctx context.Context, project, title string) bool {
+	r.mu.Lock()
+	list := r.projects[project]
+	r.mu.Unlock()
+	if list != nil {
+		list.mu.Lock()
+		fresh := list.byKey != nil && time.Since(list.fetched) < r.maxAge
+		known := list.exact[title]
+		list.mu.Unlock()
+		if fresh {
+			return known
+		}
+	}
+	if ctx.Err() != nil {
+		return false
+	}
+
+	// Scrapbox looks titles up case-insensitively, so check the page found
+	// has this exact title
+	page, err := r.reader.GetPage(project, title)
+	return err == nil && page.CommitID != "" && page.Title == title
+}
+
+// Duplicates returns groups of existing titles that normalize to the same key,
+// sorted by their first title
+func (r *Resolver) Duplicates(ctx context.Context, project string) ([][]string, error) {
+	list, err := r.list(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	list.mu.Lock()
+	defer list.mu.Unlock()
+	var duplicates [][]string
+	for _, group := range list.byKey {
+		if len(group) > 1 {
+			group = append([]string(nil), group...)
+			sort.Strings(group)
+			duplicates = append(duplicates, group)
+		}
+	}
+	sort.Slice(duplicates, func(i, j int) bool { return duplicates[i][0] < duplicates[j][0] })
+	return duplicates, nil
+}
+
+// list returns the title list of project, refetching it when stale
+func (r *Resolver) list(ctx context.Context, project string) (*titleList, error) {
+	r.mu.Lock()
+	list, ok := r.projects[project]
+	if !ok {
+		list = &titleList{}
+		r.projects[project] = list
+	}
+	r.mu.Unlock()
+
+	list.mu.Lock()
+	defer list.mu.Unlock()
+	if list.byKey != nil && time.Since(list.fetched) < r.maxAge {
+		return list, nil
+	}
+
+	var titles []string
+	for skip := 0; skip < maxTitles; skip += listPageSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		resp, err := r.reader.ListPages(project, listPageSize, skip)
+		if err != nil {
+			return nil, err
+		}
+		for _, page := range resp.Pages {
+			titles = append(titles, page.Title)
+		}
+		if len(resp.Pages) < listPageSize || skip+len(resp.Pages) >= resp.Count {
+			break
+		}
+	}
+
+	exact := make(map[string]bool, len(titles))
+	byKey := make(map[string][]string, len(titles))
+	for _, title := range titles {
+		exact[title] = true
+		// Titles of a key stay in list order; the first one is the default match
+		key := Normalize(title)
+		byKey[key] = append(byKey[key], title)
+	}
+	list.titles = titles
+	list.exact = exact
+	list.byKey = byKey
+	list.fetched = time.Now()
+	return list, nil
+}
@@ -0,0 +1,211 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/hiroki/scrapbox_mcp/internal/graph"
+	"github.com/hiroki/scrapbox_mcp/internal/index"
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+const (
+	graphDefaultTop            = 10
+	graphMaxTop                = 100
+	graphDefaultMinClusterSize = 3
+	// graphClusterPages caps the pages listed per cluster
+	graphClusterPages = 10
+	// graphMaxOrphans caps the orphan pages listed
+	graphMaxOrphans = 50
+)
+
+type AnalyzeGraphTool struct {
+	client scrapbox.Reader
+	index  *index.Index
+}
+
+func NewAnalyzeGraphTool(client scrapbox.Reader, pageIndex *index.Index) *AnalyzeGraphTool {
+	return &AnalyzeGraphTool{client: client, index: pageIndex}
+}
+
+// graphHub is a page ranked by PageRank
+type graphHub struct {
+	Title     string  `json:"title"`
+	PageRank  float64 `json:"pageRank"`
+	InDegree  int     `json:"inDegree"`
+	OutDegree int     `json:"outDegree"`
+}
+
+// graphCluster is a community or connected component; Pages are its
+// highest-ranked pages
+type graphCluster struct {
+	Label string   `json:"label,omitempty"`
+	Size  int      `json:"size"`
+	Pages []string `json:"pages"`
+}
+
+// analyzeGraphResponse is the analyze_graph result
+type analyzeGraphResponse struct {
+	Project          string         `json:"project"`
+	Pages            int            `json:"pages"`
+	Links            int            `json:"links"`
+	Hubs             []graphHub     `json:"hubs"`
+	Communities      []graphCluster `json:"communities"`
+	IsolatedClusters []graphCluster `json:"isolatedClusters"`
+	OrphanCount      int            `json:"orphanCount"`
+	Orphans          []string       `json:"orphans"`
+}
+
+func (t *AnalyzeGraphTool) Name() string {
+	return "analyze_graph"
+}
+
+func (t *AnalyzeGraphTool) Description() string {
+	return "Analyzes the link graph between pages: the top hubs by PageRank with in/out degree, communities of densely linked pages (label propagation), isolated clusters not connected to the main body of the project, and orphan pages without any links. Useful for deciding which pages to consolidate, link up or promote."
+}
+
+func (t *AnalyzeGraphTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"project": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional project name (uses the session default set by set_default_project, or the server default)",
+			},
+			"top": map[string]interface{}{
+				"type":        "number",
+				"description": fmt.Sprintf("Number of hubs and clusters to return (default: %d, max: %d)", graphDefaultTop, graphMaxTop),
+			},
+			"min_cluster_size": map[string]interface{}{
+				"type":        "number",
+				"description": fmt.Sprintf("Smallest community reported (default: %d)", graphDefaultMinClusterSize),
+			},
+			"include_tags": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Count #tags as links to the tag's page (default: true)",
+			},
+		},
+	}
+}
+
+func (t *AnalyzeGraphTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	top := graphDefaultTop
+	if n, ok := arguments["top"].(float64); ok && n > 0 {
+		top = min(int(n), graphMaxTop)
+	}
+	minClusterSize := graphDefaultMinClusterSize
+	if n, ok := arguments["min_cluster_size"].(float64); ok && n > 0 {
+		minClusterSize = int(n)
+	}
+	includeTags := true
+	if b, ok := arguments["include_tags"].(bool); ok {
+		includeTags = b
+	}
+
+	project := resolveProject(ctx, arguments, t.client)
+	pages, err := t.index.Pages(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	g := graph.Build(pages, graph.Options{Tags: includeTags})
+	rank := g.PageRank()
+	// byRank orders node indexes by PageRank, then title
+	byRank := func(nodes []int) {
+		sort.SliceStable(nodes, func(a, b int) bool { return rank[nodes[a]] > rank[nodes[b]] })
+	}
+
+	response := analyzeGraphResponse{
+		Project:          project,
+		Pages:            len(g.Titles),
+		Links:            g.Edges(),
+		Hubs:             []graphHub{},
+		Communities:      []graphCluster{},
+		IsolatedClusters: []graphCluster{},
+		Orphans:          []string{},
+	}
+
+	nodes := make([]int, len(g.Titles))
+	for i := range nodes {
+		nodes[i] = i
+	}
+	byRank(nodes)
+	for _, i := range nodes[:min(top, len(nodes))] {
+		response.Hubs = append(response.Hubs, graphHub{
+			Title:     g.Titles[i],
+			PageRank:  rank[i],
+			InDegree:  len(g.In[i]),
+			OutDegree: len(g.Out[i]),
+		})
+	}
+
+	members := make(map[int][]int)
+	for i, label := range g.Communities() {
+		members[label] = append(members[label], i)
+	}
+	var communities [][]int
+	for _, list := range members {
+		if len(list) >= minClusterSize && len(list) > 1 {
+			communities = append(communities, list)
+		}
+	}
+	response.Communities = t.clusters(g, communities, byRank, top, true)
+
+	// Everything outside the largest component is cut off from the main body
+	components := g.Components()
+	var isolated [][]int
+	for n, component := range components {
+		switch {
+		case len(component) == 1:
+			response.OrphanCount++
+			if len(response.Orphans) < graphMaxOrphans {
+				response.Orphans = append(response.Orphans, g.Titles[component[0]])
+			}
+		case n > 0:
+			isolated = append(isolated, component)
+		}
+	}
+	response.IsolatedClusters = t.clusters(g, isolated, byRank, top, false)
+
+	// Format the response as JSON
+	result, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format graph analysis: %v", err)
+	}
+
+	summary := fmt.Sprintf("%d pages, %d links in '%s': %d communities, %d isolated clusters, %d orphan pages",
+		response.Pages, response.Links, project, len(communities), len(isolated), response.OrphanCount)
+	if len(response.Hubs) > 0 {
+		summary += fmt.Sprintf("; top hub: %s", response.Hubs[0].Title)
+	}
+	return withSummary(summary, string(result)), nil
+}
+
+// clusters lists the largest groups of nodes, each with its highest-ranked
+// pages; labelled clusters are named after their top page
+func (t *AnalyzeGraphTool) clusters(g *graph.Graph, groups [][]int, byRank func([]int), top int, labelled bool) []graphCluster {
+	for _, group := range groups {
+		byRank(group)
+	}
+	sort.SliceStable(groups, func(a, b int) bool {
+		if len(groups[a]) != len(groups[b]) {
+			return len(groups[a]) > len(groups[b])
+		}
+		return g.Titles[groups[a][0]] < g.Titles[groups[b][0]]
+	})
+
+	clusters := []graphCluster{}
+	for _, group := range groups[:min(top, len(groups))] {
+		cluster := graphCluster{Size: len(group), Pages: []string{}}
+		for _, i := range group[:min(graphClusterPages, len(group))] {
+			cluster.Pages = append(cluster.Pages, g.Titles[i])
+		}
+		if labelled {
+			cluster.Label = cluster.Pages[0]
+		}
+		clusters = append(clusters, cluster)
+	}
+	return clusters
+}
@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Content audiences
+const (
+	AudienceUser      = "user"
+	AudienceAssistant = "assistant"
+)
+
+// Annotations tell the client who a content block is meant for and how
+// important it is (priority 1 is most important, 0 least)
+type Annotations struct {
+	Audience []string `json:"audience,omitempty"`
+	Priority float64  `json:"priority"`
+}
+
+// forUser returns annotations for a human-readable block, shown to the user
+// and read by the assistant
+func forUser() *Annotations {
+	return &Annotations{Audience: []string{AudienceUser, AudienceAssistant}, Priority: 1}
+}
+
+// forAssistant returns annotations for a block only the assistant needs,
+// such as a raw JSON payload
+func forAssistant(priority float64) *Annotations {
+	return &Annotations{Audience: []string{AudienceAssistant}, Priority: priority}
+}
+
+// annotate fills in the annotations of blocks that have none: JSON text is
+// for the assistant, other text and images are for both, and embedded
+// resources (copies of data already in the text) are for the assistant at
+// low priority
+func annotate(blocks []ContentBlock) {
+	for i := range blocks {
+		block := &blocks[i]
+		if block.Annotations != nil {
+			continue
+		}
+		switch {
+		case block.Resource != nil:
+			block.Annotations = forAssistant(0.2)
+		case block.Type == "text" && isJSONText(block.Text):
+			block.Annotations = forAssistant(0.5)
+		default:
+			block.Annotations = forUser()
+		}
+	}
+}
+
+// isJSONText reports whether text is a JSON object or array
+func isJSONText(text string) bool {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return false
+	}
+	return json.Valid([]byte(trimmed))
+}
+
+// withSummary puts a short human-readable summary before a tool result (text
+// or ContentBlocks), so chat clients can show it instead of the raw payload
+func withSummary(summary string, result interface{}) ContentBlocks {
+	blocks := ContentBlocks{{Type: "text", Text: summary, Annotations: forUser()}}
+	if resultBlocks, ok := result.(ContentBlocks); ok {
+		return append(blocks, resultBlocks...)
+	}
+	return append(blocks, ContentBlock{Type: "text", Text: fmt.Sprintf("%v", result)})
+}
+
+// payloadIndex returns the index of the text block that response limits
+// apply to: the first assistant-only text block, or else the first text block
+func payloadIndex(blocks []ContentBlock) int {
+	first := -1
+	for i, block := range blocks {
+		if block.Type != "text" {
+			continue
+		}
+		if first < 0 {
+			first = i
+		}
+		if block.Annotations != nil && len(block.Annotations.Audience) == 1 && block.Annotations.Audience[0] == AudienceAssistant {
+			return i
+		}
+	}
+	return first
+}
@@ -0,0 +1,268 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hiroki/scrapbox_mcp/internal/index"
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	"github.com/hiroki/scrapbox_mcp/pkg/notation"
+)
+
+const (
+	archiveDefaultPrefix = "archive/"
+	archiveDefaultTag    = "archived"
+)
+
+// PagePinner pins and unpins pages
+type PagePinner interface {
+	SetPin(title string, pinned bool, expectedCommitID string) (*scrapbox.WriteResult, error)
+}
+
+type ArchivePageTool struct {
+	client   scrapbox.API
+	pinner   PagePinner
+	rewriter *linkRewriter
+}
+
+func NewArchivePageTool(client scrapbox.API, pinner PagePinner, pageIndex *index.Index) *ArchivePageTool {
+	return &ArchivePageTool{client: client, pinner: pinner, rewriter: newLinkRewriter(client, pageIndex)}
+}
+
+// archivePageResponse is the archive_page result
+type archivePageResponse struct {
+	Title    string `json:"title"`
+	NewTitle string `json:"newTitle"`
+	DryRun   bool   `json:"dryRun"`
+	Archived bool   `json:"archived"`
+	// Tagged and Unpinned report the changes made (or planned in a dry run)
+	Tagged   bool `json:"tagged"`
+	Unpinned bool `json:"unpinned"`
+	// URL and CommitID identify the archived page
+	URL      string `json:"url,omitempty"`
+	CommitID string `json:"commitId,omitempty"`
+	// SelfLinks counts the page's links to itself rewritten with the rename
+	SelfLinks   int               `json:"selfLinks,omitempty"`
+	LinkUpdates []pageReplacement `json:"linkUpdates"`
+}
+
+func (t *ArchivePageTool) Name() string {
+	return "archive_page"
+}
+
+func (t *ArchivePageTool) Description() string {
+	return "Archives a page following the project's archive convention in one call: renames it under a prefix (default: archive/Title), adds an #archived tag, rewrites the links to it on other pages and unpins it. Use dry_run to preview the changes."
+}
+
+func (t *ArchivePageTool) IsWrite() bool {
+	return true
+}
+
+// DryRun reports whether the call only previews the archive
+func (t *ArchivePageTool) DryRun(arguments map[string]interface{}) bool {
+	dryRun, _ := arguments["dry_run"].(bool)
+	return dryRun
+}
+
+// AdminOnly reserves archiving for admins, since it relinks other pages
+func (t *ArchivePageTool) AdminOnly() bool {
+	return true
+}
+
+// TargetPages returns the page and its backlinks
+func (t *ArchivePageTool) TargetPages(arguments map[string]interface{}) []string {
+	title, _ := arguments["title"].(string)
+	if dryRun, _ := arguments["dry_run"].(bool); dryRun || title == "" {
+		return nil
+	}
+	pages := []string{title}
+	if rewrite, ok := arguments["rewrite_links"].(bool); ok && !rewrite {
+		return pages
+	}
+	backlinks, _ := t.rewriter.backlinks(context.Background(), title)
+	for _, backlink := range backlinks {
+		if backlink != title {
+			pages = append(pages, backlink)
+		}
+	}
+	return pages
+}
+
+func (t *ArchivePageTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "The title of the page to archive",
+			},
+			"prefix": map[string]interface{}{
+				"type":        "string",
+				"description": fmt.Sprintf("Prefix of the archived title (default: %q)", archiveDefaultPrefix),
+			},
+			"tag": map[string]interface{}{
+				"type":        "string",
+				"description": fmt.Sprintf("Tag added to the page, without # (default: %q; empty string adds none)", archiveDefaultTag),
+			},
+			"rewrite_links": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Rewrite links to the page on other pages (default: true)",
+			},
+			"unpin": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Unpin the page if it is pinned (default: true)",
+			},
+			"dry_run": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Only report the changes without archiving (default: false)",
+			},
+		},
+		"required": []string{"title"},
+	}
+}
+
+func (t *ArchivePageTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	title, ok := arguments["title"].(string)
+	if !ok || title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+	prefix := archiveDefaultPrefix
+	if prefixArg, ok := arguments["prefix"].(string); ok && prefixArg != "" {
+		prefix = prefixArg
+	}
+	tag := archiveDefaultTag
+	if tagArg, ok := arguments["tag"].(string); ok {
+		tag = strings.TrimPrefix(strings.TrimSpace(tagArg), "#")
+	}
+	rewriteLinks := true
+	if rewriteArg, ok := arguments["rewrite_links"].(bool); ok {
+		rewriteLinks = rewriteArg
+	}
+	unpin := true
+	if unpinArg, ok := arguments["unpin"].(bool); ok {
+		unpin = unpinArg
+	}
+	dryRun, _ := arguments["dry_run"].(bool)
+
+	project := t.client.DefaultProject()
+	page, err := t.client.GetPage(project, title)
+	if err != nil {
+		return nil, err
+	}
+	if page.CommitID == "" {
+		return nil, fmt.Errorf("page not found: %s", title)
+	}
+	if strings.HasPrefix(strings.ToLower(page.Title), strings.ToLower(prefix)) {
+		return nil, fmt.Errorf("page '%s' is already archived under '%s'", page.Title, prefix)
+	}
+	newTitle := prefix + page.Title
+	existing, err := t.client.GetPage(project, newTitle)
+	if err != nil {
+		return nil, err
+	}
+	if existing.CommitID != "" {
+		return nil, fmt.Errorf("a page titled '%s' already exists; use merge_pages to combine them", existing.Title)
+	}
+
+	response := archivePageResponse{
+		Title:       page.Title,
+		NewTitle:    newTitle,
+		DryRun:      dryRun,
+		Unpinned:    unpin && page.Pin != 0,
+		LinkUpdates: []pageReplacement{},
+	}
+	tagLine := ""
+	if tag != "" {
+		texts := make([]string, len(page.Lines))
+		for i, line := range page.Lines {
+			texts[i] = line.Text
+		}
+		if !hasAnyTag(notation.ExtractLinks(texts).Tags, map[string]bool{tagKey(tag): true}) {
+			tagLine = "#" + tag
+			if strings.ContainsAny(tag, " \t") {
+				tagLine = "#[" + tag + "]"
+			}
+			response.Tagged = true
+		}
+	}
+
+	if dryRun {
+		if rewriteLinks {
+			response.LinkUpdates, err = t.rewriter.rewrite(ctx, page.Title, newTitle, "", true)
+			if err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		// Rename first: if it fails, no backlink has been pointed at a page
+		// that does not exist. The page's own links to itself are rewritten
+		// in the same commit.
+		written, selfLinks, err := t.retitle(page.Title, newTitle, tagLine, rewriteLinks)
+		if err != nil {
+			return nil, fmt.Errorf("failed to archive page: %w", err)
+		}
+		response.Archived = true
+		response.SelfLinks = selfLinks
+		response.URL, response.CommitID = written.URL, written.CommitID
+
+		if rewriteLinks {
+			// The index may still list the archived page under its old title
+			response.LinkUpdates, err = t.rewriter.rewrite(ctx, page.Title, newTitle, page.Title, false)
+			if err != nil {
+				return nil, renamePartialError(response, fmt.Errorf("archived page '%s' as '%s' but failed to rewrite its backlinks: %w", page.Title, newTitle, err))
+			}
+		}
+
+		if response.Unpinned {
+			unpinned, err := t.pinner.SetPin(newTitle, false, written.CommitID)
+			if err != nil {
+				response.Unpinned = false
+				return nil, renamePartialError(response, fmt.Errorf("archived page '%s' but failed to unpin it: %w", newTitle, err))
+			}
+			response.CommitID = unpinned.CommitID
+		}
+	}
+
+	// Format the response as JSON
+	result, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format archive result: %v", err)
+	}
+
+	return string(result), nil
+}
+
+// retitle renames the page, with rewriteLinks also its links to itself, and
+// appends tagLine unless it is empty. It returns the number of self-links
+// rewritten.
+func (t *ArchivePageTool) retitle(title, newTitle, tagLine string, rewriteLinks bool) (*scrapbox.WriteResult, int, error) {
+	project := t.client.DefaultProject()
+	page, err := t.client.GetPage(project, title)
+	if err != nil {
+		return nil, 0, err
+	}
+	newTexts, selfLinks := retitledLines(page, title, newTitle, project, rewriteLinks)
+	if tagLine != "" {
+		newTexts = append(newTexts, tagLine)
+	}
+	written, err := t.client.PatchPage(title, newTexts, page.CommitID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return written, selfLinks, nil
+}
+
+// DestructivePreview is the dry run of an archive, which retitles the page and
+// rewrites the links to it on other pages
+func (t *ArchivePageTool) DestructivePreview(ctx context.Context, arguments map[string]interface{}) (string, bool, error) {
+	if t.DryRun(arguments) {
+		return "", false, nil
+	}
+	preview, destructive, err := dryRunPreview(ctx, t, arguments)
+	if !destructive {
+		return "", false, err
+	}
+	return fmt.Sprintf("The page '%s' will be archived.\n%s", arguments["title"], preview), true, nil
+}
@@ -0,0 +1,492 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	mcperrors "github.com/hiroki/scrapbox_mcp/pkg/errors"
+)
+
+// batchMaxOperations bounds the number of operations in one batch_edit call
+const batchMaxOperations = 100
+
+// Batch operation kinds
+const (
+	batchOpCreate      = "create"
+	batchOpInsert      = "insert"
+	batchOpReplace     = "replace"
+	batchOpDeleteLines = "delete_lines"
+)
+
+// Batch operation outcomes
+const (
+	batchStatusOK      = "ok"
+	batchStatusError   = "error"
+	batchStatusSkipped = "skipped"
+)
+
+type BatchEditTool struct {
+	client        scrapbox.API
+	titleMismatch string
+}
+
+// NewBatchEditTool creates the batch_edit tool. titleMismatch is applied to
+// replace operations as in edit_page.
+func NewBatchEditTool(client scrapbox.API, titleMismatch string) *BatchEditTool {
+	return &BatchEditTool{
+		client:        client,
+		titleMismatch: titleMismatch,
+	}
+}
+
+// batchResult is the outcome of one operation
+type batchResult struct {
+	Op     string `json:"op"`
+	Title  string `json:"title"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// URL and CommitID identify the page after a successful write
+	URL      string `json:"url,omitempty"`
+	CommitID string `json:"commitId,omitempty"`
+}
+
+// batchResponse is the structured batch_edit result
+type batchResponse struct {
+	Operations []batchResult `json:"operations"`
+	Rollback   []batchResult `json:"rollback,omitempty"`
+}
+
+// written records where a successful write left its page
+func (r *batchResult) written(result *scrapbox.WriteResult) {
+	r.URL = result.URL
+	r.CommitID = result.CommitID
+}
+
+// batchSnapshot is a page as it was before an atomic batch first touched it
+type batchSnapshot struct {
+	title   string
+	existed bool
+	lines   []string
+}
+
+func (t *BatchEditTool) Name() string {
+	return "batch_edit"
+}
+
+func (t *BatchEditTool) Description() string {
+	return "Runs an ordered list of edits (create, insert, replace, delete_lines) across one or more pages in a single call and returns one report. By default the batch stops at the first failed operation; the remaining ones are skipped. With atomic=true, pages already modified by the batch are restored (best effort) when an operation fails."
+}
+
+func (t *BatchEditTool) IsWrite() bool {
+	return true
+}
+
+// TargetPages returns the distinct pages named by the operations
+func (t *BatchEditTool) TargetPages(arguments map[string]interface{}) []string {
+	ops, _ := arguments["operations"].([]interface{})
+	seen := make(map[string]bool)
+	var pages []string
+	for _, raw := range ops {
+		op, _ := raw.(map[string]interface{})
+		title, _ := op["title"].(string)
+		if title != "" && !seen[title] {
+			seen[title] = true
+			pages = append(pages, title)
+		}
+	}
+	return pages
+}
+
+func (t *BatchEditTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"operations": map[string]interface{}{
+				"type":        "array",
+				"description": fmt.Sprintf("Operations to run in order (at most %d)", batchMaxOperations),
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"op": map[string]interface{}{
+							"type":        "string",
+							"enum":        []string{batchOpCreate, batchOpInsert, batchOpReplace, batchOpDeleteLines},
+							"description": "create (title, body, if_exists), insert (title, new_lines, target_line), replace (title, content) or delete_lines (title, start_line, end_line)",
+						},
+						"title": map[string]interface{}{
+							"type":        "string",
+							"description": "The page to edit",
+						},
+						"body": map[string]interface{}{
+							"type":        "string",
+							"description": "create: body of the new page",
+						},
+						"if_exists": map[string]interface{}{
+							"type":        "string",
+							"enum":        []string{string(scrapbox.IfExistsError), string(scrapbox.IfExistsAppend), string(scrapbox.IfExistsOverwrite)},
+							"description": "create: what to do when the page exists (default: error)",
+						},
+						"new_lines": map[string]interface{}{
+							"type":        "string",
+							"description": "insert: lines to insert, separated by newlines",
+						},
+						"target_line": map[string]interface{}{
+							"type":        "string",
+							"description": "insert: text of the line to insert after (default: end of page)",
+						},
+						"content": map[string]interface{}{
+							"type":        "string",
+							"description": "replace: the complete new page content, starting with the title line",
+						},
+						"start_line": map[string]interface{}{
+							"type":        "number",
+							"description": "delete_lines: first line to delete (0 is the title line and cannot be deleted)",
+						},
+						"end_line": map[string]interface{}{
+							"type":        "number",
+							"description": "delete_lines: last line to delete, inclusive (default: start_line)",
+						},
+						argExpectedCommitID: expectedCommitProperty(),
+					},
+					"required": []string{"op", "title"},
+				},
+			},
+			"stop_on_error": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Stop at the first failed operation and skip the rest (default: true)",
+			},
+			"atomic": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Snapshot each page before its first edit and, if an operation fails, restore the modified pages and delete pages the batch created (best effort; implies stop_on_error). Default: false",
+			},
+		},
+		"required": []string{"operations"},
+	}
+}
+
+func (t *BatchEditTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	rawOps, ok := arguments["operations"].([]interface{})
+	if !ok || len(rawOps) == 0 {
+		return nil, fmt.Errorf("operations is required and must be a non-empty array")
+	}
+	if len(rawOps) > batchMaxOperations {
+		return nil, fmt.Errorf("too many operations: %d (maximum %d)", len(rawOps), batchMaxOperations)
+	}
+
+	ops := make([]map[string]interface{}, len(rawOps))
+	for i, raw := range rawOps {
+		op, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("operation %d must be an object", i+1)
+		}
+		ops[i] = op
+	}
+
+	stopOnError := true
+	if stopArg, ok := arguments["stop_on_error"].(bool); ok {
+		stopOnError = stopArg
+	}
+	atomic, _ := arguments["atomic"].(bool)
+	if atomic {
+		stopOnError = true
+	}
+
+	results := make([]batchResult, len(ops))
+	failed := false
+	var snapshots []batchSnapshot
+	snapshotted := make(map[string]bool)
+	for i, op := range ops {
+		kind, _ := op["op"].(string)
+		title, _ := op["title"].(string)
+		results[i] = batchResult{Op: kind, Title: title}
+
+		if failed && stopOnError {
+			results[i].Status = batchStatusSkipped
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			// A cancelled batch is incomplete, so it fails and rolls back
+			failed = true
+			results[i].Status = batchStatusSkipped
+			results[i].Detail = fmt.Sprintf("batch cancelled: %v", err)
+			continue
+		}
+
+		if atomic && title != "" && !snapshotted[title] {
+			snapshot, err := t.snapshot(title)
+			if err != nil {
+				failed = true
+				results[i].Status = batchStatusError
+				results[i].Detail = fmt.Sprintf("failed to snapshot page before editing: %v", err)
+				continue
+			}
+			snapshots = append(snapshots, snapshot)
+			snapshotted[title] = true
+		}
+
+		detail, written, err := t.run(kind, title, op)
+		if err != nil {
+			failed = true
+			results[i].Status = batchStatusError
+			results[i].Detail = err.Error()
+			continue
+		}
+		results[i].Status = batchStatusOK
+		results[i].Detail = detail
+		results[i].written(written)
+	}
+
+	var rollback []batchResult
+	if atomic && failed {
+		rollback = t.rollback(snapshots)
+	}
+
+	// Format the response as JSON
+	response, err := json.MarshalIndent(batchResponse{Operations: results, Rollback: rollback}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format batch result: %v", err)
+	}
+
+	summary := batchReport(results, rollback)
+	if failed {
+		// Report what was done and rolled back, but as a failed call
+		return nil, &PartialError{Err: errors.New(strings.SplitN(summary, "\n", 2)[0]), Result: withSummary(summary, string(response))}
+	}
+	return withSummary(summary, string(response)), nil
+}
+
+// DestructivePreview lists the replace and delete_lines operations
+func (t *BatchEditTool) DestructivePreview(ctx context.Context, arguments map[string]interface{}) (string, bool, error) {
+	ops, _ := arguments["operations"].([]interface{})
+	var previews []string
+	for i, raw := range ops {
+		op, _ := raw.(map[string]interface{})
+		kind, _ := op["op"].(string)
+		title, _ := op["title"].(string)
+		switch kind {
+		case batchOpReplace:
+			content, _ := op["content"].(string)
+			if title == "" || content == "" {
+				continue
+			}
+			newTexts, err := checkTitleLine(title, strings.Split(content, "\n"), t.titleMismatch)
+			if err != nil {
+				continue
+			}
+			if preview, lost, _ := pageLossPreview(t.client, title, newTexts); lost {
+				previews = append(previews, fmt.Sprintf("Operation %d (replace) %s", i+1, preview))
+			}
+		case batchOpDeleteLines:
+			start, _ := op["start_line"].(float64)
+			end, ok := op["end_line"].(float64)
+			if !ok {
+				end = start
+			}
+			previews = append(previews, fmt.Sprintf("Operation %d (delete_lines) '%s': lines %d-%d would be deleted", i+1, title, int(start), int(end)))
+		}
+	}
+	if len(previews) == 0 {
+		return "", false, nil
+	}
+	return strings.Join(previews, "\n"), true, nil
+}
+
+// snapshot records the current content of a page for rollback
+func (t *BatchEditTool) snapshot(title string) (batchSnapshot, error) {
+	page, err := t.client.GetPage(t.client.DefaultProject(), title)
+	if err != nil {
+		var sbErr *mcperrors.ScrapboxError
+		if errors.As(err, &sbErr) && sbErr.Code == mcperrors.ErrCodeNotFound {
+			return batchSnapshot{title: title}, nil
+		}
+		return batchSnapshot{}, err
+	}
+
+	// Scrapbox returns page info without a commit ID for pages that do not exist yet
+	snapshot := batchSnapshot{title: title, existed: page.CommitID != ""}
+	if snapshot.existed {
+		snapshot.lines = make([]string, len(page.Lines))
+		for i, line := range page.Lines {
+			snapshot.lines[i] = line.Text
+		}
+	}
+	return snapshot, nil
+}
+
+// rollback restores the snapshotted pages, most recently touched first.
+// Pages that did not exist are deleted if the batch created them.
+func (t *BatchEditTool) rollback(snapshots []batchSnapshot) []batchResult {
+	results := make([]batchResult, 0, len(snapshots))
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		snapshot := snapshots[i]
+		result := batchResult{Op: "restore", Title: snapshot.title, Status: batchStatusOK}
+
+		var written *scrapbox.WriteResult
+		var err error
+		if snapshot.existed {
+			written, err = t.client.PatchPage(snapshot.title, snapshot.lines, "")
+		} else {
+			result.Op = "delete"
+			written, err = t.client.DeletePage(snapshot.title, "")
+			var sbErr *mcperrors.ScrapboxError
+			if errors.As(err, &sbErr) && sbErr.Code == mcperrors.ErrCodeNotFound {
+				// The batch never created it
+				err = nil
+				result.Status = batchStatusSkipped
+			}
+		}
+		if err != nil {
+			log.Printf("[BATCH] Failed to roll back page %s: %v", snapshot.title, err)
+			result.Status = batchStatusError
+			result.Detail = err.Error()
+		} else if written != nil {
+			result.written(written)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// run executes one operation and returns a short description of what it did
+func (t *BatchEditTool) run(kind, title string, op map[string]interface{}) (string, *scrapbox.WriteResult, error) {
+	if title == "" {
+		return "", nil, fmt.Errorf("title is required")
+	}
+	expectedCommitID := parseExpectedCommitID(op)
+
+	switch kind {
+	case batchOpCreate:
+		body, _ := op["body"].(string)
+		var bodyLines []string
+		if body != "" {
+			bodyLines = strings.Split(body, "\n")
+		}
+		ifExists := scrapbox.IfExistsError
+		if ifExistsArg, ok := op["if_exists"].(string); ok && ifExistsArg != "" {
+			ifExists = scrapbox.IfExists(ifExistsArg)
+		}
+		result, err := t.client.CreatePage(title, bodyLines, ifExists)
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("created (%d body lines)", len(bodyLines)), result, nil
+
+	case batchOpInsert:
+		newLines, _ := op["new_lines"].(string)
+		if newLines == "" {
+			return "", nil, fmt.Errorf("new_lines is required")
+		}
+		targetLine, _ := op["target_line"].(string)
+		lines := strings.Split(newLines, "\n")
+		result, err := t.client.InsertLines(title, targetLine, lines, expectedCommitID)
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("inserted %d line(s)", len(lines)), result, nil
+
+	case batchOpReplace:
+		content, _ := op["content"].(string)
+		if content == "" {
+			return "", nil, fmt.Errorf("content is required")
+		}
+		newTexts, err := checkTitleLine(title, strings.Split(content, "\n"), t.titleMismatch)
+		if err != nil {
+			return "", nil, err
+		}
+		result, err := t.client.PatchPage(title, newTexts, expectedCommitID)
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("replaced content (%d lines)", len(newTexts)), result, nil
+
+	case batchOpDeleteLines:
+		return t.deleteLines(title, op, expectedCommitID)
+
+	default:
+		return "", nil, fmt.Errorf("unknown op: %q (expected create, insert, replace or delete_lines)", kind)
+	}
+}
+
+// deleteLines removes a range of lines. Without an expected commit ID the
+// page is still guarded against edits made between reading and writing it.
+func (t *BatchEditTool) deleteLines(title string, op map[string]interface{}, expectedCommitID string) (string, *scrapbox.WriteResult, error) {
+	startArg, ok := op["start_line"].(float64)
+	if !ok {
+		return "", nil, fmt.Errorf("start_line is required")
+	}
+	start := int(startArg)
+	end := start
+	if endArg, ok := op["end_line"].(float64); ok {
+		end = int(endArg)
+	}
+
+	page, err := t.client.GetPage(t.client.DefaultProject(), title)
+	if err != nil {
+		return "", nil, err
+	}
+	if start < 1 || end < start || end >= len(page.Lines) {
+		return "", nil, fmt.Errorf("invalid line range %d-%d (page has lines 1-%d; line 0 is the title)", start, end, len(page.Lines)-1)
+	}
+	if expectedCommitID == "" {
+		expectedCommitID = page.CommitID
+	}
+
+	newTexts := make([]string, 0, len(page.Lines)-(end-start+1))
+	for i, line := range page.Lines {
+		if i < start || i > end {
+			newTexts = append(newTexts, line.Text)
+		}
+	}
+	result, err := t.client.PatchPage(title, newTexts, expectedCommitID)
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("deleted lines %d-%d", start, end), result, nil
+}
+
+// batchReport renders the results with a summary first line (used as the
+// audit summary), followed by the rollback steps of a failed atomic batch
+func batchReport(results, rollback []batchResult) string {
+	counts := make(map[string]int)
+	for _, result := range results {
+		counts[result.Status]++
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Batch of %d operations: %d succeeded, %d failed, %d skipped",
+		len(results), counts[batchStatusOK], counts[batchStatusError], counts[batchStatusSkipped])
+	if rollback != nil {
+		restored, rollbackFailed := 0, 0
+		for _, result := range rollback {
+			switch result.Status {
+			case batchStatusOK:
+				restored++
+			case batchStatusError:
+				rollbackFailed++
+			}
+		}
+		fmt.Fprintf(&b, "; rolled back %d page(s)", restored)
+		if rollbackFailed > 0 {
+			fmt.Fprintf(&b, ", %d rollback(s) FAILED", rollbackFailed)
+		}
+	}
+	writeBatchResults(&b, results)
+	if rollback != nil {
+		b.WriteString("\nRollback:")
+		writeBatchResults(&b, rollback)
+	}
+	return b.String()
+}
+
+func writeBatchResults(b *strings.Builder, results []batchResult) {
+	for i, result := range results {
+		fmt.Fprintf(b, "\n%d. %s '%s': %s", i+1, result.Op, result.Title, result.Status)
+		if result.Detail != "" {
+			fmt.Fprintf(b, " - %s", result.Detail)
+		}
+	}
+}
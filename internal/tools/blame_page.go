@@ -0,0 +1,231 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	"github.com/hiroki/scrapbox_mcp/internal/undo"
+)
+
+type BlamePageTool struct {
+	client  scrapbox.Reader
+	history CommitHistory
+	store   *undo.Store
+}
+
+// NewBlamePageTool creates the tool. store is optional; when set, lines last
+// changed by a write made through this server are marked as such.
+func NewBlamePageTool(client scrapbox.Reader, history CommitHistory, store *undo.Store) *BlamePageTool {
+	return &BlamePageTool{client: client, history: history, store: store}
+}
+
+// blameLine is one current line in the blame_page result
+type blameLine struct {
+	Index    int    `json:"index"`
+	Text     string `json:"text"`
+	Author   string `json:"author,omitempty"`
+	AuthorID string `json:"authorId,omitempty"`
+	Updated  string `json:"updated,omitempty"`
+	// CommitID is empty when the line predates the available history
+	CommitID string `json:"commitId,omitempty"`
+	// ViaServer marks lines last changed by a write made through this server
+	ViaServer bool `json:"viaServer,omitempty"`
+}
+
+// blameAuthor counts the lines last changed by one user
+type blameAuthor struct {
+	Author   string `json:"author,omitempty"`
+	AuthorID string `json:"authorId"`
+	Lines    int    `json:"lines"`
+	LastEdit string `json:"lastEdit"`
+}
+
+type blamePageResponse struct {
+	Title   string        `json:"title"`
+	PageID  string        `json:"pageId"`
+	Authors []blameAuthor `json:"authors"`
+	// ServerLines counts lines last changed through this server (requires the undo store)
+	ServerLines *int        `json:"serverLines,omitempty"`
+	Lines       []blameLine `json:"lines"`
+}
+
+// lineOrigin is the last change of a line found in the history
+type lineOrigin struct {
+	userID    string
+	created   int64
+	commitID  string
+	viaServer bool
+}
+
+func (t *BlamePageTool) Name() string {
+	return "blame_page"
+}
+
+func (t *BlamePageTool) Description() string {
+	return "Annotates every line of a page with the author and time of its last change, from the page's commit history, plus per-author line counts. When the undo store is enabled, lines last changed by writes made through this server are marked viaServer, to tell assistant edits from human ones."
+}
+
+func (t *BlamePageTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "The title of the page",
+			},
+			"project": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional project name (uses the session default set by set_default_project, or the server default)",
+			},
+		},
+		"required": []string{"title"},
+	}
+}
+
+func (t *BlamePageTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	title, ok := arguments["title"].(string)
+	if !ok || title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+
+	project := resolveProject(ctx, arguments, t.client)
+
+	page, err := t.client.GetPage(project, title)
+	if err != nil {
+		return nil, err
+	}
+	if page.CommitID == "" {
+		return nil, fmt.Errorf("page not found: %s", title)
+	}
+
+	commits, err := t.history.GetPageCommits(project, page.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var serverCommits map[string]bool
+	if t.store != nil && project == t.client.DefaultProject() {
+		serverCommits, err = t.serverCommits(project, page.Title, commits)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Replay the history; the last insert or update of a line is its origin
+	origins := make(map[string]lineOrigin)
+	for _, commit := range commits {
+		for _, change := range commit.LineChanges() {
+			if change.Type == scrapbox.LineDeleted {
+				delete(origins, change.LineID)
+				continue
+			}
+			origins[change.LineID] = lineOrigin{
+				userID:    commit.UserID,
+				created:   commit.Created,
+				commitID:  commit.ID,
+				viaServer: serverCommits[commit.ID],
+			}
+		}
+	}
+
+	users := pageUserNames(page)
+	response := &blamePageResponse{
+		Title:   page.Title,
+		PageID:  page.ID,
+		Authors: []blameAuthor{},
+		Lines:   make([]blameLine, 0, len(page.Lines)),
+	}
+	if serverCommits != nil {
+		response.ServerLines = new(int)
+	}
+
+	authors := make(map[string]*blameAuthor)
+	lastEdits := make(map[string]int64)
+	for i, line := range page.Lines {
+		origin, ok := origins[line.ID]
+		if !ok {
+			// Older than the history; fall back to the line's own metadata
+			origin = lineOrigin{userID: line.UserID, created: line.Updated}
+		}
+
+		entry := blameLine{
+			Index:     i,
+			Text:      line.Text,
+			Author:    users[origin.userID],
+			AuthorID:  origin.userID,
+			CommitID:  origin.commitID,
+			ViaServer: origin.viaServer,
+		}
+		if origin.created > 0 {
+			entry.Updated = time.Unix(origin.created, 0).UTC().Format(time.RFC3339)
+		}
+		if origin.viaServer {
+			*response.ServerLines++
+		}
+		response.Lines = append(response.Lines, entry)
+
+		if origin.userID == "" {
+			continue
+		}
+		author, ok := authors[origin.userID]
+		if !ok {
+			author = &blameAuthor{Author: entry.Author, AuthorID: origin.userID}
+			authors[origin.userID] = author
+		}
+		author.Lines++
+		if origin.created > lastEdits[origin.userID] {
+			lastEdits[origin.userID] = origin.created
+			author.LastEdit = entry.Updated
+		}
+	}
+
+	for _, author := range authors {
+		response.Authors = append(response.Authors, *author)
+	}
+	sort.Slice(response.Authors, func(i, j int) bool {
+		if response.Authors[i].Lines != response.Authors[j].Lines {
+			return response.Authors[i].Lines > response.Authors[j].Lines
+		}
+		return response.Authors[i].AuthorID < response.Authors[j].AuthorID
+	})
+
+	// Format the response as JSON
+	result, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format blame: %v", err)
+	}
+
+	return string(result), nil
+}
+
+// serverCommits returns the IDs of the commits made by writes through this
+// server: those based on the commit a pre-write snapshot recorded, or the
+// first commit of a page the server created
+func (t *BlamePageTool) serverCommits(project, title string, commits []scrapbox.Commit) (map[string]bool, error) {
+	snapshots, err := t.store.ForPage(project, title)
+	if err != nil {
+		return nil, err
+	}
+
+	parents := make(map[string]bool)
+	created := false
+	for _, snapshot := range snapshots {
+		if snapshot.Existed {
+			parents[snapshot.CommitID] = true
+		} else {
+			created = true
+		}
+	}
+
+	ids := make(map[string]bool)
+	for i, commit := range commits {
+		if parents[commit.ParentID] || (i == 0 && commit.ParentID == "" && created) {
+			ids[commit.ID] = true
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,184 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	"github.com/hiroki/scrapbox_mcp/internal/titles"
+	mcperrors "github.com/hiroki/scrapbox_mcp/pkg/errors"
+)
+
+const (
+	// checkPageMaxTitles bounds the title list walked for suggestions
+	checkPageMaxTitles = 20000
+	// checkPageDefaultSuggestions is the default number of candidates returned
+	checkPageDefaultSuggestions = 5
+)
+
+type CheckPageExistsTool struct {
+	client scrapbox.Reader
+}
+
+func NewCheckPageExistsTool(client scrapbox.Reader) *CheckPageExistsTool {
+	return &CheckPageExistsTool{client: client}
+}
+
+// pageSuggestion is a similar existing title
+type pageSuggestion struct {
+	Title    string `json:"title"`
+	Distance int    `json:"distance"`
+}
+
+// checkPageExistsResponse is the check_page_exists result
+type checkPageExistsResponse struct {
+	Title  string `json:"title"`
+	Exists bool   `json:"exists"`
+	// ExistingTitle is the title of the existing page when it differs from
+	// Title, e.g. in case
+	ExistingTitle string           `json:"existingTitle,omitempty"`
+	Suggestions   []pageSuggestion `json:"suggestions,omitempty"`
+}
+
+func (t *CheckPageExistsTool) Name() string {
+	return "check_page_exists"
+}
+
+func (t *CheckPageExistsTool) Description() string {
+	return "Checks whether a page with the title exists, matching titles the way Scrapbox links do (ignoring case). If it does not, returns the closest existing titles by edit distance. Call this before create_page to avoid creating near-duplicate pages."
+}
+
+func (t *CheckPageExistsTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "The page title to look up",
+			},
+			"project": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional project name (uses the session default set by set_default_project, or the server default)",
+			},
+			"suggestions": map[string]interface{}{
+				"type":        "number",
+				"description": fmt.Sprintf("Maximum number of similar titles to return when the page does not exist (default: %d)", checkPageDefaultSuggestions),
+			},
+		},
+		"required": []string{"title"},
+	}
+}
+
+func (t *CheckPageExistsTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	title, ok := arguments["title"].(string)
+	if !ok || title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+
+	limit := checkPageDefaultSuggestions
+	if limitArg, ok := arguments["suggestions"].(float64); ok && limitArg > 0 {
+		limit = int(limitArg)
+	}
+
+	project := resolveProject(ctx, arguments, t.client)
+
+	// Scrapbox looks titles up the way page links resolve them, so a page
+	// differing only in case exists
+	response := checkPageExistsResponse{Title: title}
+	page, err := t.client.GetPage(project, title)
+	if err != nil {
+		var sbErr *mcperrors.ScrapboxError
+		if !errors.As(err, &sbErr) || sbErr.Code != mcperrors.ErrCodeNotFound {
+			return nil, err
+		}
+	} else if page.CommitID != "" {
+		response.Exists = true
+		if page.Title != title {
+			response.ExistingTitle = page.Title
+		}
+	}
+
+	// Titles are only listed to find suggestions for a missing page
+	if !response.Exists {
+		var pageTitles []string
+		for skip := 0; skip < checkPageMaxTitles; skip += projectStatsPageSize {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			resp, err := t.client.ListPages(project, projectStatsPageSize, skip)
+			if err != nil {
+				return nil, err
+			}
+			for _, page := range resp.Pages {
+				pageTitles = append(pageTitles, page.Title)
+			}
+			if len(resp.Pages) < projectStatsPageSize || skip+len(resp.Pages) >= resp.Count {
+				break
+			}
+		}
+		response.Suggestions = similarTitles(title, pageTitles, limit)
+	}
+
+	// Format the response as JSON
+	result, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format result: %v", err)
+	}
+
+	return string(result), nil
+}
+
+// similarTitles ranks titles by edit distance between normalized titles.
+// Candidates further away than half the title length are dropped unless one
+// contains the other.
+func similarTitles(title string, existingTitles []string, limit int) []pageSuggestion {
+	target := titles.Normalize(title)
+	maxDistance := len([]rune(target))/2 + 1
+
+	var candidates []pageSuggestion
+	for _, existing := range existingTitles {
+		key := titles.Normalize(existing)
+		distance := levenshtein(target, key)
+		if distance > maxDistance && !strings.Contains(key, target) && !strings.Contains(target, key) {
+			continue
+		}
+		candidates = append(candidates, pageSuggestion{Title: existing, Distance: distance})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Distance != candidates[j].Distance {
+			return candidates[i].Distance < candidates[j].Distance
+		}
+		return candidates[i].Title < candidates[j].Title
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates
+}
+
+// levenshtein returns the edit distance between a and b, counted in runes
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,229 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hiroki/scrapbox_mcp/internal/audit"
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	mcperrors "github.com/hiroki/scrapbox_mcp/pkg/errors"
+)
+
+// argConfirmationToken carries the token that confirms a destructive call
+const argConfirmationToken = "confirmation_token"
+
+// maxPreviewLines bounds the lost lines listed in a confirmation preview
+const maxPreviewLines = 10
+
+// DestructiveTool is implemented by write tools that can delete or overwrite
+// content. When confirmations are required, such calls first return the
+// preview and a token instead of running.
+type DestructiveTool interface {
+	// DestructivePreview reports whether the call would delete or overwrite
+	// content and describes what would be lost. Invalid arguments are not
+	// destructive; Execute reports them.
+	DestructivePreview(ctx context.Context, arguments map[string]interface{}) (string, bool, error)
+}
+
+// pendingConfirmation is an issued, unused confirmation token
+type pendingConfirmation struct {
+	session  string
+	tool     string
+	argsHash string
+	expires  time.Time
+}
+
+// confirmer issues and redeems confirmation tokens
+type confirmer struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	pending map[string]pendingConfirmation
+}
+
+// RequireConfirmation makes destructive calls two-phase: the first call
+// returns a preview and a token, and the call only runs when repeated with
+// the same arguments and confirmation_token within ttl.
+func (r *Registry) RequireConfirmation(ttl time.Duration) {
+	c := &confirmer{ttl: ttl, pending: make(map[string]pendingConfirmation)}
+	r.mu.Lock()
+	r.confirmTTL = ttl
+	r.mu.Unlock()
+	r.Use(c.middleware)
+}
+
+func (c *confirmer) middleware(next ToolHandler) ToolHandler {
+	return WrapExecute(next, func(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+		token, _ := arguments[argConfirmationToken].(string)
+		arguments = withoutArgument(arguments, argConfirmationToken)
+		session := SessionIDFromContext(ctx)
+
+		if token != "" {
+			if err := c.redeem(token, session, next.Name(), arguments); err != nil {
+				return nil, err
+			}
+			log.Printf("[CONFIRM] Confirmed %s", next.Name())
+			return next.Execute(ctx, arguments)
+		}
+
+		preview, destructive, err := destructivePreview(ctx, next, arguments)
+		if err != nil {
+			return nil, err
+		}
+		if !destructive {
+			return next.Execute(ctx, arguments)
+		}
+
+		token = c.issue(session, next.Name(), arguments)
+		log.Printf("[CONFIRM] Issued confirmation token for %s", next.Name())
+		return fmt.Sprintf("Confirmation required: this %s call deletes, overwrites or misplaces content and has NOT been run.\n\n%s\n\nTo proceed, call %s again with the same arguments plus %s=%q within %s.",
+			next.Name(), preview, next.Name(), argConfirmationToken, token, c.ttl), nil
+	})
+}
+
+// issue stores a token for the call
+func (c *confirmer) issue(session, tool string, arguments map[string]interface{}) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for token, p := range c.pending {
+		if now.After(p.expires) {
+			delete(c.pending, token)
+		}
+	}
+	token := uuid.New().String()
+	c.pending[token] = pendingConfirmation{
+		session:  session,
+		tool:     tool,
+		argsHash: audit.HashArguments(arguments),
+		expires:  now.Add(c.ttl),
+	}
+	return token
+}
+
+// redeem consumes a token, which must have been issued to the same session
+// for the same tool and arguments
+func (c *confirmer) redeem(token, session, tool string, arguments map[string]interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.pending[token]
+	if !ok || time.Now().After(p.expires) {
+		delete(c.pending, token)
+		return mcperrors.NewToolError(mcperrors.ErrCodeConfirmation, "confirmation token is unknown, already used or expired", false)
+	}
+	if p.session != session || p.tool != tool || p.argsHash != audit.HashArguments(arguments) {
+		return mcperrors.NewToolError(mcperrors.ErrCodeConfirmation, "confirmation token was issued for a different call; the arguments must be unchanged", false)
+	}
+	delete(c.pending, token)
+	return nil
+}
+
+// destructivePreview asks tool for its preview, if it can destroy content
+func destructivePreview(ctx context.Context, tool ToolHandler, arguments map[string]interface{}) (string, bool, error) {
+	destructive, ok := tool.(DestructiveTool)
+	if !ok {
+		return "", false, nil
+	}
+	return destructive.DestructivePreview(ctx, arguments)
+}
+
+// isDestructiveTool reports whether the tool can ask for confirmation
+func isDestructiveTool(tool ToolHandler) bool {
+	_, ok := tool.(DestructiveTool)
+	return ok
+}
+
+// confirmationProperties is the schema property added to destructive tools
+func confirmationProperties() map[string]interface{} {
+	return map[string]interface{}{
+		argConfirmationToken: map[string]interface{}{
+			"type":        "string",
+			"description": "Token from a previous call of this tool that asked for confirmation. Repeat the call with the same arguments and the token to run it.",
+		},
+	}
+}
+
+// withoutArgument returns arguments without key; the caller's map is not modified
+func withoutArgument(arguments map[string]interface{}, key string) map[string]interface{} {
+	if _, ok := arguments[key]; !ok {
+		return arguments
+	}
+	copied := make(map[string]interface{}, len(arguments))
+	for k, v := range arguments {
+		if k != key {
+			copied[k] = v
+		}
+	}
+	return copied
+}
+
+// dryRunPreview runs tool with dry_run=true and returns its report
+func dryRunPreview(ctx context.Context, tool ToolHandler, arguments map[string]interface{}) (string, bool, error) {
+	dryRunArgs := make(map[string]interface{}, len(arguments))
+	for k, v := range arguments {
+		dryRunArgs[k] = v
+	}
+	dryRunArgs["dry_run"] = true
+	result, err := tool.Execute(ctx, dryRunArgs)
+	if err != nil {
+		// Let the real call report invalid arguments
+		return "", false, nil
+	}
+	return fmt.Sprintf("Dry run:\n%v", result), true, nil
+}
+
+// pageLossPreview describes the lines of a page that newTexts would remove
+// or change. A page that does not exist yet loses nothing.
+func pageLossPreview(client scrapbox.Reader, title string, newTexts []string) (string, bool, error) {
+	page, err := client.GetPage(client.DefaultProject(), title)
+	if err != nil || page.CommitID == "" {
+		return "", false, nil
+	}
+	lines := make([]string, len(page.Lines))
+	for i, line := range page.Lines {
+		lines[i] = line.Text
+	}
+	return linesLossPreview(title, lines, lostLines(lines, newTexts))
+}
+
+// linesLossPreview lists lost lines of a page, or reports nothing lost
+func linesLossPreview(title string, lines, lost []string) (string, bool, error) {
+	if len(lost) == 0 {
+		return "", false, nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "'%s': %d of %d lines would be removed or changed:", title, len(lost), len(lines))
+	for i, line := range lost {
+		if i == maxPreviewLines {
+			fmt.Fprintf(&b, "\n  ... and %d more", len(lost)-maxPreviewLines)
+			break
+		}
+		b.WriteString("\n  - " + line)
+	}
+	return b.String(), true, nil
+}
+
+// lostLines returns the lines of before that do not appear in after,
+// counting repeated lines
+func lostLines(before, after []string) []string {
+	remaining := make(map[string]int, len(after))
+	for _, line := range after {
+		remaining[line]++
+	}
+	var lost []string
+	for _, line := range before {
+		if remaining[line] > 0 {
+			remaining[line]--
+			continue
+		}
+		lost = append(lost, line)
+	}
+	return lost
+}
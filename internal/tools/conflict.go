@@ -0,0 +1,45 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+// argExpectedCommitID is the optimistic concurrency argument of write tools
+const argExpectedCommitID = "expected_commit_id"
+
+// expectedCommitProperty is the InputSchema property for expected_commit_id
+func expectedCommitProperty() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"description": "Optional commitId from get_page. If the page has been edited since, the write fails with a conflict and returns the current content instead of overwriting the other edit.",
+	}
+}
+
+// parseExpectedCommitID reads the expected_commit_id argument
+func parseExpectedCommitID(arguments map[string]interface{}) string {
+	commitID, _ := arguments[argExpectedCommitID].(string)
+	return commitID
+}
+
+// conflictError adds the current page content to a StaleCommitError so the
+// caller can merge its change and retry. Other errors are returned as is.
+func conflictError(err error) error {
+	var stale *scrapbox.StaleCommitError
+	if !errors.As(err, &stale) {
+		return err
+	}
+
+	var b strings.Builder
+	for i, line := range stale.Page.Lines {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(line.Text)
+	}
+	return fmt.Errorf("%w\nRe-apply your change to the current content and retry with expected_commit_id=%s.\nCurrent content:\n%s",
+		err, stale.Page.CommitID, b.String())
+}
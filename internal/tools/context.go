@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+// SessionState holds per-session values that tools can read and update
+type SessionState interface {
+	DefaultProject() string
+	SetDefaultProject(project string)
+	// Credentials are the Scrapbox credentials the client supplied for the
+	// session (nil: the server's)
+	Credentials() *scrapbox.Credentials
+	SetCredentials(credentials *scrapbox.Credentials)
+}
+
+type contextKey int
+
+const (
+	sessionIDKey contextKey = iota
+	operationIDKey
+	sessionStateKey
+	progressKey
+	elicitKey
+	projectScopeKey
+	roleKey
+)
+
+// ProgressFunc reports progress of a long-running tool call to the client
+type ProgressFunc func(progress, total float64, message string)
+
+// ElicitFunc asks the client's user for input matching schema. It returns the
+// user's action ("accept", "decline" or "cancel") and the submitted content.
+type ElicitFunc func(ctx context.Context, message string, schema map[string]interface{}) (string, map[string]interface{}, error)
+
+// WithSessionID returns a context carrying the MCP session ID
+func WithSessionID(ctx context.Context, sessionID string) context.Context {
+	return context.WithValue(ctx, sessionIDKey, sessionID)
+}
+
+// SessionIDFromContext returns the MCP session ID stored in ctx, if any
+func SessionIDFromContext(ctx context.Context) string {
+	sessionID, _ := ctx.Value(sessionIDKey).(string)
+	return sessionID
+}
+
+// WithOperationID returns a context carrying the ID of the current write operation.
+// The same ID is used for the audit entry and the undo snapshot.
+func WithOperationID(ctx context.Context, operationID string) context.Context {
+	return context.WithValue(ctx, operationIDKey, operationID)
+}
+
+// OperationIDFromContext returns the write operation ID stored in ctx, if any
+func OperationIDFromContext(ctx context.Context) string {
+	operationID, _ := ctx.Value(operationIDKey).(string)
+	return operationID
+}
+
+// WithSessionState returns a context carrying the caller's session state
+func WithSessionState(ctx context.Context, state SessionState) context.Context {
+	return context.WithValue(ctx, sessionStateKey, state)
+}
+
+// SessionStateFromContext returns the session state stored in ctx, or nil
+func SessionStateFromContext(ctx context.Context) SessionState {
+	state, _ := ctx.Value(sessionStateKey).(SessionState)
+	return state
+}
+
+// WithProgress returns a context carrying the progress reporter of a tool call
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey, fn)
+}
+
+// reportProgress sends a progress notification if the caller asked for them
+func reportProgress(ctx context.Context, progress, total float64, message string) {
+	if fn, ok := ctx.Value(progressKey).(ProgressFunc); ok {
+		fn(progress, total, message)
+	}
+}
+
+// WithElicitation returns a context carrying a way to ask the client's user for input
+func WithElicitation(ctx context.Context, fn ElicitFunc) context.Context {
+	return context.WithValue(ctx, elicitKey, fn)
+}
+
+// elicitFromContext returns the elicitation function of a tool call, if the
+// client supports elicitation
+func elicitFromContext(ctx context.Context) (ElicitFunc, bool) {
+	fn, ok := ctx.Value(elicitKey).(ElicitFunc)
+	return fn, ok
+}
+
+// WithProjectScope returns a context limiting tool calls to projects, e.g. the
+// projects granted by the client's roots. An empty list allows no project.
+func WithProjectScope(ctx context.Context, projects []string) context.Context {
+	return context.WithValue(ctx, projectScopeKey, projects)
+}
+
+// projectScopeFromContext returns the projects a call is limited to, if any
+func projectScopeFromContext(ctx context.Context) ([]string, bool) {
+	projects, ok := ctx.Value(projectScopeKey).([]string)
+	return projects, ok
+}
+
+// WithRole returns a context carrying the caller's role (see internal/rbac)
+func WithRole(ctx context.Context, role string) context.Context {
+	return context.WithValue(ctx, roleKey, role)
+}
+
+// RoleFromContext returns the caller's role, or "" if the call carries none
+func RoleFromContext(ctx context.Context) string {
+	role, _ := ctx.Value(roleKey).(string)
+	return role
+}
+
+// resolveProject picks the project for a tool call: the explicit "project"
+// argument, then the session default, then the backend default.
+func resolveProject(ctx context.Context, arguments map[string]interface{}, client scrapbox.Reader) string {
+	if projectArg, ok := arguments["project"].(string); ok && projectArg != "" {
+		return projectArg
+	}
+	if state := SessionStateFromContext(ctx); state != nil {
+		if project := state.DefaultProject(); project != "" {
+			return project
+		}
+	}
+	return client.DefaultProject()
+}
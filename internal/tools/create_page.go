@@ -9,14 +9,12 @@ import (
 )
 
 type CreatePageTool struct {
-	client *scrapbox.Client
-	wsURL  string
+	client scrapbox.API
 }
 
-func NewCreatePageTool(client *scrapbox.Client, wsURL string) *CreatePageTool {
+func NewCreatePageTool(client scrapbox.API) *CreatePageTool {
 	return &CreatePageTool{
 		client: client,
-		wsURL:  wsURL,
 	}
 }
 
@@ -25,7 +23,11 @@ func (t *CreatePageTool) Name() string {
 }
 
 func (t *CreatePageTool) Description() string {
-	return "Creates a new Scrapbox page with the specified title and body content. Returns an error if the page already exists."
+	return "Creates a new Scrapbox page with the specified title and body content. Returns an error if the page already exists, unless if_exists is append or overwrite."
+}
+
+func (t *CreatePageTool) IsWrite() bool {
+	return true
 }
 
 func (t *CreatePageTool) InputSchema() map[string]interface{} {
@@ -44,6 +46,11 @@ func (t *CreatePageTool) InputSchema() map[string]interface{} {
 				"type":        "string",
 				"description": "Optional project name (uses default if not specified)",
 			},
+			"if_exists": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{string(scrapbox.IfExistsError), string(scrapbox.IfExistsAppend), string(scrapbox.IfExistsOverwrite)},
+				"description": "What to do when the page already exists: error (default), append the body to the end of the page, or overwrite the page content",
+			},
 		},
 		"required": []string{"title"},
 	}
@@ -60,14 +67,21 @@ func (t *CreatePageTool) Execute(ctx context.Context, arguments map[string]inter
 		body = bodyArg
 	}
 
-	project := t.client.ProjectName
+	ifExists := scrapbox.IfExistsError
+	if ifExistsArg, ok := arguments["if_exists"].(string); ok && ifExistsArg != "" {
+		ifExists = scrapbox.IfExists(ifExistsArg)
+		switch ifExists {
+		case scrapbox.IfExistsError, scrapbox.IfExistsAppend, scrapbox.IfExistsOverwrite:
+		default:
+			return nil, fmt.Errorf("invalid if_exists: %s (expected error, append or overwrite)", ifExistsArg)
+		}
+	}
+
+	project := t.client.DefaultProject()
 	if projectArg, ok := arguments["project"].(string); ok && projectArg != "" {
 		project = projectArg
 	}
 
-	// Ensure WebSocket client is initialized
-	t.client.EnsureWebSocket(t.wsURL)
-
 	// Split body by newline
 	var bodyLines []string
 	if body != "" {
@@ -75,10 +89,24 @@ func (t *CreatePageTool) Execute(ctx context.Context, arguments map[string]inter
 	}
 
 	// Execute create
-	if err := t.client.CreatePage(title, bodyLines); err != nil {
-		return nil, fmt.Errorf("failed to create page: %v", err)
+	result, err := t.client.CreatePage(title, bodyLines, ifExists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create page: %w", err)
 	}
 
-	pageURL := fmt.Sprintf("https://scrapbox.io/%s/%s", project, title)
-	return fmt.Sprintf("Successfully created page '%s' in project '%s'\nURL: %s", title, project, pageURL), nil
+	return formatWriteResult(fmt.Sprintf("Successfully created page '%s' in project '%s'", title, project), result)
+}
+
+// DestructivePreview lists the lines an overwrite of an existing page replaces
+func (t *CreatePageTool) DestructivePreview(ctx context.Context, arguments map[string]interface{}) (string, bool, error) {
+	title, _ := arguments["title"].(string)
+	ifExists, _ := arguments["if_exists"].(string)
+	if title == "" || scrapbox.IfExists(ifExists) != scrapbox.IfExistsOverwrite {
+		return "", false, nil
+	}
+	newTexts := []string{title}
+	if body, _ := arguments["body"].(string); body != "" {
+		newTexts = append(newTexts, strings.Split(body, "\n")...)
+	}
+	return pageLossPreview(t.client, title, newTexts)
 }
@@ -0,0 +1,259 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	mcperrors "github.com/hiroki/scrapbox_mcp/pkg/errors"
+)
+
+// diagnosePings is the number of REST requests used to measure round-trip latency
+const diagnosePings = 3
+
+// Problem areas reported by diagnose
+const (
+	problemNone          = "none"
+	problemCredentials   = "credentials"
+	problemConfiguration = "configuration"
+	problemNetwork       = "network"
+	problemRateLimit     = "rate_limit"
+	problemScrapbox      = "scrapbox"
+)
+
+// ConnectionChecker exercises the server's connections to Scrapbox
+type ConnectionChecker interface {
+	DefaultProject() string
+	GetProject(project string) (*scrapbox.ProjectInfo, error)
+	ValidateCredentials() (*scrapbox.CredentialsInfo, error)
+	CheckWebSocket(wsURL string) (scrapbox.Transport, error)
+}
+
+type DiagnoseTool struct {
+	checker ConnectionChecker
+	wsURL   string
+}
+
+func NewDiagnoseTool(checker ConnectionChecker, wsURL string) *DiagnoseTool {
+	return &DiagnoseTool{checker: checker, wsURL: wsURL}
+}
+
+// diagnosis is the diagnose result
+type diagnosis struct {
+	Project string `json:"project"`
+	// Problem is the area of the first failing check, or "none"
+	Problem string            `json:"problem"`
+	Summary string            `json:"summary"`
+	Checks  []diagnosticCheck `json:"checks"`
+	// RoundTripMs summarizes the latency of the REST requests
+	RoundTripMs *latencyStats `json:"roundTripMs,omitempty"`
+}
+
+type diagnosticCheck struct {
+	Name      string      `json:"name"`
+	OK        bool        `json:"ok"`
+	LatencyMs int64       `json:"latencyMs"`
+	Details   interface{} `json:"details,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	Problem   string      `json:"problem,omitempty"`
+}
+
+type latencyStats struct {
+	Min    int64 `json:"min"`
+	Median int64 `json:"median"`
+	Max    int64 `json:"max"`
+}
+
+func (t *DiagnoseTool) Name() string {
+	return "diagnose"
+}
+
+func (t *DiagnoseTool) Description() string {
+	return "Checks the connection to Scrapbox when tool calls fail: REST reachability and round-trip latency, session cookie validity, and the WebSocket handshake used for writes. Reports whether the problem is credentials, configuration, network or Scrapbox itself."
+}
+
+func (t *DiagnoseTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{},
+		"required":   []string{},
+	}
+}
+
+func (t *DiagnoseTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	project := t.checker.DefaultProject()
+	result := &diagnosis{Project: project}
+
+	// The checks are independent, so run them concurrently to bound the total time
+	checks := []func() diagnosticCheck{
+		func() diagnosticCheck {
+			check, latencies := t.checkREST(ctx, project)
+			result.RoundTripMs = summarizeLatencies(latencies)
+			return check
+		},
+		func() diagnosticCheck {
+			return runDiagnostic(ctx, "auth", func() (interface{}, error) {
+				info, err := t.checker.ValidateCredentials()
+				if err != nil {
+					return nil, err
+				}
+				return info, nil
+			})
+		},
+		func() diagnosticCheck {
+			return runDiagnostic(ctx, "websocket", func() (interface{}, error) {
+				transport, err := t.checker.CheckWebSocket(t.wsURL)
+				if err != nil {
+					return nil, err
+				}
+				return map[string]scrapbox.Transport{"transport": transport}, nil
+			})
+		},
+	}
+	result.Checks = make([]diagnosticCheck, len(checks))
+	done := make(chan struct{}, len(checks))
+	for i, check := range checks {
+		go func(i int, check func() diagnosticCheck) {
+			result.Checks[i] = check()
+			done <- struct{}{}
+		}(i, check)
+	}
+	for range checks {
+		<-done
+	}
+
+	result.Problem = problemNone
+	for _, check := range result.Checks {
+		if !check.OK {
+			result.Problem = check.Problem
+			break
+		}
+	}
+	result.Summary = diagnosisSummary(result.Problem)
+
+	// Format the response as JSON
+	jsonResult, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format response: %v", err)
+	}
+
+	return withSummary(result.Summary, string(jsonResult)), nil
+}
+
+// checkREST fetches the project several times; the first request is the
+// reachability check and all of them measure round-trip latency. A response
+// rejecting the credentials still proves that Scrapbox is reachable.
+func (t *DiagnoseTool) checkREST(ctx context.Context, project string) (diagnosticCheck, []int64) {
+	var latencies []int64
+	var first diagnosticCheck
+	for i := 0; i < diagnosePings; i++ {
+		check := runDiagnostic(ctx, "rest", func() (interface{}, error) {
+			info, err := t.checker.GetProject(project)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]string{"project": info.Name}, nil
+		})
+		if i == 0 {
+			first = check
+		}
+		if !check.OK && check.Problem != problemCredentials {
+			break
+		}
+		latencies = append(latencies, check.LatencyMs)
+	}
+	if !first.OK && first.Problem == problemCredentials {
+		// Reachable; the auth check reports the credential problem
+		first.OK = true
+		first.Details = map[string]string{"note": "reachable, but the project requires valid credentials"}
+		first.Error, first.Problem = "", ""
+	}
+	return first, latencies
+}
+
+// runDiagnostic runs fn, abandoning it when ctx ends, and classifies its failure
+func runDiagnostic(ctx context.Context, name string, fn func() (interface{}, error)) diagnosticCheck {
+	type outcome struct {
+		details interface{}
+		err     error
+	}
+	done := make(chan outcome, 1)
+
+	start := time.Now()
+	go func() {
+		details, err := fn()
+		done <- outcome{details: details, err: err}
+	}()
+
+	check := diagnosticCheck{Name: name}
+	select {
+	case o := <-done:
+		check.OK = o.err == nil
+		check.Details = o.details
+		if o.err != nil {
+			check.Error = o.err.Error()
+			check.Problem = classifyProblem(o.err)
+		}
+	case <-ctx.Done():
+		check.Error = "check timed out"
+		check.Problem = problemNetwork
+	}
+	check.LatencyMs = time.Since(start).Milliseconds()
+	return check
+}
+
+// classifyProblem maps a Scrapbox error to the area that needs fixing
+func classifyProblem(err error) string {
+	var sbErr *mcperrors.ScrapboxError
+	if !errors.As(err, &sbErr) {
+		return problemNetwork
+	}
+	switch {
+	case sbErr.Code == mcperrors.ErrCodeSessionExpired,
+		sbErr.Code == mcperrors.ErrCodeAuthFailed,
+		sbErr.Code == mcperrors.ErrCodePermissionDenied:
+		return problemCredentials
+	case sbErr.Code == mcperrors.ErrCodeNotFound:
+		return problemConfiguration
+	case sbErr.Code == mcperrors.ErrCodeRateLimit:
+		return problemRateLimit
+	case sbErr.Status >= 500:
+		return problemScrapbox
+	}
+	return problemNetwork
+}
+
+// diagnosisSummary explains a problem area and what to do about it
+func diagnosisSummary(problem string) string {
+	switch problem {
+	case problemNone:
+		return "All checks passed: Scrapbox is reachable, the credentials are valid and the WebSocket handshake succeeds."
+	case problemCredentials:
+		return "Scrapbox rejected the credentials: refresh COSENSE_SID (or the cookie file/refresh command) and check that the user can access the project."
+	case problemConfiguration:
+		return "The configured project was not found: check COSENSE_PROJECT_NAME and the API URLs."
+	case problemRateLimit:
+		return "Scrapbox is rate limiting this server: wait before retrying and reduce the request rate."
+	case problemScrapbox:
+		return "Scrapbox is answering with server errors: the problem is on Scrapbox's side, retry later."
+	}
+	return "Scrapbox could not be reached: check the network, the proxy settings and SCRAPBOX_API_URL/SCRAPBOX_WS_URL."
+}
+
+// summarizeLatencies returns min/median/max of latencies, or nil if there are none
+func summarizeLatencies(latencies []int64) *latencyStats {
+	if len(latencies) == 0 {
+		return nil
+	}
+	sorted := append([]int64(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return &latencyStats{
+		Min:    sorted[0],
+		Median: sorted[len(sorted)/2],
+		Max:    sorted[len(sorted)-1],
+	}
+}
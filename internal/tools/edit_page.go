@@ -6,17 +6,31 @@ import (
 	"strings"
 
 	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	"github.com/hiroki/scrapbox_mcp/internal/titles"
+)
+
+// What edit_page does when the first content line is not the page title
+const (
+	// TitleMismatchPrepend inserts the title as the first line
+	TitleMismatchPrepend = "prepend"
+	// TitleMismatchError rejects the edit
+	TitleMismatchError = "error"
+	// titleMismatchRename accepts the new first line, renaming the page (per call only)
+	titleMismatchRename = "rename"
 )
 
 type EditPageTool struct {
-	client *scrapbox.Client
-	wsURL  string
+	client        scrapbox.API
+	titleMismatch string
 }
 
-func NewEditPageTool(client *scrapbox.Client, wsURL string) *EditPageTool {
+// NewEditPageTool creates the edit_page tool. titleMismatch is the default
+// handling of content whose first line differs from the title
+// (TitleMismatchPrepend or TitleMismatchError).
+func NewEditPageTool(client scrapbox.API, titleMismatch string) *EditPageTool {
 	return &EditPageTool{
-		client: client,
-		wsURL:  wsURL,
+		client:        client,
+		titleMismatch: titleMismatch,
 	}
 }
 
@@ -25,7 +39,11 @@ func (t *EditPageTool) Name() string {
 }
 
 func (t *EditPageTool) Description() string {
-	return "Replaces the entire content of a Scrapbox page with new text. Use get_page first to retrieve current content, then modify and pass the complete new content. The first line becomes the page title."
+	return "Replaces the entire content of a Scrapbox page with new text. Use get_page first to retrieve current content, then modify and pass the complete new content. The first line must be the page title; if it is not, the title is prepended or the edit is rejected (see title_mismatch). Pass title_mismatch=rename to change the title."
+}
+
+func (t *EditPageTool) IsWrite() bool {
+	return true
 }
 
 func (t *EditPageTool) InputSchema() map[string]interface{} {
@@ -44,6 +62,12 @@ func (t *EditPageTool) InputSchema() map[string]interface{} {
 				"type":        "string",
 				"description": "Optional project name (uses default if not specified)",
 			},
+			"title_mismatch": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{TitleMismatchPrepend, TitleMismatchError, titleMismatchRename},
+				"description": fmt.Sprintf("What to do when the first content line is not the title: prepend the title, return an error, or rename the page to the first line (default: %s)", t.titleMismatch),
+			},
+			argExpectedCommitID: expectedCommitProperty(),
 		},
 		"required": []string{"title", "content"},
 	}
@@ -60,21 +84,70 @@ func (t *EditPageTool) Execute(ctx context.Context, arguments map[string]interfa
 		return nil, fmt.Errorf("content is required and must be a string")
 	}
 
-	project := t.client.ProjectName
+	project := t.client.DefaultProject()
 	if projectArg, ok := arguments["project"].(string); ok && projectArg != "" {
 		project = projectArg
 	}
 
-	// Ensure WebSocket client is initialized
-	t.client.EnsureWebSocket(t.wsURL)
-
-	// Split content into lines
-	newTexts := strings.Split(content, "\n")
+	newTexts, err := t.newTexts(title, content, arguments)
+	if err != nil {
+		return nil, err
+	}
 
 	// Execute patch
-	if err := t.client.PatchPage(title, newTexts); err != nil {
-		return nil, fmt.Errorf("failed to edit page: %v", err)
+	result, err := t.client.PatchPage(title, newTexts, parseExpectedCommitID(arguments))
+	if err != nil {
+		return nil, fmt.Errorf("failed to edit page: %w", conflictError(err))
+	}
+
+	return formatWriteResult(fmt.Sprintf("Successfully edited page '%s' in project '%s' (%d lines)", title, project, len(newTexts)), result)
+}
+
+// DestructivePreview lists the existing lines the new content drops or changes
+func (t *EditPageTool) DestructivePreview(ctx context.Context, arguments map[string]interface{}) (string, bool, error) {
+	title, _ := arguments["title"].(string)
+	content, _ := arguments["content"].(string)
+	if title == "" || content == "" {
+		return "", false, nil
 	}
+	newTexts, err := t.newTexts(title, content, arguments)
+	if err != nil {
+		return "", false, nil
+	}
+	return pageLossPreview(t.client, title, newTexts)
+}
 
-	return fmt.Sprintf("Successfully edited page '%s' in project '%s' (%d lines)", title, project, len(newTexts)), nil
+// newTexts splits content into lines and checks its title line
+func (t *EditPageTool) newTexts(title, content string, arguments map[string]interface{}) ([]string, error) {
+	mismatch := t.titleMismatch
+	if mismatchArg, ok := arguments["title_mismatch"].(string); ok && mismatchArg != "" {
+		switch mismatchArg {
+		case TitleMismatchPrepend, TitleMismatchError, titleMismatchRename:
+			mismatch = mismatchArg
+		default:
+			return nil, fmt.Errorf("invalid title_mismatch: %s (expected prepend, error or rename)", mismatchArg)
+		}
+	}
+	return checkTitleLine(title, strings.Split(content, "\n"), mismatch)
+}
+
+// checkTitleLine makes sure the first line of newTexts is a non-empty title.
+// A first line that only differs from title in width, case or spacing counts
+// as the title. A blank first line is replaced by title unless mismatch is error.
+func checkTitleLine(title string, newTexts []string, mismatch string) ([]string, error) {
+	first := strings.TrimSpace(newTexts[0])
+	switch {
+	case first != "" && titles.Normalize(first) == titles.Normalize(title):
+		return newTexts, nil
+	case mismatch == TitleMismatchError && first == "":
+		return nil, fmt.Errorf("the first line of content is empty; it must be the page title %q", title)
+	case mismatch == TitleMismatchError:
+		return nil, fmt.Errorf("the first line of content (%q) does not match the page title %q; start content with the title, or pass title_mismatch=rename to rename the page", newTexts[0], title)
+	case first == "":
+		return append([]string{title}, newTexts[1:]...), nil
+	case mismatch == titleMismatchRename:
+		return newTexts, nil
+	default:
+		return append([]string{title}, newTexts...), nil
+	}
 }
@@ -0,0 +1,246 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	"github.com/hiroki/scrapbox_mcp/pkg/notation"
+)
+
+// Section edit modes
+const (
+	sectionReplace = "replace"
+	sectionAppend  = "append"
+)
+
+type EditSectionTool struct {
+	client scrapbox.API
+}
+
+func NewEditSectionTool(client scrapbox.API) *EditSectionTool {
+	return &EditSectionTool{client: client}
+}
+
+func (t *EditSectionTool) Name() string {
+	return "edit_section"
+}
+
+func (t *EditSectionTool) Description() string {
+	return "Edits the lines indented under one parent line (a section) without resubmitting the whole page. The parent is found by its text (decoration such as [* ] is optional) or by its line index from get_page_outline. mode=replace replaces everything under the parent; mode=append adds lines at the end of the section. new_lines are written relative to the parent: unindented lines become its direct children."
+}
+
+func (t *EditSectionTool) IsWrite() bool {
+	return true
+}
+
+func (t *EditSectionTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "The title of the page",
+			},
+			"section": map[string]interface{}{
+				"type":        "string",
+				"description": "Text of the parent line, without indentation",
+			},
+			"line": map[string]interface{}{
+				"type":        "number",
+				"description": "Index of the parent line (from get_page_outline); use instead of section when the text is ambiguous",
+			},
+			"mode": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{sectionReplace, sectionAppend},
+				"description": "replace the section body (default) or append to it",
+			},
+			"new_lines": map[string]interface{}{
+				"type":        "string",
+				"description": "The lines to write, separated by newlines and indented relative to the parent. Empty with mode=replace clears the section.",
+			},
+			argExpectedCommitID: expectedCommitProperty(),
+		},
+		"required": []string{"title", "new_lines"},
+	}
+}
+
+func (t *EditSectionTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	title, ok := arguments["title"].(string)
+	if !ok || title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+	newLinesStr, ok := arguments["new_lines"].(string)
+	if !ok {
+		return nil, fmt.Errorf("new_lines is required and must be a string")
+	}
+	section, _ := arguments["section"].(string)
+	if _, hasLine := arguments["line"].(float64); section == "" && !hasLine {
+		return nil, fmt.Errorf("section or line is required")
+	}
+	mode := sectionReplace
+	if modeArg, ok := arguments["mode"].(string); ok && modeArg != "" {
+		if modeArg != sectionReplace && modeArg != sectionAppend {
+			return nil, fmt.Errorf("invalid mode: %s (expected replace or append)", modeArg)
+		}
+		mode = modeArg
+	}
+	if mode == sectionAppend && newLinesStr == "" {
+		return nil, fmt.Errorf("new_lines is required to append")
+	}
+
+	page, err := t.client.GetPage(t.client.DefaultProject(), title)
+	if err != nil {
+		return nil, err
+	}
+	if page.CommitID == "" {
+		return nil, fmt.Errorf("page not found: %s", title)
+	}
+	lines := make([]string, len(page.Lines))
+	for i, line := range page.Lines {
+		lines[i] = line.Text
+	}
+
+	node, err := locateSection(lines, arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	body := sectionBody(node, newLinesStr)
+
+	// Replace covers the lines after the parent up to the end of the
+	// section; append inserts after the last of them
+	start, end := node.Line+1, node.EndLine+1
+	if mode == sectionAppend {
+		start = end
+	}
+	newTexts := make([]string, 0, len(lines)-(end-start)+len(body))
+	newTexts = append(newTexts, lines[:start]...)
+	newTexts = append(newTexts, body...)
+	newTexts = append(newTexts, lines[end:]...)
+
+	// Without an explicit commit ID, guard against the section having moved
+	// since it was located
+	expectedCommitID := parseExpectedCommitID(arguments)
+	if expectedCommitID == "" {
+		expectedCommitID = page.CommitID
+	}
+	result, err := t.client.PatchPage(title, newTexts, expectedCommitID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to edit section: %w", conflictError(err))
+	}
+
+	if mode == sectionAppend {
+		return formatWriteResult(fmt.Sprintf("Appended %d line(s) to section '%s' (line %d) in page '%s'", len(body), node.Text, node.Line, title), result)
+	}
+	return formatWriteResult(fmt.Sprintf("Replaced section '%s' (line %d) in page '%s': %d line(s) removed, %d added", node.Text, node.Line, title, end-start, len(body)), result)
+}
+
+// DestructivePreview lists the lines a replace removes from the section
+func (t *EditSectionTool) DestructivePreview(ctx context.Context, arguments map[string]interface{}) (string, bool, error) {
+	title, _ := arguments["title"].(string)
+	if mode, _ := arguments["mode"].(string); title == "" || mode == sectionAppend {
+		return "", false, nil
+	}
+	page, err := t.client.GetPage(t.client.DefaultProject(), title)
+	if err != nil || page.CommitID == "" {
+		return "", false, nil
+	}
+	lines := make([]string, len(page.Lines))
+	for i, line := range page.Lines {
+		lines[i] = line.Text
+	}
+	node, err := locateSection(lines, arguments)
+	if err != nil {
+		return "", false, nil
+	}
+	newLines, _ := arguments["new_lines"].(string)
+	return linesLossPreview(title, lines, lostLines(lines[node.Line+1:node.EndLine+1], sectionBody(node, newLines)))
+}
+
+// sectionBody indents new_lines one level below the parent
+func sectionBody(node *outlineNode, newLines string) []string {
+	if newLines == "" {
+		return nil
+	}
+	indent := strings.Repeat(" ", node.Indent+1)
+	var body []string
+	for _, line := range strings.Split(newLines, "\n") {
+		if strings.TrimSpace(line) == "" {
+			body = append(body, "")
+		} else {
+			body = append(body, indent+line)
+		}
+	}
+	return body
+}
+
+// locateSection finds the parent line named by the section or line argument
+func locateSection(lines []string, arguments map[string]interface{}) (*outlineNode, error) {
+	if lineArg, ok := arguments["line"].(float64); ok {
+		return sectionAtLine(buildOutline(lines), int(lineArg))
+	}
+	section, _ := arguments["section"].(string)
+	return findSection(buildOutline(lines), section)
+}
+
+// sectionAtLine returns the outline node starting at the given line
+func sectionAtLine(outline []*outlineNode, line int) (*outlineNode, error) {
+	if line == 0 {
+		return nil, fmt.Errorf("line 0 is the title; use edit_page to edit the whole page")
+	}
+	var found *outlineNode
+	walkOutline(outline, func(node *outlineNode) {
+		if node.Line == line {
+			found = node
+		}
+	})
+	if found == nil {
+		return nil, fmt.Errorf("no section starts at line %d (blank lines and lines inside code or table blocks cannot be sections)", line)
+	}
+	return found, nil
+}
+
+// findSection returns the outline node whose text matches section, either
+// exactly or with its decoration removed. More than one match is an error.
+func findSection(outline []*outlineNode, section string) (*outlineNode, error) {
+	section = strings.TrimSpace(section)
+	var matches []*outlineNode
+	walkOutline(outline, func(node *outlineNode) {
+		if node.Text == section || sectionLabel(node.Text) == section {
+			matches = append(matches, node)
+		}
+	})
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("section not found: %s", section)
+	case 1:
+		return matches[0], nil
+	default:
+		lines := make([]string, len(matches))
+		for i, match := range matches {
+			lines[i] = fmt.Sprint(match.Line)
+		}
+		return nil, fmt.Errorf("section '%s' matches lines %s; pass line to choose one", section, strings.Join(lines, ", "))
+	}
+}
+
+// sectionLabel returns the text of a heading-style line without its
+// decoration: "[* Intro]" and "[[Intro]]" become "Intro"
+func sectionLabel(text string) string {
+	nodes := notation.ParseInline(text)
+	if len(nodes) == 1 && (nodes[0].Type == notation.NodeDecoration || nodes[0].Type == notation.NodeStrong) {
+		return strings.TrimSpace(notation.FormatInline(nodes[0].Children))
+	}
+	return text
+}
+
+// walkOutline calls fn for every node in document order
+func walkOutline(nodes []*outlineNode, fn func(node *outlineNode)) {
+	for _, node := range nodes {
+		fn(node)
+		walkOutline(node.Children, fn)
+	}
+}
@@ -0,0 +1,54 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	mcperrors "github.com/hiroki/scrapbox_mcp/pkg/errors"
+)
+
+// chooseTitle asks the user which of several pages matching title a write
+// tool should change. Without an answer the call fails rather than guess.
+func chooseTitle(ctx context.Context, elicit ElicitFunc, toolName, title string, matches []string) (string, error) {
+	message := fmt.Sprintf("%s: %q matches %d pages. Which page should be changed?", toolName, title, len(matches))
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title": map[string]interface{}{
+				"type":  "string",
+				"title": "Page",
+				"enum":  matches,
+			},
+		},
+		"required": []string{"title"},
+	}
+
+	ambiguous := func(reason string) error {
+		return mcperrors.NewToolError(mcperrors.ErrCodeAmbiguousTitle,
+			fmt.Sprintf("%q matches several pages (%s) and %s", title, strings.Join(matches, ", "), reason), false)
+	}
+
+	action, content, err := elicit(ctx, message, schema)
+	if err != nil {
+		log.Printf("[TOOL] Elicitation for %q failed: %v", title, err)
+		return "", ambiguous("no page was chosen")
+	}
+	switch action {
+	case "accept":
+	case "decline":
+		return "", ambiguous("the user declined to choose one")
+	default:
+		return "", ambiguous("the user cancelled the choice")
+	}
+
+	chosen, _ := content["title"].(string)
+	for _, match := range matches {
+		if match == chosen {
+			log.Printf("[TOOL] User chose %q for title %q", chosen, title)
+			return chosen, nil
+		}
+	}
+	return "", ambiguous(fmt.Sprintf("%q is not one of them", chosen))
+}
@@ -0,0 +1,301 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/index"
+	"github.com/hiroki/scrapbox_mcp/internal/markdown"
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	mcperrors "github.com/hiroki/scrapbox_mcp/pkg/errors"
+	"github.com/hiroki/scrapbox_mcp/pkg/notation"
+)
+
+const (
+	exportOutputDirectory = "directory"
+	exportOutputZip       = "zip"
+
+	// exportDefaultMaxPages and exportMaxPages bound the pages of one export
+	exportDefaultMaxPages = 100
+	exportMaxPages        = 1000
+)
+
+// PageLinker returns the web URL of a page, for links that leave an export
+type PageLinker interface {
+	PageURL(project, title string) string
+}
+
+type ExportMarkdownTool struct {
+	client scrapbox.Reader
+	index  *index.Index
+	linker PageLinker
+	dir    string
+}
+
+// NewExportMarkdownTool creates the export tool. dir is the directory exports
+// are written under ("" allows zip output only); a nil linker leaves links to
+// pages outside the export as plain text.
+func NewExportMarkdownTool(client scrapbox.Reader, pageIndex *index.Index, linker PageLinker, dir string) *ExportMarkdownTool {
+	return &ExportMarkdownTool{client: client, index: pageIndex, linker: linker, dir: dir}
+}
+
+// exportedFile is one file of the export_markdown result
+type exportedFile struct {
+	Title string `json:"title"`
+	File  string `json:"file"`
+}
+
+// exportMarkdownResponse is the export_markdown result
+type exportMarkdownResponse struct {
+	Project   string         `json:"project"`
+	Profile   string         `json:"profile"`
+	Output    string         `json:"output"`
+	Directory string         `json:"directory,omitempty"`
+	Files     []exportedFile `json:"files"`
+	Missing   []string       `json:"missing,omitempty"`
+	Truncated bool           `json:"truncated,omitempty"`
+}
+
+func (t *ExportMarkdownTool) Name() string {
+	return "export_markdown"
+}
+
+func (t *ExportMarkdownTool) Description() string {
+	output := "returns them as a base64 zip archive (embedded resource)"
+	if t.dir != "" {
+		output = "writes them to the server's export directory, or returns them as a base64 zip archive (embedded resource) with output=zip"
+	}
+	return "Converts a set of pages, chosen by titles, tag and/or search query, to Markdown files and " + output + ". Links between exported pages become relative links to their .md files; links to other pages point to Scrapbox. Indented lines become lists, code: and table: blocks become fenced code and tables. profile=hugo or zenn writes front matter (title, dates, tags from #hashtags) and file names for that site generator."
+}
+
+// AdminCall reserves directory output for admins, since it overwrites
+// files in the server's export directory
+func (t *ExportMarkdownTool) AdminCall(arguments map[string]interface{}) bool {
+	output, _ := arguments["output"].(string)
+	return t.dir != "" && (output == "" || output == exportOutputDirectory)
+}
+
+func (t *ExportMarkdownTool) InputSchema() map[string]interface{} {
+	outputs := []string{exportOutputZip}
+	if t.dir != "" {
+		outputs = []string{exportOutputDirectory, exportOutputZip}
+	}
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"titles": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Titles of pages to export",
+			},
+			"tag": map[string]interface{}{
+				"type":        "string",
+				"description": "Export the pages with this #tag (without #)",
+			},
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "Export the pages matching this search query",
+			},
+			"project": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional project name (uses the session default set by set_default_project, or the server default)",
+			},
+			"output": map[string]interface{}{
+				"type":        "string",
+				"enum":        outputs,
+				"description": fmt.Sprintf("Where the files go; directory requires the admin role (default: %s)", outputs[0]),
+			},
+			"max_pages": map[string]interface{}{
+				"type":        "number",
+				"description": fmt.Sprintf("Maximum number of pages to export (default: %d, max: %d)", exportDefaultMaxPages, exportMaxPages),
+			},
+			"profile": map[string]interface{}{
+				"type":        "string",
+				"enum":        markdown.ProfileNames(),
+				"description": "File layout: plain (default; Title.md with a # heading), hugo (front matter with title, date, lastmod and tags; ref shortcode links) or zenn (articles/<slug>.md with Zenn front matter, unpublished)",
+			},
+		},
+	}
+}
+
+func (t *ExportMarkdownTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	titles, err := stringList(arguments, "titles")
+	if err != nil {
+		return nil, err
+	}
+	tag, _ := arguments["tag"].(string)
+	query, _ := arguments["query"].(string)
+	if len(titles) == 0 && tag == "" && query == "" {
+		return nil, fmt.Errorf("titles, tag or query is required")
+	}
+
+	output, _ := arguments["output"].(string)
+	switch output {
+	case "":
+		output = exportOutputZip
+		if t.dir != "" {
+			output = exportOutputDirectory
+		}
+	case exportOutputZip:
+	case exportOutputDirectory:
+		if t.dir == "" {
+			return nil, fmt.Errorf("output=directory requires MARKDOWN_EXPORT_DIR to be configured; use output=zip")
+		}
+	default:
+		return nil, fmt.Errorf("invalid output: %s (expected directory or zip)", output)
+	}
+
+	profileName, _ := arguments["profile"].(string)
+	if profileName == "" {
+		profileName = "plain"
+	}
+	profile, err := markdown.LookupProfile(profileName)
+	if err != nil {
+		return nil, err
+	}
+
+	maxPages := exportDefaultMaxPages
+	if n, ok := arguments["max_pages"].(float64); ok && n > 0 {
+		maxPages = min(int(n), exportMaxPages)
+	}
+
+	project := resolveProject(ctx, arguments, t.client)
+
+	selected, err := t.selectTitles(ctx, project, titles, tag, query)
+	if err != nil {
+		return nil, err
+	}
+
+	response := exportMarkdownResponse{Project: project, Profile: profile.Name(), Output: output, Files: []exportedFile{}}
+	if len(selected) > maxPages {
+		selected = selected[:maxPages]
+		response.Truncated = true
+	}
+
+	var pages []markdown.Page
+	for i, title := range selected {
+		reportProgress(ctx, float64(i), float64(len(selected)), "Reading "+title)
+		page, err := t.client.GetPage(project, title)
+		if err != nil {
+			var sbErr *mcperrors.ScrapboxError
+			if errors.As(err, &sbErr) && sbErr.Code == mcperrors.ErrCodeNotFound {
+				response.Missing = append(response.Missing, title)
+				continue
+			}
+			return nil, fmt.Errorf("failed to read %s: %w", title, err)
+		}
+		if page.CommitID == "" {
+			response.Missing = append(response.Missing, title)
+			continue
+		}
+		lines := make([]string, len(page.Lines))
+		for j, line := range page.Lines {
+			lines[j] = line.Text
+		}
+		pages = append(pages, markdown.Page{
+			Title:   page.Title,
+			Lines:   lines,
+			Created: time.Unix(page.Created, 0),
+			Updated: time.Unix(page.Updated, 0),
+		})
+	}
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("no pages to export (missing: %s)", strings.Join(response.Missing, ", "))
+	}
+
+	opts := markdown.Options{Profile: profile}
+	if t.linker != nil {
+		opts.PageURL = func(title string) string { return t.linker.PageURL(project, title) }
+		opts.ProjectPageURL = t.linker.PageURL
+	}
+	files := markdown.Export(pages, opts)
+	for _, file := range files {
+		response.Files = append(response.Files, exportedFile{Title: file.Title, File: file.Name})
+	}
+
+	var archive []byte
+	summary := fmt.Sprintf("Exported %d pages from '%s' to Markdown", len(files), project)
+	if output == exportOutputDirectory {
+		response.Directory = filepath.Join(t.dir, markdown.FileBase(project))
+		if err := markdown.WriteDir(response.Directory, files); err != nil {
+			return nil, err
+		}
+		summary += " in " + response.Directory
+	} else {
+		archive, err = markdown.Zip(files)
+		if err != nil {
+			return nil, err
+		}
+		summary += fmt.Sprintf(" as a zip archive (%d bytes)", len(archive))
+	}
+	if len(response.Missing) > 0 {
+		summary += fmt.Sprintf("; not found: %s", strings.Join(response.Missing, ", "))
+	}
+
+	// Format the response as JSON
+	result, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format export: %v", err)
+	}
+
+	blocks := withSummary(summary, string(result))
+	if archive != nil {
+		blocks = append(blocks, ContentBlock{
+			Type: "resource",
+			Resource: &EmbeddedResource{
+				URI:      fmt.Sprintf("export://%s/markdown.zip", project),
+				MimeType: "application/zip",
+				Blob:     base64.StdEncoding.EncodeToString(archive),
+			},
+			Annotations: forUser(),
+		})
+	}
+	return blocks, nil
+}
+
+// selectTitles returns the titles named, tagged and found by the query, in
+// that order and without duplicates
+func (t *ExportMarkdownTool) selectTitles(ctx context.Context, project string, titles []string, tag, query string) ([]string, error) {
+	var selected []string
+	seen := make(map[string]bool)
+	add := func(title string) {
+		if key := tagKey(title); !seen[key] {
+			seen[key] = true
+			selected = append(selected, title)
+		}
+	}
+
+	for _, title := range titles {
+		add(title)
+	}
+
+	if tag != "" {
+		pages, err := t.index.Pages(ctx, project)
+		if err != nil {
+			return nil, err
+		}
+		wanted := map[string]bool{tagKey(tag): true}
+		for _, page := range pages {
+			if hasAnyTag(notation.ExtractLinks(page.Lines).Tags, wanted) {
+				add(page.Title)
+			}
+		}
+	}
+
+	if query != "" {
+		results, err := t.client.SearchPages(project, query, exportMaxPages, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search pages: %w", err)
+		}
+		for _, result := range results.Pages {
+			add(result.Title)
+		}
+	}
+	return selected, nil
+}
@@ -0,0 +1,199 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/markdown"
+	"github.com/hiroki/scrapbox_mcp/internal/pagelist"
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+const (
+	pageListOutputFile   = "file"
+	pageListOutputInline = "inline"
+)
+
+type ExportPageListTool struct {
+	client scrapbox.Reader
+	dir    string
+}
+
+// NewExportPageListTool creates the page list export tool. dir is the
+// directory files are written to ("" allows inline output only).
+func NewExportPageListTool(client scrapbox.Reader, dir string) *ExportPageListTool {
+	return &ExportPageListTool{client: client, dir: dir}
+}
+
+// exportPageListResponse is the export_page_list result for file output
+type exportPageListResponse struct {
+	Project string   `json:"project"`
+	Format  string   `json:"format"`
+	Pages   int      `json:"pages"`
+	File    string   `json:"file"`
+	Bytes   int64    `json:"bytes"`
+	Columns []string `json:"columns"`
+}
+
+func (t *ExportPageListTool) Name() string {
+	return "export_page_list"
+}
+
+func (t *ExportPageListTool) Description() string {
+	output := "returns it inline"
+	if t.dir != "" {
+		output = "streams it to a file in the server's export directory, or returns it inline with output=inline"
+	}
+	return "Exports the metadata of every page (id, title, created, updated, accessed, views, linked, pin, author, last editor) as CSV or JSON for spreadsheets and BI tools, and " + output + ". The page list is read and written in batches, so large projects are not held in memory."
+}
+
+// AdminCall reserves file output for admins, since it overwrites files in
+// the server's export directory
+func (t *ExportPageListTool) AdminCall(arguments map[string]interface{}) bool {
+	output, _ := arguments["output"].(string)
+	return t.dir != "" && (output == "" || output == pageListOutputFile)
+}
+
+func (t *ExportPageListTool) InputSchema() map[string]interface{} {
+	outputs := []string{pageListOutputInline}
+	if t.dir != "" {
+		outputs = []string{pageListOutputFile, pageListOutputInline}
+	}
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"format": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{pagelist.FormatCSV, pagelist.FormatJSON},
+				"description": "Output format (default: csv)",
+			},
+			"output": map[string]interface{}{
+				"type":        "string",
+				"enum":        outputs,
+				"description": fmt.Sprintf("Where the export goes; file requires the admin role (default: %s)", outputs[0]),
+			},
+			"bom": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Start CSV output with a UTF-8 byte order mark so Excel reads non-ASCII titles correctly (default: false)",
+			},
+			"project": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional project name (uses the session default set by set_default_project, or the server default)",
+			},
+		},
+	}
+}
+
+func (t *ExportPageListTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	format, _ := arguments["format"].(string)
+	if format == "" {
+		format = pagelist.FormatCSV
+	}
+	if format != pagelist.FormatCSV && format != pagelist.FormatJSON {
+		return nil, fmt.Errorf("invalid format: %s (expected csv or json)", format)
+	}
+
+	output, _ := arguments["output"].(string)
+	switch output {
+	case "":
+		output = pageListOutputInline
+		if t.dir != "" {
+			output = pageListOutputFile
+		}
+	case pageListOutputInline:
+	case pageListOutputFile:
+		if t.dir == "" {
+			return nil, fmt.Errorf("output=file requires EXPORT_DIR to be configured; use output=inline")
+		}
+	default:
+		return nil, fmt.Errorf("invalid output: %s (expected file or inline)", output)
+	}
+
+	project := resolveProject(ctx, arguments, t.client)
+	bom, _ := arguments["bom"].(bool)
+	opts := pagelist.Options{
+		Format: format,
+		BOM:    bom,
+		Progress: func(written, total int) {
+			reportProgress(ctx, float64(written), float64(total), fmt.Sprintf("Exported %d of %d pages", written, total))
+		},
+	}
+
+	if output == pageListOutputInline {
+		var b strings.Builder
+		n, err := pagelist.Write(ctx, t.client, project, &b, opts)
+		if err != nil {
+			return nil, err
+		}
+		return withSummary(fmt.Sprintf("Exported %d pages of '%s' as %s", n, project, strings.ToUpper(format)), b.String()), nil
+	}
+
+	response, err := t.writeFile(ctx, project, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Format the response as JSON
+	result, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format export: %v", err)
+	}
+
+	summary := fmt.Sprintf("Exported %d pages of '%s' to %s (%d bytes)", response.Pages, project, response.File, response.Bytes)
+	return withSummary(summary, string(result)), nil
+}
+
+// writeFile streams the page list into a new file in the export directory.
+// The file appears under its final name only once it is complete.
+func (t *ExportPageListTool) writeFile(ctx context.Context, project string, opts pagelist.Options) (*exportPageListResponse, error) {
+	if err := os.MkdirAll(t.dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+	name := fmt.Sprintf("%s-pages-%s.%s", markdown.FileBase(project), time.Now().UTC().Format("20060102T150405Z"), opts.Format)
+	path := filepath.Join(t.dir, name)
+
+	file, err := os.CreateTemp(t.dir, "."+name+".*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", name, err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+	// CreateTemp makes the file private; exports are shared like other exports
+	if err := file.Chmod(0o644); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", name, err)
+	}
+
+	w := bufio.NewWriter(file)
+	n, err := pagelist.Write(ctx, t.client, project, w, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	return &exportPageListResponse{
+		Project: project,
+		Format:  opts.Format,
+		Pages:   n,
+		File:    path,
+		Bytes:   info.Size(),
+		Columns: pagelist.Columns,
+	}, nil
+}
@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	"github.com/hiroki/scrapbox_mcp/internal/titles"
+)
+
+type FindDuplicateTitlesTool struct {
+	client   scrapbox.Reader
+	resolver *titles.Resolver
+}
+
+func NewFindDuplicateTitlesTool(client scrapbox.Reader, resolver *titles.Resolver) *FindDuplicateTitlesTool {
+	return &FindDuplicateTitlesTool{client: client, resolver: resolver}
+}
+
+// duplicateTitlesResponse is the find_duplicate_titles result
+type duplicateTitlesResponse struct {
+	Project    string     `json:"project"`
+	Duplicates [][]string `json:"duplicates"`
+}
+
+func (t *FindDuplicateTitlesTool) Name() string {
+	return "find_duplicate_titles"
+}
+
+func (t *FindDuplicateTitlesTool) Description() string {
+	return "Reports groups of pages whose titles differ only in width (ＡＰＩ vs API), case, spacing or underscores and are likely duplicates."
+}
+
+func (t *FindDuplicateTitlesTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"project": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional project name (uses the session default set by set_default_project, or the server default)",
+			},
+		},
+		"required": []string{},
+	}
+}
+
+func (t *FindDuplicateTitlesTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	project := resolveProject(ctx, arguments, t.client)
+
+	duplicates, err := t.resolver.Duplicates(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	if duplicates == nil {
+		duplicates = [][]string{}
+	}
+
+	// Format the response as JSON
+	result, err := json.MarshalIndent(duplicateTitlesResponse{Project: project, Duplicates: duplicates}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format duplicates: %v", err)
+	}
+
+	return string(result), nil
+}
@@ -0,0 +1,262 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/index"
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	"github.com/hiroki/scrapbox_mcp/pkg/notation"
+)
+
+const (
+	staleDefaultDays  = 180
+	staleDefaultLimit = 100
+	staleMaxLimit     = 1000
+	// stalePageSize is the page size used to walk the page list (the API maximum)
+	stalePageSize = 1000
+)
+
+// Activities a page's staleness is measured by
+const (
+	staleByUpdated  = "updated"
+	staleByAccessed = "accessed"
+	staleByBoth     = "both"
+)
+
+type FindStalePagesTool struct {
+	client scrapbox.Reader
+	index  *index.Index
+}
+
+// NewFindStalePagesTool creates the tool. The page index is only read when
+// pages are excluded by tag.
+func NewFindStalePagesTool(client scrapbox.Reader, pageIndex *index.Index) *FindStalePagesTool {
+	return &FindStalePagesTool{client: client, index: pageIndex}
+}
+
+// stalePage is one page in the find_stale_pages result
+type stalePage struct {
+	Title            string `json:"title"`
+	Updated          string `json:"updated"`
+	Accessed         string `json:"accessed,omitempty"`
+	DaysSinceUpdate  int    `json:"daysSinceUpdate"`
+	DaysSinceAccess  int    `json:"daysSinceAccess,omitempty"`
+	Views            int    `json:"views"`
+	Linked           int    `json:"linked"`
+	Pinned           bool   `json:"pinned,omitempty"`
+	LastEditor       string `json:"lastEditor,omitempty"`
+	lastActivityUnix int64
+}
+
+type stalePagesResponse struct {
+	Project        string      `json:"project"`
+	Days           float64     `json:"days"`
+	By             string      `json:"by"`
+	Cutoff         string      `json:"cutoff"`
+	Scanned        int         `json:"scanned"`
+	Count          int         `json:"count"`
+	ExcludedPinned int         `json:"excludedPinned"`
+	ExcludedTagged int         `json:"excludedTagged"`
+	Truncated      bool        `json:"truncated"`
+	Pages          []stalePage `json:"pages"`
+}
+
+func (t *FindStalePagesTool) Name() string {
+	return "find_stale_pages"
+}
+
+func (t *FindStalePagesTool) Description() string {
+	return "Lists pages not updated and/or accessed in the last N days, least recently active first, with views and incoming link counts. Pinned pages and pages with given tags can be excluded. Use it to propose pages for archiving or cleanup."
+}
+
+func (t *FindStalePagesTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"days": map[string]interface{}{
+				"type":        "number",
+				"description": fmt.Sprintf("Pages without activity for this many days are stale (default: %d)", staleDefaultDays),
+			},
+			"by": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{staleByBoth, staleByUpdated, staleByAccessed},
+				"description": "Activity that counts: both (neither updated nor accessed, default), updated or accessed",
+			},
+			"exclude_pinned": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Leave out pinned pages (default: true)",
+			},
+			"exclude_tags": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Leave out pages with any of these tags (e.g. [\"archive\", \"reference\"])",
+			},
+			"limit": map[string]interface{}{
+				"type":        "number",
+				"description": fmt.Sprintf("Maximum number of pages to return (default: %d, max: %d)", staleDefaultLimit, staleMaxLimit),
+			},
+			"project": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional project name (uses the session default set by set_default_project, or the server default)",
+			},
+		},
+	}
+}
+
+func (t *FindStalePagesTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	days := float64(staleDefaultDays)
+	if n, ok := arguments["days"].(float64); ok {
+		if n <= 0 {
+			return nil, fmt.Errorf("days must be positive")
+		}
+		days = n
+	}
+	by := staleByBoth
+	if b, ok := arguments["by"].(string); ok && b != "" {
+		by = b
+	}
+	if by != staleByBoth && by != staleByUpdated && by != staleByAccessed {
+		return nil, fmt.Errorf("invalid by: %s (expected both, updated or accessed)", by)
+	}
+	excludePinned := true
+	if b, ok := arguments["exclude_pinned"].(bool); ok {
+		excludePinned = b
+	}
+	limit := staleDefaultLimit
+	if n, ok := arguments["limit"].(float64); ok && n > 0 {
+		limit = min(int(n), staleMaxLimit)
+	}
+	tags, err := stringList(arguments, "exclude_tags")
+	if err != nil {
+		return nil, err
+	}
+
+	project := resolveProject(ctx, arguments, t.client)
+
+	// Tags live in page text, so exclusion by tag reads the page cache
+	var tagged map[string]bool
+	if len(tags) > 0 {
+		wanted := make(map[string]bool, len(tags))
+		for _, tag := range tags {
+			wanted[tagKey(tag)] = true
+		}
+		pages, err := t.index.Pages(ctx, project)
+		if err != nil {
+			return nil, err
+		}
+		tagged = make(map[string]bool)
+		for _, page := range pages {
+			if hasAnyTag(notation.ExtractLinks(page.Lines).Tags, wanted) {
+				tagged[page.Title] = true
+			}
+		}
+	}
+
+	now := time.Now()
+	cutoff := now.Add(-time.Duration(days * float64(24*time.Hour)))
+	response := &stalePagesResponse{
+		Project: project,
+		Days:    days,
+		By:      by,
+		Cutoff:  cutoff.UTC().Format(time.RFC3339),
+		Pages:   []stalePage{},
+	}
+
+	var stale []stalePage
+	for skip := 0; ; skip += stalePageSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		resp, err := t.client.ListPages(project, stalePageSize, skip)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range resp.Pages {
+			response.Scanned++
+			last := lastActivity(info, by)
+			if last >= cutoff.Unix() {
+				continue
+			}
+			if excludePinned && info.Pin != 0 {
+				response.ExcludedPinned++
+				continue
+			}
+			if tagged[info.Title] {
+				response.ExcludedTagged++
+				continue
+			}
+			stale = append(stale, newStalePage(info, now, last))
+		}
+		reportProgress(ctx, float64(response.Scanned), float64(resp.Count), "Scanning the page list")
+		if len(resp.Pages) < stalePageSize || skip+len(resp.Pages) >= resp.Count {
+			break
+		}
+	}
+
+	sort.SliceStable(stale, func(i, j int) bool { return stale[i].lastActivityUnix < stale[j].lastActivityUnix })
+	response.Count = len(stale)
+	if len(stale) > limit {
+		stale = stale[:limit]
+		response.Truncated = true
+	}
+	response.Pages = append(response.Pages, stale...)
+
+	// Format the response as JSON
+	result, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format stale pages: %v", err)
+	}
+
+	activity := map[string]string{staleByBoth: "update or access", staleByUpdated: "update", staleByAccessed: "access"}[by]
+	summary := fmt.Sprintf("%d of %d pages in '%s' have had no %s in %g days", response.Count, response.Scanned, project, activity, days)
+	if response.Truncated {
+		summary += fmt.Sprintf("; showing the %d least recently active", limit)
+	}
+	return withSummary(summary, string(result)), nil
+}
+
+// lastActivity returns the Unix time of a page's latest activity of the
+// given kind. Pages never accessed count as accessed when last updated.
+func lastActivity(info scrapbox.PageInfo, by string) int64 {
+	accessed := info.Accessed
+	if accessed == 0 {
+		accessed = info.Updated
+	}
+	switch by {
+	case staleByUpdated:
+		return info.Updated
+	case staleByAccessed:
+		return accessed
+	default:
+		return max(info.Updated, accessed)
+	}
+}
+
+func newStalePage(info scrapbox.PageInfo, now time.Time, last int64) stalePage {
+	page := stalePage{
+		Title:            info.Title,
+		Updated:          time.Unix(info.Updated, 0).UTC().Format(time.RFC3339),
+		DaysSinceUpdate:  daysSince(now, info.Updated),
+		Views:            info.Views,
+		Linked:           info.Linked,
+		Pinned:           info.Pin != 0,
+		lastActivityUnix: last,
+	}
+	if info.Accessed > 0 {
+		page.Accessed = time.Unix(info.Accessed, 0).UTC().Format(time.RFC3339)
+		page.DaysSinceAccess = daysSince(now, info.Accessed)
+	}
+	if info.LastUpdateUser != nil {
+		page.LastEditor = info.LastUpdateUser.Name
+	}
+	return page
+}
+
+// daysSince returns the whole days between a Unix time and now
+func daysSince(now time.Time, unix int64) int {
+	return int(now.Sub(time.Unix(unix, 0)) / (24 * time.Hour))
+}
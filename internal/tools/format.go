@@ -0,0 +1,143 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+// Output formats supported by read tools
+const (
+	formatJSON       = "json"
+	formatText       = "text"
+	formatTitlesOnly = "titles_only"
+)
+
+// formatProperty is the InputSchema property for the format argument
+func formatProperty() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"enum":        []string{formatJSON, formatText, formatTitlesOnly},
+		"description": "Output format: json (full metadata, default), text (plain text, fewer tokens) or titles_only",
+	}
+}
+
+// parseFormat reads the format argument, defaulting to json
+func parseFormat(arguments map[string]interface{}) (string, error) {
+	format, ok := arguments["format"].(string)
+	if !ok || format == "" {
+		return formatJSON, nil
+	}
+	switch format {
+	case formatJSON, formatText, formatTitlesOnly:
+		return format, nil
+	default:
+		return "", fmt.Errorf("invalid format: %s (expected json, text or titles_only)", format)
+	}
+}
+
+// pageText renders page lines as plain text, noting when more lines remain
+func pageText(response pageResponse) string {
+	var b strings.Builder
+	for i, line := range response.Lines {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(line.Text)
+	}
+	if response.HasMore {
+		fmt.Fprintf(&b, "\n\n(lines %d-%d of %d; more lines available from start_line=%d)",
+			response.StartLine, response.StartLine+len(response.Lines)-1, response.TotalLines, response.StartLine+len(response.Lines))
+	}
+	return b.String()
+}
+
+// pagesText renders a page list as one line per page with its description
+func pagesText(pages *scrapbox.PagesResponse, titlesOnly bool) string {
+	var b strings.Builder
+	if !titlesOnly {
+		fmt.Fprintf(&b, "%d pages in %s (showing %d from %d)\n", pages.Count, pages.ProjectName, len(pages.Pages), pages.Skip)
+	}
+	for _, page := range pages.Pages {
+		b.WriteString(page.Title)
+		if !titlesOnly && len(page.Descriptions) > 0 {
+			b.WriteString(": ")
+			b.WriteString(strings.Join(page.Descriptions, " / "))
+		}
+		b.WriteByte('\n')
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// searchText renders search results as titles followed by indented matching lines
+func searchText(results *scrapbox.SearchResponse, titlesOnly bool, lineMatches []searchPageMatches) string {
+	matches := make(map[string][]searchLineMatch, len(lineMatches))
+	for _, page := range lineMatches {
+		matches[page.Title] = page.Matches
+	}
+
+	var b strings.Builder
+	if !titlesOnly {
+		fmt.Fprintf(&b, "%d pages match %q\n", results.Count, results.SearchQuery)
+	}
+	for _, page := range results.Pages {
+		b.WriteString(page.Title)
+		b.WriteByte('\n')
+		if titlesOnly {
+			continue
+		}
+		if pageMatches, ok := matches[page.Title]; ok {
+			searchMatchesText(&b, pageMatches)
+			continue
+		}
+		for _, line := range page.Lines {
+			b.WriteString("  ")
+			b.WriteString(line)
+			b.WriteByte('\n')
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// summaryTitles is the number of titles named in a result summary
+const summaryTitles = 5
+
+// pagesSummary is a one-line summary of a page list, e.g.
+// "100 of 1234 pages in demo (from 0); first: A, B, C, D, E, …"
+func pagesSummary(pages *scrapbox.PagesResponse) string {
+	titles := make([]string, 0, len(pages.Pages))
+	for _, page := range pages.Pages {
+		titles = append(titles, page.Title)
+	}
+	summary := fmt.Sprintf("%d of %d pages in %s (from %d)", len(pages.Pages), pages.Count, pages.ProjectName, pages.Skip)
+	if len(titles) > 0 {
+		summary += "; first: " + summaryList(titles)
+	}
+	return summary
+}
+
+// searchSummary is a one-line summary of search results, e.g.
+// `12 pages matched "go"; top: A, B, C, D, E, … (more with skip=10)`
+func searchSummary(results *scrapbox.SearchResponse, nextSkip *int) string {
+	titles := make([]string, 0, len(results.Pages))
+	for _, page := range results.Pages {
+		titles = append(titles, page.Title)
+	}
+	summary := fmt.Sprintf("%d pages matched %q", results.Count, results.SearchQuery)
+	if len(titles) > 0 {
+		summary += "; top: " + summaryList(titles)
+	}
+	if nextSkip != nil {
+		summary += fmt.Sprintf(" (more with skip=%d)", *nextSkip)
+	}
+	return summary
+}
+
+// summaryList joins the first summaryTitles titles, marking any omitted ones
+func summaryList(titles []string) string {
+	if len(titles) <= summaryTitles {
+		return strings.Join(titles, ", ")
+	}
+	return strings.Join(titles[:summaryTitles], ", ") + ", …"
+}
@@ -0,0 +1,288 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/hiroki/scrapbox_mcp/internal/index"
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	"github.com/hiroki/scrapbox_mcp/pkg/notation"
+)
+
+const (
+	groupByInitial = "initial"
+	groupByTag     = "tag"
+
+	// untaggedGroup heads the pages without tags when grouping by tag
+	untaggedGroup = "Untagged"
+)
+
+type GenerateIndexPageTool struct {
+	client scrapbox.API
+	index  *index.Index
+}
+
+func NewGenerateIndexPageTool(client scrapbox.API, pageIndex *index.Index) *GenerateIndexPageTool {
+	return &GenerateIndexPageTool{client: client, index: pageIndex}
+}
+
+// indexGroup is one heading of the index page and the titles listed under it
+type indexGroup struct {
+	name   string
+	titles []string
+}
+
+// indexPagePlan is the content generate_index_page would write
+type indexPagePlan struct {
+	texts    []string
+	groups   int
+	pages    int
+	existing *scrapbox.Page
+}
+
+func (t *GenerateIndexPageTool) Name() string {
+	return "generate_index_page"
+}
+
+func (t *GenerateIndexPageTool) Description() string {
+	return "Builds or refreshes an index page (e.g. a glossary) listing the project's pages as [links] under headings, grouped by initial character or by tag. The whole page is regenerated on each call, so keep hand-written text in header. Use dry_run to preview the page."
+}
+
+func (t *GenerateIndexPageTool) IsWrite() bool {
+	return true
+}
+
+// DryRun reports whether the call only previews the index page
+func (t *GenerateIndexPageTool) DryRun(arguments map[string]interface{}) bool {
+	dryRun, _ := arguments["dry_run"].(bool)
+	return dryRun
+}
+
+func (t *GenerateIndexPageTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "The title of the index page to create or refresh",
+			},
+			"group_by": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{groupByInitial, groupByTag},
+				"description": "Group pages by the initial character of their title (A-Z, 0-9, kana, ...) or by their #tags (default: initial)",
+			},
+			"tags": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Only list pages with one of these tags (without #); when grouping by tag, only these tags become headings",
+			},
+			"title_pattern": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional regular expression; only pages whose title matches are listed",
+			},
+			"header": map[string]interface{}{
+				"type":        "string",
+				"description": "Text written under the title before the groups (can be multiple lines)",
+			},
+			"dry_run": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Return the generated page without writing it (default: false)",
+			},
+		},
+		"required": []string{"title"},
+	}
+}
+
+func (t *GenerateIndexPageTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	title, ok := arguments["title"].(string)
+	if !ok || title == "" {
+		return nil, fmt.Errorf("title is required and must be a string")
+	}
+
+	plan, err := t.plan(ctx, title, arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun, _ := arguments["dry_run"].(bool); dryRun {
+		return fmt.Sprintf("Dry run: '%s' would list %d pages in %d groups:\n%s",
+			title, plan.pages, plan.groups, strings.Join(plan.texts, "\n")), nil
+	}
+
+	var result *scrapbox.WriteResult
+	if plan.existing != nil {
+		// Guard against hand edits made since the page was read
+		result, err = t.client.PatchPage(title, plan.texts, plan.existing.CommitID)
+	} else {
+		result, err = t.client.CreatePage(title, plan.texts[1:], scrapbox.IfExistsError)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write index page: %w", conflictError(err))
+	}
+
+	return formatWriteResult(fmt.Sprintf("Generated index page '%s' listing %d pages in %d groups", title, plan.pages, plan.groups), result)
+}
+
+// DestructivePreview lists the lines of an existing index page that the
+// regenerated content drops or changes
+func (t *GenerateIndexPageTool) DestructivePreview(ctx context.Context, arguments map[string]interface{}) (string, bool, error) {
+	title, _ := arguments["title"].(string)
+	if dryRun, _ := arguments["dry_run"].(bool); dryRun || title == "" {
+		return "", false, nil
+	}
+	plan, err := t.plan(ctx, title, arguments)
+	if err != nil || plan.existing == nil {
+		return "", false, nil
+	}
+	return pageLossPreview(t.client, title, plan.texts)
+}
+
+// plan reads the pages of the default project and renders the index page
+func (t *GenerateIndexPageTool) plan(ctx context.Context, title string, arguments map[string]interface{}) (*indexPagePlan, error) {
+	groupBy, _ := arguments["group_by"].(string)
+	if groupBy == "" {
+		groupBy = groupByInitial
+	}
+	if groupBy != groupByInitial && groupBy != groupByTag {
+		return nil, fmt.Errorf("invalid group_by: %s (expected initial or tag)", groupBy)
+	}
+
+	tags, err := stringList(arguments, "tags")
+	if err != nil {
+		return nil, err
+	}
+	wanted := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		wanted[tagKey(tag)] = true
+	}
+
+	var titleRe *regexp.Regexp
+	if pattern, ok := arguments["title_pattern"].(string); ok && pattern != "" {
+		titleRe, err = regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid title_pattern: %v", err)
+		}
+	}
+
+	project := t.client.DefaultProject()
+	pages, err := t.index.Pages(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string]*indexGroup)
+	// Tags differing only in case or "_" share the group of the first spelling seen
+	add := func(name, title string) {
+		group, ok := groups[tagKey(name)]
+		if !ok {
+			group = &indexGroup{name: name}
+			groups[tagKey(name)] = group
+		}
+		group.titles = append(group.titles, title)
+	}
+	listed := 0
+	for _, page := range pages {
+		if page.Title == title || (titleRe != nil && !titleRe.MatchString(page.Title)) {
+			continue
+		}
+		pageTags := notation.ExtractLinks(page.Lines).Tags
+		if len(wanted) > 0 && !hasAnyTag(pageTags, wanted) {
+			continue
+		}
+
+		listed++
+		if groupBy == groupByInitial {
+			add(titleInitial(page.Title), page.Title)
+			continue
+		}
+		grouped := false
+		for _, tag := range pageTags {
+			if len(wanted) == 0 || wanted[tagKey(tag)] {
+				add(tag, page.Title)
+				grouped = true
+			}
+		}
+		if !grouped {
+			add(untaggedGroup, page.Title)
+		}
+	}
+
+	sorted := make([]*indexGroup, 0, len(groups))
+	for _, group := range groups {
+		sorted = append(sorted, group)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		// Untagged pages go last
+		if (sorted[i].name == untaggedGroup) != (sorted[j].name == untaggedGroup) {
+			return sorted[j].name == untaggedGroup
+		}
+		return strings.ToLower(sorted[i].name) < strings.ToLower(sorted[j].name)
+	})
+
+	texts := []string{title}
+	if header, _ := arguments["header"].(string); header != "" {
+		texts = append(texts, strings.Split(header, "\n")...)
+	}
+	for _, group := range sorted {
+		heading := group.name
+		if groupBy == groupByTag && group.name != untaggedGroup {
+			heading = "#" + group.name
+		}
+		texts = append(texts, "", "[** "+heading+"]")
+		sort.SliceStable(group.titles, func(i, j int) bool {
+			return strings.ToLower(group.titles[i]) < strings.ToLower(group.titles[j])
+		})
+		for _, listedTitle := range group.titles {
+			texts = append(texts, " ["+listedTitle+"]")
+		}
+	}
+
+	plan := &indexPagePlan{texts: texts, groups: len(sorted), pages: listed}
+	existing, err := t.client.GetPage(project, title)
+	if err != nil {
+		return nil, err
+	}
+	if existing.CommitID != "" {
+		plan.existing = existing
+	}
+	return plan, nil
+}
+
+// titleInitial returns the index heading of a title: its first letter in
+// upper case, "0-9" for digits, "#" for symbols. Katakana is folded into
+// hiragana and full-width forms into ASCII so they share a heading.
+func titleInitial(title string) string {
+	r, _ := utf8.DecodeRuneInString(norm.NFKC.String(title))
+	switch {
+	case unicode.IsDigit(r):
+		return "0-9"
+	case r >= 'ァ' && r <= 'ヶ':
+		return string(r - 'ァ' + 'ぁ')
+	case unicode.IsLetter(r):
+		return string(unicode.ToUpper(r))
+	default:
+		return "#"
+	}
+}
+
+// tagKey normalizes a tag for comparison: case-insensitive, "_" as space
+func tagKey(tag string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(strings.TrimSpace(tag), "#"), "_", " "))
+}
+
+// hasAnyTag reports whether one of tags is in wanted
+func hasAnyTag(tags []string, wanted map[string]bool) bool {
+	for _, tag := range tags {
+		if wanted[tagKey(tag)] {
+			return true
+		}
+	}
+	return false
+}
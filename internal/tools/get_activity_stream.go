@@ -0,0 +1,160 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+// ActivityStream reads a project's activity stream
+type ActivityStream interface {
+	GetStream(project string) ([]scrapbox.StreamPage, error)
+}
+
+type GetActivityStreamTool struct {
+	client scrapbox.Reader
+	stream ActivityStream
+}
+
+func NewGetActivityStreamTool(client scrapbox.Reader, stream ActivityStream) *GetActivityStreamTool {
+	return &GetActivityStreamTool{client: client, stream: stream}
+}
+
+// activityEntry is one page in the get_activity_stream result
+type activityEntry struct {
+	Title     string `json:"title"`
+	Updated   string `json:"updated"`
+	New       bool   `json:"new"`
+	UpdatedBy string `json:"updatedBy,omitempty"`
+	// Snippet holds the most recently edited lines, in page order
+	Snippet []string `json:"snippet"`
+}
+
+type activityStreamResponse struct {
+	Project string          `json:"project"`
+	Count   int             `json:"count"`
+	HasMore bool            `json:"hasMore"`
+	Pages   []activityEntry `json:"pages"`
+}
+
+func (t *GetActivityStreamTool) Name() string {
+	return "get_activity_stream"
+}
+
+func (t *GetActivityStreamTool) Description() string {
+	return "Returns the project's activity stream: recently edited pages, most recent first, with who edited them and a snippet of the most recently changed lines. Useful for daily summaries of what changed across the project."
+}
+
+func (t *GetActivityStreamTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"project": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional project name (uses the session default set by set_default_project, or the server default)",
+			},
+			"since": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional RFC 3339 timestamp or Unix seconds; only pages edited at or after this time are returned",
+			},
+			"limit": map[string]interface{}{
+				"type":        "number",
+				"description": "Maximum number of pages to return (default: 20)",
+			},
+			"snippet_lines": map[string]interface{}{
+				"type":        "number",
+				"description": "Number of recently changed lines to include per page (default: 3)",
+			},
+		},
+	}
+}
+
+func (t *GetActivityStreamTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	var since int64
+	if sinceArg, ok := arguments["since"].(string); ok && sinceArg != "" {
+		sinceTime, err := parseTimestamp(sinceArg)
+		if err != nil {
+			return nil, err
+		}
+		since = sinceTime.Unix()
+	}
+
+	limit := 20
+	if limitArg, ok := arguments["limit"].(float64); ok && limitArg > 0 {
+		limit = int(limitArg)
+	}
+	snippetLines := 3
+	if snippetArg, ok := arguments["snippet_lines"].(float64); ok && snippetArg >= 0 {
+		snippetLines = int(snippetArg)
+	}
+
+	project := resolveProject(ctx, arguments, t.client)
+
+	pages, err := t.stream.GetStream(project)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &activityStreamResponse{
+		Project: project,
+		Pages:   []activityEntry{},
+	}
+	for _, page := range pages {
+		if page.Updated < since {
+			continue
+		}
+		if len(response.Pages) == limit {
+			response.HasMore = true
+			break
+		}
+		entry := activityEntry{
+			Title:   page.Title,
+			Updated: time.Unix(page.Updated, 0).UTC().Format(time.RFC3339),
+			New:     since > 0 && page.Created >= since,
+			Snippet: recentLines(page.Lines, snippetLines),
+		}
+		if page.LastUpdateUser != nil {
+			entry.UpdatedBy = page.LastUpdateUser.Name
+		} else if page.User != nil {
+			entry.UpdatedBy = page.User.Name
+		}
+		response.Pages = append(response.Pages, entry)
+	}
+	response.Count = len(response.Pages)
+
+	// Format the response as JSON
+	result, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format activity stream: %v", err)
+	}
+
+	return string(result), nil
+}
+
+// recentLines returns the texts of the n most recently updated non-blank lines
+// (the title line excluded), in page order
+func recentLines(lines []scrapbox.Line, n int) []string {
+	var indexes []int
+	for i, line := range lines {
+		if i > 0 && line.Text != "" {
+			indexes = append(indexes, i)
+		}
+	}
+	sort.SliceStable(indexes, func(a, b int) bool {
+		return lines[indexes[a]].Updated > lines[indexes[b]].Updated
+	})
+	if len(indexes) > n {
+		indexes = indexes[:n]
+	}
+	sort.Ints(indexes)
+
+	snippet := make([]string, 0, len(indexes))
+	for _, i := range indexes {
+		snippet = append(snippet, lines[i].Text)
+	}
+	return snippet
+}
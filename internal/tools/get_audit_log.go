@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hiroki/scrapbox_mcp/internal/audit"
+)
+
+type GetAuditLogTool struct {
+	logger *audit.Logger
+}
+
+func NewGetAuditLogTool(logger *audit.Logger) *GetAuditLogTool {
+	return &GetAuditLogTool{logger: logger}
+}
+
+func (t *GetAuditLogTool) Name() string {
+	return "get_audit_log"
+}
+
+func (t *GetAuditLogTool) Description() string {
+	return "Returns recent write operations performed through this server (newest first). Optionally filter by page title."
+}
+
+// AdminOnly reserves the audit log, which covers every session, for admins
+func (t *GetAuditLogTool) AdminOnly() bool {
+	return true
+}
+
+func (t *GetAuditLogTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"page": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional page title to filter entries by",
+			},
+			"limit": map[string]interface{}{
+				"type":        "number",
+				"description": "Maximum number of entries to return (default: 50)",
+			},
+		},
+		"required": []string{},
+	}
+}
+
+func (t *GetAuditLogTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	page := ""
+	if pageArg, ok := arguments["page"].(string); ok {
+		page = pageArg
+	}
+
+	limit := 50
+	if limitArg, ok := arguments["limit"].(float64); ok && limitArg > 0 {
+		limit = int(limitArg)
+	}
+
+	entries, err := t.logger.Recent(limit, page)
+	if err != nil {
+		return nil, err
+	}
+
+	// Format the response as JSON
+	result, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format audit log: %v", err)
+	}
+
+	return string(result), nil
+}
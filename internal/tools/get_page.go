@@ -4,15 +4,25 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
+	"github.com/hiroki/scrapbox_mcp/internal/changes"
 	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
 )
 
+// pageResponse is the get_page result: the page plus line range information
+type pageResponse struct {
+	*scrapbox.Page
+	TotalLines int  `json:"totalLines"`
+	StartLine  int  `json:"startLine"`
+	HasMore    bool `json:"hasMore"`
+}
+
 type GetPageTool struct {
-	client *scrapbox.Client
+	client scrapbox.Reader
 }
 
-func NewGetPageTool(client *scrapbox.Client) *GetPageTool {
+func NewGetPageTool(client scrapbox.Reader) *GetPageTool {
 	return &GetPageTool{client: client}
 }
 
@@ -21,7 +31,7 @@ func (t *GetPageTool) Name() string {
 }
 
 func (t *GetPageTool) Description() string {
-	return "Retrieves a Scrapbox page by title. Returns the page content including all lines, metadata, and links."
+	return "Retrieves a Scrapbox page by title. Returns the page content including lines, metadata, and links. For long pages, use start_line and max_lines to read the page in chunks; totalLines and hasMore tell you whether more lines remain."
 }
 
 func (t *GetPageTool) InputSchema() map[string]interface{} {
@@ -32,9 +42,23 @@ func (t *GetPageTool) InputSchema() map[string]interface{} {
 				"type":        "string",
 				"description": "The title of the page to retrieve",
 			},
+			"start_line": map[string]interface{}{
+				"type":        "number",
+				"description": "Index of the first line to return; line 0 is the title (default: 0)",
+			},
+			"max_lines": map[string]interface{}{
+				"type":        "number",
+				"description": "Maximum number of lines to return (default: all)",
+			},
+			"format":         formatProperty(),
+			"include_images": includeImagesProperty(),
+			"include_resource": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Also return the page text as an embedded resource (scrapbox://project/title) the client can pin (default: true)",
+			},
 			"project": map[string]interface{}{
 				"type":        "string",
-				"description": "Optional project name (uses default if not specified)",
+				"description": "Optional project name (uses the session default set by set_default_project, or the server default)",
 			},
 		},
 		"required": []string{"title"},
@@ -47,21 +71,98 @@ func (t *GetPageTool) Execute(ctx context.Context, arguments map[string]interfac
 		return nil, fmt.Errorf("title is required and must be a string")
 	}
 
-	project := t.client.ProjectName
-	if projectArg, ok := arguments["project"].(string); ok && projectArg != "" {
-		project = projectArg
+	startLine := 0
+	if startArg, ok := arguments["start_line"].(float64); ok && startArg > 0 {
+		startLine = int(startArg)
+	}
+
+	maxLines := 0
+	if maxArg, ok := arguments["max_lines"].(float64); ok && maxArg > 0 {
+		maxLines = int(maxArg)
 	}
 
-	page, err := t.client.RESTClient.GetPage(project, title)
+	project := resolveProject(ctx, arguments, t.client)
+
+	format, err := parseFormat(arguments)
 	if err != nil {
 		return nil, err
 	}
 
-	// Format the response as JSON
-	result, err := json.MarshalIndent(page, "", "  ")
+	page, err := t.client.GetPage(project, title)
 	if err != nil {
-		return nil, fmt.Errorf("failed to format page: %v", err)
+		return nil, err
+	}
+
+	// Copy so the backend's page is not modified, then slice the requested range
+	paged := *page
+	total := len(page.Lines)
+	start := startLine
+	if start > total {
+		start = total
+	}
+	end := total
+	if maxLines > 0 && start+maxLines < total {
+		end = start + maxLines
+	}
+	paged.Lines = page.Lines[start:end]
+
+	response := pageResponse{
+		Page:       &paged,
+		TotalLines: total,
+		StartLine:  start,
+		HasMore:    end < total,
 	}
 
-	return string(result), nil
+	var text string
+	switch format {
+	case formatText:
+		text = pageText(response)
+	case formatTitlesOnly:
+		text = page.Title
+	default:
+		// Format the response as JSON
+		result, err := json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to format page: %v", err)
+		}
+		text = string(result)
+	}
+
+	var images []string
+	if includeImages, _ := arguments["include_images"].(bool); includeImages && page.Image != "" {
+		images = []string{page.Image}
+	}
+
+	includeResource := true
+	if includeArg, ok := arguments["include_resource"].(bool); ok {
+		includeResource = includeArg
+	}
+	if !includeResource {
+		return withImages(ctx, text, images), nil
+	}
+
+	blocks := ContentBlocks{
+		{Type: "text", Text: text},
+		pageResourceBlock(project, page.Title, paged.Lines),
+	}
+	if withThumbnails, ok := withImages(ctx, text, images).(ContentBlocks); ok {
+		blocks = append(blocks, withThumbnails[1:]...)
+	}
+	return blocks, nil
+}
+
+// pageResourceBlock returns the page lines as an embedded text/plain resource
+func pageResourceBlock(project, title string, lines []scrapbox.Line) ContentBlock {
+	texts := make([]string, 0, len(lines))
+	for _, line := range lines {
+		texts = append(texts, line.Text)
+	}
+	return ContentBlock{
+		Type: "resource",
+		Resource: &EmbeddedResource{
+			URI:      changes.PageURI(project, title),
+			MimeType: "text/plain",
+			Text:     strings.Join(texts, "\n"),
+		},
+	}
 }
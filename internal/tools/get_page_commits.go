@@ -0,0 +1,198 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+// CommitHistory reads the commit history of pages
+type CommitHistory interface {
+	GetPageCommits(project, pageID string) ([]scrapbox.Commit, error)
+}
+
+type GetPageCommitsTool struct {
+	client  scrapbox.Reader
+	history CommitHistory
+}
+
+func NewGetPageCommitsTool(client scrapbox.Reader, history CommitHistory) *GetPageCommitsTool {
+	return &GetPageCommitsTool{client: client, history: history}
+}
+
+// pageCommit is one commit in the get_page_commits result
+type pageCommit struct {
+	ID      string `json:"id"`
+	Created string `json:"created"`
+	UserID  string `json:"userId"`
+	User    string `json:"user,omitempty"`
+	// Title is set when the commit renamed the page
+	Title   string             `json:"title,omitempty"`
+	Deleted bool               `json:"deleted,omitempty"`
+	Changes []commitLineChange `json:"changes"`
+}
+
+// commitLineChange is one line change of a commit
+type commitLineChange struct {
+	Type   string `json:"type"`
+	LineID string `json:"lineId"`
+	Text   string `json:"text,omitempty"`
+	// Previous is the text before an update or delete, when the history has it
+	Previous string `json:"previous,omitempty"`
+}
+
+type pageCommitsResponse struct {
+	Title   string       `json:"title"`
+	PageID  string       `json:"pageId"`
+	Total   int          `json:"total"`
+	HasMore bool         `json:"hasMore"`
+	Commits []pageCommit `json:"commits"`
+}
+
+func (t *GetPageCommitsTool) Name() string {
+	return "get_page_commits"
+}
+
+func (t *GetPageCommitsTool) Description() string {
+	return "Lists the commit history of a page, most recent first: who changed it, when, and which lines were inserted, updated (with the previous text) or deleted. Use it to answer who edited a line or what changed since a given time."
+}
+
+func (t *GetPageCommitsTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "The title of the page",
+			},
+			"project": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional project name (uses the session default set by set_default_project, or the server default)",
+			},
+			"since": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional RFC 3339 timestamp or Unix seconds; only commits made at or after this time are returned",
+			},
+			"limit": map[string]interface{}{
+				"type":        "number",
+				"description": "Maximum number of commits to return (default: 20)",
+			},
+		},
+		"required": []string{"title"},
+	}
+}
+
+func (t *GetPageCommitsTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	title, ok := arguments["title"].(string)
+	if !ok || title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+
+	var since int64
+	if sinceArg, ok := arguments["since"].(string); ok && sinceArg != "" {
+		sinceTime, err := parseTimestamp(sinceArg)
+		if err != nil {
+			return nil, err
+		}
+		since = sinceTime.Unix()
+	}
+
+	limit := 20
+	if limitArg, ok := arguments["limit"].(float64); ok && limitArg > 0 {
+		limit = int(limitArg)
+	}
+
+	project := resolveProject(ctx, arguments, t.client)
+
+	page, err := t.client.GetPage(project, title)
+	if err != nil {
+		return nil, err
+	}
+	if page.CommitID == "" {
+		return nil, fmt.Errorf("page not found: %s", title)
+	}
+
+	commits, err := t.history.GetPageCommits(project, page.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	users := pageUserNames(page)
+	response := &pageCommitsResponse{
+		Title:   page.Title,
+		PageID:  page.ID,
+		Commits: []pageCommit{},
+	}
+
+	// Replay the history oldest first so updates and deletes know the previous text
+	texts := make(map[string]string)
+	var entries []pageCommit
+	for _, commit := range commits {
+		entry := pageCommit{
+			ID:      commit.ID,
+			Created: time.Unix(commit.Created, 0).UTC().Format(time.RFC3339),
+			UserID:  commit.UserID,
+			User:    users[commit.UserID],
+			Deleted: commit.Deleted(),
+			Changes: []commitLineChange{},
+		}
+		if newTitle, ok := commit.Title(); ok {
+			entry.Title = newTitle
+		}
+		for _, change := range commit.LineChanges() {
+			previous, known := texts[change.LineID]
+			lineChange := commitLineChange{Type: change.Type, LineID: change.LineID, Text: change.Text}
+			if known && change.Type != scrapbox.LineInserted {
+				lineChange.Previous = previous
+			}
+			if change.Type == scrapbox.LineDeleted {
+				delete(texts, change.LineID)
+			} else {
+				texts[change.LineID] = change.Text
+			}
+			entry.Changes = append(entry.Changes, lineChange)
+		}
+		if commit.Created >= since {
+			entries = append(entries, entry)
+		}
+	}
+
+	response.Total = len(entries)
+	for i := len(entries) - 1; i >= 0; i-- {
+		if len(response.Commits) == limit {
+			response.HasMore = true
+			break
+		}
+		response.Commits = append(response.Commits, entries[i])
+	}
+
+	// Format the response as JSON
+	result, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format commits: %v", err)
+	}
+
+	return string(result), nil
+}
+
+// pageUserNames maps the IDs of the users known from a page (creator, last
+// editor and collaborators) to their names
+func pageUserNames(page *scrapbox.Page) map[string]string {
+	names := make(map[string]string)
+	add := func(user scrapbox.User) {
+		if user.ID != "" && user.Name != "" {
+			names[user.ID] = user.Name
+		}
+	}
+	add(page.User)
+	if page.LastUpdateUser != nil {
+		add(*page.LastUpdateUser)
+	}
+	for _, user := range page.Collaborators {
+		add(user)
+	}
+	return names
+}
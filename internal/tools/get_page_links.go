@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	"github.com/hiroki/scrapbox_mcp/pkg/notation"
+)
+
+type GetPageLinksTool struct {
+	client scrapbox.Reader
+}
+
+func NewGetPageLinksTool(client scrapbox.Reader) *GetPageLinksTool {
+	return &GetPageLinksTool{client: client}
+}
+
+// pageLinksResponse is the get_page_links result
+type pageLinksResponse struct {
+	Title         string   `json:"title"`
+	InternalLinks []string `json:"internal_links"`
+	ProjectLinks  []string `json:"project_links"`
+	Tags          []string `json:"tags"`
+	ExternalURLs  []string `json:"external_urls"`
+}
+
+func (t *GetPageLinksTool) Name() string {
+	return "get_page_links"
+}
+
+func (t *GetPageLinksTool) Description() string {
+	return "Extracts the outgoing links of a page: internal [page] links, [/project/page] links to other projects, #tags and external URLs. Links in code blocks and inline code are ignored."
+}
+
+func (t *GetPageLinksTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "The title of the page",
+			},
+			"project": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional project name (uses the session default set by set_default_project, or the server default)",
+			},
+		},
+		"required": []string{"title"},
+	}
+}
+
+func (t *GetPageLinksTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	title, ok := arguments["title"].(string)
+	if !ok || title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+
+	project := resolveProject(ctx, arguments, t.client)
+
+	page, err := t.client.GetPage(project, title)
+	if err != nil {
+		return nil, err
+	}
+
+	// The first line is the page title
+	var lines []string
+	for i, line := range page.Lines {
+		if i == 0 {
+			continue
+		}
+		lines = append(lines, line.Text)
+	}
+	links := notation.ExtractLinks(lines)
+
+	response := pageLinksResponse{
+		Title:         page.Title,
+		InternalLinks: nonNil(links.InternalLinks),
+		ProjectLinks:  nonNil(links.ProjectLinks),
+		Tags:          nonNil(links.Tags),
+		ExternalURLs:  nonNil(links.ExternalURLs),
+	}
+
+	// Format the response as JSON
+	result, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format links: %v", err)
+	}
+
+	return string(result), nil
+}
+
+// nonNil returns s, or an empty slice if s is nil, so it marshals as [] rather than null
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
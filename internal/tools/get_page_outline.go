@@ -0,0 +1,171 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	"github.com/hiroki/scrapbox_mcp/pkg/notation"
+)
+
+type GetPageOutlineTool struct {
+	client scrapbox.Reader
+}
+
+func NewGetPageOutlineTool(client scrapbox.Reader) *GetPageOutlineTool {
+	return &GetPageOutlineTool{client: client}
+}
+
+// outlineNode is a line of the page with the lines indented below it
+type outlineNode struct {
+	// Line is the index of the line in the page (line 0 is the title)
+	Line   int    `json:"line"`
+	Indent int    `json:"indent"`
+	Text   string `json:"text"`
+	// Type is "code" or "table" for block headers
+	Type string `json:"type,omitempty"`
+	// EndLine is the last line covered by this node and its children
+	EndLine  int            `json:"endLine"`
+	Children []*outlineNode `json:"children,omitempty"`
+}
+
+// pageOutlineResponse is the get_page_outline result
+type pageOutlineResponse struct {
+	Title      string         `json:"title"`
+	CommitID   string         `json:"commitId"`
+	TotalLines int            `json:"totalLines"`
+	Outline    []*outlineNode `json:"outline"`
+}
+
+func (t *GetPageOutlineTool) Name() string {
+	return "get_page_outline"
+}
+
+func (t *GetPageOutlineTool) Description() string {
+	return "Returns a page as a tree built from its indentation: each node has its line index, indent level, text, children and endLine (the last line of the node and everything under it). Code and table blocks are single nodes spanning their body. Blank lines are omitted. Use it to locate a section before editing by line index."
+}
+
+func (t *GetPageOutlineTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "The title of the page",
+			},
+			"max_depth": map[string]interface{}{
+				"type":        "number",
+				"description": "Only return nodes up to this depth; 1 returns top-level lines only (default: unlimited)",
+			},
+			"project": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional project name (uses the session default set by set_default_project, or the server default)",
+			},
+		},
+		"required": []string{"title"},
+	}
+}
+
+func (t *GetPageOutlineTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	title, ok := arguments["title"].(string)
+	if !ok || title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+	maxDepth := 0
+	if depthArg, ok := arguments["max_depth"].(float64); ok && depthArg > 0 {
+		maxDepth = int(depthArg)
+	}
+
+	project := resolveProject(ctx, arguments, t.client)
+
+	page, err := t.client.GetPage(project, title)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]string, len(page.Lines))
+	for i, line := range page.Lines {
+		lines[i] = line.Text
+	}
+	outline := buildOutline(lines)
+	if maxDepth > 0 {
+		pruneOutline(outline, maxDepth)
+	}
+
+	response := pageOutlineResponse{
+		Title:      page.Title,
+		CommitID:   page.CommitID,
+		TotalLines: len(lines),
+		Outline:    outline,
+	}
+
+	// Format the response as JSON
+	result, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format outline: %v", err)
+	}
+
+	return string(result), nil
+}
+
+// buildOutline nests the lines after the title by indentation. A line
+// becomes a child of the nearest preceding line with a smaller indent.
+func buildOutline(lines []string) []*outlineNode {
+	outline := []*outlineNode{}
+	if len(lines) <= 1 {
+		return outline
+	}
+
+	var stack []*outlineNode
+	lineIndex := 1
+	for _, block := range notation.Parse(lines[1:]).Blocks {
+		start := lineIndex
+		lineIndex += block.Len()
+
+		node := &outlineNode{Line: start, EndLine: lineIndex - 1}
+		switch block.Type {
+		case notation.BlockCode:
+			node.Indent = notation.IndentLevel(block.Indent)
+			node.Text = strings.TrimLeft(lines[start], " \t　")
+			node.Type = "code"
+		case notation.BlockTable:
+			node.Indent = notation.IndentLevel(block.Indent)
+			node.Text = strings.TrimLeft(lines[start], " \t　")
+			node.Type = "table"
+		default:
+			if strings.TrimSpace(lines[start]) == "" {
+				continue
+			}
+			node.Indent = notation.IndentLevel(block.Line.Indent)
+			node.Text = lines[start][len(block.Line.Indent):]
+		}
+
+		for len(stack) > 0 && stack[len(stack)-1].Indent >= node.Indent {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			outline = append(outline, node)
+		} else {
+			stack[len(stack)-1].Children = append(stack[len(stack)-1].Children, node)
+		}
+		for _, parent := range stack {
+			parent.EndLine = node.EndLine
+		}
+		stack = append(stack, node)
+	}
+	return outline
+}
+
+// pruneOutline drops the children below maxDepth; endLine is kept so the
+// extent of each remaining node is still known
+func pruneOutline(nodes []*outlineNode, maxDepth int) {
+	for _, node := range nodes {
+		if maxDepth <= 1 {
+			node.Children = nil
+		} else {
+			pruneOutline(node.Children, maxDepth-1)
+		}
+	}
+}
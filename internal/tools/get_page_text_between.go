@@ -0,0 +1,179 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+type GetPageTextBetweenTool struct {
+	client scrapbox.Reader
+}
+
+func NewGetPageTextBetweenTool(client scrapbox.Reader) *GetPageTextBetweenTool {
+	return &GetPageTextBetweenTool{client: client}
+}
+
+// numberedLine is a page line with its index
+type numberedLine struct {
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// pageTextBetweenResponse is the get_page_text_between result
+type pageTextBetweenResponse struct {
+	Title    string         `json:"title"`
+	CommitID string         `json:"commitId"`
+	From     string         `json:"from"`
+	Lines    []numberedLine `json:"lines"`
+}
+
+func (t *GetPageTextBetweenTool) Name() string {
+	return "get_page_text_between"
+}
+
+func (t *GetPageTextBetweenTool) Description() string {
+	return "Returns only part of a page: the lines indented under a parent line (section or line, as in edit_section), or the lines between two marker lines (start_marker and optional end_marker, both excluded). Markers match a whole line, ignoring indentation and decoration such as [* ]. Use it to read one section of a long page."
+}
+
+func (t *GetPageTextBetweenTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "The title of the page",
+			},
+			"section": map[string]interface{}{
+				"type":        "string",
+				"description": "Text of the parent line whose indented lines to return",
+			},
+			"line": map[string]interface{}{
+				"type":        "number",
+				"description": "Index of the parent line, instead of section",
+			},
+			"start_marker": map[string]interface{}{
+				"type":        "string",
+				"description": "Return the lines after the first line matching this text",
+			},
+			"end_marker": map[string]interface{}{
+				"type":        "string",
+				"description": "Stop before the next line matching this text (default: end of page)",
+			},
+			"format": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{formatJSON, formatText},
+				"description": "Output format: json (lines with their indices, default) or text",
+			},
+			"project": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional project name (uses the session default set by set_default_project, or the server default)",
+			},
+		},
+		"required": []string{"title"},
+	}
+}
+
+func (t *GetPageTextBetweenTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	title, ok := arguments["title"].(string)
+	if !ok || title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+	section, _ := arguments["section"].(string)
+	_, hasLine := arguments["line"].(float64)
+	startMarker, _ := arguments["start_marker"].(string)
+	endMarker, _ := arguments["end_marker"].(string)
+	if section == "" && !hasLine && startMarker == "" {
+		return nil, fmt.Errorf("one of section, line or start_marker is required")
+	}
+	format, err := parseFormat(arguments)
+	if err != nil {
+		return nil, err
+	}
+	if format == formatTitlesOnly {
+		return nil, fmt.Errorf("invalid format: %s (expected json or text)", format)
+	}
+
+	project := resolveProject(ctx, arguments, t.client)
+
+	page, err := t.client.GetPage(project, title)
+	if err != nil {
+		return nil, err
+	}
+	lines := make([]string, len(page.Lines))
+	for i, line := range page.Lines {
+		lines[i] = line.Text
+	}
+
+	var start, end int
+	var from string
+	switch {
+	case startMarker != "":
+		start, end, err = markerRange(lines, startMarker, endMarker)
+		from = startMarker
+	default:
+		var node *outlineNode
+		node, err = locateSection(lines, arguments)
+		if node != nil {
+			start, end, from = node.Line+1, node.EndLine+1, node.Text
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response := pageTextBetweenResponse{Title: page.Title, CommitID: page.CommitID, From: from, Lines: []numberedLine{}}
+	for i := start; i < end; i++ {
+		response.Lines = append(response.Lines, numberedLine{Line: i, Text: lines[i]})
+	}
+
+	if format == formatText {
+		texts := make([]string, len(response.Lines))
+		for i, line := range response.Lines {
+			texts[i] = line.Text
+		}
+		return strings.Join(texts, "\n"), nil
+	}
+
+	// Format the response as JSON
+	result, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format lines: %v", err)
+	}
+
+	return string(result), nil
+}
+
+// markerRange returns the lines after the first line matching startMarker,
+// up to the next line matching endMarker or the end of the page
+func markerRange(lines []string, startMarker, endMarker string) (int, int, error) {
+	start := -1
+	for i := 1; i < len(lines); i++ {
+		if matchesMarker(lines[i], startMarker) {
+			start = i + 1
+			break
+		}
+	}
+	if start < 0 {
+		return 0, 0, fmt.Errorf("start_marker not found: %s", startMarker)
+	}
+	if endMarker == "" {
+		return start, len(lines), nil
+	}
+	for i := start; i < len(lines); i++ {
+		if matchesMarker(lines[i], endMarker) {
+			return start, i, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("end_marker not found after start_marker: %s", endMarker)
+}
+
+// matchesMarker reports whether a line is the marker, ignoring indentation and decoration
+func matchesMarker(line, marker string) bool {
+	text := strings.TrimSpace(line)
+	marker = strings.TrimSpace(marker)
+	return text == marker || sectionLabel(text) == marker
+}
@@ -0,0 +1,249 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	"github.com/hiroki/scrapbox_mcp/internal/undo"
+)
+
+// recentChangesPageSize is the page size used to walk the page list
+const recentChangesPageSize = 100
+
+// diffPreviewLines is the number of added/removed lines included in a diff summary
+const diffPreviewLines = 3
+
+type GetRecentChangesTool struct {
+	client scrapbox.Reader
+	store  *undo.Store
+}
+
+// NewGetRecentChangesTool creates the tool. store is optional; when set,
+// diff summaries are computed against pre-edit snapshots.
+func NewGetRecentChangesTool(client scrapbox.Reader, store *undo.Store) *GetRecentChangesTool {
+	return &GetRecentChangesTool{client: client, store: store}
+}
+
+// recentChange is one page in the get_recent_changes result
+type recentChange struct {
+	Title        string       `json:"title"`
+	Updated      string       `json:"updated"`
+	Created      string       `json:"created"`
+	New          bool         `json:"new"`
+	UpdatedBy    string       `json:"updatedBy,omitempty"`
+	Descriptions []string     `json:"descriptions,omitempty"`
+	Diff         *diffSummary `json:"diff,omitempty"`
+}
+
+// diffSummary summarizes line changes between a snapshot and the current page
+type diffSummary struct {
+	Since          string   `json:"since"`
+	LinesAdded     int      `json:"linesAdded"`
+	LinesRemoved   int      `json:"linesRemoved"`
+	AddedPreview   []string `json:"addedPreview,omitempty"`
+	RemovedPreview []string `json:"removedPreview,omitempty"`
+}
+
+type recentChangesResponse struct {
+	Project string         `json:"project"`
+	Since   string         `json:"since"`
+	Count   int            `json:"count"`
+	HasMore bool           `json:"hasMore"`
+	Pages   []recentChange `json:"pages"`
+}
+
+func (t *GetRecentChangesTool) Name() string {
+	return "get_recent_changes"
+}
+
+func (t *GetRecentChangesTool) Description() string {
+	return "Lists pages updated since a given time, most recent first. With include_diff, pages edited through this server include a summary of added and removed lines."
+}
+
+func (t *GetRecentChangesTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"since": map[string]interface{}{
+				"type":        "string",
+				"description": "RFC 3339 timestamp or Unix seconds; only pages updated at or after this time are returned",
+			},
+			"project": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional project name (uses the session default set by set_default_project, or the server default)",
+			},
+			"limit": map[string]interface{}{
+				"type":        "number",
+				"description": "Maximum number of pages to return (default: 50)",
+			},
+			"include_diff": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Include a diff summary for pages with a pre-edit snapshot (requires UNDO_STORE_PATH; default: false)",
+			},
+		},
+		"required": []string{"since"},
+	}
+}
+
+func (t *GetRecentChangesTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	sinceArg, ok := arguments["since"].(string)
+	if !ok || sinceArg == "" {
+		return nil, fmt.Errorf("since is required")
+	}
+	since, err := parseTimestamp(sinceArg)
+	if err != nil {
+		return nil, err
+	}
+
+	project := resolveProject(ctx, arguments, t.client)
+
+	limit := 50
+	if limitArg, ok := arguments["limit"].(float64); ok && limitArg > 0 {
+		limit = int(limitArg)
+	}
+
+	includeDiff, _ := arguments["include_diff"].(bool)
+	if includeDiff && t.store == nil {
+		return nil, fmt.Errorf("include_diff requires the undo store (UNDO_STORE_PATH)")
+	}
+
+	response := &recentChangesResponse{
+		Project: project,
+		Since:   since.UTC().Format(time.RFC3339),
+		Pages:   []recentChange{},
+	}
+
+	// Pages are listed most recently updated first, so stop at the first older
+	// page. Pinned pages are listed before all others whatever their update
+	// time, so they are picked out without ending the walk.
+	sinceUnix := since.Unix()
+	var changed, pinned []scrapbox.PageInfo
+walk:
+	for skip := 0; ; skip += recentChangesPageSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		resp, err := t.client.ListPages(project, recentChangesPageSize, skip)
+		if err != nil {
+			return nil, err
+		}
+		for _, page := range resp.Pages {
+			if page.Pin != 0 {
+				if page.Updated >= sinceUnix {
+					pinned = append(pinned, page)
+				}
+				continue
+			}
+			if page.Updated < sinceUnix {
+				break walk
+			}
+			if len(changed) == limit {
+				response.HasMore = true
+				break walk
+			}
+			changed = append(changed, page)
+		}
+		if len(resp.Pages) < recentChangesPageSize || skip+len(resp.Pages) >= resp.Count {
+			break
+		}
+	}
+	changed = append(changed, pinned...)
+	sort.SliceStable(changed, func(i, j int) bool {
+		return changed[i].Updated > changed[j].Updated
+	})
+	if len(changed) > limit {
+		changed = changed[:limit]
+		response.HasMore = true
+	}
+
+	var snapshots map[string]*undo.Snapshot
+	if includeDiff {
+		snapshots, err = t.store.FirstSince(project, since)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, page := range changed {
+		change := recentChange{
+			Title:        page.Title,
+			Updated:      time.Unix(page.Updated, 0).UTC().Format(time.RFC3339),
+			Created:      time.Unix(page.Created, 0).UTC().Format(time.RFC3339),
+			New:          page.Created >= sinceUnix,
+			Descriptions: page.Descriptions,
+		}
+		if page.LastUpdateUser != nil {
+			change.UpdatedBy = page.LastUpdateUser.Name
+		}
+
+		if snapshot, ok := snapshots[page.Title]; ok {
+			current, err := t.client.GetPage(project, page.Title)
+			if err != nil {
+				return nil, err
+			}
+			change.Diff = summarizeDiff(snapshot, current)
+		}
+
+		response.Pages = append(response.Pages, change)
+	}
+	response.Count = len(response.Pages)
+
+	// Format the response as JSON
+	result, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format recent changes: %v", err)
+	}
+
+	return string(result), nil
+}
+
+// parseTimestamp accepts RFC 3339 timestamps and Unix seconds
+func parseTimestamp(value string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q: expected RFC 3339 or Unix seconds", value)
+	}
+	return t, nil
+}
+
+// summarizeDiff counts lines added and removed between a snapshot and the current
+// page, treating lines as a multiset so moved lines are not counted as changes
+func summarizeDiff(snapshot *undo.Snapshot, current *scrapbox.Page) *diffSummary {
+	summary := &diffSummary{Since: snapshot.Timestamp.UTC().Format(time.RFC3339)}
+
+	before := make(map[string]int, len(snapshot.Lines))
+	for _, line := range snapshot.Lines {
+		before[line]++
+	}
+
+	for _, line := range current.Lines {
+		if before[line.Text] > 0 {
+			before[line.Text]--
+			continue
+		}
+		summary.LinesAdded++
+		if len(summary.AddedPreview) < diffPreviewLines {
+			summary.AddedPreview = append(summary.AddedPreview, line.Text)
+		}
+	}
+
+	for _, line := range snapshot.Lines {
+		if before[line] == 0 {
+			continue
+		}
+		before[line]--
+		summary.LinesRemoved++
+		if len(summary.RemovedPreview) < diffPreviewLines {
+			summary.RemovedPreview = append(summary.RemovedPreview, line)
+		}
+	}
+	return summary
+}
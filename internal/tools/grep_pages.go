@@ -0,0 +1,188 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hiroki/scrapbox_mcp/internal/index"
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+// grepDefaultMaxResults caps the number of matching lines returned by default
+const grepDefaultMaxResults = 100
+
+type GrepPagesTool struct {
+	client scrapbox.Reader
+	index  *index.Index
+}
+
+func NewGrepPagesTool(client scrapbox.Reader, pageIndex *index.Index) *GrepPagesTool {
+	return &GrepPagesTool{client: client, index: pageIndex}
+}
+
+// grepMatch is one matching line
+type grepMatch struct {
+	Title string `json:"title"`
+	Line  int    `json:"line"`
+	Text  string `json:"text"`
+}
+
+// grepPagesResponse is the grep_pages result
+type grepPagesResponse struct {
+	Project      string      `json:"project"`
+	Pattern      string      `json:"pattern"`
+	ScannedPages int         `json:"scannedPages"`
+	MatchedPages int         `json:"matchedPages"`
+	Truncated    bool        `json:"truncated"`
+	Matches      []grepMatch `json:"matches"`
+}
+
+func (t *GrepPagesTool) Name() string {
+	return "grep_pages"
+}
+
+func (t *GrepPagesTool) Description() string {
+	return "Searches the text of every page with a Go regular expression and returns matching lines with page titles and line numbers. Use it for regex or punctuation-heavy strings (error codes, paths) that search_pages cannot match. Pages are read from a local cache that is refreshed incrementally."
+}
+
+func (t *GrepPagesTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"pattern": map[string]interface{}{
+				"type":        "string",
+				"description": "Go (RE2) regular expression matched against each line",
+			},
+			"project": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional project name (uses the session default set by set_default_project, or the server default)",
+			},
+			"ignore_case": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Match case-insensitively (default: false)",
+			},
+			"title_pattern": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional regular expression; only pages whose title matches are searched",
+			},
+			"max_results": map[string]interface{}{
+				"type":        "number",
+				"description": fmt.Sprintf("Maximum number of matching lines to return (default: %d)", grepDefaultMaxResults),
+			},
+			"format": formatProperty(),
+		},
+		"required": []string{"pattern"},
+	}
+}
+
+func (t *GrepPagesTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	pattern, ok := arguments["pattern"].(string)
+	if !ok || pattern == "" {
+		return nil, fmt.Errorf("pattern is required")
+	}
+
+	ignoreCase, _ := arguments["ignore_case"].(bool)
+	re, err := compileGrepPattern(pattern, ignoreCase)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %v", err)
+	}
+
+	var titleRe *regexp.Regexp
+	if titlePattern, ok := arguments["title_pattern"].(string); ok && titlePattern != "" {
+		titleRe, err = compileGrepPattern(titlePattern, ignoreCase)
+		if err != nil {
+			return nil, fmt.Errorf("invalid title_pattern: %v", err)
+		}
+	}
+
+	maxResults := grepDefaultMaxResults
+	if maxArg, ok := arguments["max_results"].(float64); ok && maxArg > 0 {
+		maxResults = int(maxArg)
+	}
+
+	format, err := parseFormat(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	project := resolveProject(ctx, arguments, t.client)
+
+	pages, err := t.index.Pages(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	response := grepPagesResponse{
+		Project: project,
+		Pattern: pattern,
+		Matches: []grepMatch{},
+	}
+scan:
+	for _, page := range pages {
+		if titleRe != nil && !titleRe.MatchString(page.Title) {
+			continue
+		}
+		response.ScannedPages++
+		matched := false
+		for n, line := range page.Lines {
+			if !re.MatchString(line) {
+				continue
+			}
+			if len(response.Matches) >= maxResults {
+				response.Truncated = true
+				break scan
+			}
+			response.Matches = append(response.Matches, grepMatch{Title: page.Title, Line: n, Text: line})
+			matched = true
+		}
+		if matched {
+			response.MatchedPages++
+		}
+	}
+
+	if format != formatJSON {
+		return grepText(response, format == formatTitlesOnly), nil
+	}
+
+	// Format the response as JSON
+	result, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format matches: %v", err)
+	}
+
+	return string(result), nil
+}
+
+func compileGrepPattern(pattern string, ignoreCase bool) (*regexp.Regexp, error) {
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// grepText renders matches as "title:line: text", or just the matching titles
+func grepText(response grepPagesResponse, titlesOnly bool) string {
+	var b strings.Builder
+	seen := make(map[string]bool)
+	for _, match := range response.Matches {
+		if titlesOnly {
+			if !seen[match.Title] {
+				seen[match.Title] = true
+				b.WriteString(match.Title)
+				b.WriteByte('\n')
+			}
+			continue
+		}
+		fmt.Fprintf(&b, "%s:%d: %s\n", match.Title, match.Line, match.Text)
+	}
+	if len(response.Matches) == 0 {
+		fmt.Fprintf(&b, "No matches in %d pages\n", response.ScannedPages)
+	}
+	if response.Truncated {
+		b.WriteString("(results truncated; raise max_results or narrow the pattern)\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
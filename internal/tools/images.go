@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/safehttp"
+)
+
+// Limits for images attached to read tool results
+const (
+	maxThumbnailBytes   = 2 << 20
+	maxThumbnailsPerHit = 5
+)
+
+// imageHTTPClient fetches page thumbnails. Their URLs are written by page
+// editors, so it only reaches public addresses.
+var imageHTTPClient = safehttp.NewClient(10 * time.Second)
+
+// ContentBlocks is returned by tools whose result has more than one content block,
+// e.g. text followed by images. The first text block is subject to response truncation.
+type ContentBlocks []ContentBlock
+
+// includeImagesProperty is the InputSchema property for the include_images argument
+func includeImagesProperty() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "boolean",
+		"description": "Also return page thumbnails as image content blocks (default: false)",
+	}
+}
+
+// withImages returns text alone, or followed by an image block for each URL
+// that could be fetched. Images that fail to load are skipped.
+func withImages(ctx context.Context, text string, urls []string) interface{} {
+	if len(urls) == 0 {
+		return text
+	}
+
+	blocks := ContentBlocks{{Type: "text", Text: text}}
+	for _, url := range urls {
+		if len(blocks) > maxThumbnailsPerHit {
+			break
+		}
+		block, err := fetchImageBlock(ctx, url)
+		if err != nil {
+			log.Printf("[TOOL] Skipping image %s: %v", url, err)
+			continue
+		}
+		blocks = append(blocks, block)
+	}
+	if len(blocks) == 1 {
+		return text
+	}
+	return blocks
+}
+
+// fetchImageBlock downloads an image and returns it as a base64 image content block
+func fetchImageBlock(ctx context.Context, url string) (ContentBlock, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return ContentBlock{}, err
+	}
+	resp, err := imageHTTPClient.Do(req)
+	if err != nil {
+		return ContentBlock{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return ContentBlock{}, fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxThumbnailBytes+1))
+	if err != nil {
+		return ContentBlock{}, err
+	}
+	if len(data) > maxThumbnailBytes {
+		return ContentBlock{}, fmt.Errorf("larger than %d bytes", maxThumbnailBytes)
+	}
+
+	mimeType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !strings.HasPrefix(mimeType, "image/") {
+		mimeType = http.DetectContentType(data)
+	}
+	if !strings.HasPrefix(mimeType, "image/") {
+		return ContentBlock{}, fmt.Errorf("not an image: %s", mimeType)
+	}
+
+	return ContentBlock{
+		Type:     "image",
+		Data:     base64.StdEncoding.EncodeToString(data),
+		MimeType: mimeType,
+	}, nil
+}
@@ -0,0 +1,29 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// pngBytes is the signature of a PNG file, enough to pass as an image
+var pngBytes = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR")
+
+func TestWithImagesSkipsNonPublicAddresses(t *testing.T) {
+	requested := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(pngBytes)
+	}))
+	defer server.Close()
+
+	got := withImages(context.Background(), "text", []string{server.URL + "/thumbnail.png"})
+	if text, ok := got.(string); !ok || text != "text" {
+		t.Errorf("withImages = %#v, want the text alone", got)
+	}
+	if requested {
+		t.Error("thumbnail on 127.0.0.1 was fetched")
+	}
+}
@@ -0,0 +1,350 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/hiroki/scrapbox_mcp/internal/markdown"
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	mcperrors "github.com/hiroki/scrapbox_mcp/pkg/errors"
+)
+
+const (
+	importSkip      = "skip"
+	importAppend    = "append"
+	importOverwrite = "overwrite"
+	importRename    = "rename"
+
+	// importDefaultMaxPages and importMaxPages bound the pages of one import
+	importDefaultMaxPages = 100
+	importMaxPages        = 1000
+	// importMaxRenames bounds the "Title (n)" names tried by if_exists=rename
+	importMaxRenames = 20
+)
+
+type ImportMarkdownTool struct {
+	client scrapbox.API
+	root   string
+}
+
+// NewImportMarkdownTool creates the import tool. Only files under root can be
+// imported.
+func NewImportMarkdownTool(client scrapbox.API, root string) *ImportMarkdownTool {
+	return &ImportMarkdownTool{client: client, root: root}
+}
+
+// importRequest is the parsed import_markdown arguments
+type importRequest struct {
+	dir      string
+	ifExists string
+	tag      string
+	maxPages int
+	dryRun   bool
+}
+
+// importedPage is the outcome for one file
+type importedPage struct {
+	File  string `json:"file"`
+	Title string `json:"title"`
+	// Status is created, appended, overwritten, renamed, skipped or failed
+	Status string `json:"status"`
+	// RenamedFrom is the title the file asked for when if_exists=rename
+	// picked another one
+	RenamedFrom string `json:"renamedFrom,omitempty"`
+	Lines       int    `json:"lines"`
+	Reason      string `json:"reason,omitempty"`
+	URL         string `json:"url,omitempty"`
+}
+
+// importMarkdownResponse is the import_markdown result
+type importMarkdownResponse struct {
+	Project   string         `json:"project"`
+	Directory string         `json:"directory"`
+	DryRun    bool           `json:"dryRun"`
+	Counts    map[string]int `json:"counts"`
+	Truncated bool           `json:"truncated,omitempty"`
+	Pages     []importedPage `json:"pages"`
+}
+
+func (t *ImportMarkdownTool) Name() string {
+	return "import_markdown"
+}
+
+func (t *ImportMarkdownTool) Description() string {
+	return "Imports a directory of Markdown files (such as an Obsidian vault) as pages. Titles come from front matter, a leading # heading or the file name; [[wiki links]] and relative .md links become page links, and front matter tags become #tags. if_exists decides what happens to pages that already exist: skip (default), append, overwrite or rename to \"Title (2)\". Use dry_run to preview."
+}
+
+func (t *ImportMarkdownTool) IsWrite() bool {
+	return true
+}
+
+// DryRun reports whether the call only previews the import
+func (t *ImportMarkdownTool) DryRun(arguments map[string]interface{}) bool {
+	dryRun, _ := arguments["dry_run"].(bool)
+	return dryRun
+}
+
+// AdminOnly reserves bulk imports for admins
+func (t *ImportMarkdownTool) AdminOnly() bool {
+	return true
+}
+
+func (t *ImportMarkdownTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "Directory to import, relative to the server's import directory (default: the whole import directory)",
+			},
+			"if_exists": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{importSkip, importAppend, importOverwrite, importRename},
+				"description": "What to do when a page already exists: skip it (default), append the file, overwrite the page, or import under \"Title (2)\"",
+			},
+			"tag": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional tag (without #) added to the end of every imported page",
+			},
+			"max_pages": map[string]interface{}{
+				"type":        "number",
+				"description": fmt.Sprintf("Maximum number of files to import (default: %d, max: %d)", importDefaultMaxPages, importMaxPages),
+			},
+			"dry_run": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Only report what would be imported (default: false)",
+			},
+		},
+	}
+}
+
+func (t *ImportMarkdownTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	req, err := t.parseRequest(arguments)
+	if err != nil {
+		return nil, err
+	}
+	response, err := t.run(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	imported := len(response.Pages) - response.Counts["skipped"] - response.Counts["failed"]
+	summary := fmt.Sprintf("Imported %d of %d Markdown files into '%s'", imported, len(response.Pages), response.Project)
+	if req.dryRun {
+		summary = fmt.Sprintf("Dry run: %d of %d Markdown files would be imported into '%s'", imported, len(response.Pages), response.Project)
+	}
+	if n := response.Counts["skipped"]; n > 0 {
+		summary += fmt.Sprintf(", %d skipped", n)
+	}
+	if n := response.Counts["failed"]; n > 0 {
+		summary += fmt.Sprintf(", %d failed", n)
+	}
+
+	// Format the response as JSON
+	result, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format import: %v", err)
+	}
+
+	return withSummary(summary, string(result)), nil
+}
+
+// run imports the files of req.dir, or only plans it on a dry run
+func (t *ImportMarkdownTool) run(ctx context.Context, req *importRequest) (*importMarkdownResponse, error) {
+	notes, err := markdown.ReadDir(req.dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(notes) == 0 {
+		return nil, fmt.Errorf("no .md files found in %s", req.dir)
+	}
+
+	response := &importMarkdownResponse{
+		Project:   t.client.DefaultProject(),
+		Directory: req.dir,
+		DryRun:    req.dryRun,
+		Counts:    map[string]int{},
+		Pages:     []importedPage{},
+	}
+	if len(notes) > req.maxPages {
+		notes = notes[:req.maxPages]
+		response.Truncated = true
+	}
+
+	seen := make(map[string]string, len(notes))
+	for i, note := range notes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		reportProgress(ctx, float64(i), float64(len(notes)), "Importing "+note.Path)
+
+		lines := note.Lines
+		if req.tag != "" {
+			lines = append(lines, "#"+strings.ReplaceAll(strings.TrimPrefix(req.tag, "#"), " ", "_"))
+		}
+
+		page := importedPage{File: note.Path, Title: note.Title, Lines: len(lines)}
+		if first, ok := seen[tagKey(note.Title)]; ok {
+			page.Status = "skipped"
+			page.Reason = "same title as " + first
+		} else {
+			seen[tagKey(note.Title)] = note.Path
+			t.importNote(&page, lines, req)
+		}
+		response.Counts[page.Status]++
+		response.Pages = append(response.Pages, page)
+	}
+	reportProgress(ctx, float64(len(notes)), float64(len(notes)), "Done")
+	return response, nil
+}
+
+// importNote creates the page of one file according to the collision policy
+// and records the outcome in page
+func (t *ImportMarkdownTool) importNote(page *importedPage, lines []string, req *importRequest) {
+	ifExists := scrapbox.IfExistsError
+	status := "created"
+	switch req.ifExists {
+	case importAppend:
+		ifExists, status = scrapbox.IfExistsAppend, "appended"
+	case importOverwrite:
+		ifExists, status = scrapbox.IfExistsOverwrite, "overwritten"
+	}
+
+	title := page.Title
+	for n := 2; ; n++ {
+		exists, err := t.pageExists(title)
+		if err != nil {
+			page.Status, page.Reason = "failed", err.Error()
+			return
+		}
+		if !exists {
+			if status == "appended" || status == "overwritten" {
+				status = "created"
+			}
+			break
+		}
+		if req.ifExists == importSkip {
+			page.Status, page.Reason = "skipped", "page exists"
+			return
+		}
+		if req.ifExists != importRename {
+			break
+		}
+		if n > importMaxRenames {
+			page.Status, page.Reason = "failed", "no free title"
+			return
+		}
+		title = fmt.Sprintf("%s (%d)", page.Title, n)
+		status = "renamed"
+	}
+	if title != page.Title {
+		page.RenamedFrom, page.Title = page.Title, title
+	}
+	page.Status = status
+	if req.dryRun {
+		return
+	}
+
+	result, err := t.client.CreatePage(title, lines, ifExists)
+	if err != nil {
+		var sbErr *mcperrors.ScrapboxError
+		if errors.As(err, &sbErr) && sbErr.Code == mcperrors.ErrCodePageExists {
+			// Created since the check
+			page.Status, page.Reason = "skipped", "page exists"
+			return
+		}
+		log.Printf("[IMPORT] Failed to create %s: %v", title, err)
+		page.Status, page.Reason = "failed", err.Error()
+		return
+	}
+	page.URL = result.URL
+}
+
+// pageExists reports whether the page has been saved; unsaved pages have no commit
+func (t *ImportMarkdownTool) pageExists(title string) (bool, error) {
+	existing, err := t.client.GetPage(t.client.DefaultProject(), title)
+	if err != nil {
+		var sbErr *mcperrors.ScrapboxError
+		if errors.As(err, &sbErr) && sbErr.Code == mcperrors.ErrCodeNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return existing.CommitID != "", nil
+}
+
+func (t *ImportMarkdownTool) parseRequest(arguments map[string]interface{}) (*importRequest, error) {
+	req := &importRequest{ifExists: importSkip, maxPages: importDefaultMaxPages}
+
+	rel, _ := arguments["path"].(string)
+	req.dir = filepath.Join(t.root, filepath.FromSlash(rel))
+	if within, err := filepath.Rel(t.root, req.dir); err != nil || within == ".." || strings.HasPrefix(within, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("path must stay inside the import directory: %s", rel)
+	}
+
+	if ifExists, ok := arguments["if_exists"].(string); ok && ifExists != "" {
+		switch ifExists {
+		case importSkip, importAppend, importOverwrite, importRename:
+			req.ifExists = ifExists
+		default:
+			return nil, fmt.Errorf("invalid if_exists: %s (expected skip, append, overwrite or rename)", ifExists)
+		}
+	}
+	req.tag, _ = arguments["tag"].(string)
+	if n, ok := arguments["max_pages"].(float64); ok && n > 0 {
+		req.maxPages = min(int(n), importMaxPages)
+	}
+	req.dryRun, _ = arguments["dry_run"].(bool)
+	return req, nil
+}
+
+// TargetPages returns the pages an append or overwrite import changes, so
+// they can be snapshotted
+func (t *ImportMarkdownTool) TargetPages(arguments map[string]interface{}) []string {
+	req, err := t.parseRequest(arguments)
+	if err != nil || req.dryRun || (req.ifExists != importAppend && req.ifExists != importOverwrite) {
+		return nil
+	}
+	notes, err := markdown.ReadDir(req.dir)
+	if err != nil {
+		return nil
+	}
+	if len(notes) > req.maxPages {
+		notes = notes[:req.maxPages]
+	}
+	titles := make([]string, len(notes))
+	for i, note := range notes {
+		titles[i] = note.Title
+	}
+	return titles
+}
+
+// DestructivePreview lists the existing pages an overwrite import replaces
+func (t *ImportMarkdownTool) DestructivePreview(ctx context.Context, arguments map[string]interface{}) (string, bool, error) {
+	req, err := t.parseRequest(arguments)
+	if err != nil || req.dryRun || req.ifExists != importOverwrite {
+		return "", false, nil
+	}
+	req.dryRun = true
+	response, err := t.run(ctx, req)
+	if err != nil {
+		// Let the real call report the error
+		return "", false, nil
+	}
+
+	var overwritten []string
+	for _, page := range response.Pages {
+		if page.Status == "overwritten" {
+			overwritten = append(overwritten, "- "+page.Title)
+		}
+	}
+	if len(overwritten) == 0 {
+		return "", false, nil
+	}
+	return fmt.Sprintf("The import replaces the content of %d existing pages:\n%s", len(overwritten), strings.Join(overwritten, "\n")), true, nil
+}
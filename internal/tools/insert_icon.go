@@ -0,0 +1,148 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+// UserProvider returns the user the server writes as
+type UserProvider interface {
+	CurrentUser() (*scrapbox.User, error)
+}
+
+type InsertIconTool struct {
+	client scrapbox.API
+	users  UserProvider
+}
+
+func NewInsertIconTool(client scrapbox.API, users UserProvider) *InsertIconTool {
+	return &InsertIconTool{client: client, users: users}
+}
+
+func (t *InsertIconTool) Name() string {
+	return "insert_icon"
+}
+
+func (t *InsertIconTool) Description() string {
+	return "Inserts a [username.icon] user icon into a page, by default for the user the server writes as. Adds a new line (optionally followed by text) after target_line or at the end of the page, or with inline=true appends the icon to the end of target_line itself, as is common for attribution in meeting notes."
+}
+
+func (t *InsertIconTool) IsWrite() bool {
+	return true
+}
+
+func (t *InsertIconTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "The title of the page",
+			},
+			"user": map[string]interface{}{
+				"type":        "string",
+				"description": "User name of the icon (default: the current user)",
+			},
+			"count": map[string]interface{}{
+				"type":        "number",
+				"description": "Repeat the icon, written as [user.icon*count] (default: 1)",
+			},
+			"target_line": map[string]interface{}{
+				"type":        "string",
+				"description": "The line after which to insert, or with inline=true the line to append the icon to (default: end of page)",
+			},
+			"inline": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Append the icon to target_line instead of adding a new line (default: false)",
+			},
+			"text": map[string]interface{}{
+				"type":        "string",
+				"description": "Text to write after the icon on a new line",
+			},
+			argExpectedCommitID: expectedCommitProperty(),
+		},
+		"required": []string{"title"},
+	}
+}
+
+func (t *InsertIconTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	title, ok := arguments["title"].(string)
+	if !ok || title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+	targetLine, _ := arguments["target_line"].(string)
+	inline, _ := arguments["inline"].(bool)
+	if inline && targetLine == "" {
+		return nil, fmt.Errorf("target_line is required with inline")
+	}
+	text, _ := arguments["text"].(string)
+	if inline && text != "" {
+		return nil, fmt.Errorf("text cannot be used with inline")
+	}
+
+	user, _ := arguments["user"].(string)
+	if user == "" {
+		me, err := t.users.CurrentUser()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the current user: %w", err)
+		}
+		user = me.Name
+	}
+	if strings.ContainsAny(user, "[]* \t\n") {
+		return nil, fmt.Errorf("invalid user name: %s", user)
+	}
+	icon := iconNotation(user, arguments)
+
+	if !inline {
+		line := icon
+		if text != "" {
+			line += " " + text
+		}
+		result, err := t.client.InsertLines(title, targetLine, []string{line}, parseExpectedCommitID(arguments))
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert icon: %w", conflictError(err))
+		}
+		return formatWriteResult(fmt.Sprintf("Inserted %s into page '%s'", icon, title), result)
+	}
+
+	page, err := t.client.GetPage(t.client.DefaultProject(), title)
+	if err != nil {
+		return nil, err
+	}
+	if page.CommitID == "" {
+		return nil, fmt.Errorf("page not found: %s", title)
+	}
+	newTexts := make([]string, len(page.Lines))
+	target := -1
+	for i, line := range page.Lines {
+		newTexts[i] = line.Text
+		if i > 0 && target < 0 && line.Text == targetLine {
+			target = i
+		}
+	}
+	if target < 0 {
+		return nil, fmt.Errorf("target line not found: %s", targetLine)
+	}
+	newTexts[target] = strings.TrimRight(newTexts[target], " ") + " " + icon
+
+	expectedCommitID := parseExpectedCommitID(arguments)
+	if expectedCommitID == "" {
+		expectedCommitID = page.CommitID
+	}
+	result, err := t.client.PatchPage(title, newTexts, expectedCommitID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert icon: %w", conflictError(err))
+	}
+	return formatWriteResult(fmt.Sprintf("Appended %s to line %d of page '%s'", icon, target, title), result)
+}
+
+// iconNotation writes [user.icon], or [user.icon*n] for a count above one
+func iconNotation(user string, arguments map[string]interface{}) string {
+	if count, ok := arguments["count"].(float64); ok && int(count) > 1 {
+		return fmt.Sprintf("[%s.icon*%d]", user, int(count))
+	}
+	return "[" + user + ".icon]"
+}
@@ -3,20 +3,32 @@ package tools
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
 )
 
+// Insert positions relative to the target line
+const (
+	insertAfter  = "after"
+	insertBefore = "before"
+)
+
+// Target line match modes
+const (
+	matchExact  = "exact"
+	matchPrefix = "prefix"
+	matchRegex  = "regex"
+)
+
 type InsertLinesTool struct {
-	client *scrapbox.Client
-	wsURL  string
+	client scrapbox.API
 }
 
-func NewInsertLinesTool(client *scrapbox.Client, wsURL string) *InsertLinesTool {
+func NewInsertLinesTool(client scrapbox.API) *InsertLinesTool {
 	return &InsertLinesTool{
 		client: client,
-		wsURL:  wsURL,
 	}
 }
 
@@ -25,7 +37,11 @@ func (t *InsertLinesTool) Name() string {
 }
 
 func (t *InsertLinesTool) Description() string {
-	return "Inserts lines into a Scrapbox page after a specified target line. If the target line is not found, lines are appended to the end of the page."
+	return "Inserts lines into a Scrapbox page after (or before) a target line. The target is matched exactly (ignoring surrounding whitespace), by prefix or by regular expression, and match_occurrence picks the nth matching line. If the target line is not found, lines are appended to the end of the page and the result carries a warning with the actual position; with strict=true the call fails instead."
+}
+
+func (t *InsertLinesTool) IsWrite() bool {
+	return true
 }
 
 func (t *InsertLinesTool) InputSchema() map[string]interface{} {
@@ -40,6 +56,24 @@ func (t *InsertLinesTool) InputSchema() map[string]interface{} {
 				"type":        "string",
 				"description": "The line after which to insert new lines (or empty to append at end)",
 			},
+			"position": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{insertAfter, insertBefore},
+				"description": "Insert after (default) or before the target line",
+			},
+			"match": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{matchExact, matchPrefix, matchRegex},
+				"description": "How target_line is matched: exact (default; leading indentation and trailing spaces are ignored), prefix (the line starts with target_line, after indentation) or regex (a Go regular expression matched anywhere in the line)",
+			},
+			"match_occurrence": map[string]interface{}{
+				"type":        "number",
+				"description": "Which matching line to use, counting from 1 (default: 1)",
+			},
+			"strict": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Fail when the target line is not found instead of appending to the end of the page (default: false)",
+			},
 			"new_lines": map[string]interface{}{
 				"type":        "string",
 				"description": "The lines to insert (can be a single line or multiple lines separated by newlines)",
@@ -48,6 +82,7 @@ func (t *InsertLinesTool) InputSchema() map[string]interface{} {
 				"type":        "string",
 				"description": "Optional project name (uses default if not specified)",
 			},
+			argExpectedCommitID: expectedCommitProperty(),
 		},
 		"required": []string{"title", "new_lines"},
 	}
@@ -69,21 +104,149 @@ func (t *InsertLinesTool) Execute(ctx context.Context, arguments map[string]inte
 		targetLine = targetLineArg
 	}
 
-	project := t.client.ProjectName
+	project := t.client.DefaultProject()
 	if projectArg, ok := arguments["project"].(string); ok && projectArg != "" {
 		project = projectArg
 	}
 
-	// Ensure WebSocket client is initialized
-	t.client.EnsureWebSocket(t.wsURL)
-
 	// Split new lines by newline
 	newLines := strings.Split(newLinesStr, "\n")
 
 	// Execute insert
-	if err := t.client.InsertLines(title, targetLine, newLines); err != nil {
-		return nil, fmt.Errorf("failed to insert lines: %v", err)
+	var result *scrapbox.WriteResult
+	found := true
+	var err error
+	if targetLine == "" {
+		result, err = t.client.InsertLines(title, "", newLines, parseExpectedCommitID(arguments))
+	} else {
+		result, found, err = t.insertAtTarget(title, targetLine, newLines, arguments)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert lines: %w", conflictError(err))
+	}
+
+	response := newWriteResponse(fmt.Sprintf("Successfully inserted %d line(s) into page '%s' in project '%s'", len(newLines), title, project), result)
+	if len(result.Changed) > 0 {
+		response.InsertedAt = &result.Changed[0]
 	}
+	if !found && response.InsertedAt != nil {
+		response.Warning = fmt.Sprintf("target line not found: %s; the lines were appended to the end of the page (line %d)", targetLine, *response.InsertedAt)
+	}
+	return response.format()
+}
 
-	return fmt.Sprintf("Successfully inserted %d line(s) into page '%s' in project '%s'", len(newLines), title, project), nil
+// insertAtTarget inserts newLines next to the line picked by the target
+// arguments and reports whether the target was found. Without an explicit
+// commit ID, the page is guarded against edits made between locating the
+// target and writing.
+func (t *InsertLinesTool) insertAtTarget(title, targetLine string, newLines []string, arguments map[string]interface{}) (*scrapbox.WriteResult, bool, error) {
+	position := insertAfter
+	if positionArg, ok := arguments["position"].(string); ok && positionArg != "" {
+		if positionArg != insertAfter && positionArg != insertBefore {
+			return nil, false, fmt.Errorf("invalid position: %s (expected after or before)", positionArg)
+		}
+		position = positionArg
+	}
+	page, err := t.client.GetPage(t.client.DefaultProject(), title)
+	if err != nil {
+		return nil, false, err
+	}
+	expectedCommitID := parseExpectedCommitID(arguments)
+	if expectedCommitID == "" {
+		expectedCommitID = page.CommitID
+	}
+
+	target, err := findTargetLine(page, targetLine, arguments)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if strict, _ := arguments["strict"].(bool); strict && target < 0 {
+		return nil, false, fmt.Errorf("target line not found: %s", targetLine)
+	}
+
+	// A target line that is not found falls back to the end of the page
+	beforeLineID := ""
+	switch {
+	case target < 0:
+	case position == insertBefore:
+		if target == 0 {
+			return nil, false, fmt.Errorf("cannot insert before the title line")
+		}
+		beforeLineID = page.Lines[target].ID
+	case target+1 < len(page.Lines):
+		beforeLineID = page.Lines[target+1].ID
+	}
+	result, err := t.client.InsertLinesBefore(title, beforeLineID, newLines, expectedCommitID)
+	return result, target >= 0, err
+}
+
+// lineMatcher returns the test of a line against target for a match mode
+// (exact when empty)
+func lineMatcher(mode, target string) (func(text string) bool, error) {
+	switch mode {
+	case "", matchExact:
+		target = strings.TrimSpace(target)
+		return func(text string) bool {
+			return strings.TrimSpace(text) == target
+		}, nil
+	case matchPrefix:
+		return func(text string) bool {
+			return strings.HasPrefix(strings.TrimLeft(text, " \t"), target)
+		}, nil
+	case matchRegex:
+		re, err := regexp.Compile(target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target_line regex: %v", err)
+		}
+		return re.MatchString, nil
+	}
+	return nil, fmt.Errorf("invalid match: %s (expected exact, prefix or regex)", mode)
+}
+
+// findTargetLine returns the index of the line picked by the target
+// arguments, or -1 if no line matches
+func findTargetLine(page *scrapbox.Page, targetLine string, arguments map[string]interface{}) (int, error) {
+	mode, _ := arguments["match"].(string)
+	matches, err := lineMatcher(mode, targetLine)
+	if err != nil {
+		return -1, err
+	}
+	occurrence := 1
+	if occurrenceArg, ok := arguments["match_occurrence"].(float64); ok {
+		if occurrenceArg < 1 {
+			return -1, fmt.Errorf("match_occurrence must be 1 or more")
+		}
+		occurrence = int(occurrenceArg)
+	}
+
+	for i, line := range page.Lines {
+		if matches(line.Text) {
+			occurrence--
+			if occurrence == 0 {
+				return i, nil
+			}
+		}
+	}
+	return -1, nil
+}
+
+// DestructivePreview asks for confirmation when the target line is missing
+// and the lines would be appended to the end of the page instead: content
+// meant for one place landing in another is as hard to spot as lost content
+func (t *InsertLinesTool) DestructivePreview(ctx context.Context, arguments map[string]interface{}) (string, bool, error) {
+	title, _ := arguments["title"].(string)
+	targetLine, _ := arguments["target_line"].(string)
+	newLines, _ := arguments["new_lines"].(string)
+	if strict, _ := arguments["strict"].(bool); strict || title == "" || targetLine == "" || newLines == "" {
+		return "", false, nil
+	}
+	page, err := t.client.GetPage(t.client.DefaultProject(), title)
+	if err != nil || page.CommitID == "" {
+		return "", false, nil
+	}
+	if target, err := findTargetLine(page, targetLine, arguments); err != nil || target >= 0 {
+		return "", false, nil
+	}
+	return fmt.Sprintf("'%s': target line not found: %s\n%d line(s) would be appended to the end of the page (line %d) instead.", title, targetLine, len(strings.Split(newLines, "\n")), len(page.Lines)), true, nil
 }
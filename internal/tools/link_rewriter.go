@@ -0,0 +1,126 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/hiroki/scrapbox_mcp/internal/index"
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	"github.com/hiroki/scrapbox_mcp/pkg/notation"
+)
+
+// linkRewriter rewrites the links to a page across the project. It is shared
+// by rename_page, archive_page and merge_pages.
+type linkRewriter struct {
+	client scrapbox.API
+	index  *index.Index
+}
+
+func newLinkRewriter(client scrapbox.API, pageIndex *index.Index) *linkRewriter {
+	return &linkRewriter{client: client, index: pageIndex}
+}
+
+// backlinks returns the titles of pages that link to from, per the local index
+func (w *linkRewriter) backlinks(ctx context.Context, from string) ([]string, error) {
+	project := w.client.DefaultProject()
+	pages, err := w.index.Pages(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	var titles []string
+	for _, page := range pages {
+		if _, n := notation.RewriteLinks(page.Lines, from, from, project); n > 0 {
+			titles = append(titles, page.Title)
+		}
+	}
+	return titles, nil
+}
+
+// rewrite changes the links to from into links to to. In a dry run it only
+// reports the lines that would change. skip names a page to leave alone
+// (e.g. the page being merged away).
+func (w *linkRewriter) rewrite(ctx context.Context, from, to, skip string, dryRun bool) ([]pageReplacement, error) {
+	titles, err := w.backlinks(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+
+	project := w.client.DefaultProject()
+	results := make([]pageReplacement, 0, len(titles))
+	for i, title := range titles {
+		if title == skip {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		if !dryRun {
+			reportProgress(ctx, float64(i), float64(len(titles)), "Rewriting links in "+title)
+		}
+
+		page, err := w.client.GetPage(project, title)
+		if err != nil {
+			results = append(results, pageReplacement{Title: title, Preview: []lineChange{}, Status: "error", Error: err.Error()})
+			continue
+		}
+		lines := make([]string, len(page.Lines))
+		for n, line := range page.Lines {
+			lines[n] = line.Text
+		}
+
+		newTexts, _ := notation.RewriteLinks(lines, from, to, project)
+		var changes []lineChange
+		for n := range lines {
+			if newTexts[n] != lines[n] {
+				changes = append(changes, lineChange{Line: n, Before: lines[n], After: newTexts[n]})
+			}
+		}
+		result := newPageReplacement(title, changes)
+		switch {
+		case len(changes) == 0:
+			result.Status = "unchanged"
+		case dryRun:
+		default:
+			written, err := w.client.PatchPage(title, newTexts, page.CommitID)
+			if err != nil {
+				log.Printf("[LINKS] Failed to rewrite links in %s: %v", title, err)
+				result.Status = "error"
+				result.Error = err.Error()
+			} else {
+				result.changed(written)
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// retitledLines returns the lines of page with newTitle as the title line
+// and, with rewriteLinks, links to title rewritten, and the number of links
+// rewritten
+func retitledLines(page *scrapbox.Page, title, newTitle, project string, rewriteLinks bool) ([]string, int) {
+	newTexts := make([]string, len(page.Lines))
+	for i, line := range page.Lines {
+		newTexts[i] = line.Text
+	}
+	if len(newTexts) == 0 {
+		newTexts = []string{newTitle}
+	}
+	newTexts[0] = newTitle
+	if !rewriteLinks {
+		return newTexts, 0
+	}
+	return notation.RewriteLinks(newTexts, title, newTitle, project)
+}
+
+// renamePartialError reports a rename (or archive) whose backlinks could not all be
+// rewritten, with the result of what was done
+func renamePartialError(response interface{}, err error) error {
+	result, jsonErr := json.MarshalIndent(response, "", "  ")
+	if jsonErr != nil {
+		return err
+	}
+	return &PartialError{Err: err, Result: withSummary(err.Error(), string(result))}
+}
@@ -0,0 +1,247 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/index"
+	"github.com/hiroki/scrapbox_mcp/internal/linkcheck"
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+// linkCheckMaxConcurrency caps the concurrency argument of link checks
+const linkCheckMaxConcurrency = 32
+
+type ListExternalLinksTool struct {
+	client scrapbox.Reader
+	index  *index.Index
+}
+
+func NewListExternalLinksTool(client scrapbox.Reader, pageIndex *index.Index) *ListExternalLinksTool {
+	return &ListExternalLinksTool{client: client, index: pageIndex}
+}
+
+// externalLink is one URL of the list_external_links result
+type externalLink struct {
+	URL   string   `json:"url"`
+	Pages []string `json:"pages"`
+	// Status and Error are set when the links were checked
+	Status int    `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+	Broken bool   `json:"broken,omitempty"`
+	// Skipped is set for URLs on non-public addresses, which are not requested
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+// linkDomain groups the URLs of one domain
+type linkDomain struct {
+	Domain string         `json:"domain"`
+	Count  int            `json:"count"`
+	Links  []externalLink `json:"links"`
+}
+
+// listExternalLinksResponse is the list_external_links result
+type listExternalLinksResponse struct {
+	Project string       `json:"project"`
+	URLs    int          `json:"urls"`
+	Checked bool         `json:"checked"`
+	Broken  int          `json:"broken,omitempty"`
+	Domains []linkDomain `json:"domains"`
+}
+
+func (t *ListExternalLinksTool) Name() string {
+	return "list_external_links"
+}
+
+func (t *ListExternalLinksTool) Description() string {
+	return "Lists every http(s) URL cited in the project, grouped by domain, with the pages citing each. With check=true each URL is requested (HEAD, then GET if refused) and 404/410 responses or failed requests are reported as broken. Pages are read from the local page cache."
+}
+
+// AdminCall reserves checks for admins, like run_link_check, since they
+// request every URL written on the project's pages
+func (t *ListExternalLinksTool) AdminCall(arguments map[string]interface{}) bool {
+	check, _ := arguments["check"].(bool)
+	return check
+}
+
+func (t *ListExternalLinksTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"project": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional project name (uses the session default set by set_default_project, or the server default)",
+			},
+			"domain": map[string]interface{}{
+				"type":        "string",
+				"description": "Only list URLs on this domain or its subdomains (e.g. github.com)",
+			},
+			"check": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Request each URL and report broken ones; admin role only (default: false)",
+			},
+			"broken_only": map[string]interface{}{
+				"type":        "boolean",
+				"description": "With check, list only the broken URLs (default: false)",
+			},
+			"concurrency": map[string]interface{}{
+				"type":        "number",
+				"description": fmt.Sprintf("With check, the number of URLs requested at once (default: %d, max: %d)", linkcheck.DefaultConcurrency, linkCheckMaxConcurrency),
+			},
+			"timeout_seconds": map[string]interface{}{
+				"type":        "number",
+				"description": fmt.Sprintf("With check, the timeout of each request (default: %d)", int(linkcheck.DefaultTimeout/time.Second)),
+			},
+			"format": formatProperty(),
+		},
+	}
+}
+
+func (t *ListExternalLinksTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	domain, _ := arguments["domain"].(string)
+	domain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "www.")
+	check, _ := arguments["check"].(bool)
+	brokenOnly, _ := arguments["broken_only"].(bool)
+	if brokenOnly && !check {
+		return nil, fmt.Errorf("broken_only requires check")
+	}
+
+	concurrency := 0
+	if n, ok := arguments["concurrency"].(float64); ok && n > 0 {
+		concurrency = min(int(n), linkCheckMaxConcurrency)
+	}
+	var timeout time.Duration
+	if n, ok := arguments["timeout_seconds"].(float64); ok && n > 0 {
+		timeout = time.Duration(n * float64(time.Second))
+	}
+
+	format, err := parseFormat(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	project := resolveProject(ctx, arguments, t.client)
+
+	pages, err := t.index.Pages(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	var links []*linkcheck.Link
+	for _, link := range linkcheck.Collect(pages) {
+		if domain == "" || link.Domain == domain || strings.HasSuffix(link.Domain, "."+domain) {
+			links = append(links, link)
+		}
+	}
+
+	var statuses map[string]linkcheck.Status
+	if check {
+		urls := make([]string, len(links))
+		for i, link := range links {
+			urls[i] = link.URL
+		}
+		statuses = linkcheck.NewChecker(concurrency, timeout).Check(ctx, urls)
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("link check interrupted: %w", err)
+		}
+	}
+
+	response := listExternalLinksResponse{
+		Project: project,
+		Checked: check,
+		Domains: []linkDomain{},
+	}
+	byDomain := make(map[string]*linkDomain)
+	for _, link := range links {
+		entry := externalLink{URL: link.URL, Pages: link.Pages}
+		if status, ok := statuses[link.URL]; ok {
+			entry.Status = status.Code
+			entry.Error = status.Error
+			entry.Broken = status.Broken
+			entry.Skipped = status.Skipped
+		}
+		if entry.Broken {
+			response.Broken++
+		}
+		if brokenOnly && !entry.Broken {
+			continue
+		}
+		group, ok := byDomain[link.Domain]
+		if !ok {
+			group = &linkDomain{Domain: link.Domain}
+			byDomain[link.Domain] = group
+		}
+		group.Links = append(group.Links, entry)
+		group.Count++
+		response.URLs++
+	}
+	for _, group := range byDomain {
+		response.Domains = append(response.Domains, *group)
+	}
+	// Most cited domains first
+	sort.Slice(response.Domains, func(i, j int) bool {
+		if response.Domains[i].Count != response.Domains[j].Count {
+			return response.Domains[i].Count > response.Domains[j].Count
+		}
+		return response.Domains[i].Domain < response.Domains[j].Domain
+	})
+
+	if format != formatJSON {
+		return externalLinksText(response, format == formatTitlesOnly), nil
+	}
+
+	// Format the response as JSON
+	result, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format links: %v", err)
+	}
+
+	return string(result), nil
+}
+
+// externalLinksText renders the links under their domain, or just the titles
+// of the citing pages
+func externalLinksText(response listExternalLinksResponse, titlesOnly bool) string {
+	var b strings.Builder
+	seen := make(map[string]bool)
+	for _, group := range response.Domains {
+		if !titlesOnly {
+			fmt.Fprintf(&b, "%s (%d)\n", group.Domain, group.Count)
+		}
+		for _, link := range group.Links {
+			if titlesOnly {
+				for _, title := range link.Pages {
+					if !seen[title] {
+						seen[title] = true
+						b.WriteString(title)
+						b.WriteByte('\n')
+					}
+				}
+				continue
+			}
+			status := ""
+			switch {
+			case link.Skipped:
+				status = " [skipped: not a public address]"
+			case link.Error != "":
+				status = " [error: " + link.Error + "]"
+			case link.Status != 0:
+				status = fmt.Sprintf(" [%d]", link.Status)
+			}
+			if link.Broken {
+				status = " BROKEN" + status
+			}
+			fmt.Fprintf(&b, "  %s%s - %s\n", link.URL, status, strings.Join(link.Pages, ", "))
+		}
+	}
+	if len(response.Domains) == 0 {
+		b.WriteString("No external links found\n")
+	} else if response.Checked && !titlesOnly {
+		fmt.Fprintf(&b, "%d broken\n", response.Broken)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
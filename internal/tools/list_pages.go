@@ -9,10 +9,10 @@ import (
 )
 
 type ListPagesTool struct {
-	client *scrapbox.Client
+	client scrapbox.Reader
 }
 
-func NewListPagesTool(client *scrapbox.Client) *ListPagesTool {
+func NewListPagesTool(client scrapbox.Reader) *ListPagesTool {
 	return &ListPagesTool{client: client}
 }
 
@@ -28,9 +28,10 @@ func (t *ListPagesTool) InputSchema() map[string]interface{} {
 	return map[string]interface{}{
 		"type": "object",
 		"properties": map[string]interface{}{
+			"format": formatProperty(),
 			"project": map[string]interface{}{
 				"type":        "string",
-				"description": "Optional project name (uses default if not specified)",
+				"description": "Optional project name (uses the session default set by set_default_project, or the server default)",
 			},
 			"limit": map[string]interface{}{
 				"type":        "number",
@@ -46,9 +47,11 @@ func (t *ListPagesTool) InputSchema() map[string]interface{} {
 }
 
 func (t *ListPagesTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
-	project := t.client.ProjectName
-	if projectArg, ok := arguments["project"].(string); ok && projectArg != "" {
-		project = projectArg
+	project := resolveProject(ctx, arguments, t.client)
+
+	format, err := parseFormat(arguments)
+	if err != nil {
+		return nil, err
 	}
 
 	limit := 100
@@ -61,16 +64,20 @@ func (t *ListPagesTool) Execute(ctx context.Context, arguments map[string]interf
 		skip = int(skipArg)
 	}
 
-	pages, err := t.client.RESTClient.ListPages(project, limit, skip)
+	pages, err := t.client.ListPages(project, limit, skip)
 	if err != nil {
 		return nil, err
 	}
 
+	if format != formatJSON {
+		return pagesText(pages, format == formatTitlesOnly), nil
+	}
+
 	// Format the response as JSON
 	result, err := json.MarshalIndent(pages, "", "  ")
 	if err != nil {
 		return nil, fmt.Errorf("failed to format pages: %v", err)
 	}
 
-	return string(result), nil
+	return withSummary(pagesSummary(pages), string(result)), nil
 }
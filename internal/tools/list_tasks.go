@@ -0,0 +1,138 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hiroki/scrapbox_mcp/internal/index"
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	"github.com/hiroki/scrapbox_mcp/internal/tasks"
+)
+
+// Task states accepted by list_tasks and toggle_task
+const (
+	taskStateOpen = "open"
+	taskStateDone = "done"
+	taskStateAll  = "all"
+)
+
+// tasksDefaultMaxResults bounds a project-wide task listing
+const tasksDefaultMaxResults = 100
+
+type ListTasksTool struct {
+	client  scrapbox.Reader
+	index   *index.Index
+	matcher *tasks.Matcher
+}
+
+func NewListTasksTool(client scrapbox.Reader, pageIndex *index.Index, matcher *tasks.Matcher) *ListTasksTool {
+	return &ListTasksTool{client: client, index: pageIndex, matcher: matcher}
+}
+
+// pageTask is a task with the page it is on
+type pageTask struct {
+	Title string `json:"title"`
+	tasks.Task
+}
+
+// listTasksResponse is the list_tasks result
+type listTasksResponse struct {
+	Project   string     `json:"project"`
+	State     string     `json:"state"`
+	Truncated bool       `json:"truncated"`
+	Tasks     []pageTask `json:"tasks"`
+}
+
+func (t *ListTasksTool) Name() string {
+	return "list_tasks"
+}
+
+func (t *ListTasksTool) Description() string {
+	return "Lists todo items on a page, or across the project when no title is given. Tasks are lines starting with an open or done marker (by default \"[ ]\" / \"✅\" and \"☐\" / \"☑\") or carrying a #todo / #done tag; the markers are configured with TASK_MARKERS. Each task has its page, line index and text; pass these to toggle_task."
+}
+
+func (t *ListTasksTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "Only list the tasks of this page (default: the whole project)",
+			},
+			"state": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{taskStateOpen, taskStateDone, taskStateAll},
+				"description": "Which tasks to list (default: open)",
+			},
+			"max_results": map[string]interface{}{
+				"type":        "number",
+				"description": "Maximum number of tasks to return (default: 100)",
+			},
+			"project": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional project name (uses the session default set by set_default_project, or the server default)",
+			},
+		},
+	}
+}
+
+func (t *ListTasksTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	title, _ := arguments["title"].(string)
+	state := taskStateOpen
+	if stateArg, ok := arguments["state"].(string); ok && stateArg != "" {
+		if stateArg != taskStateOpen && stateArg != taskStateDone && stateArg != taskStateAll {
+			return nil, fmt.Errorf("invalid state: %s (expected open, done or all)", stateArg)
+		}
+		state = stateArg
+	}
+	maxResults := tasksDefaultMaxResults
+	if maxArg, ok := arguments["max_results"].(float64); ok && maxArg > 0 {
+		maxResults = int(maxArg)
+	}
+
+	project := resolveProject(ctx, arguments, t.client)
+
+	// A single page is read live; the project is scanned from the page index
+	var pages []*index.Page
+	if title != "" {
+		page, err := t.client.GetPage(project, title)
+		if err != nil {
+			return nil, err
+		}
+		lines := make([]string, len(page.Lines))
+		for i, line := range page.Lines {
+			lines[i] = line.Text
+		}
+		pages = []*index.Page{{Title: page.Title, Lines: lines}}
+	} else {
+		var err error
+		pages, err = t.index.Pages(ctx, project)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	response := listTasksResponse{Project: project, State: state, Tasks: []pageTask{}}
+scan:
+	for _, page := range pages {
+		for _, task := range t.matcher.Find(page.Lines) {
+			if (state == taskStateOpen && task.Done) || (state == taskStateDone && !task.Done) {
+				continue
+			}
+			if len(response.Tasks) >= maxResults {
+				response.Truncated = true
+				break scan
+			}
+			response.Tasks = append(response.Tasks, pageTask{Title: page.Title, Task: task})
+		}
+	}
+
+	// Format the response as JSON
+	result, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format tasks: %v", err)
+	}
+
+	return string(result), nil
+}
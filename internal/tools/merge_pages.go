@@ -0,0 +1,197 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hiroki/scrapbox_mcp/internal/index"
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+type MergePagesTool struct {
+	client   scrapbox.API
+	rewriter *linkRewriter
+}
+
+func NewMergePagesTool(client scrapbox.API, pageIndex *index.Index) *MergePagesTool {
+	return &MergePagesTool{client: client, rewriter: newLinkRewriter(client, pageIndex)}
+}
+
+// mergePagesResponse is the merge_pages result
+type mergePagesResponse struct {
+	Source        string `json:"source"`
+	Target        string `json:"target"`
+	DryRun        bool   `json:"dryRun"`
+	AppendedLines int    `json:"appendedLines"`
+	SourceDeleted bool   `json:"sourceDeleted"`
+	// URL and CommitID identify the target page after the merge
+	URL         string            `json:"url,omitempty"`
+	CommitID    string            `json:"commitId,omitempty"`
+	LinkUpdates []pageReplacement `json:"linkUpdates"`
+}
+
+func (t *MergePagesTool) Name() string {
+	return "merge_pages"
+}
+
+func (t *MergePagesTool) Description() string {
+	return "Merges a source page into a target page: appends the source body to the target, rewrites links to the source so they point at the target, and deletes the source page. Use dry_run to preview."
+}
+
+func (t *MergePagesTool) IsWrite() bool {
+	return true
+}
+
+// DryRun reports whether the call only previews the merge
+func (t *MergePagesTool) DryRun(arguments map[string]interface{}) bool {
+	dryRun, _ := arguments["dry_run"].(bool)
+	return dryRun
+}
+
+// AdminOnly reserves merges for admins: they delete a page and relink others
+func (t *MergePagesTool) AdminOnly() bool {
+	return true
+}
+
+// TargetPages returns both pages and the backlinks of the source
+func (t *MergePagesTool) TargetPages(arguments map[string]interface{}) []string {
+	source, _ := arguments["source"].(string)
+	target, _ := arguments["target"].(string)
+	if dryRun, _ := arguments["dry_run"].(bool); dryRun || source == "" || target == "" {
+		return nil
+	}
+	pages := []string{source, target}
+	if rewrite, ok := arguments["rewrite_links"].(bool); ok && !rewrite {
+		return pages
+	}
+	backlinks, _ := t.rewriter.backlinks(context.Background(), source)
+	for _, backlink := range backlinks {
+		if backlink != source && backlink != target {
+			pages = append(pages, backlink)
+		}
+	}
+	return pages
+}
+
+func (t *MergePagesTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"source": map[string]interface{}{
+				"type":        "string",
+				"description": "The page to merge away",
+			},
+			"target": map[string]interface{}{
+				"type":        "string",
+				"description": "The page that receives the content and links",
+			},
+			"rewrite_links": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Point links to the source at the target (default: true)",
+			},
+			"delete_source": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Delete the source page after merging (default: true)",
+			},
+			"dry_run": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Only report what would change (default: false)",
+			},
+		},
+		"required": []string{"source", "target"},
+	}
+}
+
+func (t *MergePagesTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	source, ok := arguments["source"].(string)
+	if !ok || source == "" {
+		return nil, fmt.Errorf("source is required")
+	}
+	target, ok := arguments["target"].(string)
+	if !ok || target == "" {
+		return nil, fmt.Errorf("target is required")
+	}
+	rewriteLinks := true
+	if rewriteArg, ok := arguments["rewrite_links"].(bool); ok {
+		rewriteLinks = rewriteArg
+	}
+	deleteSource := true
+	if deleteArg, ok := arguments["delete_source"].(bool); ok {
+		deleteSource = deleteArg
+	}
+	dryRun, _ := arguments["dry_run"].(bool)
+
+	project := t.client.DefaultProject()
+	sourcePage, err := t.client.GetPage(project, source)
+	if err != nil {
+		return nil, err
+	}
+	if sourcePage.CommitID == "" {
+		return nil, fmt.Errorf("page not found: %s", source)
+	}
+	targetPage, err := t.client.GetPage(project, target)
+	if err != nil {
+		return nil, err
+	}
+	if targetPage.CommitID == "" {
+		return nil, fmt.Errorf("page not found: %s (use rename_page to move a page to a new title)", target)
+	}
+	if sourcePage.ID == targetPage.ID {
+		return nil, fmt.Errorf("source and target are the same page")
+	}
+
+	// The source title line is dropped; its body goes to the end of the target
+	var body []string
+	for i, line := range sourcePage.Lines {
+		if i > 0 {
+			body = append(body, line.Text)
+		}
+	}
+	response := mergePagesResponse{Source: source, Target: target, DryRun: dryRun, AppendedLines: len(body), LinkUpdates: []pageReplacement{}}
+
+	if !dryRun && len(body) > 0 {
+		written, err := t.client.InsertLines(target, "", body, targetPage.CommitID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to append source content: %w", err)
+		}
+		response.URL, response.CommitID = written.URL, written.CommitID
+	}
+
+	if rewriteLinks {
+		response.LinkUpdates, err = t.rewriter.rewrite(ctx, source, target, source, dryRun)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !dryRun && deleteSource {
+		if _, err := t.client.DeletePage(source, sourcePage.CommitID); err != nil {
+			return nil, fmt.Errorf("merged, but failed to delete the source page: %w", err)
+		}
+		response.SourceDeleted = true
+	}
+
+	// Format the response as JSON
+	result, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format merge result: %v", err)
+	}
+
+	return string(result), nil
+}
+
+// DestructivePreview is the dry run of a merge that deletes the source page
+func (t *MergePagesTool) DestructivePreview(ctx context.Context, arguments map[string]interface{}) (string, bool, error) {
+	if dryRun, _ := arguments["dry_run"].(bool); dryRun {
+		return "", false, nil
+	}
+	if deleteSource, ok := arguments["delete_source"].(bool); ok && !deleteSource {
+		return "", false, nil
+	}
+	preview, destructive, err := dryRunPreview(ctx, t, arguments)
+	if !destructive {
+		return "", false, err
+	}
+	return fmt.Sprintf("The source page '%s' will be deleted.\n%s", arguments["source"], preview), true, nil
+}
@@ -0,0 +1,145 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// Middleware wraps tool execution. It returns a handler that runs next,
+// adding behaviour before or after it; WrapExecute builds such a handler.
+// Middleware applies to every tool call, including calls to tools
+// registered after it was added.
+type Middleware func(next ToolHandler) ToolHandler
+
+// ExecuteFunc is the signature of ToolHandler.Execute
+type ExecuteFunc func(ctx context.Context, arguments map[string]interface{}) (interface{}, error)
+
+// Use adds middleware to the registry. Middleware added first is outermost:
+// it sees the call first and the result last.
+func (r *Registry) Use(middleware ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// WrapExecute returns a handler that behaves like next but executes with
+// execute. The handler still reports whether next is a write tool, which
+// pages it targets, what it would destroy, whether the call is a dry run and
+// whether it or the call is reserved for admins, so inner middleware can rely
+// on IsWriteTool, TargetPages, DestructiveTool, DryRunTool, IsAdminTool and
+// IsAdminCall.
+func WrapExecute(next ToolHandler, execute ExecuteFunc) ToolHandler {
+	return &wrappedTool{ToolHandler: next, execute: execute}
+}
+
+// wrappedTool is the handler returned by WrapExecute
+type wrappedTool struct {
+	ToolHandler
+	execute ExecuteFunc
+}
+
+func (w *wrappedTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	return w.execute(ctx, arguments)
+}
+
+func (w *wrappedTool) IsWrite() bool {
+	return isWriteTool(w.ToolHandler)
+}
+
+func (w *wrappedTool) AdminOnly() bool {
+	return isAdminTool(w.ToolHandler)
+}
+
+func (w *wrappedTool) AdminCall(arguments map[string]interface{}) bool {
+	return isAdminCall(w.ToolHandler, arguments)
+}
+
+func (w *wrappedTool) DryRun(arguments map[string]interface{}) bool {
+	return isDryRun(w.ToolHandler, arguments)
+}
+
+func (w *wrappedTool) TargetPages(arguments map[string]interface{}) []string {
+	return targetPages(w.ToolHandler, arguments)
+}
+
+func (w *wrappedTool) DestructivePreview(ctx context.Context, arguments map[string]interface{}) (string, bool, error) {
+	return destructivePreview(ctx, w.ToolHandler, arguments)
+}
+
+// IsWriteTool reports whether a tool, or the tool wrapped by middleware, modifies Scrapbox pages
+func IsWriteTool(tool ToolHandler) bool {
+	return isWriteTool(tool)
+}
+
+// IsAdminTool reports whether a tool, or the tool wrapped by middleware, is reserved for admins
+func IsAdminTool(tool ToolHandler) bool {
+	return isAdminTool(tool)
+}
+
+// IsAdminCall reports whether a call needs the admin role: the tool is
+// reserved for admins or its arguments are (see AdminArgumentsTool)
+func IsAdminCall(tool ToolHandler, arguments map[string]interface{}) bool {
+	return isAdminCall(tool, arguments)
+}
+
+// TargetPages returns the pages a write tool edits, as snapshotted and audited by the registry
+func TargetPages(tool ToolHandler, arguments map[string]interface{}) []string {
+	return targetPages(tool, arguments)
+}
+
+// handler returns tool wrapped in the registry's middleware. Audit logging
+// is the innermost layer so it records what the tool itself returned, and
+// pages are snapshotted just outside it so calls refused by middleware leave
+// no snapshot.
+func (r *Registry) handler(tool ToolHandler) ToolHandler {
+	r.mu.RLock()
+	middleware := r.middleware
+	r.mu.RUnlock()
+
+	var h ToolHandler = tool
+	if r.auditLogger != nil {
+		h = r.auditMiddleware(h)
+	}
+	if r.undoStore != nil {
+		h = r.snapshotMiddleware(h)
+	}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h
+}
+
+// auditMiddleware records an audit entry for each write tool execution
+func (r *Registry) auditMiddleware(next ToolHandler) ToolHandler {
+	return WrapExecute(next, func(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+		result, err := next.Execute(ctx, arguments)
+		r.recordAudit(ctx, next, arguments, result, err)
+		return result, err
+	})
+}
+
+// snapshotMiddleware saves the pages a write tool targets before it runs.
+// A write whose pages cannot be snapshotted is refused: without a snapshot,
+// revert_last_edit would restore an older one.
+func (r *Registry) snapshotMiddleware(next ToolHandler) ToolHandler {
+	return WrapExecute(next, func(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+		if !isWriteTool(next) || isDryRun(next, arguments) || r.undoClient == nil {
+			return next.Execute(ctx, arguments)
+		}
+		// Writes always go to the backend's project, whatever the arguments
+		// say, so a write naming another project would edit a page other
+		// than the one asked for and snapshotted
+		project := r.undoClient.DefaultProject()
+		if requested, ok := arguments["project"].(string); ok && requested != "" && requested != project {
+			return nil, fmt.Errorf("writes go to project '%s'; project '%s' cannot be written", project, requested)
+		}
+		for _, title := range targetPages(next, arguments) {
+			if err := r.snapshotPage(ctx, project, title); err != nil {
+				log.Printf("[UNDO] Failed to snapshot page %s: %v", title, err)
+				return nil, fmt.Errorf("failed to snapshot page '%s' before writing, so the edit would not be revertible: %w", title, err)
+			}
+		}
+		return next.Execute(ctx, arguments)
+	})
+}
@@ -0,0 +1,286 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+// Periods the page_growth timeline is grouped by
+const (
+	growthByDay   = "day"
+	growthByWeek  = "week"
+	growthByMonth = "month"
+)
+
+// growthDefaultPoints is the default number of timeline entries returned
+const growthDefaultPoints = 52
+
+type PageGrowthTool struct {
+	client  scrapbox.Reader
+	history CommitHistory
+}
+
+func NewPageGrowthTool(client scrapbox.Reader, history CommitHistory) *PageGrowthTool {
+	return &PageGrowthTool{client: client, history: history}
+}
+
+// growthPoint is one period with commits in the page_growth timeline
+type growthPoint struct {
+	Period string `json:"period"`
+	// Lines is the line count at the end of the period
+	Lines        int      `json:"lines"`
+	Added        int      `json:"added"`
+	Removed      int      `json:"removed"`
+	Updated      int      `json:"updated"`
+	Commits      int      `json:"commits"`
+	Contributors []string `json:"contributors"`
+	// NewContributors are the contributors' first edits in the history
+	NewContributors []string `json:"newContributors,omitempty"`
+	// TotalContributors counts everyone who edited the page so far
+	TotalContributors int `json:"totalContributors"`
+}
+
+// growthContributor sums the changes of one user over the history
+type growthContributor struct {
+	Name      string `json:"name"`
+	UserID    string `json:"userId"`
+	Commits   int    `json:"commits"`
+	Added     int    `json:"added"`
+	Removed   int    `json:"removed"`
+	Updated   int    `json:"updated"`
+	FirstEdit string `json:"firstEdit"`
+	LastEdit  string `json:"lastEdit"`
+}
+
+type pageGrowthResponse struct {
+	Title  string `json:"title"`
+	PageID string `json:"pageId"`
+	By     string `json:"by"`
+	// HistoryStart is the time of the oldest commit available
+	HistoryStart string              `json:"historyStart,omitempty"`
+	Commits      int                 `json:"commits"`
+	CurrentLines int                 `json:"currentLines"`
+	PeakLines    int                 `json:"peakLines"`
+	Contributors []growthContributor `json:"contributors"`
+	Truncated    bool                `json:"truncated"`
+	Timeline     []growthPoint       `json:"timeline"`
+}
+
+func (t *PageGrowthTool) Name() string {
+	return "page_growth"
+}
+
+func (t *PageGrowthTool) Description() string {
+	return "Reports how a page grew over time from its commit history: per day, week or month the line count, lines added/removed/updated, commits and contributors (marking first-time contributors), plus per-contributor totals and the peak line count. Use it for documentation health dashboards or to see whether a page is still maintained."
+}
+
+func (t *PageGrowthTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "The title of the page",
+			},
+			"project": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional project name (uses the session default set by set_default_project, or the server default)",
+			},
+			"by": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{growthByDay, growthByWeek, growthByMonth},
+				"description": "Period the timeline is grouped by, in UTC; weeks start on Monday (default: week)",
+			},
+			"since": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional RFC 3339 timestamp or Unix seconds; the timeline starts at this time",
+			},
+			"max_points": map[string]interface{}{
+				"type":        "number",
+				"description": fmt.Sprintf("Maximum number of periods returned, most recent kept (default: %d)", growthDefaultPoints),
+			},
+		},
+		"required": []string{"title"},
+	}
+}
+
+func (t *PageGrowthTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	title, ok := arguments["title"].(string)
+	if !ok || title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+	by := growthByWeek
+	if byArg, ok := arguments["by"].(string); ok && byArg != "" {
+		by = byArg
+	}
+	if by != growthByDay && by != growthByWeek && by != growthByMonth {
+		return nil, fmt.Errorf("invalid by: %s (expected day, week or month)", by)
+	}
+	var since time.Time
+	if sinceArg, ok := arguments["since"].(string); ok && sinceArg != "" {
+		var err error
+		if since, err = parseTimestamp(sinceArg); err != nil {
+			return nil, err
+		}
+	}
+	maxPoints := growthDefaultPoints
+	if n, ok := arguments["max_points"].(float64); ok && n > 0 {
+		maxPoints = int(n)
+	}
+
+	project := resolveProject(ctx, arguments, t.client)
+
+	page, err := t.client.GetPage(project, title)
+	if err != nil {
+		return nil, err
+	}
+	if page.CommitID == "" {
+		return nil, fmt.Errorf("page not found: %s", title)
+	}
+
+	commits, err := t.history.GetPageCommits(project, page.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The history may not reach back to the page's creation, so the line
+	// count is worked out backwards from the current page
+	lines := len(page.Lines)
+	for _, commit := range commits {
+		for _, change := range commit.LineChanges() {
+			switch change.Type {
+			case scrapbox.LineInserted:
+				lines--
+			case scrapbox.LineDeleted:
+				lines++
+			}
+		}
+	}
+
+	users := pageUserNames(page)
+	name := func(userID string) string {
+		if users[userID] != "" {
+			return users[userID]
+		}
+		return userID
+	}
+
+	response := &pageGrowthResponse{
+		Title:        page.Title,
+		PageID:       page.ID,
+		By:           by,
+		Commits:      len(commits),
+		CurrentLines: len(page.Lines),
+		PeakLines:    max(lines, 0),
+		Contributors: []growthContributor{},
+		Timeline:     []growthPoint{},
+	}
+	if len(commits) > 0 {
+		response.HistoryStart = formatUnix(commits[0].Created)
+	}
+
+	contributors := make(map[string]*growthContributor)
+	var point *growthPoint
+	seen := make(map[string]bool)
+	for _, commit := range commits {
+		added, removed, updated := 0, 0, 0
+		for _, change := range commit.LineChanges() {
+			switch change.Type {
+			case scrapbox.LineInserted:
+				added++
+			case scrapbox.LineDeleted:
+				removed++
+			case scrapbox.LineUpdated:
+				updated++
+			}
+		}
+		lines += added - removed
+		response.PeakLines = max(response.PeakLines, lines)
+
+		contributor, ok := contributors[commit.UserID]
+		if !ok {
+			contributor = &growthContributor{Name: name(commit.UserID), UserID: commit.UserID, FirstEdit: formatUnix(commit.Created)}
+			contributors[commit.UserID] = contributor
+		}
+		contributor.Commits++
+		contributor.Added += added
+		contributor.Removed += removed
+		contributor.Updated += updated
+		contributor.LastEdit = formatUnix(commit.Created)
+
+		created := time.Unix(commit.Created, 0)
+		if created.Before(since) {
+			continue
+		}
+		period := growthPeriod(created, by)
+		if point == nil || point.Period != period {
+			response.Timeline = append(response.Timeline, growthPoint{Period: period, Contributors: []string{}})
+			point = &response.Timeline[len(response.Timeline)-1]
+			seen = make(map[string]bool)
+		}
+		point.Lines = lines
+		point.Added += added
+		point.Removed += removed
+		point.Updated += updated
+		point.Commits++
+		if !seen[commit.UserID] {
+			seen[commit.UserID] = true
+			point.Contributors = append(point.Contributors, contributor.Name)
+			if contributor.Commits == 1 {
+				point.NewContributors = append(point.NewContributors, contributor.Name)
+			}
+		}
+		point.TotalContributors = len(contributors)
+	}
+
+	if len(response.Timeline) > maxPoints {
+		response.Timeline = response.Timeline[len(response.Timeline)-maxPoints:]
+		response.Truncated = true
+	}
+	for _, contributor := range contributors {
+		response.Contributors = append(response.Contributors, *contributor)
+	}
+	sort.Slice(response.Contributors, func(i, j int) bool {
+		a, b := response.Contributors[i], response.Contributors[j]
+		if a.Added+a.Updated != b.Added+b.Updated {
+			return a.Added+a.Updated > b.Added+b.Updated
+		}
+		return a.UserID < b.UserID
+	})
+
+	// Format the response as JSON
+	result, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format page growth: %v", err)
+	}
+
+	summary := fmt.Sprintf("'%s' has %d lines (peak %d) after %d commits by %d contributors", page.Title, response.CurrentLines, response.PeakLines, response.Commits, len(response.Contributors))
+	if response.HistoryStart != "" {
+		summary += " since " + response.HistoryStart
+	}
+	return withSummary(summary, string(result)), nil
+}
+
+// growthPeriod returns the label of the period containing t: 2006-01-02 for
+// days and weeks (the Monday starting the week), 2006-01 for months
+func growthPeriod(t time.Time, by string) string {
+	t = t.UTC()
+	switch by {
+	case growthByMonth:
+		return t.Format("2006-01")
+	case growthByWeek:
+		offset := (int(t.Weekday()) + 6) % 7
+		return t.AddDate(0, 0, -offset).Format("2006-01-02")
+	default:
+		return t.Format("2006-01-02")
+	}
+}
+
+func formatUnix(unix int64) string {
+	return time.Unix(unix, 0).UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,216 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+// projectStatsPageSize is the page size used to walk the page list (the API maximum)
+const projectStatsPageSize = 1000
+
+type ProjectStatsTool struct {
+	client scrapbox.Reader
+}
+
+func NewProjectStatsTool(client scrapbox.Reader) *ProjectStatsTool {
+	return &ProjectStatsTool{client: client}
+}
+
+// projectStats is the project_stats result
+type projectStats struct {
+	Project         string             `json:"project"`
+	PageCount       int                `json:"pageCount"`
+	ScannedPages    int                `json:"scannedPages"`
+	Days            int                `json:"days"`
+	Since           string             `json:"since"`
+	CreatedInPeriod int                `json:"createdInPeriod"`
+	UpdatedInPeriod int                `json:"updatedInPeriod"`
+	TopContributors []contributorStat  `json:"topContributors"`
+	MostLinked      []pageCountStat    `json:"mostLinked"`
+	MostViewed      []pageCountStat    `json:"mostViewed"`
+	RecentlyCreated []pageTimestampRef `json:"recentlyCreated"`
+}
+
+type contributorStat struct {
+	UserID       string `json:"userId"`
+	Name         string `json:"name,omitempty"`
+	PagesUpdated int    `json:"pagesUpdated"`
+}
+
+type pageCountStat struct {
+	Title string `json:"title"`
+	Count int    `json:"count"`
+}
+
+type pageTimestampRef struct {
+	Title   string `json:"title"`
+	Created string `json:"created"`
+}
+
+func (t *ProjectStatsTool) Name() string {
+	return "project_stats"
+}
+
+func (t *ProjectStatsTool) Description() string {
+	return "Reports project activity: page count, pages created/updated in the last N days, top contributors, most-linked and most-viewed pages."
+}
+
+func (t *ProjectStatsTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"project": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional project name (uses the session default set by set_default_project, or the server default)",
+			},
+			"days": map[string]interface{}{
+				"type":        "number",
+				"description": "Length of the activity period in days (default: 7)",
+			},
+			"top": map[string]interface{}{
+				"type":        "number",
+				"description": "Number of entries in each ranking (default: 10)",
+			},
+			"max_pages": map[string]interface{}{
+				"type":        "number",
+				"description": "Maximum number of pages to scan (default: 10000)",
+			},
+		},
+		"required": []string{},
+	}
+}
+
+func (t *ProjectStatsTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	project := resolveProject(ctx, arguments, t.client)
+
+	days := 7
+	if daysArg, ok := arguments["days"].(float64); ok && daysArg > 0 {
+		days = int(daysArg)
+	}
+
+	top := 10
+	if topArg, ok := arguments["top"].(float64); ok && topArg > 0 {
+		top = int(topArg)
+	}
+
+	maxPages := 10000
+	if maxArg, ok := arguments["max_pages"].(float64); ok && maxArg > 0 {
+		maxPages = int(maxArg)
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	stats := &projectStats{
+		Project: project,
+		Days:    days,
+		Since:   since.UTC().Format(time.RFC3339),
+	}
+
+	var pages []scrapbox.PageInfo
+	for skip := 0; skip < maxPages; skip += projectStatsPageSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		limit := projectStatsPageSize
+		if maxPages-skip < limit {
+			limit = maxPages - skip
+		}
+		resp, err := t.client.ListPages(project, limit, skip)
+		if err != nil {
+			return nil, err
+		}
+		stats.PageCount = resp.Count
+		pages = append(pages, resp.Pages...)
+		if len(resp.Pages) < limit || skip+len(resp.Pages) >= resp.Count {
+			break
+		}
+	}
+	stats.ScannedPages = len(pages)
+
+	sinceUnix := since.Unix()
+	contributors := make(map[string]*contributorStat)
+	var created []scrapbox.PageInfo
+	for _, page := range pages {
+		if page.Created >= sinceUnix {
+			stats.CreatedInPeriod++
+			created = append(created, page)
+		}
+		if page.Updated < sinceUnix {
+			continue
+		}
+		stats.UpdatedInPeriod++
+
+		user := page.LastUpdateUser
+		if user == nil {
+			user = page.User
+		}
+		if user == nil || user.ID == "" {
+			continue
+		}
+		c, ok := contributors[user.ID]
+		if !ok {
+			c = &contributorStat{UserID: user.ID, Name: user.Name}
+			contributors[user.ID] = c
+		}
+		c.PagesUpdated++
+	}
+
+	stats.TopContributors = make([]contributorStat, 0, len(contributors))
+	for _, c := range contributors {
+		stats.TopContributors = append(stats.TopContributors, *c)
+	}
+	sort.Slice(stats.TopContributors, func(i, j int) bool {
+		a, b := stats.TopContributors[i], stats.TopContributors[j]
+		if a.PagesUpdated != b.PagesUpdated {
+			return a.PagesUpdated > b.PagesUpdated
+		}
+		return a.UserID < b.UserID
+	})
+	if len(stats.TopContributors) > top {
+		stats.TopContributors = stats.TopContributors[:top]
+	}
+
+	stats.MostLinked = rankPages(pages, top, func(p scrapbox.PageInfo) int { return p.Linked })
+	stats.MostViewed = rankPages(pages, top, func(p scrapbox.PageInfo) int { return p.Views })
+
+	sort.Slice(created, func(i, j int) bool { return created[i].Created > created[j].Created })
+	stats.RecentlyCreated = make([]pageTimestampRef, 0, top)
+	for i := 0; i < len(created) && i < top; i++ {
+		stats.RecentlyCreated = append(stats.RecentlyCreated, pageTimestampRef{
+			Title:   created[i].Title,
+			Created: time.Unix(created[i].Created, 0).UTC().Format(time.RFC3339),
+		})
+	}
+
+	// Format the response as JSON
+	result, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format project stats: %v", err)
+	}
+
+	return string(result), nil
+}
+
+// rankPages returns the top pages by count, skipping pages with a zero count
+func rankPages(pages []scrapbox.PageInfo, top int, count func(scrapbox.PageInfo) int) []pageCountStat {
+	ranked := make([]pageCountStat, 0, len(pages))
+	for _, page := range pages {
+		if n := count(page); n > 0 {
+			ranked = append(ranked, pageCountStat{Title: page.Title, Count: n})
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Count != ranked[j].Count {
+			return ranked[i].Count > ranked[j].Count
+		}
+		return ranked[i].Title < ranked[j].Title
+	})
+	if len(ranked) > top {
+		ranked = ranked[:top]
+	}
+	return ranked
+}
@@ -2,17 +2,28 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/hiroki/scrapbox_mcp/internal/audit"
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	"github.com/hiroki/scrapbox_mcp/internal/titles"
+	"github.com/hiroki/scrapbox_mcp/internal/undo"
 	mcperrors "github.com/hiroki/scrapbox_mcp/pkg/errors"
 )
 
 // Tool represents a tool definition for MCP
 type Tool struct {
-	Name        string
-	Description string
-	InputSchema map[string]interface{}
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
 }
 
 // ToolCallResult represents the result of a tool execution
@@ -21,10 +32,42 @@ type ToolCallResult struct {
 	IsError bool
 }
 
-// ContentBlock represents a content block in a tool result
+// PartialError is returned by tools that fail after doing part of their
+// work, such as a batch whose operations failed. Result reports what was done
+// and is returned to the client as an isError result; the audit log records
+// the call as failed.
+type PartialError struct {
+	Err    error
+	Result ContentBlocks
+}
+
+func (e *PartialError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PartialError) Unwrap() error {
+	return e.Err
+}
+
+// ContentBlock represents a content block in a tool result.
+// Text blocks set Text; image blocks set Data (base64) and MimeType;
+// resource blocks set Resource. Annotations left nil are filled in by Execute.
 type ContentBlock struct {
-	Type string
-	Text string
+	Type        string
+	Text        string
+	Data        string
+	MimeType    string
+	Resource    *EmbeddedResource
+	Annotations *Annotations
+}
+
+// EmbeddedResource is the resource of an embedded resource content block
+type EmbeddedResource struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	// Blob is base64-encoded binary content, used instead of Text
+	Blob string `json:"blob,omitempty"`
 }
 
 // ToolHandler defines the interface for all MCP tools
@@ -35,81 +78,661 @@ type ToolHandler interface {
 	Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error)
 }
 
-// Registry manages all available tools
+// WriteTool is implemented by tools that modify Scrapbox pages.
+// Executions of write tools are recorded in the audit log and the
+// target page is snapshotted beforehand so the edit can be reverted.
+type WriteTool interface {
+	ToolHandler
+	IsWrite() bool
+}
+
+// PageTargeter is implemented by write tools whose target pages are not
+// given by the title argument (e.g. batch_edit). Every returned page is
+// snapshotted and named in the audit entry.
+type PageTargeter interface {
+	TargetPages(arguments map[string]interface{}) []string
+}
+
+// DryRunTool is implemented by write tools with a dry run mode. Dry runs
+// change nothing, so they are neither snapshotted nor audited.
+type DryRunTool interface {
+	DryRun(arguments map[string]interface{}) bool
+}
+
+// AdminTool is implemented by tools reserved for the admin role: writes
+// across the whole project and server operations such as backups
+type AdminTool interface {
+	AdminOnly() bool
+}
+
+// AdminArgumentsTool is implemented by tools open to every role whose calls
+// need the admin role with certain arguments, such as requesting every URL
+// of the project or writing files on the server
+type AdminArgumentsTool interface {
+	AdminCall(arguments map[string]interface{}) bool
+}
+
+// SecretTool is implemented by tools taking secrets (e.g. session cookies)
+// as arguments. Their values are held as secrets from before the call is
+// logged until it returns, so logs and captures redact them.
+type SecretTool interface {
+	SecretArguments() []string
+}
+
+// Registry manages all available tools.
+// It is safe for concurrent use; tools may be registered, removed, enabled
+// or disabled at runtime and change listeners are notified.
 type Registry struct {
-	tools map[string]ToolHandler
+	mu          sync.RWMutex
+	tools       map[string]ToolHandler
+	disabled    map[string]bool
+	listeners   []func()
+	inflight    sync.WaitGroup
+	timeout     time.Duration
+	maxResponse int
+	responses   *responseCache
+	auditLogger *audit.Logger
+	auditUser   string
+	undoStore   *undo.Store
+	undoClient  scrapbox.Reader
+	resolver    *titles.Resolver
+	titleClient scrapbox.Reader
+	scopeClient scrapbox.Reader
+	middleware  []Middleware
+	confirmTTL  time.Duration
 }
 
 // NewRegistry creates a new tool registry
 func NewRegistry() *Registry {
 	return &Registry{
-		tools: make(map[string]ToolHandler),
+		tools:     make(map[string]ToolHandler),
+		disabled:  make(map[string]bool),
+		responses: newResponseCache(),
 	}
 }
 
 // Register adds a tool to the registry
 func (r *Registry) Register(tool ToolHandler) {
+	r.mu.Lock()
 	r.tools[tool.Name()] = tool
+	r.mu.Unlock()
+
+	r.notifyChange()
+}
+
+// Unregister removes a tool from the registry
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	_, ok := r.tools[name]
+	delete(r.tools, name)
+	delete(r.disabled, name)
+	r.mu.Unlock()
+
+	if ok {
+		r.notifyChange()
+	}
+}
+
+// SetEnabled enables or disables a registered tool.
+// Disabled tools are hidden from List and cannot be executed.
+func (r *Registry) SetEnabled(name string, enabled bool) error {
+	r.mu.Lock()
+	if _, ok := r.tools[name]; !ok {
+		r.mu.Unlock()
+		return fmt.Errorf("tool not found: %s", name)
+	}
+	changed := r.disabled[name] == enabled
+	if enabled {
+		delete(r.disabled, name)
+	} else {
+		r.disabled[name] = true
+	}
+	r.mu.Unlock()
+
+	if changed {
+		r.notifyChange()
+	}
+	return nil
+}
+
+// Status returns every registered tool name mapped to whether it is enabled
+func (r *Registry) Status() map[string]bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	status := make(map[string]bool, len(r.tools))
+	for name := range r.tools {
+		status[name] = !r.disabled[name]
+	}
+	return status
+}
+
+// OnChange registers a function called whenever the set of available tools changes
+func (r *Registry) OnChange(fn func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, fn)
+}
+
+// notifyChange calls all change listeners
+func (r *Registry) notifyChange() {
+	r.mu.RLock()
+	listeners := make([]func(), len(r.listeners))
+	copy(listeners, r.listeners)
+	r.mu.RUnlock()
+
+	for _, fn := range listeners {
+		fn()
+	}
+}
+
+// SetTimeout sets the maximum time a single tool call may take (0 disables the limit)
+func (r *Registry) SetTimeout(timeout time.Duration) {
+	r.timeout = timeout
+}
+
+// SetMaxResponseBytes sets the maximum size of a tool result in bytes (0 disables the limit).
+// Calls may lower the limit with the max_response_bytes argument.
+func (r *Registry) SetMaxResponseBytes(n int) {
+	r.maxResponse = n
+}
+
+// SetAuditLogger enables audit logging of write tool executions
+func (r *Registry) SetAuditLogger(logger *audit.Logger) {
+	r.auditLogger = logger
+}
+
+// SetAuditUser names the Scrapbox user the registry's tools write as in its
+// audit entries, so writes of different credentials can be told apart
+func (r *Registry) SetAuditUser(user string) {
+	r.auditUser = user
+}
+
+// SetUndoStore enables snapshotting of pages before write tool executions.
+// client is used to fetch the current page content.
+func (r *Registry) SetUndoStore(store *undo.Store, client scrapbox.Reader) {
+	r.undoStore = store
+	r.undoClient = client
+}
+
+// SetTitleResolver makes every tool resolve its title argument to an existing
+// page (full-width, case, spacing and alias variants). client supplies the
+// default project.
+func (r *Registry) SetTitleResolver(resolver *titles.Resolver, client scrapbox.Reader) {
+	r.resolver = resolver
+	r.titleClient = client
 }
 
 // Get retrieves a tool by name
 func (r *Registry) Get(name string) (ToolHandler, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	tool, ok := r.tools[name]
-	if !ok {
+	if !ok || r.disabled[name] {
 		return nil, fmt.Errorf("tool not found: %s", name)
 	}
 	return tool, nil
 }
 
-// List returns all registered tools
+// List returns all enabled tools sorted by name
 func (r *Registry) List() []Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	tools := make([]Tool, 0, len(r.tools))
-	for _, handler := range r.tools {
+	for name, handler := range r.tools {
+		if r.disabled[name] {
+			continue
+		}
+		schema := withResponseLimitProperties(handler.InputSchema())
+		if r.confirmTTL > 0 && isDestructiveTool(handler) {
+			schema = withProperties(schema, confirmationProperties())
+		}
 		tools = append(tools, Tool{
 			Name:        handler.Name(),
 			Description: handler.Description(),
-			InputSchema: handler.InputSchema(),
+			InputSchema: schema,
 		})
 	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
 	return tools
 }
 
-// Execute runs a tool with the given arguments
+// Wait blocks until all in-flight tool executions have completed or ctx is done
+func (r *Registry) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		r.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Execute runs a tool with the given arguments. Failures of the tool itself,
+// including timeouts, are returned as results with IsError set; an error is
+// returned only for calls that cannot be dispatched (unknown tool, invalid
+// registry arguments), which become JSON-RPC errors.
 func (r *Registry) Execute(ctx context.Context, name string, arguments map[string]interface{}) (*ToolCallResult, error) {
-	log.Printf("[TOOL] Executing tool: %s, arguments: %v", name, arguments)
+	r.inflight.Add(1)
+	defer r.inflight.Done()
 
 	tool, err := r.Get(name)
+	if err == nil {
+		defer holdSecretArguments(tool, arguments)()
+	}
+	log.Printf("[TOOL] Executing tool: %s, arguments: %v", name, arguments)
 	if err != nil {
 		log.Printf("[TOOL] Tool not found: %s", name)
-		return &ToolCallResult{
-			Content: []ContentBlock{{
-				Type: "text",
-				Text: fmt.Sprintf("Tool not found: %s", name),
-			}},
-			IsError: true,
-		}, mcperrors.NewMCPError(mcperrors.ErrCodeMethodNotFound, "Tool not found", map[string]string{"tool": name})
+		return nil, mcperrors.NewMCPError(mcperrors.ErrCodeMethodNotFound, "Tool not found",
+			mcperrors.NewErrorData(mcperrors.ErrCodeToolNotFound, err))
 	}
 
-	result, err := tool.Execute(ctx, arguments)
+	// Continue a truncated result without running the tool again
+	if cursor, ok := arguments[argCursor].(string); ok && cursor != "" {
+		return r.continueResponse(name, cursor, arguments)
+	}
+
+	limit, err := r.responseLimit(arguments)
+	if err != nil {
+		return invalidParamsError(err)
+	}
+
+	if err := r.checkProjectScope(ctx, tool, arguments); err != nil {
+		log.Printf("[TOOL] Project scope rejected %s: %v", name, err)
+		data := mcperrors.NewErrorData(mcperrors.ErrCodeTool, err)
+		return errorResult(fmt.Sprintf("Tool execution failed: %v", err), data), nil
+	}
+
+	arguments, err = r.resolveTitle(ctx, tool, arguments)
+	if err != nil {
+		log.Printf("[TOOL] Title resolution failed: %s, error: %v", name, err)
+		data := mcperrors.NewErrorData(mcperrors.ErrCodeTool, err)
+		return errorResult(mcperrors.Redact(fmt.Sprintf("Tool execution failed: %v", err)), data), nil
+	}
+
+	if isWriteTool(tool) {
+		ctx = WithOperationID(ctx, uuid.New().String())
+	}
+
+	result, err := r.runTool(ctx, tool, arguments)
+	if errors.Is(err, errToolTimeout) {
+		log.Printf("[TOOL] Tool execution timed out: %s after %s", name, r.timeout)
+		data := mcperrors.NewErrorData(mcperrors.ErrCodeToolTimedOut, fmt.Errorf("tool execution timed out after %s", r.timeout))
+		return errorResult(fmt.Sprintf("Tool execution timed out after %s", r.timeout), data), nil
+	}
+	var partial *PartialError
+	if errors.As(err, &partial) {
+		log.Printf("[TOOL] Tool execution failed partway: %s, error: %v", name, err)
+		return &ToolCallResult{Content: r.limitBlocks(name, partial.Result, limit), IsError: true}, nil
+	}
 	if err != nil {
 		log.Printf("[TOOL] Tool execution failed: %s, error: %v", name, err)
-		return &ToolCallResult{
-			Content: []ContentBlock{{
-				Type: "text",
-				Text: fmt.Sprintf("Tool execution failed: %v", err),
-			}},
-			IsError: true,
-		}, mcperrors.NewMCPError(mcperrors.ErrCodeToolExecutionErr, "Tool execution failed", map[string]string{"error": err.Error()})
+		data := mcperrors.NewErrorData(mcperrors.ErrCodeTool, err)
+		return errorResult(mcperrors.Redact(fmt.Sprintf("Tool execution failed: %v", err)), data), nil
 	}
 
 	log.Printf("[TOOL] Tool execution completed: %s", name)
 
-	// Convert result to text content
+	if blocks, ok := result.(ContentBlocks); ok {
+		return &ToolCallResult{Content: r.limitBlocks(name, blocks, limit), IsError: false}, nil
+	}
+
+	// Convert result to text content, annotated before truncation can break its JSON
+	content := []ContentBlock{{Type: "text", Text: fmt.Sprintf("%v", result)}}
+	annotate(content)
+	content[0].Text = r.limitResponse(name, content[0].Text, limit)
+	return &ToolCallResult{Content: content, IsError: false}, nil
+}
+
+// limitBlocks annotates blocks and applies the response limit to their payload
+func (r *Registry) limitBlocks(name string, blocks ContentBlocks, limit int) []ContentBlock {
+	annotate(blocks)
+	payload := payloadIndex(blocks)
+	content := make([]ContentBlock, 0, len(blocks))
+	for i, block := range blocks {
+		switch {
+		case i == payload:
+			block.Text = r.limitResponse(name, block.Text, limit)
+		case block.Resource != nil && limit > 0 && len(block.Resource.Text)+len(block.Resource.Blob) > limit:
+			// Embedded copies would defeat the response limit; use the cursor instead
+			continue
+		}
+		content = append(content, block)
+	}
+	return content
+}
+
+// responseLimit returns the effective response size limit for a call
+func (r *Registry) responseLimit(arguments map[string]interface{}) (int, error) {
+	limit, err := parseMaxResponseBytes(arguments)
+	if err != nil {
+		return 0, err
+	}
+	if r.maxResponse > 0 && (limit == 0 || limit > r.maxResponse) {
+		limit = r.maxResponse
+	}
+	return limit, nil
+}
+
+// limitResponse truncates text to limit bytes, caching the full text so the
+// rest can be fetched with the returned cursor
+func (r *Registry) limitResponse(name, text string, limit int) string {
+	if limit <= 0 || len(text) <= limit {
+		return text
+	}
+	id := r.responses.store(name, text)
+	return r.responseChunk(id, text, 0, limit)
+}
+
+// responseChunk returns the chunk of text starting at offset, with a
+// continuation notice if more remains
+func (r *Registry) responseChunk(id, text string, offset, limit int) string {
+	if limit <= 0 {
+		return text[offset:]
+	}
+	chunk, n := truncateText(text[offset:], limit)
+	end := offset + n
+	if end >= len(text) {
+		return chunk
+	}
+	return chunk + truncationNotice(offset, end, len(text), encodeCursor(id, end))
+}
+
+// continueResponse returns the next chunk of a previously truncated result
+func (r *Registry) continueResponse(name, cursor string, arguments map[string]interface{}) (*ToolCallResult, error) {
+	limit, err := r.responseLimit(arguments)
+	if err != nil {
+		return invalidParamsError(err)
+	}
+	id, offset, err := decodeCursor(cursor)
+	if err != nil {
+		return invalidParamsError(err)
+	}
+	text, ok := r.responses.load(name, id)
+	if !ok || offset > len(text) {
+		return invalidParamsError(fmt.Errorf("cursor expired or not found: %s", cursor))
+	}
+
+	log.Printf("[TOOL] Continuing truncated result: %s, offset: %d", name, offset)
 	return &ToolCallResult{
 		Content: []ContentBlock{{
-			Type: "text",
-			Text: fmt.Sprintf("%v", result),
+			Type:        "text",
+			Text:        r.responseChunk(id, text, offset, limit),
+			Annotations: forAssistant(0.5),
 		}},
 		IsError: false,
 	}, nil
 }
+
+// invalidParamsError builds the protocol error for call arguments the
+// registry itself rejects (response limits and cursors)
+func invalidParamsError(err error) (*ToolCallResult, error) {
+	return nil, mcperrors.NewMCPError(mcperrors.ErrCodeInvalidParams, err.Error(),
+		mcperrors.NewErrorData(mcperrors.ErrCodeInvalidArguments, err))
+}
+
+// errorResult builds an isError result: the message for people, followed by
+// the error data as JSON for agents
+func errorResult(message string, data *mcperrors.ErrorData) *ToolCallResult {
+	content := []ContentBlock{{Type: "text", Text: message, Annotations: forUser()}}
+	if payload, err := json.Marshal(map[string]interface{}{"error": data}); err == nil {
+		content = append(content, ContentBlock{Type: "text", Text: string(payload), Annotations: forAssistant(0.8)})
+	}
+	return &ToolCallResult{Content: content, IsError: true}
+}
+
+// errToolTimeout is returned by runTool when a tool exceeds the registry timeout
+var errToolTimeout = errors.New("tool execution timed out")
+
+// runTool executes the tool through its middleware, giving up after the
+// configured timeout. A timed-out tool keeps running in the background (and is
+// still tracked for graceful shutdown) because Scrapbox commits cannot be aborted
+// midway; its audit entry reflects the real outcome.
+func (r *Registry) runTool(ctx context.Context, tool ToolHandler, arguments map[string]interface{}) (interface{}, error) {
+	handler := r.handler(tool)
+	if r.timeout <= 0 {
+		return handler.Execute(ctx, arguments)
+	}
+
+	toolCtx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	type outcome struct {
+		result interface{}
+		err    error
+	}
+	done := make(chan outcome, 1)
+
+	r.inflight.Add(1)
+	go func() {
+		defer r.inflight.Done()
+		result, err := handler.Execute(toolCtx, arguments)
+		done <- outcome{result: result, err: err}
+	}()
+
+	select {
+	case o := <-done:
+		return o.result, o.err
+	case <-toolCtx.Done():
+		// Only the tool's own deadline is a timeout; a request cancelled by
+		// the client (or past its own deadline) reports why it ended
+		if errors.Is(toolCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+			return nil, errToolTimeout
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// recordAudit writes an audit entry if the call modifies pages: a write tool
+// called without dry_run
+func (r *Registry) recordAudit(ctx context.Context, tool ToolHandler, arguments map[string]interface{}, result interface{}, execErr error) {
+	if !isWriteTool(tool) || isDryRun(tool, arguments) {
+		return
+	}
+
+	entry := &audit.Entry{
+		ID:        OperationIDFromContext(ctx),
+		SessionID: SessionIDFromContext(ctx),
+		User:      r.auditUser,
+		Tool:      tool.Name(),
+		ArgsHash:  audit.HashArguments(arguments),
+		Result:    audit.ResultSuccess,
+	}
+	entry.Page = strings.Join(targetPages(tool, arguments), ", ")
+	if execErr != nil {
+		entry.Result = audit.ResultError
+		entry.Error = mcperrors.Redact(execErr.Error())
+	} else if result != nil {
+		entry.Summary = resultSummary(result)
+	}
+
+	if err := r.auditLogger.Record(entry); err != nil {
+		log.Printf("[AUDIT] Failed to record entry for %s: %v", tool.Name(), err)
+	}
+}
+
+// resultSummary is the first line of a tool result (of its first text block
+// for ContentBlocks), used as the audited change summary
+func resultSummary(result interface{}) string {
+	text := fmt.Sprintf("%v", result)
+	if blocks, ok := result.(ContentBlocks); ok {
+		text = ""
+		for _, block := range blocks {
+			if block.Type == "text" {
+				text = block.Text
+				break
+			}
+		}
+	}
+	return strings.SplitN(text, "\n", 2)[0]
+}
+
+// EnforceProjectScope makes Execute reject calls that use a project outside
+// the scope carried by the context (see WithProjectScope). client supplies
+// the default project, which is also where writes always go.
+func (r *Registry) EnforceProjectScope(client scrapbox.Reader) {
+	r.scopeClient = client
+}
+
+// checkProjectScope returns an error if the call uses a project outside its scope
+func (r *Registry) checkProjectScope(ctx context.Context, tool ToolHandler, arguments map[string]interface{}) error {
+	allowed, ok := projectScopeFromContext(ctx)
+	if !ok || r.scopeClient == nil {
+		return nil
+	}
+
+	projects := []string{resolveProject(ctx, arguments, r.scopeClient)}
+	if isWriteTool(tool) {
+		// Writes always go to the backend's project, whatever the arguments say
+		projects = append(projects, r.scopeClient.DefaultProject())
+	}
+	for _, project := range projects {
+		if !projectAllowed(allowed, project) {
+			granted := "none"
+			if len(allowed) > 0 {
+				granted = strings.Join(allowed, ", ")
+			}
+			return mcperrors.NewToolError(mcperrors.ErrCodeOutOfScope,
+				fmt.Sprintf("project %q is outside the client's roots (granted: %s)", project, granted), false)
+		}
+	}
+	return nil
+}
+
+// projectAllowed reports whether project is in allowed
+func projectAllowed(allowed []string, project string) bool {
+	for _, p := range allowed {
+		if p == project {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveTitle returns arguments with the title argument replaced by the
+// existing page it refers to. When the title of a write tool matches several
+// pages and the client supports elicitation, the user picks one instead.
+// The caller's map is not modified.
+func (r *Registry) resolveTitle(ctx context.Context, tool ToolHandler, arguments map[string]interface{}) (map[string]interface{}, error) {
+	if r.resolver == nil {
+		return arguments, nil
+	}
+	title, ok := arguments["title"].(string)
+	if !ok || title == "" {
+		return arguments, nil
+	}
+
+	project := resolveProject(ctx, arguments, r.titleClient)
+	resolved := r.resolver.Resolve(ctx, project, title)
+	if elicit, ok := elicitFromContext(ctx); ok && isWriteTool(tool) {
+		if matches := r.resolver.Matches(ctx, project, title); len(matches) > 1 {
+			chosen, err := chooseTitle(ctx, elicit, tool.Name(), title, matches)
+			if err != nil {
+				return nil, err
+			}
+			resolved = chosen
+		}
+	}
+	if resolved == title {
+		return arguments, nil
+	}
+	log.Printf("[TOOL] Resolved title %q to %q", title, resolved)
+
+	copied := make(map[string]interface{}, len(arguments))
+	for k, v := range arguments {
+		copied[k] = v
+	}
+	copied["title"] = resolved
+	return copied, nil
+}
+
+// snapshotPage saves the current content of a page of project targeted by a
+// write tool
+func (r *Registry) snapshotPage(ctx context.Context, project, title string) error {
+	snapshot := &undo.Snapshot{
+		ID:      OperationIDFromContext(ctx),
+		Project: project,
+		Page:    title,
+	}
+
+	page, err := r.undoClient.GetPage(project, title)
+	if err != nil {
+		var sbErr *mcperrors.ScrapboxError
+		if !errors.As(err, &sbErr) || sbErr.Code != mcperrors.ErrCodeNotFound {
+			return err
+		}
+	} else if page.CommitID != "" {
+		// Scrapbox returns page info without a commit ID for pages that do not exist yet
+		snapshot.Existed = true
+		snapshot.CommitID = page.CommitID
+		snapshot.Lines = make([]string, 0, len(page.Lines))
+		for _, line := range page.Lines {
+			snapshot.Lines = append(snapshot.Lines, line.Text)
+		}
+	}
+
+	return r.undoStore.Save(snapshot)
+}
+
+// targetPages returns the pages a write tool edits: those reported by a
+// PageTargeter, or else the title argument
+func targetPages(tool ToolHandler, arguments map[string]interface{}) []string {
+	if targeter, ok := tool.(PageTargeter); ok {
+		return targeter.TargetPages(arguments)
+	}
+	if title, ok := arguments["title"].(string); ok && title != "" {
+		return []string{title}
+	}
+	return nil
+}
+
+// holdSecretArguments holds the secret arguments of a call for redaction
+// until the returned function is called at the end of the call
+func holdSecretArguments(tool ToolHandler, arguments map[string]interface{}) func() {
+	secretTool, ok := tool.(SecretTool)
+	if !ok {
+		return func() {}
+	}
+	var releases []func()
+	for _, name := range secretTool.SecretArguments() {
+		if value, ok := arguments[name].(string); ok {
+			releases = append(releases, mcperrors.HoldSecret(value))
+		}
+	}
+	return func() {
+		for _, release := range releases {
+			release()
+		}
+	}
+}
+
+// isWriteTool reports whether the tool modifies Scrapbox pages
+func isWriteTool(tool ToolHandler) bool {
+	wt, ok := tool.(WriteTool)
+	return ok && wt.IsWrite()
+}
+
+func isAdminTool(tool ToolHandler) bool {
+	at, ok := tool.(AdminTool)
+	return ok && at.AdminOnly()
+}
+
+func isAdminCall(tool ToolHandler, arguments map[string]interface{}) bool {
+	if isAdminTool(tool) {
+		return true
+	}
+	at, ok := tool.(AdminArgumentsTool)
+	return ok && at.AdminCall(arguments)
+}
+
+func isDryRun(tool ToolHandler, arguments map[string]interface{}) bool {
+	dt, ok := tool.(DryRunTool)
+	return ok && dt.DryRun(arguments)
+}
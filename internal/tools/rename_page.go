@@ -0,0 +1,204 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hiroki/scrapbox_mcp/internal/index"
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+type RenamePageTool struct {
+	client   scrapbox.API
+	rewriter *linkRewriter
+}
+
+func NewRenamePageTool(client scrapbox.API, pageIndex *index.Index) *RenamePageTool {
+	return &RenamePageTool{client: client, rewriter: newLinkRewriter(client, pageIndex)}
+}
+
+// renamePageResponse is the rename_page result
+type renamePageResponse struct {
+	Title    string `json:"title"`
+	NewTitle string `json:"newTitle"`
+	DryRun   bool   `json:"dryRun"`
+	Renamed  bool   `json:"renamed"`
+	// URL and CommitID identify the renamed page
+	URL      string `json:"url,omitempty"`
+	CommitID string `json:"commitId,omitempty"`
+	// SelfLinks counts the page's links to itself rewritten with the rename
+	SelfLinks   int               `json:"selfLinks,omitempty"`
+	LinkUpdates []pageReplacement `json:"linkUpdates"`
+}
+
+func (t *RenamePageTool) Name() string {
+	return "rename_page"
+}
+
+func (t *RenamePageTool) Description() string {
+	return "Renames a page and rewrites the [links] and #tags that point to it on other pages so backlinks are not orphaned. Use dry_run to preview the link changes. Fails if a page with the new title already exists (use merge_pages instead)."
+}
+
+func (t *RenamePageTool) IsWrite() bool {
+	return true
+}
+
+// DryRun reports whether the call only previews the rename
+func (t *RenamePageTool) DryRun(arguments map[string]interface{}) bool {
+	dryRun, _ := arguments["dry_run"].(bool)
+	return dryRun
+}
+
+// AdminOnly reserves renames for admins, since they rewrite links on other pages
+func (t *RenamePageTool) AdminOnly() bool {
+	return true
+}
+
+// TargetPages returns the page and its backlinks
+func (t *RenamePageTool) TargetPages(arguments map[string]interface{}) []string {
+	title, _ := arguments["title"].(string)
+	if dryRun, _ := arguments["dry_run"].(bool); dryRun || title == "" {
+		return nil
+	}
+	pages := []string{title}
+	if rewrite, ok := arguments["rewrite_links"].(bool); ok && !rewrite {
+		return pages
+	}
+	backlinks, _ := t.rewriter.backlinks(context.Background(), title)
+	for _, backlink := range backlinks {
+		if backlink != title {
+			pages = append(pages, backlink)
+		}
+	}
+	return pages
+}
+
+func (t *RenamePageTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "The current title of the page",
+			},
+			"new_title": map[string]interface{}{
+				"type":        "string",
+				"description": "The new title",
+			},
+			"rewrite_links": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Rewrite links to the page on other pages (default: true)",
+			},
+			"dry_run": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Only report the link changes without renaming (default: false)",
+			},
+		},
+		"required": []string{"title", "new_title"},
+	}
+}
+
+func (t *RenamePageTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	title, ok := arguments["title"].(string)
+	if !ok || title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+	newTitle, ok := arguments["new_title"].(string)
+	if !ok || newTitle == "" {
+		return nil, fmt.Errorf("new_title is required")
+	}
+	if newTitle == title {
+		return nil, fmt.Errorf("new_title is the same as title")
+	}
+	rewriteLinks := true
+	if rewriteArg, ok := arguments["rewrite_links"].(bool); ok {
+		rewriteLinks = rewriteArg
+	}
+	dryRun, _ := arguments["dry_run"].(bool)
+
+	project := t.client.DefaultProject()
+	page, err := t.client.GetPage(project, title)
+	if err != nil {
+		return nil, err
+	}
+	if page.CommitID == "" {
+		return nil, fmt.Errorf("page not found: %s", title)
+	}
+	// A case-only rename resolves to the same page
+	existing, err := t.client.GetPage(project, newTitle)
+	if err != nil {
+		return nil, err
+	}
+	if existing.CommitID != "" && existing.ID != page.ID {
+		return nil, fmt.Errorf("a page titled '%s' already exists; use merge_pages to combine them", existing.Title)
+	}
+
+	response := renamePageResponse{Title: title, NewTitle: newTitle, DryRun: dryRun, LinkUpdates: []pageReplacement{}}
+
+	if dryRun {
+		if rewriteLinks {
+			response.LinkUpdates, err = t.rewriter.rewrite(ctx, title, newTitle, "", true)
+			if err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		// Rename first: if it fails, no backlink has been pointed at a page
+		// that does not exist. The page's own links to itself are rewritten
+		// in the same commit.
+		written, selfLinks, err := t.renameTitleLine(title, newTitle, rewriteLinks)
+		if err != nil {
+			return nil, fmt.Errorf("failed to rename page: %w", err)
+		}
+		response.Renamed = true
+		response.SelfLinks = selfLinks
+		response.URL, response.CommitID = written.URL, written.CommitID
+
+		if rewriteLinks {
+			// The index may still list the renamed page under its old title
+			response.LinkUpdates, err = t.rewriter.rewrite(ctx, title, newTitle, title, false)
+			if err != nil {
+				return nil, renamePartialError(response, fmt.Errorf("renamed page '%s' to '%s' but failed to rewrite its backlinks: %w", title, newTitle, err))
+			}
+		}
+	}
+
+	// Format the response as JSON
+	result, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format rename result: %v", err)
+	}
+
+	return string(result), nil
+}
+
+// renameTitleLine replaces the first line of the page with newTitle and, with
+// rewriteLinks, its links to itself. It returns the number of self-links
+// rewritten.
+func (t *RenamePageTool) renameTitleLine(title, newTitle string, rewriteLinks bool) (*scrapbox.WriteResult, int, error) {
+	project := t.client.DefaultProject()
+	page, err := t.client.GetPage(project, title)
+	if err != nil {
+		return nil, 0, err
+	}
+	newTexts, selfLinks := retitledLines(page, title, newTitle, project, rewriteLinks)
+	written, err := t.client.PatchPage(title, newTexts, page.CommitID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return written, selfLinks, nil
+}
+
+// DestructivePreview is the dry run of a rename, which retitles the page and
+// rewrites the links to it on other pages
+func (t *RenamePageTool) DestructivePreview(ctx context.Context, arguments map[string]interface{}) (string, bool, error) {
+	if t.DryRun(arguments) {
+		return "", false, nil
+	}
+	preview, destructive, err := dryRunPreview(ctx, t, arguments)
+	if !destructive {
+		return "", false, err
+	}
+	return fmt.Sprintf("The page '%s' will be renamed to '%s'.\n%s", arguments["title"], arguments["new_title"], preview), true, nil
+}
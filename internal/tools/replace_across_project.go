@@ -0,0 +1,356 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/hiroki/scrapbox_mcp/internal/index"
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+const (
+	// replaceDefaultMaxPages caps the pages changed by one call
+	replaceDefaultMaxPages = 50
+	// replacePreviewLines caps the changed lines shown per page
+	replacePreviewLines = 5
+)
+
+type ReplaceAcrossProjectTool struct {
+	client scrapbox.API
+	index  *index.Index
+}
+
+func NewReplaceAcrossProjectTool(client scrapbox.API, pageIndex *index.Index) *ReplaceAcrossProjectTool {
+	return &ReplaceAcrossProjectTool{client: client, index: pageIndex}
+}
+
+// replaceRequest is the parsed replace_across_project arguments
+type replaceRequest struct {
+	pattern     *regexp.Regexp
+	replacement string
+	titleRe     *regexp.Regexp
+	maxPages    int
+	dryRun      bool
+}
+
+// lineChange is one replaced line
+type lineChange struct {
+	Line   int    `json:"line"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// pageReplacement is the outcome for one page
+type pageReplacement struct {
+	Title        string       `json:"title"`
+	ChangedLines int          `json:"changedLines"`
+	Preview      []lineChange `json:"preview"`
+	Status       string       `json:"status,omitempty"`
+	Error        string       `json:"error,omitempty"`
+	// URL and CommitID identify a changed page after the write
+	URL      string `json:"url,omitempty"`
+	CommitID string `json:"commitId,omitempty"`
+}
+
+// replaceResponse is the replace_across_project result
+type replaceResponse struct {
+	DryRun       bool              `json:"dryRun"`
+	MatchedPages int               `json:"matchedPages"`
+	ChangedPages int               `json:"changedPages"`
+	ChangedLines int               `json:"changedLines"`
+	Truncated    bool              `json:"truncated"`
+	Pages        []pageReplacement `json:"pages"`
+}
+
+func (t *ReplaceAcrossProjectTool) Name() string {
+	return "replace_across_project"
+}
+
+func (t *ReplaceAcrossProjectTool) Description() string {
+	return "Finds a string or regular expression in every page and replaces it. Runs as a dry run by default and previews the affected pages and lines; pass dry_run=false to apply the changes page by page. Title lines are never changed."
+}
+
+func (t *ReplaceAcrossProjectTool) IsWrite() bool {
+	return true
+}
+
+// DryRun reports whether the call only previews the replacement, as it does
+// unless dry_run is false
+func (t *ReplaceAcrossProjectTool) DryRun(arguments map[string]interface{}) bool {
+	req, err := parseReplaceRequest(arguments)
+	return err == nil && req.dryRun
+}
+
+// AdminOnly reserves project-wide replacements for admins
+func (t *ReplaceAcrossProjectTool) AdminOnly() bool {
+	return true
+}
+
+// TargetPages returns the pages a non-dry run will change, according to the
+// local index, so they can be snapshotted
+func (t *ReplaceAcrossProjectTool) TargetPages(arguments map[string]interface{}) []string {
+	req, err := parseReplaceRequest(arguments)
+	if err != nil || req.dryRun {
+		return nil
+	}
+	pages, err := t.index.Pages(context.Background(), t.client.DefaultProject())
+	if err != nil {
+		return nil
+	}
+
+	var titles []string
+	for _, page := range pages {
+		if len(titles) >= req.maxPages {
+			break
+		}
+		if _, changes := req.apply(page.Title, page.Lines); len(changes) > 0 {
+			titles = append(titles, page.Title)
+		}
+	}
+	return titles
+}
+
+func (t *ReplaceAcrossProjectTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"find": map[string]interface{}{
+				"type":        "string",
+				"description": "The text to find (a Go regular expression when regex is true)",
+			},
+			"replace": map[string]interface{}{
+				"type":        "string",
+				"description": "The replacement text. With regex, $1 or ${name} insert capture groups",
+			},
+			"regex": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Treat find as a regular expression (default: false)",
+			},
+			"ignore_case": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Match case-insensitively (default: false)",
+			},
+			"title_pattern": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional regular expression; only pages whose title matches are changed",
+			},
+			"max_pages": map[string]interface{}{
+				"type":        "number",
+				"description": fmt.Sprintf("Maximum number of pages to change (default: %d)", replaceDefaultMaxPages),
+			},
+			"dry_run": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Only preview the changes (default: true)",
+			},
+		},
+		"required": []string{"find", "replace"},
+	}
+}
+
+func (t *ReplaceAcrossProjectTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	req, err := parseReplaceRequest(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	project := t.client.DefaultProject()
+	pages, err := t.index.Pages(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	response := replaceResponse{DryRun: req.dryRun, Pages: []pageReplacement{}}
+	var targets []*index.Page
+	for _, page := range pages {
+		_, changes := req.apply(page.Title, page.Lines)
+		if len(changes) == 0 {
+			continue
+		}
+		response.MatchedPages++
+		if len(targets) >= req.maxPages {
+			response.Truncated = true
+			continue
+		}
+		targets = append(targets, page)
+		if req.dryRun {
+			response.Pages = append(response.Pages, newPageReplacement(page.Title, changes))
+			response.ChangedLines += len(changes)
+		}
+	}
+
+	var cancelled error
+	if !req.dryRun {
+		for i, page := range targets {
+			if err := ctx.Err(); err != nil {
+				// Pages already written stay written; report them with the
+				// rest marked as skipped
+				cancelled = err
+				for _, skipped := range targets[i:] {
+					response.Pages = append(response.Pages, pageReplacement{Title: skipped.Title, Preview: []lineChange{}, Status: "skipped"})
+				}
+				break
+			}
+			reportProgress(ctx, float64(i), float64(len(targets)), fmt.Sprintf("Replacing in %s", page.Title))
+
+			result := t.replaceInPage(page.Title, req)
+			if result.Status == "changed" {
+				response.ChangedPages++
+				response.ChangedLines += result.ChangedLines
+			}
+			response.Pages = append(response.Pages, result)
+		}
+		if cancelled == nil {
+			reportProgress(ctx, float64(len(targets)), float64(len(targets)), "Done")
+		}
+	}
+
+	// Format the response as JSON
+	result, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format replacements: %v", err)
+	}
+
+	var failed []string
+	for _, page := range response.Pages {
+		if page.Status == "error" {
+			failed = append(failed, fmt.Sprintf("'%s'", page.Title))
+		}
+	}
+	// A cancelled call or a failed page fails the call; the result still
+	// lists every page, including those already changed
+	var partial error
+	switch {
+	case cancelled != nil:
+		partial = fmt.Errorf("replacement cancelled with %d of %d pages changed: %w", response.ChangedPages, len(targets), cancelled)
+	case len(failed) > 0:
+		partial = fmt.Errorf("replacement failed on %d of %d pages (%s); %d pages changed", len(failed), len(targets), strings.Join(failed, ", "), response.ChangedPages)
+	}
+	if partial != nil {
+		return nil, &PartialError{Err: partial, Result: withSummary(partial.Error(), string(result))}
+	}
+	return string(result), nil
+}
+
+// replaceInPage applies the replacement to the live page. The commit check
+// keeps a concurrent edit from being overwritten.
+func (t *ReplaceAcrossProjectTool) replaceInPage(title string, req *replaceRequest) pageReplacement {
+	page, err := t.client.GetPage(t.client.DefaultProject(), title)
+	if err != nil {
+		return pageReplacement{Title: title, Preview: []lineChange{}, Status: "error", Error: err.Error()}
+	}
+
+	lines := make([]string, len(page.Lines))
+	for i, line := range page.Lines {
+		lines[i] = line.Text
+	}
+	newTexts, changes := req.apply(title, lines)
+	result := newPageReplacement(title, changes)
+	if len(changes) == 0 {
+		// The index was stale and the text is gone
+		result.Status = "unchanged"
+		return result
+	}
+
+	written, err := t.client.PatchPage(title, newTexts, page.CommitID)
+	if err != nil {
+		log.Printf("[REPLACE] Failed to update %s: %v", title, err)
+		result.Status = "error"
+		result.Error = err.Error()
+		return result
+	}
+	result.changed(written)
+	return result
+}
+
+// changed marks the page as written
+func (r *pageReplacement) changed(written *scrapbox.WriteResult) {
+	r.Status = "changed"
+	r.URL = written.URL
+	r.CommitID = written.CommitID
+}
+
+func newPageReplacement(title string, changes []lineChange) pageReplacement {
+	preview := changes
+	if len(preview) > replacePreviewLines {
+		preview = preview[:replacePreviewLines]
+	}
+	return pageReplacement{Title: title, ChangedLines: len(changes), Preview: preview}
+}
+
+// apply returns the page lines with the replacement applied and the changed
+// lines. The title line (line 0) is left alone.
+func (req *replaceRequest) apply(title string, lines []string) ([]string, []lineChange) {
+	if req.titleRe != nil && !req.titleRe.MatchString(title) {
+		return lines, nil
+	}
+
+	var changes []lineChange
+	newTexts := make([]string, len(lines))
+	for i, line := range lines {
+		newTexts[i] = line
+		if i == 0 {
+			continue
+		}
+		replaced := req.pattern.ReplaceAllString(line, req.replacement)
+		if replaced != line {
+			newTexts[i] = replaced
+			changes = append(changes, lineChange{Line: i, Before: line, After: replaced})
+		}
+	}
+	return newTexts, changes
+}
+
+func parseReplaceRequest(arguments map[string]interface{}) (*replaceRequest, error) {
+	find, ok := arguments["find"].(string)
+	if !ok || find == "" {
+		return nil, fmt.Errorf("find is required")
+	}
+	replacement, ok := arguments["replace"].(string)
+	if !ok {
+		return nil, fmt.Errorf("replace is required (use an empty string to delete matches)")
+	}
+
+	useRegex, _ := arguments["regex"].(bool)
+	ignoreCase, _ := arguments["ignore_case"].(bool)
+	if !useRegex {
+		find = regexp.QuoteMeta(find)
+		// Keep $ in the replacement literal
+		replacement = strings.ReplaceAll(replacement, "$", "$$")
+	}
+	pattern, err := compileGrepPattern(find, ignoreCase)
+	if err != nil {
+		return nil, fmt.Errorf("invalid find pattern: %v", err)
+	}
+
+	req := &replaceRequest{
+		pattern:     pattern,
+		replacement: replacement,
+		maxPages:    replaceDefaultMaxPages,
+		dryRun:      true,
+	}
+	if titlePattern, ok := arguments["title_pattern"].(string); ok && titlePattern != "" {
+		req.titleRe, err = regexp.Compile(titlePattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid title_pattern: %v", err)
+		}
+	}
+	if maxArg, ok := arguments["max_pages"].(float64); ok && maxArg > 0 {
+		req.maxPages = int(maxArg)
+	}
+	if dryRunArg, ok := arguments["dry_run"].(bool); ok {
+		req.dryRun = dryRunArg
+	}
+	return req, nil
+}
+
+// DestructivePreview is the dry run of a call that applies its changes
+func (t *ReplaceAcrossProjectTool) DestructivePreview(ctx context.Context, arguments map[string]interface{}) (string, bool, error) {
+	if dryRun, ok := arguments["dry_run"].(bool); !ok || dryRun {
+		return "", false, nil
+	}
+	return dryRunPreview(ctx, t, arguments)
+}
@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	"github.com/hiroki/scrapbox_mcp/internal/undo"
+)
+
+type RevertLastEditTool struct {
+	client scrapbox.API
+	store  *undo.Store
+}
+
+func NewRevertLastEditTool(client scrapbox.API, store *undo.Store) *RevertLastEditTool {
+	return &RevertLastEditTool{
+		client: client,
+		store:  store,
+	}
+}
+
+func (t *RevertLastEditTool) Name() string {
+	return "revert_last_edit"
+}
+
+func (t *RevertLastEditTool) Description() string {
+	return "Restores a page to the content it had before the most recent edit made through this server. Specify the page title, or an audit entry ID to revert a specific edit. A revert is itself an edit, so reverting twice restores the reverted change."
+}
+
+func (t *RevertLastEditTool) IsWrite() bool {
+	return true
+}
+
+func (t *RevertLastEditTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "The title of the page whose last edit should be reverted",
+			},
+			"audit_id": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional audit entry ID of a specific single-page edit to revert (takes precedence over title); edits of several pages are reverted by title",
+			},
+		},
+		"required": []string{},
+	}
+}
+
+func (t *RevertLastEditTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	snapshot, err := t.snapshot(ctx, arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := t.client.PatchPage(snapshot.Page, snapshot.Lines, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to revert page: %v", err)
+	}
+
+	return formatWriteResult(fmt.Sprintf("Successfully reverted page '%s' to its content as of %s (%d lines)", snapshot.Page, snapshot.Timestamp.Format("2006-01-02 15:04:05 MST"), len(snapshot.Lines)), result)
+}
+
+// DestructivePreview lists the current lines the revert drops or changes
+func (t *RevertLastEditTool) DestructivePreview(ctx context.Context, arguments map[string]interface{}) (string, bool, error) {
+	snapshot, err := t.snapshot(ctx, arguments)
+	if err != nil {
+		return "", false, nil
+	}
+	return pageLossPreview(t.client, snapshot.Page, snapshot.Lines)
+}
+
+// snapshot returns the snapshot the call restores
+func (t *RevertLastEditTool) snapshot(ctx context.Context, arguments map[string]interface{}) (*undo.Snapshot, error) {
+	title, _ := arguments["title"].(string)
+	auditID, _ := arguments["audit_id"].(string)
+	if title == "" && auditID == "" {
+		return nil, fmt.Errorf("title or audit_id is required")
+	}
+
+	var snapshot *undo.Snapshot
+	var err error
+	if auditID != "" {
+		snapshot, err = t.operationSnapshot(auditID)
+	} else {
+		// Skip the snapshot the registry just took for this revert itself
+		snapshot, err = t.store.Latest(t.client.DefaultProject(), title, OperationIDFromContext(ctx))
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Writes go to the default project, so only its snapshots can be restored
+	if snapshot.Project != "" && snapshot.Project != t.client.DefaultProject() {
+		return nil, fmt.Errorf("the snapshot is of page '%s' in project '%s'; this server writes to '%s'", snapshot.Page, snapshot.Project, t.client.DefaultProject())
+	}
+
+	if !snapshot.Existed {
+		return nil, fmt.Errorf("page '%s' did not exist before the edit; delete it manually to revert", snapshot.Page)
+	}
+	return snapshot, nil
+}
+
+// operationSnapshot returns the snapshot of a single-page operation.
+// Operations on several pages are refused rather than restoring one of
+// them; their pages are listed so each can be reverted by title.
+func (t *RevertLastEditTool) operationSnapshot(auditID string) (*undo.Snapshot, error) {
+	snapshots, err := t.store.Get(auditID)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 1 {
+		return snapshots[0], nil
+	}
+	pages := make([]string, len(snapshots))
+	for i, snapshot := range snapshots {
+		pages[i] = fmt.Sprintf("'%s'", snapshot.Page)
+	}
+	return nil, fmt.Errorf("operation %s changed %d pages (%s); revert them one at a time by title", auditID, len(snapshots), strings.Join(pages, ", "))
+}
@@ -0,0 +1,45 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	"github.com/hiroki/scrapbox_mcp/internal/scrapboxtest"
+	"github.com/hiroki/scrapbox_mcp/internal/undo"
+)
+
+func TestRevertRefusesMultiPageOperation(t *testing.T) {
+	fake := scrapboxtest.New("test-project")
+	defer fake.Close()
+	client := scrapbox.NewClient(fake.Project(), fake.SessionCookie, fake.APIURL(), 10*time.Second)
+	client.EnsureWebSocket(fake.WebSocketURL())
+	defer client.Close()
+	fake.AddPage("First", "edited")
+	fake.AddPage("Second", "edited")
+
+	store, err := undo.NewStore(filepath.Join(t.TempDir(), "undo.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, page := range []string{"First", "Second"} {
+		snapshot := &undo.Snapshot{ID: "op-1", Project: fake.Project(), Page: page, Existed: true, Lines: []string{page, "original"}}
+		if err := store.Save(snapshot); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tool := NewRevertLastEditTool(client, store)
+	_, err = tool.Execute(context.Background(), map[string]interface{}{"audit_id": "op-1"})
+	if err == nil || !strings.Contains(err.Error(), "'First'") || !strings.Contains(err.Error(), "'Second'") {
+		t.Fatalf("Execute error = %v, want one listing both pages", err)
+	}
+	for _, page := range []string{"First", "Second"} {
+		if got, _ := fake.Page(page); len(got.Lines) != 2 || got.Lines[1].Text != "edited" {
+			t.Errorf("page %s was changed", page)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hiroki/scrapbox_mcp/internal/linkcheck"
+)
+
+type RunLinkCheckTool struct {
+	scheduler *linkcheck.Scheduler
+}
+
+func NewRunLinkCheckTool(scheduler *linkcheck.Scheduler) *RunLinkCheckTool {
+	return &RunLinkCheckTool{scheduler: scheduler}
+}
+
+// runLinkCheckResponse is the run_link_check result
+type runLinkCheckResponse struct {
+	*linkcheck.Report
+	ReportPage string `json:"reportPage,omitempty"`
+	ReportURL  string `json:"reportUrl,omitempty"`
+}
+
+func (t *RunLinkCheckTool) Name() string {
+	return "run_link_check"
+}
+
+func (t *RunLinkCheckTool) Description() string {
+	return fmt.Sprintf("Checks every external URL in the default project now and replaces the '%s' page with the broken ones (404, 410 or unreachable) and the pages citing them. The same check runs on a schedule when LINK_CHECK_INTERVAL is set. Set write_report=false to only return the result.", t.scheduler.ReportPage())
+}
+
+func (t *RunLinkCheckTool) IsWrite() bool {
+	return true
+}
+
+// AdminOnly reserves link check runs for admins
+func (t *RunLinkCheckTool) AdminOnly() bool {
+	return true
+}
+
+func (t *RunLinkCheckTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"write_report": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Update the report page with the result (default: true)",
+			},
+		},
+		"required": []string{},
+	}
+}
+
+// TargetPages reports the report page as the page this tool edits
+func (t *RunLinkCheckTool) TargetPages(arguments map[string]interface{}) []string {
+	if writeReport, ok := arguments["write_report"].(bool); ok && !writeReport {
+		return nil
+	}
+	return []string{t.scheduler.ReportPage()}
+}
+
+func (t *RunLinkCheckTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	writeReport := true
+	if b, ok := arguments["write_report"].(bool); ok {
+		writeReport = b
+	}
+
+	report, err := t.scheduler.Check(ctx, writeReport)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check links: %v", err)
+	}
+
+	response := runLinkCheckResponse{Report: report}
+	summary := fmt.Sprintf("%d of %d external URLs broken", len(report.Broken), report.URLs)
+	if report.Skipped > 0 {
+		summary += fmt.Sprintf(", %d on non-public addresses skipped", report.Skipped)
+	}
+	if report.Page != nil {
+		response.ReportPage = report.Page.Title
+		response.ReportURL = report.Page.URL
+		summary += fmt.Sprintf("; updated '%s'", report.Page.Title)
+	}
+
+	// Format the response as JSON
+	output, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format link check: %v", err)
+	}
+
+	return withSummary(summary, string(output)), nil
+}
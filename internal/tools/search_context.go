@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+// searchContextPages bounds the pages fetched to locate matched lines
+const searchContextPages = 20
+
+// searchLineMatch is a matched line of a search result page with its context
+type searchLineMatch struct {
+	Line   int    `json:"line"`
+	LineID string `json:"line_id"`
+	Text   string `json:"text"`
+	// Highlight is the text with the matched words wrapped in **
+	Highlight string   `json:"highlight"`
+	Before    []string `json:"before,omitempty"`
+	After     []string `json:"after,omitempty"`
+}
+
+// searchPageMatches are the matched lines of one result page
+type searchPageMatches struct {
+	Title   string            `json:"title"`
+	Matches []searchLineMatch `json:"matches"`
+}
+
+// searchLineMatches fetches up to searchContextPages result pages and returns
+// the lines containing a search word, each with contextLines lines around it
+func searchLineMatches(client scrapbox.Reader, project string, results *scrapbox.SearchResponse, contextLines int) ([]searchPageMatches, error) {
+	var pages []searchPageMatches
+	for i, result := range results.Pages {
+		if i == searchContextPages {
+			break
+		}
+		words := result.Words
+		if len(words) == 0 {
+			words = results.Query.Words
+		}
+		pattern := wordsPattern(words)
+		if pattern == nil {
+			continue
+		}
+
+		page, err := client.GetPage(project, result.Title)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s for match context: %w", result.Title, err)
+		}
+
+		entry := searchPageMatches{Title: result.Title, Matches: []searchLineMatch{}}
+		for n, line := range page.Lines {
+			if !pattern.MatchString(line.Text) {
+				continue
+			}
+			match := searchLineMatch{
+				Line:      n,
+				LineID:    line.ID,
+				Text:      line.Text,
+				Highlight: pattern.ReplaceAllString(line.Text, "**$0**"),
+			}
+			for j := max(0, n-contextLines); j < n; j++ {
+				match.Before = append(match.Before, page.Lines[j].Text)
+			}
+			for j := n + 1; j < len(page.Lines) && j <= n+contextLines; j++ {
+				match.After = append(match.After, page.Lines[j].Text)
+			}
+			entry.Matches = append(entry.Matches, match)
+		}
+		pages = append(pages, entry)
+	}
+	return pages, nil
+}
+
+// wordsPattern matches any of words, case-insensitively and longest first;
+// nil when there are no words
+func wordsPattern(words []string) *regexp.Regexp {
+	quoted := make([]string, 0, len(words))
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			quoted = append(quoted, regexp.QuoteMeta(word))
+		}
+	}
+	if len(quoted) == 0 {
+		return nil
+	}
+	sort.Slice(quoted, func(i, j int) bool { return len(quoted[i]) > len(quoted[j]) })
+	return regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+}
+
+// searchMatchesText renders matched lines for the text format: the matched
+// line marked with its index and ">", context lines indented below it
+func searchMatchesText(b *strings.Builder, matches []searchLineMatch) {
+	for _, match := range matches {
+		for i, text := range match.Before {
+			fmt.Fprintf(b, "  %4d  %s\n", match.Line-len(match.Before)+i, text)
+		}
+		fmt.Fprintf(b, "  %4d> %s\n", match.Line, match.Highlight)
+		for i, text := range match.After {
+			fmt.Fprintf(b, "  %4d  %s\n", match.Line+1+i, text)
+		}
+	}
+}
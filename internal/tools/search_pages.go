@@ -8,11 +8,22 @@ import (
 	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
 )
 
+// searchPagesResponse is the search_pages result: the search response plus
+// the skip value for the next page of matches, if there is one
+type searchPagesResponse struct {
+	*scrapbox.SearchResponse
+	NextSkip *int `json:"next_skip,omitempty"`
+	// CompiledQueries are the Scrapbox queries built from the structured arguments
+	CompiledQueries []string `json:"compiled_queries,omitempty"`
+	// LineMatches locate the matched lines when context_lines is set
+	LineMatches []searchPageMatches `json:"line_matches,omitempty"`
+}
+
 type SearchPagesTool struct {
-	client *scrapbox.Client
+	client scrapbox.Reader
 }
 
-func NewSearchPagesTool(client *scrapbox.Client) *SearchPagesTool {
+func NewSearchPagesTool(client scrapbox.Reader) *SearchPagesTool {
 	return &SearchPagesTool{client: client}
 }
 
@@ -21,39 +32,56 @@ func (t *SearchPagesTool) Name() string {
 }
 
 func (t *SearchPagesTool) Description() string {
-	return "Searches for pages containing the specified query string. Returns matching pages with their metadata."
+	return "Searches for pages containing the specified query string. Returns matching pages with their metadata. When more matches remain, next_skip gives the skip value for the next page. Prefer all_words, any_words, exclude_words and phrase over hand-written query operators; the compiled Scrapbox queries are returned in compiled_queries. With context_lines, the matched lines of the first results are returned with their line index, line ID, highlighted words and surrounding lines, ready for targeted edits."
 }
 
 func (t *SearchPagesTool) InputSchema() map[string]interface{} {
-	return map[string]interface{}{
-		"type": "object",
-		"properties": map[string]interface{}{
-			"query": map[string]interface{}{
-				"type":        "string",
-				"description": "The search query string",
-			},
-			"project": map[string]interface{}{
-				"type":        "string",
-				"description": "Optional project name (uses default if not specified)",
-			},
-			"limit": map[string]interface{}{
-				"type":        "number",
-				"description": "Maximum number of results to return",
-			},
+	properties := map[string]interface{}{
+		"query": map[string]interface{}{
+			"type":        "string",
+			"description": "The search query string in Scrapbox syntax (space-separated words are ANDed, -word excludes, \"...\" matches a phrase)",
+		},
+		"format":         formatProperty(),
+		"include_images": includeImagesProperty(),
+		"project": map[string]interface{}{
+			"type":        "string",
+			"description": "Optional project name (uses the session default set by set_default_project, or the server default)",
 		},
-		"required": []string{"query"},
+		"limit": map[string]interface{}{
+			"type":        "number",
+			"description": "Maximum number of results to return",
+		},
+		"skip": map[string]interface{}{
+			"type":        "number",
+			"description": "Number of matches to skip for pagination (default: 0)",
+		},
+		"context_lines": map[string]interface{}{
+			"type":        "number",
+			"description": fmt.Sprintf("Fetch the first %d result pages and return each matched line with its index and this many lines before and after it (0 for the matched lines only)", searchContextPages),
+		},
+	}
+	for name, property := range searchQueryProperties() {
+		properties[name] = property
+	}
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   []string{},
 	}
 }
 
 func (t *SearchPagesTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
-	query, ok := arguments["query"].(string)
-	if !ok || query == "" {
-		return nil, fmt.Errorf("query is required and must be a string")
+	queries, err := buildSearchQueries(arguments)
+	if err != nil {
+		return nil, err
 	}
 
-	project := t.client.ProjectName
-	if projectArg, ok := arguments["project"].(string); ok && projectArg != "" {
-		project = projectArg
+	project := resolveProject(ctx, arguments, t.client)
+
+	format, err := parseFormat(arguments)
+	if err != nil {
+		return nil, err
 	}
 
 	limit := 0
@@ -61,16 +89,92 @@ func (t *SearchPagesTool) Execute(ctx context.Context, arguments map[string]inte
 		limit = int(limitArg)
 	}
 
-	searchResult, err := t.client.RESTClient.SearchPages(project, query, limit)
-	if err != nil {
-		return nil, err
+	skip := 0
+	if skipArg, ok := arguments["skip"].(float64); ok && skipArg > 0 {
+		skip = int(skipArg)
 	}
 
-	// Format the response as JSON
-	result, err := json.MarshalIndent(searchResult, "", "  ")
-	if err != nil {
-		return nil, fmt.Errorf("failed to format search results: %v", err)
+	// -1 leaves matched lines unlocated
+	contextLines := -1
+	if contextArg, ok := arguments["context_lines"].(float64); ok {
+		if contextArg < 0 {
+			return nil, fmt.Errorf("context_lines must not be negative")
+		}
+		contextLines = int(contextArg)
+	}
+
+	var searchResult *scrapbox.SearchResponse
+	if len(queries) == 1 {
+		searchResult, err = t.client.SearchPages(project, queries[0], limit, skip)
+		if err != nil {
+			return nil, err
+		}
+		searchResult.Skip = skip
+	} else {
+		// OR search: fetch enough of every query to page through the merged results
+		fetch := 0
+		if limit > 0 {
+			fetch = skip + limit
+		}
+		results := make([]*scrapbox.SearchResponse, 0, len(queries))
+		for _, query := range queries {
+			result, err := t.client.SearchPages(project, query, fetch, 0)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, result)
+		}
+		searchResult = mergeSearchResults(results, limit, skip)
 	}
 
-	return string(result), nil
+	response := searchPagesResponse{SearchResponse: searchResult}
+	for name := range searchQueryProperties() {
+		if _, ok := arguments[name]; ok {
+			response.CompiledQueries = queries
+			break
+		}
+	}
+	if next := skip + len(searchResult.Pages); len(searchResult.Pages) > 0 && next < searchResult.Count {
+		response.NextSkip = &next
+	}
+	if contextLines >= 0 && format != formatTitlesOnly {
+		response.LineMatches, err = searchLineMatches(t.client, project, searchResult, contextLines)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var text string
+	if format != formatJSON {
+		text = searchText(searchResult, format == formatTitlesOnly, response.LineMatches)
+		if response.NextSkip != nil && format == formatText {
+			text += fmt.Sprintf("\n(more matches available with skip=%d)", *response.NextSkip)
+		}
+	} else {
+		// Format the response as JSON
+		result, err := json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to format search results: %v", err)
+		}
+		text = string(result)
+	}
+
+	if includeImages, _ := arguments["include_images"].(bool); includeImages {
+		var images []string
+		for _, page := range searchResult.Pages {
+			if page.Image != "" {
+				images = append(images, page.Image)
+			}
+		}
+		return summarizeSearch(format, searchResult, response.NextSkip, withImages(ctx, text, images)), nil
+	}
+	return summarizeSearch(format, searchResult, response.NextSkip, text), nil
+}
+
+// summarizeSearch adds a summary to JSON results; the text formats already start with one
+func summarizeSearch(format string, results *scrapbox.SearchResponse, nextSkip *int, result interface{}) interface{} {
+	if format != formatJSON {
+		return result
+	}
+	return withSummary(searchSummary(results, nextSkip), result)
 }
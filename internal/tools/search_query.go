@@ -0,0 +1,146 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+// searchQueryProperties are the structured search arguments of search_pages
+func searchQueryProperties() map[string]interface{} {
+	words := func(description string) map[string]interface{} {
+		return map[string]interface{}{
+			"type":        "array",
+			"items":       map[string]interface{}{"type": "string"},
+			"description": description,
+		}
+	}
+	return map[string]interface{}{
+		"all_words":     words("Pages must contain every one of these words"),
+		"any_words":     words("Pages must contain at least one of these words"),
+		"exclude_words": words("Pages must not contain any of these words"),
+		"phrase": map[string]interface{}{
+			"type":        "string",
+			"description": "Pages must contain this exact phrase",
+		},
+	}
+}
+
+// buildSearchQueries compiles the raw query and structured search arguments into
+// Scrapbox query strings. Scrapbox has no OR operator, so each of any_words
+// yields its own query whose results are merged.
+func buildSearchQueries(arguments map[string]interface{}) ([]string, error) {
+	var base []string
+	if query, ok := arguments["query"].(string); ok && strings.TrimSpace(query) != "" {
+		base = append(base, strings.TrimSpace(query))
+	}
+
+	allWords, err := stringList(arguments, "all_words")
+	if err != nil {
+		return nil, err
+	}
+	for _, word := range allWords {
+		base = append(base, quoteSearchTerm(word))
+	}
+
+	if phrase, ok := arguments["phrase"].(string); ok && strings.TrimSpace(phrase) != "" {
+		base = append(base, `"`+strings.ReplaceAll(strings.TrimSpace(phrase), `"`, "")+`"`)
+	}
+
+	excludeWords, err := stringList(arguments, "exclude_words")
+	if err != nil {
+		return nil, err
+	}
+	for _, word := range excludeWords {
+		base = append(base, "-"+quoteSearchTerm(word))
+	}
+
+	anyWords, err := stringList(arguments, "any_words")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(anyWords) == 0 {
+		if len(base) == 0 {
+			return nil, fmt.Errorf("query or one of all_words, any_words, phrase is required")
+		}
+		if len(excludeWords) == len(base) {
+			return nil, fmt.Errorf("a search cannot consist of exclude_words only")
+		}
+		return []string{strings.Join(base, " ")}, nil
+	}
+
+	queries := make([]string, 0, len(anyWords))
+	for _, word := range anyWords {
+		queries = append(queries, strings.Join(append([]string{quoteSearchTerm(word)}, base...), " "))
+	}
+	return queries, nil
+}
+
+// quoteSearchTerm strips operators from a single term and quotes it if it contains spaces
+func quoteSearchTerm(term string) string {
+	term = strings.TrimLeft(strings.TrimSpace(term), "-")
+	term = strings.ReplaceAll(term, `"`, "")
+	if strings.ContainsAny(term, " \t　") {
+		return `"` + term + `"`
+	}
+	return term
+}
+
+// stringList reads an optional array-of-strings argument
+func stringList(arguments map[string]interface{}, name string) ([]string, error) {
+	raw, ok := arguments[name]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must be an array of strings", name)
+	}
+
+	var list []string
+	for _, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s must be an array of strings", name)
+		}
+		if strings.TrimSpace(s) != "" {
+			list = append(list, s)
+		}
+	}
+	return list, nil
+}
+
+// mergeSearchResults combines the results of several queries (an OR search),
+// dropping duplicate pages and applying skip and limit to the merged list.
+// Count is the number of distinct pages found, a lower bound of the true total.
+func mergeSearchResults(results []*scrapbox.SearchResponse, limit, skip int) *scrapbox.SearchResponse {
+	merged := &scrapbox.SearchResponse{
+		ProjectName: results[0].ProjectName,
+		Limit:       limit,
+		Skip:        skip,
+		Backend:     results[0].Backend,
+		Pages:       []scrapbox.SearchPageInfo{},
+	}
+
+	seen := make(map[string]bool)
+	var all []scrapbox.SearchPageInfo
+	var queries []string
+	for _, result := range results {
+		queries = append(queries, result.SearchQuery)
+		merged.ExistsExactTitleMatch = merged.ExistsExactTitleMatch || result.ExistsExactTitleMatch
+		for _, page := range result.Pages {
+			if !seen[page.ID] {
+				seen[page.ID] = true
+				all = append(all, page)
+			}
+		}
+	}
+	merged.SearchQuery = strings.Join(queries, " OR ")
+	merged.Count = len(all)
+	for i := skip; i < len(all) && (limit <= 0 || len(merged.Pages) < limit); i++ {
+		merged.Pages = append(merged.Pages, all[i])
+	}
+	return merged
+}
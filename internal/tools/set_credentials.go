@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+// CredentialChecker validates credentials a client supplies for its session
+type CredentialChecker interface {
+	Check(credentials scrapbox.Credentials) error
+}
+
+type SetCredentialsTool struct {
+	checker CredentialChecker
+}
+
+func NewSetCredentialsTool(checker CredentialChecker) *SetCredentialsTool {
+	return &SetCredentialsTool{checker: checker}
+}
+
+func (t *SetCredentialsTool) Name() string {
+	return "set_credentials"
+}
+
+func (t *SetCredentialsTool) Description() string {
+	return "Sets the Scrapbox project and session cookie (connect.sid) used by the tools for the rest of this session, instead of the server's own credentials. The credentials are checked before they are used. Pass empty project and sid to return to the server's credentials."
+}
+
+// SecretArguments keeps the session cookie out of logs
+func (t *SetCredentialsTool) SecretArguments() []string {
+	return []string{"sid"}
+}
+
+func (t *SetCredentialsTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"project": map[string]interface{}{
+				"type":        "string",
+				"description": "The Scrapbox project name (empty with an empty sid to reset)",
+			},
+			"sid": map[string]interface{}{
+				"type":        "string",
+				"description": "The connect.sid session cookie of a user with access to the project",
+			},
+		},
+		"required": []string{"project", "sid"},
+	}
+}
+
+func (t *SetCredentialsTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	project, _ := arguments["project"].(string)
+	sid, _ := arguments["sid"].(string)
+	project, sid = strings.TrimSpace(project), strings.TrimSpace(sid)
+
+	state := SessionStateFromContext(ctx)
+	if state == nil {
+		return nil, fmt.Errorf("set_credentials requires an MCP session")
+	}
+
+	if project == "" && sid == "" {
+		state.SetCredentials(nil)
+		// The default project belonged to the previous credentials
+		state.SetDefaultProject("")
+		return "Credentials reset; this session uses the server's credentials", nil
+	}
+	if project == "" || sid == "" {
+		return nil, fmt.Errorf("project and sid are both required (or both empty to reset)")
+	}
+
+	credentials := scrapbox.Credentials{Project: project, SessionCookie: sid}
+	if err := t.checker.Check(credentials); err != nil {
+		return nil, fmt.Errorf("credentials rejected: %w", err)
+	}
+	state.SetCredentials(&credentials)
+	state.SetDefaultProject("")
+	return fmt.Sprintf("This session now uses its own credentials for project '%s'", project), nil
+}
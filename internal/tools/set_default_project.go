@@ -0,0 +1,62 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+type SetDefaultProjectTool struct {
+	client scrapbox.Reader
+}
+
+func NewSetDefaultProjectTool(client scrapbox.Reader) *SetDefaultProjectTool {
+	return &SetDefaultProjectTool{client: client}
+}
+
+func (t *SetDefaultProjectTool) Name() string {
+	return "set_default_project"
+}
+
+func (t *SetDefaultProjectTool) Description() string {
+	return "Sets the default project for the rest of this session so read tools (get_page, list_pages, search_pages) don't need the project argument. Pass an empty project to reset to the server default."
+}
+
+func (t *SetDefaultProjectTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"project": map[string]interface{}{
+				"type":        "string",
+				"description": "The project name to use by default (empty to reset)",
+			},
+		},
+		"required": []string{"project"},
+	}
+}
+
+func (t *SetDefaultProjectTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	project, ok := arguments["project"].(string)
+	if !ok {
+		return nil, fmt.Errorf("project is required and must be a string")
+	}
+
+	state := SessionStateFromContext(ctx)
+	if state == nil {
+		return nil, fmt.Errorf("set_default_project requires an MCP session")
+	}
+
+	if project == "" {
+		state.SetDefaultProject("")
+		return fmt.Sprintf("Default project reset to '%s'", t.client.DefaultProject()), nil
+	}
+
+	// Verify the project is accessible before storing it
+	if _, err := t.client.ListPages(project, 1, 0); err != nil {
+		return nil, fmt.Errorf("cannot access project '%s': %v", project, err)
+	}
+
+	state.SetDefaultProject(project)
+	return fmt.Sprintf("Default project for this session set to '%s'", project), nil
+}
@@ -0,0 +1,62 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hiroki/scrapbox_mcp/internal/gitmirror"
+)
+
+type SyncToGitTool struct {
+	mirror *gitmirror.Mirror
+}
+
+func NewSyncToGitTool(mirror *gitmirror.Mirror) *SyncToGitTool {
+	return &SyncToGitTool{mirror: mirror}
+}
+
+func (t *SyncToGitTool) Name() string {
+	return "sync_to_git"
+}
+
+func (t *SyncToGitTool) Description() string {
+	return "Mirrors the project into the server's git repository now: every page changed since the last sync is written to its own file and committed as its last editor at the time of the edit, and deleted pages are removed. Returns the pages committed and the new HEAD."
+}
+
+// AdminOnly reserves mirror runs for admins
+func (t *SyncToGitTool) AdminOnly() bool {
+	return true
+}
+
+func (t *SyncToGitTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{},
+		"required":   []string{},
+	}
+}
+
+func (t *SyncToGitTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	result, err := t.mirror.Sync(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sync to git: %v", err)
+	}
+
+	summary := fmt.Sprintf("No changes to mirror in %s", result.Dir)
+	if result.Commits > 0 {
+		summary = fmt.Sprintf("Committed %d changes (%d added, %d updated, %d deleted) to %s at %s",
+			result.Commits, len(result.Added), len(result.Updated), len(result.Deleted), result.Dir, result.Head)
+	}
+	if result.Pushed {
+		summary += "; pushed"
+	}
+
+	// Format the response as JSON
+	output, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format sync result: %v", err)
+	}
+
+	return withSummary(summary, string(output)), nil
+}
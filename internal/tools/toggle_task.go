@@ -0,0 +1,144 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+	"github.com/hiroki/scrapbox_mcp/internal/tasks"
+)
+
+type ToggleTaskTool struct {
+	client  scrapbox.API
+	matcher *tasks.Matcher
+}
+
+func NewToggleTaskTool(client scrapbox.API, matcher *tasks.Matcher) *ToggleTaskTool {
+	return &ToggleTaskTool{client: client, matcher: matcher}
+}
+
+func (t *ToggleTaskTool) Name() string {
+	return "toggle_task"
+}
+
+func (t *ToggleTaskTool) Description() string {
+	return "Marks a task (as listed by list_tasks) done or open by swapping its marker. The task is found by its line index or its text. Without state, the task is flipped."
+}
+
+func (t *ToggleTaskTool) IsWrite() bool {
+	return true
+}
+
+func (t *ToggleTaskTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "The title of the page",
+			},
+			"line": map[string]interface{}{
+				"type":        "number",
+				"description": "Line index of the task (from list_tasks)",
+			},
+			"text": map[string]interface{}{
+				"type":        "string",
+				"description": "Text of the task without its marker, instead of line",
+			},
+			"state": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{taskStateOpen, taskStateDone},
+				"description": "The state to set (default: the opposite of the current state)",
+			},
+			argExpectedCommitID: expectedCommitProperty(),
+		},
+		"required": []string{"title"},
+	}
+}
+
+func (t *ToggleTaskTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	title, ok := arguments["title"].(string)
+	if !ok || title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+	lineArg, hasLine := arguments["line"].(float64)
+	text, _ := arguments["text"].(string)
+	if !hasLine && text == "" {
+		return nil, fmt.Errorf("line or text is required")
+	}
+	state, _ := arguments["state"].(string)
+	if state != "" && state != taskStateOpen && state != taskStateDone {
+		return nil, fmt.Errorf("invalid state: %s (expected open or done)", state)
+	}
+
+	page, err := t.client.GetPage(t.client.DefaultProject(), title)
+	if err != nil {
+		return nil, err
+	}
+	if page.CommitID == "" {
+		return nil, fmt.Errorf("page not found: %s", title)
+	}
+	lines := make([]string, len(page.Lines))
+	for i, line := range page.Lines {
+		lines[i] = line.Text
+	}
+
+	task, err := findTask(t.matcher.Find(lines), hasLine, int(lineArg), text)
+	if err != nil {
+		return nil, err
+	}
+	done := !task.Done
+	if state != "" {
+		done = state == taskStateDone
+	}
+	newState := taskStateOpen
+	if done {
+		newState = taskStateDone
+	}
+	if done == task.Done {
+		return fmt.Sprintf("Task '%s' (line %d) in page '%s' is already %s", task.Text, task.Line, title, newState), nil
+	}
+
+	newLine, _ := t.matcher.Set(lines[task.Line], done)
+	lines[task.Line] = newLine
+
+	expectedCommitID := parseExpectedCommitID(arguments)
+	if expectedCommitID == "" {
+		expectedCommitID = page.CommitID
+	}
+	result, err := t.client.PatchPage(title, lines, expectedCommitID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update task: %w", conflictError(err))
+	}
+
+	return formatWriteResult(fmt.Sprintf("Marked task '%s' (line %d) in page '%s' as %s", task.Text, task.Line, title, newState), result)
+}
+
+// findTask picks the task at line, or the one task whose text matches
+func findTask(pageTasks []tasks.Task, byLine bool, line int, text string) (tasks.Task, error) {
+	if byLine {
+		for _, task := range pageTasks {
+			if task.Line == line {
+				return task, nil
+			}
+		}
+		return tasks.Task{}, fmt.Errorf("line %d is not a task", line)
+	}
+
+	text = strings.TrimSpace(text)
+	var matches []tasks.Task
+	for _, task := range pageTasks {
+		if task.Text == text {
+			matches = append(matches, task)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return tasks.Task{}, fmt.Errorf("task not found: %s", text)
+	case 1:
+		return matches[0], nil
+	default:
+		return tasks.Task{}, fmt.Errorf("task '%s' appears on %d lines; pass line to choose one", text, len(matches))
+	}
+}
@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hiroki/scrapbox_mcp/internal/backup"
+)
+
+type TriggerBackupTool struct {
+	scheduler *backup.Scheduler
+}
+
+func NewTriggerBackupTool(scheduler *backup.Scheduler) *TriggerBackupTool {
+	return &TriggerBackupTool{scheduler: scheduler}
+}
+
+func (t *TriggerBackupTool) Name() string {
+	return "trigger_backup"
+}
+
+func (t *TriggerBackupTool) Description() string {
+	return "Exports the whole Scrapbox project and stores a timestamped archive in the configured backup location. Use before large edits."
+}
+
+// AdminOnly reserves backups for admins
+func (t *TriggerBackupTool) AdminOnly() bool {
+	return true
+}
+
+func (t *TriggerBackupTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{},
+		"required":   []string{},
+	}
+}
+
+func (t *TriggerBackupTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	name, err := t.scheduler.Backup(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to back up project: %v", err)
+	}
+
+	return fmt.Sprintf("Successfully wrote backup '%s' to %s", name, t.scheduler.Storage()), nil
+}
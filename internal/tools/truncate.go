@@ -0,0 +1,170 @@
+package tools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Arguments handled by the registry for every tool
+const (
+	argMaxResponseBytes = "max_response_bytes"
+	argCursor           = "response_cursor"
+)
+
+// responseCacheTTL is how long truncated results are kept for continuation
+const responseCacheTTL = 10 * time.Minute
+
+// responseCache keeps the full text of truncated results so the remaining
+// chunks can be fetched with a cursor without re-running the tool.
+type responseCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedResponse
+}
+
+type cachedResponse struct {
+	tool    string
+	text    string
+	expires time.Time
+}
+
+func newResponseCache() *responseCache {
+	return &responseCache{entries: make(map[string]*cachedResponse)}
+}
+
+// store saves a result and returns its ID
+func (c *responseCache) store(tool, text string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for id, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, id)
+		}
+	}
+
+	id := uuid.New().String()
+	c.entries[id] = &cachedResponse{tool: tool, text: text, expires: now.Add(responseCacheTTL)}
+	return id
+}
+
+// load returns a cached result if it exists, has not expired and belongs to tool
+func (c *responseCache) load(tool, id string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[id]
+	if !ok || entry.tool != tool || time.Now().After(entry.expires) {
+		return "", false
+	}
+	return entry.text, true
+}
+
+// responseLimitProperties are added to every tool's InputSchema
+func responseLimitProperties() map[string]interface{} {
+	return map[string]interface{}{
+		argMaxResponseBytes: map[string]interface{}{
+			"type":        "integer",
+			"description": "Maximum size of the result in bytes; longer results are truncated and a cursor is returned",
+			"minimum":     1,
+		},
+		argCursor: map[string]interface{}{
+			"type":        "string",
+			"description": "Cursor from a truncated result; returns the next chunk instead of running the tool again",
+		},
+	}
+}
+
+// withResponseLimitProperties returns a copy of schema with the response limit properties added
+func withResponseLimitProperties(schema map[string]interface{}) map[string]interface{} {
+	return withProperties(schema, responseLimitProperties())
+}
+
+// withProperties returns a copy of schema with extra properties added,
+// keeping any the tool already defines
+func withProperties(schema, extra map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(schema)+1)
+	for k, v := range schema {
+		merged[k] = v
+	}
+
+	properties := make(map[string]interface{})
+	if existing, ok := schema["properties"].(map[string]interface{}); ok {
+		for k, v := range existing {
+			properties[k] = v
+		}
+	}
+	for k, v := range extra {
+		if _, ok := properties[k]; !ok {
+			properties[k] = v
+		}
+	}
+	merged["properties"] = properties
+	return merged
+}
+
+// parseMaxResponseBytes returns the per-call limit, or 0 if none was given
+func parseMaxResponseBytes(arguments map[string]interface{}) (int, error) {
+	v, ok := arguments[argMaxResponseBytes]
+	if !ok {
+		return 0, nil
+	}
+	n, ok := v.(float64)
+	if !ok || n < 1 {
+		return 0, fmt.Errorf("%s must be a positive integer", argMaxResponseBytes)
+	}
+	return int(n), nil
+}
+
+// encodeCursor and decodeCursor convert between a cursor string and a cache ID plus byte offset
+func encodeCursor(id string, offset int) string {
+	return id + ":" + strconv.Itoa(offset)
+}
+
+func decodeCursor(cursor string) (string, int, error) {
+	id, offsetStr, ok := strings.Cut(cursor, ":")
+	if !ok {
+		return "", 0, fmt.Errorf("invalid cursor: %s", cursor)
+	}
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil || offset < 0 {
+		return "", 0, fmt.Errorf("invalid cursor: %s", cursor)
+	}
+	return id, offset, nil
+}
+
+// truncateText cuts text to at most limit bytes. The cut is made after the last
+// newline in the chunk when there is one in its second half, otherwise at a rune
+// boundary, so the same input and limit always produce the same chunks.
+// It returns the chunk and the number of bytes consumed.
+func truncateText(text string, limit int) (string, int) {
+	if len(text) <= limit {
+		return text, len(text)
+	}
+
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	if cut == 0 {
+		// limit is smaller than the first rune; always make progress
+		_, size := utf8.DecodeRuneInString(text)
+		cut = size
+	}
+	if nl := strings.LastIndexByte(text[:cut], '\n'); nl >= cut/2 {
+		cut = nl + 1
+	}
+	return text[:cut], cut
+}
+
+// truncationNotice is appended to a truncated chunk
+func truncationNotice(start, end, total int, cursor string) string {
+	return fmt.Sprintf("\n\n[truncated: showing bytes %d-%d of %d; call again with %s=%q for more]",
+		start, end, total, argCursor, cursor)
+}
@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+type UpdateLineTool struct {
+	client scrapbox.API
+}
+
+func NewUpdateLineTool(client scrapbox.API) *UpdateLineTool {
+	return &UpdateLineTool{client: client}
+}
+
+func (t *UpdateLineTool) Name() string {
+	return "update_line"
+}
+
+func (t *UpdateLineTool) Description() string {
+	return "Replaces the text of one line, addressed by its line ID (the id of a line in get_page, or from insertedLineIds of a previous write). Only that line changes; no text matching or whole-page diff is involved. Updating the title line renames the page."
+}
+
+func (t *UpdateLineTool) IsWrite() bool {
+	return true
+}
+
+func (t *UpdateLineTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "The title of the page",
+			},
+			"line_id": map[string]interface{}{
+				"type":        "string",
+				"description": "The ID of the line to update",
+			},
+			"text": map[string]interface{}{
+				"type":        "string",
+				"description": "The new text of the line (a single line; may be empty)",
+			},
+			argExpectedCommitID: expectedCommitProperty(),
+		},
+		"required": []string{"title", "line_id", "text"},
+	}
+}
+
+func (t *UpdateLineTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	title, ok := arguments["title"].(string)
+	if !ok || title == "" {
+		return nil, fmt.Errorf("title is required and must be a string")
+	}
+
+	lineID, ok := arguments["line_id"].(string)
+	if !ok || lineID == "" {
+		return nil, fmt.Errorf("line_id is required and must be a string")
+	}
+
+	text, ok := arguments["text"].(string)
+	if !ok {
+		return nil, fmt.Errorf("text is required and must be a string")
+	}
+	if strings.Contains(text, "\n") {
+		return nil, fmt.Errorf("text must be a single line; use insert_lines to add lines")
+	}
+
+	result, err := t.client.UpdateLine(title, lineID, text, parseExpectedCommitID(arguments))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update line: %w", conflictError(err))
+	}
+
+	return formatWriteResult(fmt.Sprintf("Updated line %s of page '%s'", lineID, title), result)
+}
+
+// DestructivePreview shows the line text the update replaces
+func (t *UpdateLineTool) DestructivePreview(ctx context.Context, arguments map[string]interface{}) (string, bool, error) {
+	title, _ := arguments["title"].(string)
+	lineID, _ := arguments["line_id"].(string)
+	text, ok := arguments["text"].(string)
+	if title == "" || lineID == "" || !ok {
+		return "", false, nil
+	}
+	page, err := t.client.GetPage(t.client.DefaultProject(), title)
+	if err != nil || page.CommitID == "" {
+		return "", false, nil
+	}
+	lines := make([]string, len(page.Lines))
+	var lost []string
+	for i, line := range page.Lines {
+		lines[i] = line.Text
+		if line.ID == lineID && line.Text != text && strings.TrimSpace(line.Text) != "" {
+			lost = append(lost, line.Text)
+		}
+	}
+	return linesLossPreview(title, lines, lost)
+}
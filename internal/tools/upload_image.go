@@ -0,0 +1,201 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hiroki/scrapbox_mcp/internal/safehttp"
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+// maxImageBytes is the largest image upload_image accepts
+const maxImageBytes = 20 << 20
+
+// ImageUploader stores an image and returns a URL Scrapbox can embed
+type ImageUploader interface {
+	Upload(ctx context.Context, name, contentType string, data []byte) (string, error)
+	String() string
+}
+
+type UploadImageTool struct {
+	client     scrapbox.API
+	uploader   ImageUploader
+	httpClient *http.Client
+}
+
+func NewUploadImageTool(client scrapbox.API, uploader ImageUploader) *UploadImageTool {
+	return &UploadImageTool{
+		client:     client,
+		uploader:   uploader,
+		httpClient: safehttp.NewClient(30 * time.Second),
+	}
+}
+
+func (t *UploadImageTool) Name() string {
+	return "upload_image"
+}
+
+func (t *UploadImageTool) Description() string {
+	return fmt.Sprintf("Uploads an image (base64 data or a URL) to %s and returns the URL to embed as [url]. Optionally inserts the image into a page in the same call.", t.uploader)
+}
+
+func (t *UploadImageTool) IsWrite() bool {
+	return true
+}
+
+func (t *UploadImageTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"data": map[string]interface{}{
+				"type":        "string",
+				"description": "Base64-encoded image data (or a data: URI)",
+			},
+			"url": map[string]interface{}{
+				"type":        "string",
+				"description": "URL to download the image from (instead of data)",
+			},
+			"filename": map[string]interface{}{
+				"type":        "string",
+				"description": "File name of the image (default: image.png)",
+			},
+			"content_type": map[string]interface{}{
+				"type":        "string",
+				"description": "MIME type of the image (detected from the data if omitted)",
+			},
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional page to insert the image into",
+			},
+			"target_line": map[string]interface{}{
+				"type":        "string",
+				"description": "The line after which to insert the image (or empty to append at end)",
+			},
+		},
+		"required": []string{},
+	}
+}
+
+func (t *UploadImageTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	filename := "image.png"
+	if nameArg, ok := arguments["filename"].(string); ok && nameArg != "" {
+		filename = nameArg
+	}
+	contentType, _ := arguments["content_type"].(string)
+
+	var data []byte
+	var err error
+	dataArg, _ := arguments["data"].(string)
+	urlArg, _ := arguments["url"].(string)
+	switch {
+	case dataArg != "" && urlArg != "":
+		return nil, fmt.Errorf("specify either data or url, not both")
+	case dataArg != "":
+		data, contentType, err = decodeImageData(dataArg, contentType)
+	case urlArg != "":
+		data, contentType, err = t.downloadImage(ctx, urlArg, contentType)
+		if _, ok := arguments["filename"].(string); !ok {
+			if base := path.Base(urlArg); base != "" && base != "/" && strings.Contains(base, ".") {
+				filename = base
+			}
+		}
+	default:
+		return nil, fmt.Errorf("data or url is required")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(filename))
+	}
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, fmt.Errorf("not an image: %s", contentType)
+	}
+
+	imageURL, err := t.uploader.Upload(ctx, filename, contentType, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload image: %v", err)
+	}
+
+	title, _ := arguments["title"].(string)
+	if title == "" {
+		return fmt.Sprintf("Uploaded image to %s: %s\nEmbed with: [%s]", t.uploader, imageURL, imageURL), nil
+	}
+
+	targetLine, _ := arguments["target_line"].(string)
+	result, err := t.client.InsertLines(title, targetLine, []string{"[" + imageURL + "]"}, "")
+	if err != nil {
+		return nil, fmt.Errorf("uploaded image to %s but failed to insert it into page '%s': %v", imageURL, title, err)
+	}
+	return formatWriteResult(fmt.Sprintf("Uploaded image to %s and inserted it into page '%s': %s", t.uploader, title, imageURL), result)
+}
+
+// decodeImageData decodes base64 data, accepting data: URIs
+func decodeImageData(value, contentType string) ([]byte, string, error) {
+	if strings.HasPrefix(value, "data:") {
+		header, payload, ok := strings.Cut(value, ",")
+		if !ok || !strings.HasSuffix(header, ";base64") {
+			return nil, "", fmt.Errorf("data URI must be base64-encoded")
+		}
+		if contentType == "" {
+			contentType = strings.TrimSuffix(strings.TrimPrefix(header, "data:"), ";base64")
+		}
+		value = payload
+	}
+
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid base64 image data: %v", err)
+	}
+	if len(data) > maxImageBytes {
+		return nil, "", fmt.Errorf("image is larger than %d bytes", maxImageBytes)
+	}
+	return data, contentType, nil
+}
+
+// downloadImage fetches an image from an http(s) URL. The client refuses
+// non-public addresses so a caller cannot have the server fetch and upload
+// internal resources.
+func (t *UploadImageTool) downloadImage(ctx context.Context, rawURL, contentType string) ([]byte, string, error) {
+	if !strings.HasPrefix(rawURL, "http://") && !strings.HasPrefix(rawURL, "https://") {
+		return nil, "", fmt.Errorf("url must be http(s)")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid url: %v", err)
+	}
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download image: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to download image: status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageBytes+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download image: %v", err)
+	}
+	if len(data) > maxImageBytes {
+		return nil, "", fmt.Errorf("image is larger than %d bytes", maxImageBytes)
+	}
+
+	if contentType == "" {
+		contentType, _, _ = mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	}
+	return data, contentType, nil
+}
@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hiroki/scrapbox_mcp/internal/changes"
+)
+
+type WatchPageTool struct {
+	watcher *changes.Watcher
+	project string
+}
+
+// NewWatchPageTool creates the tool for the watcher's project
+func NewWatchPageTool(watcher *changes.Watcher, project string) *WatchPageTool {
+	return &WatchPageTool{watcher: watcher, project: project}
+}
+
+func (t *WatchPageTool) Name() string {
+	return "watch_page"
+}
+
+func (t *WatchPageTool) Description() string {
+	return "Adds a page to this session's watch list (or removes it with watch=false). When a watched page changes, the server sends notifications/resources/updated with the page URI on the SSE stream, so its content only needs to be refetched then."
+}
+
+func (t *WatchPageTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "The title of the page to watch",
+			},
+			"watch": map[string]interface{}{
+				"type":        "boolean",
+				"description": "true to watch the page, false to stop watching it (default: true)",
+			},
+		},
+		"required": []string{"title"},
+	}
+}
+
+func (t *WatchPageTool) Execute(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+	title, ok := arguments["title"].(string)
+	if !ok || title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+
+	watch := true
+	if watchArg, ok := arguments["watch"].(bool); ok {
+		watch = watchArg
+	}
+
+	sessionID := SessionIDFromContext(ctx)
+	if sessionID == "" {
+		return nil, fmt.Errorf("watch_page requires an MCP session")
+	}
+
+	uri := changes.PageURI(t.project, title)
+	if watch {
+		if err := t.watcher.Subscribe(sessionID, uri); err != nil {
+			return nil, err
+		}
+	} else {
+		t.watcher.Unsubscribe(sessionID, uri)
+	}
+
+	result, err := json.MarshalIndent(map[string]interface{}{
+		"uri":       uri,
+		"watching":  watch,
+		"watchList": t.watcher.Subscriptions(sessionID),
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format watch list: %v", err)
+	}
+
+	return string(result), nil
+}
@@ -0,0 +1,87 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hiroki/scrapbox_mcp/internal/scrapbox"
+)
+
+// writeResponse is the result of a write tool: what changed and where to find it
+type writeResponse struct {
+	Message  string `json:"message"`
+	Project  string `json:"project"`
+	Title    string `json:"title"`
+	PageID   string `json:"pageId,omitempty"`
+	URL      string `json:"url"`
+	CommitID string `json:"commitId,omitempty"`
+	Deleted  bool   `json:"deleted,omitempty"`
+	// Warning reports a write that did not go as asked, e.g. a fallback position
+	Warning string `json:"warning,omitempty"`
+	// InsertedAt is the index of the first inserted line (insert tools)
+	InsertedAt *int `json:"insertedAt,omitempty"`
+	// InsertedLineIDs are the IDs of the new lines, usable to address them in
+	// follow-up edits without matching their text
+	InsertedLineIDs []string `json:"insertedLineIds,omitempty"`
+	// Lines are the lines the write inserted or rewrote, with deep links
+	Lines []writtenLine `json:"lines,omitempty"`
+}
+
+// writtenLine is one line a write inserted or rewrote
+type writtenLine struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id,omitempty"`
+	Text     string `json:"text"`
+	URL      string `json:"url"`
+	Inserted bool   `json:"inserted,omitempty"`
+}
+
+// newWriteResponse describes the page a write left behind
+func newWriteResponse(message string, result *scrapbox.WriteResult) *writeResponse {
+	response := &writeResponse{
+		Message:         message,
+		Project:         result.Project,
+		Title:           result.Title,
+		PageID:          result.PageID,
+		URL:             result.URL,
+		CommitID:        result.CommitID,
+		Deleted:         result.Deleted,
+		InsertedLineIDs: result.InsertedLineIDs,
+	}
+	inserted := make(map[string]bool, len(result.InsertedLineIDs))
+	for _, id := range result.InsertedLineIDs {
+		inserted[id] = true
+	}
+	for _, i := range result.Changed {
+		line := result.Lines[i]
+		response.Lines = append(response.Lines, writtenLine{
+			Index:    i,
+			ID:       line.ID,
+			Text:     line.Text,
+			URL:      result.LineURL(line.ID),
+			Inserted: inserted[line.ID],
+		})
+	}
+	return response
+}
+
+// formatWriteResult formats the result of a single-page write tool: message
+// for the user, followed by the structured result as JSON
+func formatWriteResult(message string, result *scrapbox.WriteResult) (interface{}, error) {
+	return newWriteResponse(message, result).format()
+}
+
+// format renders the response as its message (with any warning) followed by JSON
+func (r *writeResponse) format() (interface{}, error) {
+	// Format the response as JSON
+	output, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format write result: %v", err)
+	}
+
+	summary := r.Message
+	if r.Warning != "" {
+		summary += "\nWarning: " + r.Warning
+	}
+	return withSummary(summary, string(output)), nil
+}
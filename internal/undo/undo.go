@@ -0,0 +1,170 @@
+package undo
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Snapshot holds the content of a page as it was before a write operation
+type Snapshot struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	// Project is empty in snapshots saved before projects were recorded
+	Project  string   `json:"project,omitempty"`
+	Page     string   `json:"page"`
+	CommitID string   `json:"commitId,omitempty"`
+	Existed  bool     `json:"existed"`
+	Lines    []string `json:"lines"`
+}
+
+// of reports whether the snapshot is of page in project. Snapshots without a
+// project predate multi-project support and match any project.
+func (s *Snapshot) of(project, page string) bool {
+	return s.Page == page && (s.Project == "" || s.Project == project)
+}
+
+// Store persists page snapshots to an append-only JSONL file
+type Store struct {
+	path string
+	file *os.File
+	mu   sync.Mutex
+}
+
+// NewStore opens (or creates) the snapshot file at path in append mode
+func NewStore(path string) (*Store, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open undo store: %w", err)
+	}
+	return &Store{
+		path: path,
+		file: file,
+	}, nil
+}
+
+// Save appends a snapshot to the store
+func (s *Store) Save(snapshot *Snapshot) error {
+	if snapshot.Timestamp.IsZero() {
+		snapshot.Timestamp = time.Now().UTC()
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// Latest returns the most recent snapshot for the given page title of project.
+// If skipID is non-empty, the snapshot with that ID is ignored.
+func (s *Store) Latest(project, page, skipID string) (*Snapshot, error) {
+	var latest *Snapshot
+	err := s.scan(func(snapshot *Snapshot) {
+		if snapshot.of(project, page) && snapshot.ID != skipID {
+			latest = snapshot
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no snapshot found for page: %s", page)
+	}
+	return latest, nil
+}
+
+// FirstSince returns, for every page of project written since the given time,
+// the earliest snapshot taken at or after it, i.e. the page content before the
+// first of those writes
+func (s *Store) FirstSince(project string, since time.Time) (map[string]*Snapshot, error) {
+	first := make(map[string]*Snapshot)
+	err := s.scan(func(snapshot *Snapshot) {
+		if snapshot.Timestamp.Before(since) || !snapshot.of(project, snapshot.Page) {
+			return
+		}
+		if _, ok := first[snapshot.Page]; !ok {
+			first[snapshot.Page] = snapshot
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return first, nil
+}
+
+// ForPage returns every snapshot of the given page title of project, oldest first
+func (s *Store) ForPage(project, page string) ([]*Snapshot, error) {
+	var snapshots []*Snapshot
+	err := s.scan(func(snapshot *Snapshot) {
+		if snapshot.of(project, page) {
+			snapshots = append(snapshots, snapshot)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+// Get returns the snapshots recorded for the given operation ID, oldest
+// first. Writes to several pages record one snapshot per page.
+func (s *Store) Get(id string) ([]*Snapshot, error) {
+	var found []*Snapshot
+	err := s.scan(func(snapshot *Snapshot) {
+		if snapshot.ID == id {
+			found = append(found, snapshot)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("no snapshot found for id: %s", id)
+	}
+	return found, nil
+}
+
+// scan calls fn for every snapshot in the store, oldest first
+func (s *Store) scan(fn func(*Snapshot)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.Open(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to open undo store: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var snapshot Snapshot
+		if err := json.Unmarshal(scanner.Bytes(), &snapshot); err != nil {
+			// Skip partially written or corrupt lines
+			continue
+		}
+		fn(&snapshot)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read undo store: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying file
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
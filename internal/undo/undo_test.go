@@ -0,0 +1,33 @@
+package undo
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestGetReturnsEverySnapshotOfAnOperation(t *testing.T) {
+	store, err := NewStore(filepath.Join(t.TempDir(), "undo.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, snapshot := range []*Snapshot{
+		{ID: "op-1", Project: "p", Page: "Old", Existed: true, Lines: []string{"Old"}},
+		{ID: "op-2", Project: "p", Page: "Other", Existed: true, Lines: []string{"Other"}},
+		{ID: "op-1", Project: "p", Page: "New", Existed: false},
+	} {
+		if err := store.Save(snapshot); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snapshots, err := store.Get("op-1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(snapshots) != 2 || snapshots[0].Page != "Old" || snapshots[1].Page != "New" {
+		t.Errorf("Get returned %d snapshots, want Old and New", len(snapshots))
+	}
+	if _, err := store.Get("op-3"); err == nil {
+		t.Error("Get of an unknown ID succeeded")
+	}
+}
@@ -1,19 +1,25 @@
 package errors
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 // ScrapboxError represents errors from Scrapbox API
 type ScrapboxError struct {
 	Code    string
 	Message string
-	Cause   error
+	// Status is the HTTP status of the failed request, or 0 if there was none
+	Status int
+	Cause  error
 }
 
 func (e *ScrapboxError) Error() string {
+	// Causes such as dial errors may embed request details, so redact secrets
 	if e.Cause != nil {
-		return fmt.Sprintf("%s: %s (cause: %v)", e.Code, e.Message, e.Cause)
+		return Redact(fmt.Sprintf("%s: %s (cause: %v)", e.Code, e.Message, e.Cause))
 	}
-	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+	return Redact(fmt.Sprintf("%s: %s", e.Code, e.Message))
 }
 
 func (e *ScrapboxError) Unwrap() error {
@@ -22,14 +28,38 @@ func (e *ScrapboxError) Unwrap() error {
 
 // Common Scrapbox error codes
 const (
-	ErrCodeNotFound      = "SCRAPBOX_NOT_FOUND"
-	ErrCodeAuthFailed    = "SCRAPBOX_AUTH_FAILED"
-	ErrCodeNetworkError  = "SCRAPBOX_NETWORK_ERROR"
-	ErrCodeInvalidInput  = "SCRAPBOX_INVALID_INPUT"
-	ErrCodeRateLimit     = "SCRAPBOX_RATE_LIMIT"
-	ErrCodeWebSocketFail = "SCRAPBOX_WEBSOCKET_FAILED"
+	ErrCodeNotFound       = "SCRAPBOX_NOT_FOUND"
+	ErrCodeAuthFailed     = "SCRAPBOX_AUTH_FAILED"
+	ErrCodeSessionExpired = "SCRAPBOX_SESSION_EXPIRED"
+	ErrCodeNetworkError   = "SCRAPBOX_NETWORK_ERROR"
+	ErrCodeInvalidInput   = "SCRAPBOX_INVALID_INPUT"
+	ErrCodeRateLimit      = "SCRAPBOX_RATE_LIMIT"
+	ErrCodeWebSocketFail  = "SCRAPBOX_WEBSOCKET_FAILED"
+
+	// Commit failures reported in the Socket.IO ACK
+	ErrCodeCommitConflict   = "SCRAPBOX_COMMIT_CONFLICT"
+	ErrCodePermissionDenied = "SCRAPBOX_PERMISSION_DENIED"
+	ErrCodeInvalidChange    = "SCRAPBOX_INVALID_CHANGE"
+	ErrCodeQuotaExceeded    = "SCRAPBOX_QUOTA_EXCEEDED"
+	ErrCodePageExists       = "SCRAPBOX_PAGE_EXISTS"
 )
 
+// IsRetryable reports whether err is a transient Scrapbox failure that may
+// succeed if retried (after refetching the page for commit conflicts).
+// Permission, authentication, validation and quota errors are fatal.
+func IsRetryable(err error) bool {
+	var sbErr *ScrapboxError
+	if !errors.As(err, &sbErr) {
+		return false
+	}
+	switch sbErr.Code {
+	case ErrCodeCommitConflict, ErrCodeNetworkError, ErrCodeRateLimit, ErrCodeWebSocketFail:
+		return true
+	default:
+		return false
+	}
+}
+
 // MCPError represents JSON-RPC errors
 type MCPError struct {
 	Code    int         `json:"code"`
@@ -56,6 +86,7 @@ const (
 	ErrCodeUnauthorized     = -32001
 	ErrCodeToolExecutionErr = -32002
 	ErrCodeSessionNotFound  = -32003
+	ErrCodeToolTimeout      = -32004
 )
 
 // NewMCPError creates a new MCP error
@@ -75,3 +106,12 @@ func NewScrapboxError(code, message string, cause error) *ScrapboxError {
 		Cause:   cause,
 	}
 }
+
+// NewScrapboxStatusError creates a Scrapbox error for an HTTP response with the given status
+func NewScrapboxStatusError(code, message string, status int) *ScrapboxError {
+	return &ScrapboxError{
+		Code:    code,
+		Message: message,
+		Status:  status,
+	}
+}
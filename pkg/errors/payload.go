@@ -0,0 +1,122 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error codes for tool failures that do not come from Scrapbox
+const (
+	ErrCodeTool             = "TOOL_ERROR"
+	ErrCodeToolNotFound     = "TOOL_NOT_FOUND"
+	ErrCodeToolTimedOut     = "TOOL_TIMEOUT"
+	ErrCodeInvalidArguments = "INVALID_ARGUMENTS"
+	ErrCodeUsageQuota       = "TOOL_QUOTA_EXCEEDED"
+	ErrCodeConfirmation     = "TOOL_CONFIRMATION_INVALID"
+	ErrCodeAmbiguousTitle   = "TOOL_TITLE_AMBIGUOUS"
+	ErrCodeOutOfScope       = "TOOL_PROJECT_OUT_OF_SCOPE"
+	ErrCodeForbidden        = "TOOL_FORBIDDEN"
+)
+
+// ToolError is a failure raised by the server itself rather than Scrapbox,
+// e.g. by middleware refusing a call
+type ToolError struct {
+	Code      string
+	Message   string
+	Retryable bool
+}
+
+func (e *ToolError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// NewToolError creates a new tool error
+func NewToolError(code, message string, retryable bool) *ToolError {
+	return &ToolError{
+		Code:      code,
+		Message:   message,
+		Retryable: retryable,
+	}
+}
+
+// ErrorData is the machine-readable description of a failed tool call. It is
+// sent as the JSON-RPC error data and in the isError tool result.
+type ErrorData struct {
+	// Error is the redacted error message
+	Error string `json:"error"`
+	// Code is a Scrapbox error code (SCRAPBOX_*) or one of the TOOL_* codes
+	Code string `json:"code"`
+	// Status is the HTTP status returned by Scrapbox, if any
+	Status int `json:"status,omitempty"`
+	// Retryable reports whether repeating the call may succeed
+	Retryable bool `json:"retryable"`
+	// Action suggests what the caller should do next
+	Action string `json:"action,omitempty"`
+}
+
+// NewErrorData describes err. Scrapbox errors and tool errors keep their
+// code; other errors get code as given.
+func NewErrorData(code string, err error) *ErrorData {
+	data := &ErrorData{
+		Error: Redact(err.Error()),
+		Code:  code,
+	}
+	var sbErr *ScrapboxError
+	var toolErr *ToolError
+	switch {
+	case errors.As(err, &sbErr):
+		data.Code = sbErr.Code
+		data.Status = sbErr.Status
+		data.Retryable = IsRetryable(err)
+	case errors.As(err, &toolErr):
+		data.Code = toolErr.Code
+		data.Retryable = toolErr.Retryable
+	}
+	if code == ErrCodeToolTimedOut {
+		data.Retryable = true
+	}
+	data.Action = SuggestedAction(data.Code)
+	return data
+}
+
+// SuggestedAction returns what a caller should do after an error with the given code
+func SuggestedAction(code string) string {
+	switch code {
+	case ErrCodeNotFound:
+		return "Check the title or project name, e.g. with check_page_exists or search_pages."
+	case ErrCodeAuthFailed:
+		return "The session cannot access this project; retrying will not help."
+	case ErrCodeSessionExpired:
+		return "The server's Scrapbox session has expired; an administrator must refresh COSENSE_SID."
+	case ErrCodeNetworkError, ErrCodeWebSocketFail:
+		return "Retry after a short delay."
+	case ErrCodeRateLimit:
+		return "Wait before retrying; Scrapbox is rate limiting requests."
+	case ErrCodeCommitConflict:
+		return "Fetch the page again with get_page, re-apply the change to the current content and retry with the new commitId as expected_commit_id."
+	case ErrCodePermissionDenied:
+		return "The user cannot edit this page or project; do not retry."
+	case ErrCodeInvalidChange, ErrCodeInvalidInput, ErrCodeInvalidArguments:
+		return "Fix the arguments and call again."
+	case ErrCodeQuotaExceeded:
+		return "The project is over its quota; free space before writing again."
+	case ErrCodePageExists:
+		return "Use if_exists=append or if_exists=overwrite, or edit the existing page with edit_page."
+	case ErrCodeToolNotFound:
+		return "Call tools/list for the available tools."
+	case ErrCodeUsageQuota:
+		return "This session has used up its quota for the tool; wait until the time given in the error or ask an operator to raise TOOL_QUOTAS."
+	case ErrCodeConfirmation:
+		return "Call the tool again without confirmation_token to get a new preview and token."
+	case ErrCodeAmbiguousTitle:
+		return "Call the tool again with the exact title of one of the matching pages."
+	case ErrCodeOutOfScope:
+		return "Use one of the projects granted by the client's roots; do not retry with this project."
+	case ErrCodeForbidden:
+		return "The session's role does not allow this tool; do not retry, or ask an operator for a token with a higher role."
+	case ErrCodeToolTimedOut:
+		return "A write may still complete in the background; read the page before repeating it."
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,116 @@
+package errors
+
+import (
+	"io"
+	"strings"
+	"sync"
+)
+
+// redactedPlaceholder replaces secret values in redacted text
+const redactedPlaceholder = "[REDACTED]"
+
+// maxReleasedSecrets bounds how many released held secrets stay redacted
+const maxReleasedSecrets = 256
+
+var (
+	secretsMu sync.RWMutex
+	secrets   []string
+	// held counts the holders of each secret registered with HoldSecret
+	held = make(map[string]int)
+	// released are the most recently released held secrets, oldest first
+	released []string
+)
+
+// RegisterSecret marks a value (e.g. the session cookie) that must never appear
+// in logs or error payloads. Empty values are ignored.
+func RegisterSecret(secret string) {
+	if secret == "" {
+		return
+	}
+
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, s := range secrets {
+		if s == secret {
+			return
+		}
+	}
+	secrets = append(secrets, secret)
+}
+
+// HoldSecret marks a value that must not appear in logs while it is in use,
+// such as the cookie of a client's session, and returns the function that
+// releases it. Unlike RegisterSecret the list does not grow for the life of
+// the process: once every holder released a secret it is only kept among the
+// last maxReleasedSecrets released ones, so lines logged about a call that
+// just failed are still redacted. Empty values are ignored.
+func HoldSecret(secret string) (release func()) {
+	if secret == "" {
+		return func() {}
+	}
+
+	secretsMu.Lock()
+	held[secret]++
+	released = removeSecret(released, secret)
+	secretsMu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			secretsMu.Lock()
+			defer secretsMu.Unlock()
+			if held[secret]--; held[secret] > 0 {
+				return
+			}
+			delete(held, secret)
+			released = append(released, secret)
+			if len(released) > maxReleasedSecrets {
+				released = released[len(released)-maxReleasedSecrets:]
+			}
+		})
+	}
+}
+
+// removeSecret returns list without secret
+func removeSecret(list []string, secret string) []string {
+	for i, s := range list {
+		if s == secret {
+			return append(list[:i], list[i+1:]...)
+		}
+	}
+	return list
+}
+
+// Redact replaces every registered secret in text with a placeholder
+func Redact(text string) string {
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+	for _, s := range secrets {
+		text = strings.ReplaceAll(text, s, redactedPlaceholder)
+	}
+	for s := range held {
+		text = strings.ReplaceAll(text, s, redactedPlaceholder)
+	}
+	for _, s := range released {
+		text = strings.ReplaceAll(text, s, redactedPlaceholder)
+	}
+	return text
+}
+
+// redactingWriter redacts secrets from everything written through it
+type redactingWriter struct {
+	w io.Writer
+}
+
+// NewRedactingWriter wraps w so registered secrets are redacted, e.g. for log output
+func NewRedactingWriter(w io.Writer) io.Writer {
+	return &redactingWriter{w: w}
+}
+
+func (rw *redactingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(rw.w, Redact(string(p))); err != nil {
+		return 0, err
+	}
+	// Report the original length so callers don't treat redaction as a short write
+	return len(p), nil
+}
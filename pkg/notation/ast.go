@@ -0,0 +1,379 @@
+package notation
+
+import (
+	"strings"
+)
+
+// BlockType is the kind of a Block
+type BlockType int
+
+const (
+	// BlockLine is an ordinary line
+	BlockLine BlockType = iota
+	// BlockCode is a code:name block; its body is kept verbatim
+	BlockCode
+	// BlockTable is a table:name block; each row is parsed as a line
+	BlockTable
+)
+
+// NodeType is the kind of an inline Node
+type NodeType int
+
+const (
+	// NodeText is plain text
+	NodeText NodeType = iota
+	// NodeLink is a [page] link; Text is the page title
+	NodeLink
+	// NodeProjectLink is a [/project/page] link; Text is "/project/page"
+	NodeProjectLink
+	// NodeExternalLink is a bracketed URL, optionally labelled:
+	// [url], [url label] or [label url]. Text is the URL.
+	NodeExternalLink
+	// NodeURL is a bare URL
+	NodeURL
+	// NodeHashtag is a #tag; Text is the tag without "#"
+	NodeHashtag
+	// NodeIcon is an [name.icon] icon; Text is the bracket content
+	NodeIcon
+	// NodeDecoration is [marks text], e.g. [* bold] or [/ italic]
+	NodeDecoration
+	// NodeStrong is [[text]]
+	NodeStrong
+	// NodeMath is [$ formula]; Text is the formula
+	NodeMath
+	// NodeCode is `inline code`; Text is the code
+	NodeCode
+)
+
+// Document is a parsed page. Serializing it with Lines returns the original
+// lines unchanged unless nodes were modified.
+type Document struct {
+	Blocks []Block
+}
+
+// Block is one line, or a code or table block spanning several lines
+type Block struct {
+	Type BlockType
+	// Line is the content of a BlockLine
+	Line Line
+	// Indent is the indentation of a code or table header
+	Indent string
+	// Name follows "code:" or "table:" in the header
+	Name string
+	// Code holds the raw body lines of a BlockCode
+	Code []string
+	// Rows holds the body lines of a BlockTable; cells are tab-separated
+	Rows []Line
+}
+
+// Line is a parsed line
+type Line struct {
+	// Indent is the leading whitespace; its length is the indent level
+	Indent string
+	// Quote is set for lines starting with ">"
+	Quote bool
+	Nodes []Node
+}
+
+// Node is an inline element
+type Node struct {
+	Type NodeType
+	Text string
+	// Label is the label of a NodeExternalLink
+	Label string
+	// LabelFirst is set for [label url] links
+	LabelFirst bool
+	// Marks are the decoration characters of a NodeDecoration
+	Marks string
+	// Children are the contents of a NodeDecoration or NodeStrong
+	Children []Node
+}
+
+// Parse parses page lines into a Document
+func Parse(lines []string) *Document {
+	doc := &Document{}
+	var block *Block
+
+	for _, line := range lines {
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t　"))]
+		body := line[len(indent):]
+
+		// Lines indented below a code: or table: header belong to the block
+		if block != nil {
+			if IndentLevel(indent) > IndentLevel(block.Indent) && body != "" {
+				if block.Type == BlockCode {
+					block.Code = append(block.Code, line)
+				} else {
+					block.Rows = append(block.Rows, parseLine(indent, body))
+				}
+				continue
+			}
+			block = nil
+		}
+
+		switch {
+		case strings.HasPrefix(body, "code:"):
+			doc.Blocks = append(doc.Blocks, Block{Type: BlockCode, Indent: indent, Name: body[len("code:"):]})
+			block = &doc.Blocks[len(doc.Blocks)-1]
+		case strings.HasPrefix(body, "table:"):
+			doc.Blocks = append(doc.Blocks, Block{Type: BlockTable, Indent: indent, Name: body[len("table:"):]})
+			block = &doc.Blocks[len(doc.Blocks)-1]
+		default:
+			doc.Blocks = append(doc.Blocks, Block{Type: BlockLine, Line: parseLine(indent, body)})
+		}
+	}
+	return doc
+}
+
+// Len returns the number of page lines the block spans
+func (b Block) Len() int {
+	switch b.Type {
+	case BlockCode:
+		return 1 + len(b.Code)
+	case BlockTable:
+		return 1 + len(b.Rows)
+	default:
+		return 1
+	}
+}
+
+// Lines serializes the document back into page lines
+func (d *Document) Lines() []string {
+	var lines []string
+	for _, block := range d.Blocks {
+		switch block.Type {
+		case BlockCode:
+			lines = append(lines, block.Indent+"code:"+block.Name)
+			lines = append(lines, block.Code...)
+		case BlockTable:
+			lines = append(lines, block.Indent+"table:"+block.Name)
+			for _, row := range block.Rows {
+				lines = append(lines, row.String())
+			}
+		default:
+			lines = append(lines, block.Line.String())
+		}
+	}
+	return lines
+}
+
+// Walk calls fn for every inline node of the document in order of
+// appearance, including the children of decorations. Code blocks have no
+// nodes. fn may modify the node.
+func (d *Document) Walk(fn func(n *Node)) {
+	for i := range d.Blocks {
+		block := &d.Blocks[i]
+		switch block.Type {
+		case BlockLine:
+			Walk(block.Line.Nodes, fn)
+		case BlockTable:
+			for j := range block.Rows {
+				Walk(block.Rows[j].Nodes, fn)
+			}
+		}
+	}
+}
+
+// Walk calls fn for each node and then its children
+func Walk(nodes []Node, fn func(n *Node)) {
+	for i := range nodes {
+		fn(&nodes[i])
+		Walk(nodes[i].Children, fn)
+	}
+}
+
+// String serializes the line
+func (l Line) String() string {
+	prefix := l.Indent
+	if l.Quote {
+		prefix += ">"
+	}
+	return prefix + FormatInline(l.Nodes)
+}
+
+func parseLine(indent, body string) Line {
+	line := Line{Indent: indent}
+	if strings.HasPrefix(body, ">") {
+		line.Quote = true
+		body = body[1:]
+	}
+	line.Nodes = ParseInline(body)
+	return line
+}
+
+// ParseInline parses the inline elements of a line without its indentation
+func ParseInline(s string) []Node {
+	var nodes []Node
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, Node{Type: NodeText, Text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); {
+		switch {
+		case s[i] == '`':
+			// Inline code runs to the next backtick
+			end := strings.IndexByte(s[i+1:], '`')
+			if end < 0 {
+				text.WriteString(s[i:])
+				i = len(s)
+				continue
+			}
+			flush()
+			nodes = append(nodes, Node{Type: NodeCode, Text: s[i+1 : i+1+end]})
+			i += end + 2
+
+		case strings.HasPrefix(s[i:], "[["):
+			end := strings.Index(s[i+2:], "]]")
+			if end < 0 {
+				text.WriteString("[[")
+				i += 2
+				continue
+			}
+			flush()
+			nodes = append(nodes, Node{Type: NodeStrong, Children: ParseInline(s[i+2 : i+2+end])})
+			i += end + 4
+
+		case s[i] == '[':
+			end := closingBracket(s[i+1:])
+			if end < 0 || end == 0 {
+				text.WriteByte('[')
+				i++
+				continue
+			}
+			flush()
+			nodes = append(nodes, parseBracket(s[i+1:i+1+end]))
+			i += end + 2
+
+		case s[i] == '#' && (i == 0 || isSpace(s[i-1])):
+			end := i + 1
+			for end < len(s) && !isSpace(s[end]) && s[end] != '[' && s[end] != ']' {
+				end++
+			}
+			if end == i+1 {
+				text.WriteByte('#')
+				i++
+				continue
+			}
+			flush()
+			nodes = append(nodes, Node{Type: NodeHashtag, Text: s[i+1 : end]})
+			i = end
+
+		case IsURL(s[i:]) && (i == 0 || isSpace(s[i-1])):
+			end := i
+			for end < len(s) && !isSpace(s[end]) {
+				end++
+			}
+			flush()
+			nodes = append(nodes, Node{Type: NodeURL, Text: s[i:end]})
+			i = end
+
+		default:
+			text.WriteByte(s[i])
+			i++
+		}
+	}
+	flush()
+	return nodes
+}
+
+// closingBracket returns the index in s of the ']' closing a bracket opened
+// just before s, or -1. Decorations may contain links ([* [Foo]]), so their
+// brackets nest, skipping inline code; other brackets end at the first ']'.
+func closingBracket(s string) int {
+	first := strings.IndexByte(s, ']')
+	sep := strings.IndexByte(s, ' ')
+	if first < 0 || sep <= 0 || sep > first || !isDecoration(s[:sep]) {
+		return first
+	}
+	depth := 1
+	for j := sep; j < len(s); j++ {
+		switch s[j] {
+		case '`':
+			if end := strings.IndexByte(s[j+1:], '`'); end >= 0 {
+				j += end + 1
+			}
+		case '[':
+			depth++
+		case ']':
+			if depth--; depth == 0 {
+				return j
+			}
+		}
+	}
+	// Unbalanced: fall back to the first ']'
+	return first
+}
+
+// parseBracket classifies the content of a single-bracket expression
+func parseBracket(content string) Node {
+	// Decorations ([* bold], [/ italic], [- strike], [$ math] ...) start with
+	// symbol characters followed by a space
+	if sep := strings.IndexByte(content, ' '); sep > 0 && isDecoration(content[:sep]) {
+		if content[:sep] == "$" {
+			return Node{Type: NodeMath, Text: content[sep+1:]}
+		}
+		return Node{Type: NodeDecoration, Marks: content[:sep], Children: ParseInline(content[sep+1:])}
+	}
+
+	// [https://... label] or [label https://...]
+	if sep := strings.IndexByte(content, ' '); IsURL(content) && sep < 0 {
+		return Node{Type: NodeExternalLink, Text: content}
+	} else if IsURL(content) {
+		return Node{Type: NodeExternalLink, Text: content[:sep], Label: content[sep+1:]}
+	}
+	if sep := strings.LastIndexByte(content, ' '); sep >= 0 && IsURL(content[sep+1:]) {
+		return Node{Type: NodeExternalLink, Text: content[sep+1:], Label: content[:sep], LabelFirst: true}
+	}
+
+	// Icons link to the user's page but are rendered as images
+	if strings.HasSuffix(content, ".icon") || strings.Contains(content, ".icon*") {
+		return Node{Type: NodeIcon, Text: content}
+	}
+
+	if strings.HasPrefix(content, "/") {
+		return Node{Type: NodeProjectLink, Text: content}
+	}
+	return Node{Type: NodeLink, Text: content}
+}
+
+// FormatInline serializes inline nodes
+func FormatInline(nodes []Node) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		b.WriteString(n.String())
+	}
+	return b.String()
+}
+
+// String serializes the node
+func (n Node) String() string {
+	switch n.Type {
+	case NodeLink, NodeProjectLink, NodeIcon:
+		return "[" + n.Text + "]"
+	case NodeExternalLink:
+		switch {
+		case n.Label == "":
+			return "[" + n.Text + "]"
+		case n.LabelFirst:
+			return "[" + n.Label + " " + n.Text + "]"
+		default:
+			return "[" + n.Text + " " + n.Label + "]"
+		}
+	case NodeHashtag:
+		return "#" + n.Text
+	case NodeDecoration:
+		return "[" + n.Marks + " " + FormatInline(n.Children) + "]"
+	case NodeStrong:
+		return "[[" + FormatInline(n.Children) + "]]"
+	case NodeMath:
+		return "[$ " + n.Text + "]"
+	case NodeCode:
+		return "`" + n.Text + "`"
+	default:
+		return n.Text
+	}
+}
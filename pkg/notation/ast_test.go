@@ -0,0 +1,144 @@
+package notation
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseInlineNestedDecorations(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []Node
+	}{
+		{
+			name:  "bold link",
+			input: "[* [Foo]]",
+			want: []Node{{Type: NodeDecoration, Marks: "*", Children: []Node{
+				{Type: NodeLink, Text: "Foo"},
+			}}},
+		},
+		{
+			name:  "link among decorated text",
+			input: "[/ see [Foo] here] after",
+			want: []Node{
+				{Type: NodeDecoration, Marks: "/", Children: []Node{
+					{Type: NodeText, Text: "see "},
+					{Type: NodeLink, Text: "Foo"},
+					{Type: NodeText, Text: " here"},
+				}},
+				{Type: NodeText, Text: " after"},
+			},
+		},
+		{
+			name:  "nested decorations",
+			input: "[** [- [Foo]]]",
+			want: []Node{{Type: NodeDecoration, Marks: "**", Children: []Node{
+				{Type: NodeDecoration, Marks: "-", Children: []Node{
+					{Type: NodeLink, Text: "Foo"},
+				}},
+			}}},
+		},
+		{
+			name:  "inline code brackets do not nest",
+			input: "[* `]` [Foo]]",
+			want: []Node{{Type: NodeDecoration, Marks: "*", Children: []Node{
+				{Type: NodeCode, Text: "]"},
+				{Type: NodeText, Text: " "},
+				{Type: NodeLink, Text: "Foo"},
+			}}},
+		},
+		{
+			name:  "decoration followed by link",
+			input: "[* bold] [Foo]",
+			want: []Node{
+				{Type: NodeDecoration, Marks: "*", Children: []Node{{Type: NodeText, Text: "bold"}}},
+				{Type: NodeText, Text: " "},
+				{Type: NodeLink, Text: "Foo"},
+			},
+		},
+		{
+			name:  "unbalanced decoration ends at first bracket",
+			input: "[* [Foo]",
+			want: []Node{{Type: NodeDecoration, Marks: "*", Children: []Node{
+				{Type: NodeText, Text: "[Foo"},
+			}}},
+		},
+		{
+			name:  "plain link ends at first bracket",
+			input: "[Foo]]",
+			want: []Node{
+				{Type: NodeLink, Text: "Foo"},
+				{Type: NodeText, Text: "]"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseInline(tt.input)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseInline(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+			if s := FormatInline(got); s != tt.input {
+				t.Errorf("FormatInline(ParseInline(%q)) = %q", tt.input, s)
+			}
+		})
+	}
+}
+
+func TestRewriteLinksInDecorations(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []string
+		count int
+	}{
+		{
+			name:  "bold link",
+			lines: []string{"Page", "[* [Foo]]"},
+			want:  []string{"Page", "[* [Bar]]"},
+			count: 1,
+		},
+		{
+			name:  "nested decorations",
+			lines: []string{"Page", "[** [- see [foo] and [Other]]]"},
+			want:  []string{"Page", "[** [- see [Bar] and [Other]]]"},
+			count: 1,
+		},
+		{
+			name:  "project link in decoration",
+			lines: []string{"Page", "[/ [/proj/Foo]]"},
+			want:  []string{"Page", "[/ [/proj/Bar]]"},
+			count: 1,
+		},
+		{
+			name:  "inline code untouched",
+			lines: []string{"Page", "[* `[Foo]`]"},
+			want:  []string{"Page", "[* `[Foo]`]"},
+			count: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, count := RewriteLinks(tt.lines, "Foo", "Bar", "proj")
+			if count != tt.count || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RewriteLinks(%q) = %q, %d; want %q, %d", tt.lines, got, count, tt.want, tt.count)
+			}
+		})
+	}
+}
+
+func TestExtractLinksInDecorations(t *testing.T) {
+	links := ExtractLinks([]string{"Page", "[* [Foo]] [/ [https://example.com/ site]] #tag"})
+	if want := []string{"Foo"}; !reflect.DeepEqual(links.InternalLinks, want) {
+		t.Errorf("InternalLinks = %q, want %q", links.InternalLinks, want)
+	}
+	if want := []string{"https://example.com/"}; !reflect.DeepEqual(links.ExternalURLs, want) {
+		t.Errorf("ExternalURLs = %q, want %q", links.ExternalURLs, want)
+	}
+	if want := []string{"tag"}; !reflect.DeepEqual(links.Tags, want) {
+		t.Errorf("Tags = %q, want %q", links.Tags, want)
+	}
+}
@@ -0,0 +1,350 @@
+package notation
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// FromMarkdownOptions controls how FromMarkdown resolves links to other notes
+type FromMarkdownOptions struct {
+	// ResolveLink maps the target of a [[wiki link]] or a relative
+	// [text](note.md) link to a page title. When it is nil or returns "",
+	// wiki links keep their target as the title and relative links become
+	// their text.
+	ResolveLink func(target string) string
+}
+
+var (
+	mdHeading   = regexp.MustCompile(`^(#{1,6})\s+(.*?)(?:\s+#+)?\s*$`)
+	mdListItem  = regexp.MustCompile(`^([ \t]*)([-*+]|\d+[.)])\s+(.*)$`)
+	mdRule      = regexp.MustCompile(`^\s*([-*_])(\s*([-*_]))*\s*$`)
+	mdTableRule = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	mdFence     = regexp.MustCompile("^([ \t]*)(```+|~~~+)\\s*([^`\\s]*)")
+)
+
+// FromMarkdown converts Markdown to page lines (without a title line).
+// Headings become [** ] style decorations (# is the largest), list items
+// indented lines, fenced code a code: block and pipe tables a table: block.
+// Inline emphasis, code, links, images and [[wiki links]] are converted;
+// other syntax is kept as text.
+func FromMarkdown(text string, opts FromMarkdownOptions) []string {
+	var lines []string
+	// listIndents holds the indentation width of each open list level
+	var listIndents []int
+	// fence is the open code fence; mdIndent its indentation in the Markdown
+	// and codeIndent that of the code: line
+	var fence, mdIndent, codeIndent string
+	inTable := false
+
+	emit := func(line string) {
+		// Collapse runs of blank lines
+		if line == "" && (len(lines) == 0 || lines[len(lines)-1] == "") {
+			return
+		}
+		lines = append(lines, line)
+	}
+
+	for _, raw := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if fence != "" {
+			if strings.HasPrefix(strings.TrimSpace(raw), fence) && strings.Trim(strings.TrimSpace(raw), fence[:1]) == "" {
+				fence = ""
+				continue
+			}
+			lines = append(lines, codeIndent+" "+strings.TrimPrefix(raw, mdIndent))
+			continue
+		}
+
+		trimmed := strings.TrimSpace(raw)
+		if inTable && !strings.HasPrefix(trimmed, "|") {
+			inTable = false
+		}
+
+		switch {
+		case trimmed == "":
+			emit("")
+
+		case mdFence.MatchString(raw):
+			m := mdFence.FindStringSubmatch(raw)
+			fence, mdIndent = m[2], m[1]
+			lang := m[3]
+			if lang == "" {
+				lang = "text"
+			}
+			codeIndent = strings.Repeat(" ", listLevel(listIndents, indentWidth(m[1]), false))
+			lines = append(lines, codeIndent+"code:"+lang)
+
+		case strings.HasPrefix(trimmed, "|"):
+			if mdTableRule.MatchString(trimmed) {
+				continue
+			}
+			if !inTable {
+				inTable = true
+				lines = append(lines, "table:table")
+			}
+			cells := strings.Split(strings.Trim(trimmed, "|"), "|")
+			for i, cell := range cells {
+				cells[i] = inlineFromMarkdown(strings.TrimSpace(cell), opts)
+			}
+			lines = append(lines, " "+strings.Join(cells, "\t"))
+
+		case mdRule.MatchString(raw) && len(strings.ReplaceAll(trimmed, " ", "")) >= 3:
+			listIndents = nil
+			emit("")
+
+		case mdHeading.MatchString(trimmed):
+			listIndents = nil
+			m := mdHeading.FindStringSubmatch(trimmed)
+			stars := max(1, 5-len(m[1]))
+			lines = append(lines, "["+strings.Repeat("*", stars)+" "+inlineFromMarkdown(m[2], opts)+"]")
+
+		case mdListItem.MatchString(raw):
+			m := mdListItem.FindStringSubmatch(raw)
+			width := indentWidth(m[1])
+			for len(listIndents) > 0 && listIndents[len(listIndents)-1] > width {
+				listIndents = listIndents[:len(listIndents)-1]
+			}
+			if len(listIndents) == 0 || listIndents[len(listIndents)-1] < width {
+				listIndents = append(listIndents, width)
+			}
+			item := m[3]
+			switch {
+			case strings.HasPrefix(item, "[ ] "):
+				// Task markers follow the default TASK_MARKERS convention
+				item = "[ ] " + inlineFromMarkdown(item[4:], opts)
+			case strings.HasPrefix(item, "[x] "), strings.HasPrefix(item, "[X] "):
+				item = "✅ " + inlineFromMarkdown(item[4:], opts)
+			default:
+				item = inlineFromMarkdown(item, opts)
+			}
+			if m[2] != "-" && m[2] != "*" && m[2] != "+" {
+				item = m[2] + " " + item
+			}
+			lines = append(lines, strings.Repeat(" ", len(listIndents))+item)
+
+		case strings.HasPrefix(trimmed, ">"):
+			listIndents = nil
+			quote := strings.TrimSpace(strings.TrimLeft(trimmed, ">"))
+			lines = append(lines, ">"+inlineFromMarkdown(quote, opts))
+
+		default:
+			// Indented text continues the current list item
+			level := listLevel(listIndents, indentWidth(raw[:len(raw)-len(strings.TrimLeft(raw, " \t"))]), true)
+			if level == 0 {
+				listIndents = nil
+			}
+			lines = append(lines, strings.Repeat(" ", level)+inlineFromMarkdown(trimmed, opts))
+		}
+	}
+
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// indentWidth counts leading whitespace with tabs as four columns
+func indentWidth(indent string) int {
+	width := 0
+	for _, r := range indent {
+		if r == '\t' {
+			width += 4
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+// listLevel returns the indent level of a line indented by width inside the
+// open list levels; continuation lines sit one level below their item
+func listLevel(listIndents []int, width int, continuation bool) int {
+	if width == 0 || len(listIndents) == 0 {
+		return 0
+	}
+	level := 0
+	for level < len(listIndents) && listIndents[level] < width {
+		level++
+	}
+	if continuation {
+		return max(1, level)
+	}
+	return level
+}
+
+// inlineFromMarkdown converts the inline syntax of one Markdown line
+func inlineFromMarkdown(s string, opts FromMarkdownOptions) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		rest := s[i:]
+		switch {
+		case rest[0] == '\\' && len(rest) > 1 && strings.ContainsRune("\\`*_{}[]()#+-.!|<>~$", rune(rest[1])):
+			b.WriteByte(rest[1])
+			i += 2
+
+		case rest[0] == '`':
+			ticks := len(rest) - len(strings.TrimLeft(rest, "`"))
+			end := strings.Index(rest[ticks:], rest[:ticks])
+			if end < 0 {
+				b.WriteString(rest[:ticks])
+				i += ticks
+				continue
+			}
+			b.WriteString("`" + strings.TrimSpace(rest[ticks:ticks+end]) + "`")
+			i += 2*ticks + end
+
+		case strings.HasPrefix(rest, "[[") || strings.HasPrefix(rest, "![["):
+			start := strings.Index(rest, "[[") + 2
+			end := strings.Index(rest[start:], "]]")
+			if end < 0 {
+				b.WriteString(rest[:start])
+				i += start
+				continue
+			}
+			target := rest[start : start+end]
+			target, _, _ = strings.Cut(target, "|")
+			target, _, _ = strings.Cut(target, "#")
+			title := resolveLink(opts, strings.TrimSpace(target))
+			if title == "" {
+				title = strings.TrimSpace(target)
+			}
+			b.WriteString("[" + title + "]")
+			i += start + end + 2
+
+		case strings.HasPrefix(rest, "![") || rest[0] == '[':
+			image := rest[0] == '!'
+			label, target, n, ok := markdownLinkAt(rest)
+			if !ok {
+				b.WriteByte(rest[0])
+				i++
+				continue
+			}
+			b.WriteString(linkFromMarkdown(label, target, image, opts))
+			i += n
+
+		case rest[0] == '<' && IsURL(rest[1:]) && strings.IndexByte(rest, '>') > 0:
+			end := strings.IndexByte(rest, '>')
+			b.WriteString(rest[1:end])
+			i += end + 1
+
+		case strings.HasPrefix(rest, "**") || strings.HasPrefix(rest, "__"):
+			end := strings.Index(rest[2:], rest[:2])
+			if end <= 0 {
+				b.WriteString(rest[:2])
+				i += 2
+				continue
+			}
+			b.WriteString("[* " + inlineFromMarkdown(rest[2:2+end], opts) + "]")
+			i += end + 4
+
+		case strings.HasPrefix(rest, "~~"):
+			end := strings.Index(rest[2:], "~~")
+			if end <= 0 {
+				b.WriteString("~~")
+				i += 2
+				continue
+			}
+			b.WriteString("[- " + inlineFromMarkdown(rest[2:2+end], opts) + "]")
+			i += end + 4
+
+		case (rest[0] == '*' || rest[0] == '_') && emphasisOpens(s, i):
+			end := strings.IndexByte(rest[1:], rest[0])
+			if end <= 0 || rest[end] == ' ' {
+				b.WriteByte(rest[0])
+				i++
+				continue
+			}
+			b.WriteString("[/ " + inlineFromMarkdown(rest[1:1+end], opts) + "]")
+			i += end + 2
+
+		default:
+			b.WriteByte(rest[0])
+			i++
+		}
+	}
+	return b.String()
+}
+
+// emphasisOpens reports whether the * or _ at i can open emphasis: it is
+// followed by a non-space, and an _ is not inside a word (snake_case)
+func emphasisOpens(s string, i int) bool {
+	if i+1 >= len(s) || s[i+1] == ' ' {
+		return false
+	}
+	if s[i] == '_' && i > 0 && isWordByte(s[i-1]) {
+		return false
+	}
+	return true
+}
+
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
+
+// markdownLinkAt parses [label](target) or ![label](target) at the start of
+// s and returns its parts and length
+func markdownLinkAt(s string) (label, target string, n int, ok bool) {
+	start := strings.IndexByte(s, '[') + 1
+	depth := 1
+	end := start
+	for ; end < len(s) && depth > 0; end++ {
+		switch s[end] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		}
+	}
+	if depth != 0 || end >= len(s) || s[end] != '(' {
+		return "", "", 0, false
+	}
+	closing := strings.IndexByte(s[end:], ')')
+	if closing < 0 {
+		return "", "", 0, false
+	}
+	target = strings.TrimSpace(s[end+1 : end+closing])
+	// Drop an optional "title"
+	if sp := strings.IndexAny(target, " \t"); sp > 0 {
+		target = target[:sp]
+	}
+	target = strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">")
+	return s[start : end-1], target, end + closing + 1, true
+}
+
+// linkFromMarkdown converts a Markdown link or image to Scrapbox notation
+func linkFromMarkdown(label, target string, image bool, opts FromMarkdownOptions) string {
+	text := plainMarkdown(label)
+	switch {
+	case IsURL(target) && image:
+		return "[" + target + "]"
+	case IsURL(target) && (text == "" || text == target):
+		return "[" + target + "]"
+	case IsURL(target):
+		return "[" + text + " " + target + "]"
+	case image || strings.Contains(target, ":"):
+		// Local images and other schemes (mailto:) cannot be linked
+		return text
+	}
+
+	path, _, _ := strings.Cut(target, "#")
+	if unescaped, err := url.PathUnescape(path); err == nil {
+		path = unescaped
+	}
+	if title := resolveLink(opts, path); title != "" {
+		return "[" + title + "]"
+	}
+	return text
+}
+
+func resolveLink(opts FromMarkdownOptions, target string) string {
+	if opts.ResolveLink == nil || target == "" {
+		return ""
+	}
+	return opts.ResolveLink(target)
+}
+
+// plainMarkdown strips emphasis and code marks from a link label, which
+// cannot hold decorations in Scrapbox
+func plainMarkdown(s string) string {
+	return strings.TrimSpace(strings.NewReplacer("**", "", "__", "", "`", "", "[", "", "]", "").Replace(s))
+}
@@ -0,0 +1,79 @@
+// Package notation parses Scrapbox page notation into an AST (see Parse) and
+// serializes it back. Link extraction and rewriting are built on it.
+package notation
+
+import (
+	"strings"
+)
+
+// Links holds the links found in a page, each list deduplicated in order of appearance
+type Links struct {
+	// InternalLinks are [page] links within the same project
+	InternalLinks []string
+	// ProjectLinks are [/project/page] links to other projects
+	ProjectLinks []string
+	// Tags are #hashtags (without the leading #)
+	Tags []string
+	// ExternalURLs are http(s) URLs, bare or in brackets
+	ExternalURLs []string
+}
+
+// ExtractLinks returns the links in the given page lines.
+// Code blocks, inline code, math and icons are not links; links inside
+// decorations ([* [page]]) are.
+func ExtractLinks(lines []string) Links {
+	var links Links
+	seen := make(map[string]bool)
+	add := func(list *[]string, kind, value string) {
+		if value == "" || seen[kind+"\x00"+value] {
+			return
+		}
+		seen[kind+"\x00"+value] = true
+		*list = append(*list, value)
+	}
+
+	Parse(lines).Walk(func(n *Node) {
+		switch n.Type {
+		case NodeLink:
+			add(&links.InternalLinks, "page", n.Text)
+		case NodeProjectLink:
+			add(&links.ProjectLinks, "project", n.Text)
+		case NodeHashtag:
+			add(&links.Tags, "tag", n.Text)
+		case NodeURL, NodeExternalLink:
+			add(&links.ExternalURLs, "url", n.Text)
+		}
+	})
+	return links
+}
+
+// IndentLevel returns the number of leading whitespace characters of a line
+func IndentLevel(line string) int {
+	n := 0
+	for _, r := range line {
+		if r != ' ' && r != '\t' && r != '　' {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+// IsURL reports whether s is an http(s) URL
+func IsURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
+
+// isDecoration reports whether s consists only of decoration characters
+func isDecoration(s string) bool {
+	for _, r := range s {
+		if !strings.ContainsRune("*/-_!\"#%&'()~|+<>{}.,$", r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t'
+}
@@ -0,0 +1,265 @@
+package notation
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// MarkdownOptions controls how links are rendered by Markdown. A nil function,
+// or one returning "", renders the element as plain text.
+type MarkdownOptions struct {
+	// LinkURL returns the target of a [page] link or #tag
+	LinkURL func(title string) string
+	// ProjectLinkURL returns the target of a [/project/page] link
+	ProjectLinkURL func(path string) string
+	// IconURL returns the image of a [name.icon]
+	IconURL func(name string) string
+}
+
+// Markdown converts page lines (without the title line) to Markdown.
+// Indented lines become nested list items, code and table blocks become
+// fenced code and pipe tables, and a line holding only a [** heading]
+// decoration becomes a heading.
+func Markdown(lines []string, opts MarkdownOptions) string {
+	var b strings.Builder
+	// prev is the kind of the last block written: "" (none), "blank", "list" or "para"
+	prev := ""
+	separate := func(kind string) {
+		// Paragraph lines and list/paragraph transitions need a blank line
+		// between them; list items and blank lines do not
+		if prev != "" && prev != "blank" && (kind != "list" || prev != "list") {
+			b.WriteString("\n")
+		}
+		prev = kind
+	}
+
+	for _, block := range Parse(lines).Blocks {
+		switch block.Type {
+		case BlockCode:
+			separate("para")
+			writeCodeBlock(&b, block)
+		case BlockTable:
+			separate("para")
+			writeTable(&b, block, opts)
+		default:
+			line := block.Line
+			level := IndentLevel(line.Indent)
+			if level == 0 && len(line.Nodes) == 0 && !line.Quote {
+				if prev != "" && prev != "blank" {
+					b.WriteString("\n")
+				}
+				prev = "blank"
+				continue
+			}
+
+			text := markdownInline(line.Nodes, opts)
+			if line.Quote {
+				text = "> " + text
+			}
+			if level > 0 {
+				separate("list")
+				b.WriteString(strings.Repeat("  ", level-1) + "- " + text + "\n")
+				continue
+			}
+			separate("para")
+			if heading := headingLevel(line); heading > 0 {
+				text = strings.Repeat("#", heading) + " " + markdownInline(line.Nodes[0].Children, opts)
+			}
+			b.WriteString(text + "\n")
+		}
+	}
+	return b.String()
+}
+
+// headingLevel maps a line that is a single [*.. text] decoration to a
+// Markdown heading level: [** ] is ###, [*** ] is ## and more stars stay ##
+// (# is left for the page title). It returns 0 for other lines.
+func headingLevel(line Line) int {
+	if line.Quote || len(line.Nodes) != 1 || line.Nodes[0].Type != NodeDecoration {
+		return 0
+	}
+	stars := strings.Count(line.Nodes[0].Marks, "*")
+	if stars < 2 || stars != len(line.Nodes[0].Marks) {
+		return 0
+	}
+	return max(2, 5-stars)
+}
+
+func writeCodeBlock(b *strings.Builder, block Block) {
+	// code:main.go is highlighted as go, code:js as js
+	lang := strings.TrimPrefix(path.Ext(block.Name), ".")
+	if lang == "" {
+		lang = block.Name
+	}
+	fence := "```"
+	for _, line := range block.Code {
+		for strings.Contains(line, fence) {
+			fence += "`"
+		}
+	}
+
+	b.WriteString(fence + lang + "\n")
+	cut := IndentLevel(block.Indent) + 1
+	for _, line := range block.Code {
+		b.WriteString(trimIndent(line, cut) + "\n")
+	}
+	b.WriteString(fence + "\n")
+}
+
+func writeTable(b *strings.Builder, block Block, opts MarkdownOptions) {
+	if block.Name != "" {
+		b.WriteString("**" + escapeMarkdown(block.Name) + "**\n\n")
+	}
+
+	var rows [][]string
+	width := 0
+	for _, row := range block.Rows {
+		cells := strings.Split(strings.TrimLeft(row.String(), " \t　"), "\t")
+		for i, cell := range cells {
+			cells[i] = strings.ReplaceAll(markdownInline(ParseInline(cell), opts), "|", `\|`)
+		}
+		rows = append(rows, cells)
+		width = max(width, len(cells))
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	for i, cells := range rows {
+		for len(cells) < width {
+			cells = append(cells, "")
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		if i == 0 {
+			b.WriteString(strings.Repeat("| --- ", width) + "|\n")
+		}
+	}
+}
+
+// markdownInline renders inline nodes as Markdown
+func markdownInline(nodes []Node, opts MarkdownOptions) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		switch n.Type {
+		case NodeLink:
+			b.WriteString(markdownLink(escapeMarkdown(n.Text), opts.LinkURL, n.Text))
+		case NodeHashtag:
+			b.WriteString(markdownLink(escapeMarkdown("#"+n.Text), opts.LinkURL, n.Text))
+		case NodeProjectLink:
+			b.WriteString(markdownLink(escapeMarkdown(n.Text), opts.ProjectLinkURL, n.Text))
+		case NodeExternalLink:
+			switch {
+			case n.Label == "" && IsImageURL(n.Text):
+				b.WriteString("![](" + n.Text + ")")
+			case n.Label == "":
+				b.WriteString("<" + n.Text + ">")
+			case IsImageURL(n.Label):
+				// [image-url link-url] is an image linking elsewhere
+				b.WriteString("[![](" + n.Label + ")](" + n.Text + ")")
+			default:
+				b.WriteString("[" + escapeMarkdown(n.Label) + "](" + n.Text + ")")
+			}
+		case NodeURL:
+			b.WriteString("<" + n.Text + ">")
+		case NodeIcon:
+			name := strings.SplitN(strings.TrimSuffix(n.Text, ".icon"), ".icon*", 2)[0]
+			icon := ""
+			if opts.IconURL != nil {
+				icon = opts.IconURL(name)
+			}
+			if icon != "" {
+				b.WriteString("![" + escapeMarkdown(name) + "](" + icon + ")")
+			} else {
+				b.WriteString(":" + escapeMarkdown(name) + ":")
+			}
+		case NodeDecoration:
+			b.WriteString(markdownDecoration(n.Marks, markdownInline(n.Children, opts)))
+		case NodeStrong:
+			b.WriteString("**" + markdownInline(n.Children, opts) + "**")
+		case NodeMath:
+			b.WriteString("$" + n.Text + "$")
+		case NodeCode:
+			fence := "`"
+			for strings.Contains(n.Text, fence) {
+				fence += "`"
+			}
+			if len(fence) > 1 {
+				b.WriteString(fence + " " + n.Text + " " + fence)
+			} else {
+				b.WriteString(fence + n.Text + fence)
+			}
+		default:
+			b.WriteString(escapeMarkdown(n.Text))
+		}
+	}
+	return b.String()
+}
+
+// markdownLink renders label as a link to target(title), or as plain text
+// when there is no target
+func markdownLink(label string, target func(string) string, title string) string {
+	if target == nil {
+		return label
+	}
+	if u := target(title); u != "" {
+		return "[" + label + "](" + u + ")"
+	}
+	return label
+}
+
+// markdownDecoration wraps text in the Markdown equivalents of the marks:
+// * bold, / italic, - strikethrough. Other marks (underline, sizes) are dropped.
+func markdownDecoration(marks, text string) string {
+	if strings.ContainsRune(marks, '-') {
+		text = "~~" + text + "~~"
+	}
+	if strings.ContainsRune(marks, '/') {
+		text = "*" + text + "*"
+	}
+	if strings.ContainsRune(marks, '*') {
+		text = "**" + text + "**"
+	}
+	return text
+}
+
+// IsImageURL reports whether Scrapbox shows a bracketed URL as an image:
+// common image extensions and Gyazo links
+func IsImageURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil || !IsURL(s) {
+		return false
+	}
+	if u.Host == "gyazo.com" || strings.HasSuffix(u.Host, ".gyazo.com") {
+		return true
+	}
+	switch strings.ToLower(path.Ext(u.Path)) {
+	case ".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp":
+		return true
+	}
+	return false
+}
+
+// escapeMarkdown escapes the characters Markdown would read as formatting
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", `\<`,
+)
+
+// trimIndent removes up to n leading indentation characters
+func trimIndent(line string, n int) string {
+	for i := 0; i < n && line != ""; i++ {
+		switch {
+		case line[0] == ' ' || line[0] == '\t':
+			line = line[1:]
+		case strings.HasPrefix(line, "　"):
+			line = line[len("　"):]
+		default:
+			return line
+		}
+	}
+	return line
+}
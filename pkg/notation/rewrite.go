@@ -0,0 +1,60 @@
+package notation
+
+import (
+	"strings"
+)
+
+// RewriteLinks returns lines with links to the page from changed into links
+// to the page to, and the number of links rewritten. It rewrites [from],
+// [/project/from] (for the given project) and #from tags; a tag becomes
+// [to] when to cannot be written as a tag. Titles match the way Scrapbox
+// matches links: case-insensitively, with underscores equal to spaces.
+// Code blocks, inline code and URLs are left alone.
+func RewriteLinks(lines []string, from, to, project string) ([]string, int) {
+	fromKey := linkKey(from)
+	prefix := "/" + project + "/"
+	count := 0
+
+	doc := Parse(lines)
+	doc.Walk(func(n *Node) {
+		switch n.Type {
+		case NodeLink:
+			if linkKey(n.Text) == fromKey {
+				n.Text = to
+				count++
+			}
+		case NodeProjectLink:
+			if project != "" && strings.HasPrefix(n.Text, prefix) && linkKey(n.Text[len(prefix):]) == fromKey {
+				n.Text = prefix + to
+				count++
+			}
+		case NodeHashtag:
+			if linkKey(n.Text) == fromKey {
+				if form, ok := tagForm(to); ok {
+					n.Text = form
+				} else {
+					n.Type = NodeLink
+					n.Text = to
+				}
+				count++
+			}
+		}
+	})
+	if count == 0 {
+		return lines, 0
+	}
+	return doc.Lines(), count
+}
+
+// linkKey is the form in which Scrapbox compares link targets
+func linkKey(title string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(title), "_", " "))
+}
+
+// tagForm writes title as a hashtag, or returns false if it cannot be one
+func tagForm(title string) (string, bool) {
+	if strings.ContainsAny(title, "[]#") {
+		return "", false
+	}
+	return strings.ReplaceAll(title, " ", "_"), true
+}
@@ -0,0 +1,42 @@
+package sio
+
+import "fmt"
+
+// Decoder decodes the frames of one connection, collecting the binary
+// attachments that follow BinaryEvent and BinaryAck packets
+type Decoder struct {
+	pending *Packet
+}
+
+// DecodeText decodes a text frame. It returns nil while a binary packet is
+// waiting for attachments; a text frame in that state is an error.
+func (d *Decoder) DecodeText(frame []byte) (*Packet, error) {
+	if d.pending != nil {
+		d.pending = nil
+		return nil, fmt.Errorf("%w: text frame while waiting for binary attachments", ErrInvalidPacket)
+	}
+	p, err := Decode(frame)
+	if err != nil {
+		return nil, err
+	}
+	if p.attachments > 0 {
+		d.pending = p
+		return nil, nil
+	}
+	return p, nil
+}
+
+// DecodeBinary adds a binary frame to the pending packet and returns the
+// packet once all of its attachments have arrived
+func (d *Decoder) DecodeBinary(frame []byte) (*Packet, error) {
+	p := d.pending
+	if p == nil {
+		return nil, fmt.Errorf("%w: unexpected binary frame", ErrInvalidPacket)
+	}
+	p.Attachments = append(p.Attachments, append([]byte(nil), frame...))
+	if len(p.Attachments) < p.attachments {
+		return nil, nil
+	}
+	d.pending = nil
+	return p, nil
+}
@@ -0,0 +1,309 @@
+// Package sio encodes and decodes Engine.IO v4 and Socket.IO v5 packets as
+// they are exchanged over WebSocket frames. Decoding never panics: malformed
+// input is reported as an error wrapping ErrInvalidPacket.
+package sio
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidPacket is wrapped by all decoding errors
+var ErrInvalidPacket = errors.New("invalid packet")
+
+// Limits that keep hostile input from allocating or overflowing
+const (
+	// MaxAckID is the largest accepted ACK ID
+	MaxAckID = 1<<31 - 1
+	// MaxAttachments is the largest accepted number of binary attachments
+	MaxAttachments = 64
+	// maxNamespace bounds the namespace length
+	maxNamespace = 256
+)
+
+// EngineType is the type of an Engine.IO packet
+type EngineType byte
+
+const (
+	EngineOpen    EngineType = '0'
+	EngineClose   EngineType = '1'
+	EnginePing    EngineType = '2'
+	EnginePong    EngineType = '3'
+	EngineMessage EngineType = '4'
+	EngineUpgrade EngineType = '5'
+	EngineNoop    EngineType = '6'
+)
+
+// PacketType is the type of a Socket.IO packet
+type PacketType byte
+
+const (
+	Connect      PacketType = '0'
+	Disconnect   PacketType = '1'
+	Event        PacketType = '2'
+	Ack          PacketType = '3'
+	ConnectError PacketType = '4'
+	BinaryEvent  PacketType = '5'
+	BinaryAck    PacketType = '6'
+)
+
+// DefaultNamespace is the namespace of packets that do not name one
+const DefaultNamespace = "/"
+
+// OpenParams is the payload of the Engine.IO open packet
+type OpenParams struct {
+	SID          string   `json:"sid"`
+	Upgrades     []string `json:"upgrades"`
+	PingInterval int      `json:"pingInterval"`
+	PingTimeout  int      `json:"pingTimeout"`
+	MaxPayload   int      `json:"maxPayload,omitempty"`
+}
+
+// Packet is a Socket.IO packet
+type Packet struct {
+	Type PacketType
+	// Namespace is DefaultNamespace when empty
+	Namespace string
+	// ID is the ACK ID, set when HasID is true
+	ID    int
+	HasID bool
+	// Data is the JSON payload (an array for events and ACKs), or nil
+	Data json.RawMessage
+	// Attachments are the binary attachments of BinaryEvent/BinaryAck packets.
+	// Their placeholders are left in Data.
+	Attachments [][]byte
+
+	// attachments is the number announced in the header
+	attachments int
+}
+
+// Ping, Pong and Close are the Engine.IO control frames sent by clients
+var (
+	PingFrame  = []byte{byte(EnginePing)}
+	PongFrame  = []byte{byte(EnginePong)}
+	CloseFrame = []byte{byte(EngineClose)}
+)
+
+// DecodeEngine splits a text frame into its Engine.IO type and payload
+func DecodeEngine(frame []byte) (EngineType, []byte, error) {
+	if len(frame) == 0 {
+		return 0, nil, fmt.Errorf("%w: empty frame", ErrInvalidPacket)
+	}
+	t := EngineType(frame[0])
+	if t < EngineOpen || t > EngineNoop {
+		return 0, nil, fmt.Errorf("%w: unknown Engine.IO type %q", ErrInvalidPacket, frame[0])
+	}
+	return t, frame[1:], nil
+}
+
+// DecodeOpen parses an Engine.IO open frame ("0{...}")
+func DecodeOpen(frame []byte) (*OpenParams, error) {
+	t, payload, err := DecodeEngine(frame)
+	if err != nil {
+		return nil, err
+	}
+	if t != EngineOpen {
+		return nil, fmt.Errorf("%w: expected open packet, got type %q", ErrInvalidPacket, byte(t))
+	}
+	var params OpenParams
+	if err := json.Unmarshal(payload, &params); err != nil {
+		return nil, fmt.Errorf("%w: open payload: %v", ErrInvalidPacket, err)
+	}
+	return &params, nil
+}
+
+// EncodeOpen returns an Engine.IO open frame
+func EncodeOpen(params OpenParams) []byte {
+	if params.Upgrades == nil {
+		params.Upgrades = []string{}
+	}
+	payload, _ := json.Marshal(params)
+	return append([]byte{byte(EngineOpen)}, payload...)
+}
+
+// Decode parses a text frame carrying a Socket.IO packet ("4" + packet).
+// Binary packets are returned with their attachment count announced but
+// no attachments; use a Decoder to collect them.
+func Decode(frame []byte) (*Packet, error) {
+	t, payload, err := DecodeEngine(frame)
+	if err != nil {
+		return nil, err
+	}
+	if t != EngineMessage {
+		return nil, fmt.Errorf("%w: expected message packet, got type %q", ErrInvalidPacket, byte(t))
+	}
+	return decodePacket(string(payload))
+}
+
+// decodePacket parses <type>[<attachments>-][<namespace>,][<id>][<data>]
+func decodePacket(s string) (*Packet, error) {
+	if s == "" {
+		return nil, fmt.Errorf("%w: empty Socket.IO packet", ErrInvalidPacket)
+	}
+	p := &Packet{Type: PacketType(s[0]), Namespace: DefaultNamespace}
+	if p.Type < Connect || p.Type > BinaryAck {
+		return nil, fmt.Errorf("%w: unknown Socket.IO type %q", ErrInvalidPacket, s[0])
+	}
+	s = s[1:]
+
+	if p.Type == BinaryEvent || p.Type == BinaryAck {
+		dash := strings.IndexByte(s, '-')
+		if dash <= 0 {
+			return nil, fmt.Errorf("%w: missing attachment count", ErrInvalidPacket)
+		}
+		n, err := parseBounded(s[:dash], MaxAttachments)
+		if err != nil {
+			return nil, fmt.Errorf("%w: attachment count: %v", ErrInvalidPacket, err)
+		}
+		p.attachments = n
+		s = s[dash+1:]
+	}
+
+	if strings.HasPrefix(s, "/") {
+		comma := strings.IndexByte(s, ',')
+		end := comma
+		if end < 0 {
+			end = len(s)
+		}
+		if end > maxNamespace {
+			return nil, fmt.Errorf("%w: namespace too long", ErrInvalidPacket)
+		}
+		p.Namespace = s[:end]
+		if comma < 0 {
+			s = ""
+		} else {
+			s = s[comma+1:]
+		}
+	}
+
+	digits := 0
+	for digits < len(s) && s[digits] >= '0' && s[digits] <= '9' {
+		digits++
+	}
+	if digits > 0 {
+		id, err := parseBounded(s[:digits], MaxAckID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: ACK ID: %v", ErrInvalidPacket, err)
+		}
+		p.ID, p.HasID = id, true
+		s = s[digits:]
+	}
+
+	if s != "" {
+		if !json.Valid([]byte(s)) {
+			return nil, fmt.Errorf("%w: payload is not valid JSON", ErrInvalidPacket)
+		}
+		p.Data = json.RawMessage(s)
+	}
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// validate checks the payload shape required by the packet type
+func (p *Packet) validate() error {
+	switch p.Type {
+	case Event, BinaryEvent:
+		var args []json.RawMessage
+		if json.Unmarshal(p.Data, &args) != nil || len(args) == 0 {
+			return fmt.Errorf("%w: event payload must be a non-empty array", ErrInvalidPacket)
+		}
+		var name string
+		if json.Unmarshal(args[0], &name) != nil {
+			return fmt.Errorf("%w: event name must be a string", ErrInvalidPacket)
+		}
+	case Ack, BinaryAck:
+		if !p.HasID {
+			return fmt.Errorf("%w: ACK without ID", ErrInvalidPacket)
+		}
+		var args []json.RawMessage
+		if json.Unmarshal(p.Data, &args) != nil {
+			return fmt.Errorf("%w: ACK payload must be an array", ErrInvalidPacket)
+		}
+	case Disconnect:
+		if p.Data != nil {
+			return fmt.Errorf("%w: disconnect with payload", ErrInvalidPacket)
+		}
+	}
+	return nil
+}
+
+// parseBounded parses a decimal number of at most max
+func parseBounded(s string, max int) (int, error) {
+	if len(s) > len(strconv.Itoa(max)) {
+		return 0, fmt.Errorf("%q is too large", s)
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 || n > max {
+		return 0, fmt.Errorf("%q is not a number up to %d", s, max)
+	}
+	return n, nil
+}
+
+// Encode returns the text frame for p ("4" + packet). Attachments are not
+// included; send them as binary frames after it.
+func (p *Packet) Encode() []byte {
+	var b strings.Builder
+	b.WriteByte(byte(EngineMessage))
+	b.WriteByte(byte(p.Type))
+	if p.Type == BinaryEvent || p.Type == BinaryAck {
+		b.WriteString(strconv.Itoa(len(p.Attachments)))
+		b.WriteByte('-')
+	}
+	if p.Namespace != "" && p.Namespace != DefaultNamespace {
+		b.WriteString(p.Namespace)
+		b.WriteByte(',')
+	}
+	if p.HasID {
+		b.WriteString(strconv.Itoa(p.ID))
+	}
+	b.Write(p.Data)
+	return []byte(b.String())
+}
+
+// Name returns the event name of an Event packet
+func (p *Packet) Name() string {
+	var args []json.RawMessage
+	var name string
+	if json.Unmarshal(p.Data, &args) == nil && len(args) > 0 {
+		json.Unmarshal(args[0], &name)
+	}
+	return name
+}
+
+// Args returns the arguments of an Event packet (after the name) or of an Ack packet
+func (p *Packet) Args() []json.RawMessage {
+	var args []json.RawMessage
+	if json.Unmarshal(p.Data, &args) != nil {
+		return nil
+	}
+	if (p.Type == Event || p.Type == BinaryEvent) && len(args) > 0 {
+		return args[1:]
+	}
+	return args
+}
+
+// NewEvent builds an event packet; name and args are encoded as a JSON array
+func NewEvent(name string, args ...interface{}) (*Packet, error) {
+	data, err := json.Marshal(append([]interface{}{name}, args...))
+	if err != nil {
+		return nil, err
+	}
+	return &Packet{Type: Event, Data: data}, nil
+}
+
+// NewAck builds the ACK packet answering id with args
+func NewAck(id int, args ...interface{}) (*Packet, error) {
+	if args == nil {
+		args = []interface{}{}
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+	return &Packet{Type: Ack, ID: id, HasID: true, Data: data}, nil
+}
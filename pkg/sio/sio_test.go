@@ -0,0 +1,289 @@
+package sio
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame string
+		want  Packet
+	}{
+		{
+			name:  "connect",
+			frame: "40",
+			want:  Packet{Type: Connect, Namespace: DefaultNamespace},
+		},
+		{
+			name:  "connect with namespace and auth",
+			frame: `40/chat,{"token":"x"}`,
+			want:  Packet{Type: Connect, Namespace: "/chat", Data: json.RawMessage(`{"token":"x"}`)},
+		},
+		{
+			name:  "event",
+			frame: `42["commit",{"id":1}]`,
+			want:  Packet{Type: Event, Namespace: DefaultNamespace, Data: json.RawMessage(`["commit",{"id":1}]`)},
+		},
+		{
+			name:  "event with ack ID",
+			frame: `4213["socket.io-request",{}]`,
+			want:  Packet{Type: Event, Namespace: DefaultNamespace, ID: 13, HasID: true, Data: json.RawMessage(`["socket.io-request",{}]`)},
+		},
+		{
+			name:  "event in namespace with ack ID",
+			frame: `42/chat,7["join"]`,
+			want:  Packet{Type: Event, Namespace: "/chat", ID: 7, HasID: true, Data: json.RawMessage(`["join"]`)},
+		},
+		{
+			name:  "ack",
+			frame: `4313[{"data":{}}]`,
+			want:  Packet{Type: Ack, Namespace: DefaultNamespace, ID: 13, HasID: true, Data: json.RawMessage(`[{"data":{}}]`)},
+		},
+		{
+			name:  "disconnect",
+			frame: "41",
+			want:  Packet{Type: Disconnect, Namespace: DefaultNamespace},
+		},
+		{
+			name:  "connect error",
+			frame: `44{"message":"unauthorized"}`,
+			want:  Packet{Type: ConnectError, Namespace: DefaultNamespace, Data: json.RawMessage(`{"message":"unauthorized"}`)},
+		},
+		{
+			name:  "binary event",
+			frame: `452-["upload",{"_placeholder":true,"num":0},{"_placeholder":true,"num":1}]`,
+			want: Packet{Type: BinaryEvent, Namespace: DefaultNamespace, attachments: 2,
+				Data: json.RawMessage(`["upload",{"_placeholder":true,"num":0},{"_placeholder":true,"num":1}]`)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode([]byte(tt.frame))
+			if err != nil {
+				t.Fatalf("Decode(%q) error: %v", tt.frame, err)
+			}
+			if !samePacket(got, &tt.want) || got.attachments != tt.want.attachments {
+				t.Errorf("Decode(%q) = %+v, want %+v", tt.frame, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame string
+	}{
+		{"empty frame", ""},
+		{"unknown engine type", "9"},
+		{"not a message", "2"},
+		{"empty packet", "4"},
+		{"unknown packet type", "49"},
+		{"event without payload", "42"},
+		{"event with empty array", "42[]"},
+		{"event name not a string", "42[1]"},
+		{"invalid JSON", `42["a"`},
+		{"ack without ID", `43["a"]`},
+		{"ack payload not an array", `431{}`},
+		{"disconnect with payload", `41{}`},
+		{"binary without attachment count", `45["a"]`},
+		{"too many attachments", `4565-["a"]`},
+		{"ack ID too large", `4399999999999[]`},
+		{"namespace too long", "40/" + string(bytes.Repeat([]byte("a"), maxNamespace)) + ","},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Decode([]byte(tt.frame))
+			if !errors.Is(err, ErrInvalidPacket) {
+				t.Errorf("Decode(%q) = %+v, %v; want ErrInvalidPacket", tt.frame, p, err)
+			}
+		})
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	event, err := NewEvent("commit", map[string]int{"id": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ack, err := NewAck(42, "ok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	emptyAck, err := NewAck(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		packet *Packet
+		frame  string
+	}{
+		{"connect", &Packet{Type: Connect}, "40"},
+		{"connect in namespace", &Packet{Type: Connect, Namespace: "/chat", Data: json.RawMessage(`{}`)}, "40/chat,{}"},
+		{"event", event, `42["commit",{"id":1}]`},
+		{"event with ack ID", &Packet{Type: Event, ID: 5, HasID: true, Data: json.RawMessage(`["a"]`)}, `425["a"]`},
+		{"ack", ack, `4342["ok"]`},
+		{"empty ack", emptyAck, `430[]`},
+		{"binary event", &Packet{Type: BinaryEvent, Data: json.RawMessage(`["a",{"_placeholder":true,"num":0}]`), Attachments: [][]byte{{1}}}, `451-["a",{"_placeholder":true,"num":0}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame := tt.packet.Encode()
+			if string(frame) != tt.frame {
+				t.Errorf("Encode() = %q, want %q", frame, tt.frame)
+			}
+			decoded, err := Decode(frame)
+			if err != nil {
+				t.Fatalf("Decode(%q) error: %v", frame, err)
+			}
+			if !samePacket(decoded, tt.packet) {
+				t.Errorf("Decode(Encode()) = %+v, want %+v", decoded, tt.packet)
+			}
+		})
+	}
+}
+
+func TestOpenRoundTrip(t *testing.T) {
+	params := OpenParams{SID: "abc", Upgrades: []string{}, PingInterval: 25000, PingTimeout: 20000}
+	got, err := DecodeOpen(EncodeOpen(params))
+	if err != nil {
+		t.Fatalf("DecodeOpen error: %v", err)
+	}
+	if got.SID != params.SID || got.PingInterval != params.PingInterval || got.PingTimeout != params.PingTimeout {
+		t.Errorf("DecodeOpen(EncodeOpen(%+v)) = %+v", params, got)
+	}
+	if _, err := DecodeOpen([]byte(`40`)); !errors.Is(err, ErrInvalidPacket) {
+		t.Errorf("DecodeOpen of a message frame: %v, want ErrInvalidPacket", err)
+	}
+}
+
+func TestDecoderBinary(t *testing.T) {
+	tests := []struct {
+		name   string
+		frames []interface{} // string for text frames, []byte for binary frames
+		want   [][]byte      // attachments of the decoded packet; nil if none is decoded
+		errs   int
+	}{
+		{
+			name:   "two attachments",
+			frames: []interface{}{`452-["upload"]`, []byte{1, 2}, []byte{3}},
+			want:   [][]byte{{1, 2}, {3}},
+		},
+		{
+			name:   "binary ack",
+			frames: []interface{}{`461-7[{"_placeholder":true,"num":0}]`, []byte{9}},
+			want:   [][]byte{{9}},
+		},
+		{
+			name:   "no attachments",
+			frames: []interface{}{`450-["upload"]`},
+			want:   [][]byte{},
+		},
+		{
+			name:   "unexpected binary frame",
+			frames: []interface{}{[]byte{1}},
+			errs:   1,
+		},
+		{
+			name:   "text frame while waiting",
+			frames: []interface{}{`451-["upload"]`, `42["a"]`},
+			errs:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d Decoder
+			var got *Packet
+			errs := 0
+			for _, frame := range tt.frames {
+				var p *Packet
+				var err error
+				switch f := frame.(type) {
+				case string:
+					p, err = d.DecodeText([]byte(f))
+				case []byte:
+					p, err = d.DecodeBinary(f)
+				}
+				if err != nil {
+					errs++
+				}
+				if p != nil {
+					got = p
+				}
+			}
+			if errs != tt.errs {
+				t.Errorf("%d errors, want %d", errs, tt.errs)
+			}
+			switch {
+			case tt.want == nil && got != nil:
+				t.Errorf("decoded %+v, want nothing", got)
+			case tt.want != nil && got == nil:
+				t.Errorf("decoded nothing, want attachments %v", tt.want)
+			case tt.want != nil && len(got.Attachments) != len(tt.want):
+				t.Errorf("attachments = %v, want %v", got.Attachments, tt.want)
+			case tt.want != nil:
+				for i := range tt.want {
+					if !bytes.Equal(got.Attachments[i], tt.want[i]) {
+						t.Errorf("attachment %d = %v, want %v", i, got.Attachments[i], tt.want[i])
+					}
+				}
+			}
+		})
+	}
+}
+
+func FuzzDecode(f *testing.F) {
+	for _, seed := range []string{
+		"40", `40/chat,{"token":"x"}`, `42["commit",{"id":1}]`, `4213["a",{}]`, `42/chat,7["join"]`,
+		`4313[{"data":{}}]`, "41", `44{"message":"x"}`, `452-["upload",{"_placeholder":true,"num":0}]`,
+		`461-7[{"_placeholder":true,"num":0}]`, "2", "3", "", "4", "49", `45["a"]`, `4399999999999[]`,
+	} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, frame []byte) {
+		p, err := Decode(frame)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidPacket) {
+				t.Fatalf("Decode(%q) error %v does not wrap ErrInvalidPacket", frame, err)
+			}
+			return
+		}
+		// Whatever decodes must encode to a frame that decodes the same
+		encoded := p.Encode()
+		again, err := Decode(encoded)
+		if err != nil {
+			t.Fatalf("Decode(%q) decoded %+v, but its encoding %q fails: %v", frame, p, encoded, err)
+		}
+		if !samePacket(p, again) {
+			t.Fatalf("Decode(%q) = %+v, but its encoding %q decodes to %+v", frame, p, encoded, again)
+		}
+		p.Name()
+		p.Args()
+	})
+}
+
+// samePacket compares the fields carried by a text frame
+func samePacket(a, b *Packet) bool {
+	namespace := func(p *Packet) string {
+		if p.Namespace == "" {
+			return DefaultNamespace
+		}
+		return p.Namespace
+	}
+	return a.Type == b.Type &&
+		namespace(a) == namespace(b) &&
+		a.HasID == b.HasID &&
+		a.ID == b.ID &&
+		bytes.Equal(a.Data, b.Data)
+}